@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/erh-safety-system/poc/internal/backtest"
+	"github.com/erh-safety-system/poc/internal/config"
+	"github.com/erh-safety-system/poc/internal/database"
+	"github.com/erh-safety-system/poc/internal/decision"
+)
+
+// backtest replays labelled scenarios through aggregation and the decision evaluator and
+// writes a report for policy change requests. Thresholds and windows come from the same
+// environment and CONFIG_FILE as the server; -policies points at a candidate policy directory.
+func main() {
+	scenarios := flag.String("scenarios", "scenarios", "Comma-separated scenario files or directories")
+	policyDir := flag.String("policies", "", "Policy directory to evaluate (defaults to POLICY_DIR)")
+	history := flag.Bool("history", false, "Load signals for scenarios without listed signals from the configured database")
+	step := flag.Duration("step", backtest.DefaultStep, "How often to aggregate and evaluate during a replay")
+	format := flag.String("format", "markdown", "Report format: markdown or json")
+	out := flag.String("out", "", "Report file (defaults to stdout)")
+	flag.Parse()
+
+	if *format != "markdown" && *format != "json" {
+		log.Fatalf("Unknown report format: %s", *format)
+	}
+
+	cfg := config.Load()
+	cfg, err := config.NewReloader(cfg, cfg.ConfigFile).Load()
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+
+	if *policyDir == "" {
+		*policyDir = cfg.PolicyDir
+	}
+	policies, err := decision.LoadPolicies(*policyDir, &cfg.Evaluator)
+	if err != nil {
+		log.Fatalf("Failed to load decision policies: %v", err)
+	}
+
+	loaded, err := backtest.LoadScenarios(strings.Split(*scenarios, ","))
+	if err != nil {
+		log.Fatalf("Failed to load scenarios: %v", err)
+	}
+
+	runner := backtest.NewRunner(cfg, policies)
+	runner.SetStep(*step)
+	if *history {
+		if err := database.Init(&cfg.Database); err != nil {
+			log.Fatalf("Failed to initialize database: %v", err)
+		}
+		defer database.Close()
+		runner.SetHistory(database.DB)
+	}
+
+	ctx := context.Background()
+	results := make([]*backtest.Result, 0, len(loaded))
+	for _, scenario := range loaded {
+		result, err := runner.Run(ctx, scenario)
+		if err != nil {
+			log.Fatalf("Backtest failed: %v", err)
+		}
+		results = append(results, result)
+	}
+	report := backtest.NewReport(*step, results)
+
+	var w io.Writer = os.Stdout
+	if *out != "" {
+		file, err := os.Create(*out)
+		if err != nil {
+			log.Fatalf("Failed to create report: %v", err)
+		}
+		defer file.Close()
+		w = file
+	}
+
+	if *format == "json" {
+		err = report.WriteJSON(w)
+	} else {
+		err = report.WriteMarkdown(w)
+	}
+	if err != nil {
+		log.Fatalf("Failed to write report: %v", err)
+	}
+
+	fmt.Fprintf(os.Stderr, "Backtested %d scenarios: %d missed escalations, %d false escalations\n",
+		report.Totals.Scenarios, report.Totals.MissedEscalations, report.Totals.FalseEscalations)
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"log"
 	"net/http"
 	"os"
@@ -10,6 +11,7 @@ import (
 	"time"
 
 	"github.com/erh-safety-system/poc/internal/aggregation"
+	"github.com/erh-safety-system/poc/internal/cache"
 	"github.com/erh-safety-system/poc/internal/config"
 	"github.com/erh-safety-system/poc/internal/database"
 	"github.com/erh-safety-system/poc/internal/handler"
@@ -20,11 +22,17 @@ import (
 	"github.com/erh-safety-system/poc/internal/trust"
 	"github.com/erh-safety-system/poc/internal/decision"
 	"github.com/erh-safety-system/poc/internal/erh"
+	"github.com/erh-safety-system/poc/internal/events"
 	"github.com/erh-safety-system/poc/internal/gate"
 	"github.com/erh-safety-system/poc/internal/cap"
 	"github.com/erh-safety-system/poc/internal/route1"
 	"github.com/erh-safety-system/poc/internal/route2"
+	"github.com/erh-safety-system/poc/internal/vo"
 	"github.com/erh-safety-system/poc/internal/audit"
+	"github.com/erh-safety-system/poc/internal/incident"
+	"github.com/erh-safety-system/poc/internal/leader"
+	"github.com/erh-safety-system/poc/internal/playbook"
+	"github.com/erh-safety-system/poc/internal/notify"
 	"github.com/gin-gonic/gin"
 )
 
@@ -32,6 +40,13 @@ func main() {
 	// Load configuration
 	cfg := config.Load()
 
+	// Apply and validate the optional configuration file
+	configReloader := config.NewReloader(cfg, cfg.ConfigFile)
+	cfg, err := configReloader.Load()
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+
 	// Initialize database
 	if err := database.Init(&cfg.Database); err != nil {
 		log.Fatalf("Failed to initialize database: %v", err)
@@ -42,10 +57,18 @@ func main() {
 	if err := database.AutoMigrate(
 		&model.Signal{},
 		&model.AggregatedSummary{},
+		&model.SignalBaseline{},
 		&model.DeviceTrustScore{},
 		&model.DeviceReportHistory{},
 		&decision.DecisionStateRecord{},
+		&decision.DecisionTransition{},
+		&decision.ShadowEvaluation{},
+		&decision.ShadowCandidate{},
+		&decision.EvaluationRecord{},
+		&decision.DeEscalationProposal{},
 		&model.ApprovalRequest{},
+		&model.ApprovalVote{},
+		&model.ApproverProfile{},
 		&model.KeepaliveSession{},
 		&cap.CAPMessageRecord{},
 		&route2.Device{},
@@ -55,10 +78,37 @@ func main() {
 		&erh.MetricsRecord{},
 		&audit.AuditLog{},
 		&audit.EvidenceRecord{},
+		&incident.Incident{},
+		&incident.IncidentLink{},
+		&playbook.Checklist{},
+		&playbook.ChecklistItem{},
+		&notify.AckEscalation{},
+		&leader.LeaderFence{},
 	); err != nil {
 		log.Fatalf("Failed to run migrations: %v", err)
 	}
 
+	// CAP messages stored before zone_id existed only carry their zone in the area
+	if filled, err := cap.BackfillZoneIDs(context.Background(), database.DB); err != nil {
+		log.Fatalf("Failed to backfill CAP message zones: %v", err)
+	} else if filled > 0 {
+		log.Printf("Backfilled the zone of %d CAP messages", filled)
+	}
+
+	// Approval requests stored before votes kept their approvers in fixed slots and needed three approvers for D4
+	if upgraded, err := gate.BackfillApprovalVotes(context.Background(), database.DB); err != nil {
+		log.Fatalf("Failed to backfill approval votes: %v", err)
+	} else if upgraded > 0 {
+		log.Printf("Upgraded %d approval requests to votes", upgraded)
+	}
+
+	// Decisions stored before transitions were recorded have no timeline to reconstruct their state from
+	if seeded, err := decision.BackfillTransitions(context.Background(), database.DB); err != nil {
+		log.Fatalf("Failed to backfill decision transitions: %v", err)
+	} else if seeded > 0 {
+		log.Printf("Seeded the timeline of %d decisions", seeded)
+	}
+
 	// Initialize Redis
 	if err := redis.Init(&cfg.Redis); err != nil {
 		log.Fatalf("Failed to initialize Redis: %v", err)
@@ -72,11 +122,38 @@ func main() {
 	trustScorer := trust.NewTrustScorer(database.DB)
 	
 	// Initialize decision services
-	decisionEvaluator := decision.NewDecisionEvaluator(database.DB, aggregationEngine)
+	decisionEvaluator := decision.NewDecisionEvaluator(&cfg.Evaluator, database.DB, aggregationEngine)
 	decisionService := decision.NewDecisionService(database.DB, decisionEvaluator)
 	
+	// Load per-zone-type decision policies; zones without a policy document use the built-in policy
+	policyRegistry, err := decision.LoadPolicies(cfg.PolicyDir, &cfg.Evaluator)
+	if err != nil {
+		log.Fatalf("Failed to load decision policies: %v", err)
+	}
+	decisionEvaluator.SetPolicies(policyRegistry)
+	decisionService.SetPolicies(policyRegistry)
+	
+	// Evaluate candidate policies against every new summary without acting on them
+	shadowEvaluator := decision.NewShadowEvaluator(database.DB, decisionEvaluator, decisionService)
+	if candidate, err := shadowEvaluator.LoadCandidate(context.Background()); err != nil {
+		log.Printf("Shadow evaluation not resumed: %v", err)
+	} else if candidate != nil {
+		log.Printf("Resumed shadow evaluation of candidate policy %s", candidate.ID())
+	}
+	aggregationEngine.OnSummary(shadowEvaluator.Observe)
+	
+	// Step calm zones down; high-impact stand-downs wait for operator confirmation
+	deEscalator := decision.NewDeEscalator(database.DB, decisionService, decisionEvaluator)
+	aggregationEngine.OnSummary(deEscalator.Observe)
+	
+	// Cache latest summary and state per zone; guidance polling reads these for every zone
+	zoneCache := cache.NewZoneCache(redis.Client)
+	aggregationEngine.SetCache(zoneCache)
+	decisionService.SetCache(zoneCache)
+	
 	// Initialize ERH services
 	complexityCalculator := erh.NewComplexityCalculator()
+	decisionService.SetComplexityScorer(complexityCalculator)
 	ethicalPrimeCalculator := erh.NewEthicalPrimeCalculator(database.DB)
 	breakpointDetector := erh.NewBreakpointDetector(database.DB)
 	mitigationManager := erh.NewMitigationManager(database.DB)
@@ -93,6 +170,19 @@ func main() {
 	approvalService := gate.NewApprovalService(database.DB)
 	keepaliveService := gate.NewKeepaliveService(database.DB)
 	ttlManager := gate.NewTTLManager(database.DB)
+	approvalService.UpdateConfig(&cfg.Gate)
+	ttlManager.UpdateConfig(&cfg.Gate)
+	decisionEvaluator.SetQuorumSource(approvalService.Quorum)
+	decisionService.SetApprovalGate(gate.NewApprovalGate(ttlManager))
+	
+	// Apply fully approved actions to the zone's decision as soon as the last approval arrives
+	eventBus := events.NewBus()
+	eventBus.Subscribe(func(event events.Event) {
+		log.Printf("Event %s: zone=%s subject=%s %v", event.Type, event.ZoneID, event.SubjectID, event.Payload)
+	})
+	approvalService.SetDecisionService(decisionService)
+	approvalService.SetEventBus(eventBus)
+	approvalService.SetTTLManager(ttlManager)
 	rollbackService := gate.NewRollbackService(database.DB, decisionService, keepaliveService, ttlManager)
 	
 	// Initialize CAP services
@@ -128,9 +218,9 @@ func main() {
 	staffHandler := handler.NewStaffHandler(signalService)
 	infrastructureHandler := handler.NewInfrastructureHandler(signalService)
 	emergencyHandler := handler.NewEmergencyHandler(signalService)
-	operatorHandler := handler.NewOperatorHandler(decisionService, signalService)
-	dashboardHandler := handler.NewDashboardHandler(decisionService, complexityCalculator, ethicalPrimeCalculator)
-	approvalHandler := handler.NewApprovalHandler(approvalService)
+	operatorHandler := handler.NewOperatorHandler(decisionService, decisionEvaluator, signalService)
+	dashboardHandler := handler.NewDashboardHandler(decisionService, decisionEvaluator, complexityCalculator, ethicalPrimeCalculator)
+	approvalHandler := handler.NewApprovalHandler(approvalService, rollbackService, eventBus)
 	erhHandler := handler.NewERHHandler(
 		complexityCalculator,
 		ethicalPrimeCalculator,
@@ -149,6 +239,74 @@ func main() {
 	auditHandler := handler.NewAuditHandler(auditLogger, evidenceArchive)
 	_ = auditArchiver // TODO: integrate with decision/approval flows
 	
+	// Only eligible approvers may approve; refused attempts are audited
+	approverDirectory := gate.NewApproverDirectory(database.DB, &cfg.Gate)
+	approvalService.SetApproverDirectory(approverDirectory)
+	approvalService.SetAuditLogger(auditLogger)
+	approverHandler := handler.NewApproverHandler(approverDirectory)
+	
+	// Instantiate SOP checklists as decisions change state; required items hold operator transitions
+	playbookRegistry, err := playbook.LoadPlaybooks(cfg.PlaybookDir)
+	if err != nil {
+		log.Fatalf("Failed to load playbooks: %v", err)
+	}
+	checklistService := playbook.NewChecklistService(database.DB, playbookRegistry, policyRegistry, auditLogger)
+	decisionService.AddTransitionGuard(checklistService.Guard)
+	decisionService.OnTransition(checklistService.Observe)
+	
+	// Reject reloads whose evaluator thresholds the zone policies' overrides no longer fit
+	configReloader.AddValidator(func(cfg *config.Config) error {
+		return policyRegistry.CheckBase(&cfg.Evaluator)
+	})
+	
+	// Apply configuration reloads and record every attempt in the audit log
+	configReloader.OnChange(func(old, new *config.Config, changes []string, err error) {
+		entry := &audit.AuditLogEntry{
+			OperationType: "system_config",
+			OperatorID:    "system",
+			TargetType:    "config",
+			TargetID:      configReloader.Path(),
+			Action:        "update",
+			Result:        "success",
+		}
+		if err != nil {
+			entry.Result = "failure"
+			entry.Reason = err.Error()
+		} else {
+			aggregationEngine.UpdateConfig(&new.Aggregation)
+			decisionEvaluator.UpdateConfig(&new.Evaluator)
+			if err := policyRegistry.UpdateBase(&new.Evaluator); err != nil {
+				log.Printf("Failed to apply evaluator thresholds to zone policies: %v", err)
+			}
+			approvalService.UpdateConfig(&new.Gate)
+			approverDirectory.UpdateConfig(&new.Gate)
+			ttlManager.UpdateConfig(&new.Gate)
+			if metadata, marshalErr := json.Marshal(map[string]interface{}{"changes": changes}); marshalErr == nil {
+				entry.Metadata = string(metadata)
+			}
+		}
+		if logErr := auditLogger.LogOperation(context.Background(), entry); logErr != nil {
+			log.Printf("Failed to log configuration change: %v", logErr)
+		}
+	})
+	systemHandler := handler.NewSystemHandler(configReloader, zoneCache, policyRegistry)
+	shadowHandler := handler.NewShadowHandler(shadowEvaluator)
+	deEscalationHandler := handler.NewDeEscalationHandler(deEscalator)
+	incidentHandler := handler.NewIncidentHandler(incident.NewIncidentService(database.DB))
+	playbookHandler := handler.NewPlaybookHandler(playbookRegistry, checklistService)
+	
+	// Escalate unacknowledged decisions from the duty operator up to the incident commander
+	notifiers := []notify.Notifier{notify.NewConsoleNotifier(eventBus), notify.NewSMSNotifier()}
+	if cfg.Notify.WebhookURL != "" {
+		notifiers = append(notifiers, notify.NewWebhookNotifier(cfg.Notify.WebhookURL))
+	}
+	dispatcher := notify.NewDispatcher(notifiers...)
+	ackLadder := notify.NewAckLadder(database.DB, decisionService, &cfg.Notify, dispatcher)
+	ackHandler := handler.NewAckHandler(decisionService, ackLadder)
+	
+	// Tell requesters on their console and by SMS when a request expires unapproved
+	approvalService.SetNotifier(dispatcher, []string{notify.ChannelConsole, notify.ChannelSMS})
+	
 	// Initialize Route 2 services
 	deviceAuthService := route2.NewDeviceAuthService(database.DB)
 	pushService := route2.NewPushNotificationService()
@@ -163,18 +321,47 @@ func main() {
 		feedbackService,
 	)
 	
-	// Start background monitor for rollback checks
+	// Rollbacks retract the public alert, tell app users and keep both in the evidence archive
+	rollbackService.SetMessageRetractor(capService)
+	rollbackService.SetPushNotifier(pushService)
+	rollbackService.SetEvidenceArchive(evidenceArchive)
+	
+	// Background loops that write shared state run only on the elected instance
+	elector := leader.NewElector(leader.NewRedisStore(redis.Client), database.DB, "background", cfg.Leader.InstanceID, cfg.Leader.LeaseTTL)
+	
+	// Start background monitor for rollback checks and approval expiry
 	monitor := gate.NewBackgroundMonitor(rollbackService)
+	monitor.SetApprovalService(approvalService)
+	monitor.SetFence(elector.CheckFence)
 	monitorCtx, monitorCancel := context.WithCancel(context.Background())
 	defer monitorCancel()
-	go monitor.Start(monitorCtx)
+	elector.Go(monitor.Start)
+	
+	// Drop local cache copies when other instances write
+	go zoneCache.Subscribe(monitorCtx)
+	
+	// Reload configuration on SIGHUP or file change
+	go configReloader.Watch(monitorCtx, 10*time.Second)
+	
+	// Relearn time-of-day signal baselines for anomaly scoring
+	elector.Go(func(ctx context.Context) {
+		aggregationEngine.Baselines().Start(ctx, cfg.Aggregation.BaselineRelearnInterval, cfg.Aggregation.BaselineLookback)
+	})
+	
+	// Walk the acknowledgement ladder
+	elector.Go(func(ctx context.Context) {
+		ackLadder.Start(ctx, 15*time.Second)
+	})
+	
+	// Campaign for leadership; the loops above start when elected and stop when the lease is lost
+	go elector.Run(monitorCtx)
 
 	// Setup router
 	router := setupRouter(
 		crowdHandler, staffHandler, infrastructureHandler, emergencyHandler,
 		operatorHandler, dashboardHandler, approvalHandler, keepaliveHandler,
-		capHandler, route2Handler, erhHandler, auditHandler, auditLogger,
-		deviceAuthService, rateLimiter,
+		capHandler, route2Handler, erhHandler, auditHandler, systemHandler, shadowHandler, deEscalationHandler, incidentHandler, playbookHandler, ackHandler, approverHandler,
+		auditLogger, deviceAuthService, rateLimiter, elector,
 	)
 
 	// Create HTTP server
@@ -224,18 +411,42 @@ func setupRouter(
 		route2Handler *handler.Route2Handler,
 		erhHandler *handler.ERHHandler,
 		auditHandler *handler.AuditHandler,
+		systemHandler *handler.SystemHandler,
+		shadowHandler *handler.ShadowHandler,
+		deEscalationHandler *handler.DeEscalationHandler,
+		incidentHandler *handler.IncidentHandler,
+		playbookHandler *handler.PlaybookHandler,
+		ackHandler *handler.AckHandler,
+		approverHandler *handler.ApproverHandler,
 		auditLogger *audit.AuditLogger,
 		deviceAuthService *route2.DeviceAuthService,
 		rateLimiter *middleware.RateLimiter,
+		elector *leader.Elector,
 ) *gin.Engine {
 	router := gin.Default()
 	
 	// Apply audit middleware to all routes (except health check)
 	router.Use(audit.AuditMiddleware(auditLogger))
 
-	// Health check
+	// Health check; reports which instance runs the background loops
 	router.GET("/health", func(c *gin.Context) {
-		c.JSON(http.StatusOK, gin.H{"status": "ok"})
+		status, err := elector.Status(c.Request.Context())
+		if err != nil {
+			c.JSON(http.StatusOK, gin.H{"status": "ok", "leader": status, "leader_error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"status": "ok", "leader": status})
+	})
+	router.GET("/health/leader", func(c *gin.Context) {
+		status, err := elector.Status(c.Request.Context())
+		if err != nil {
+			c.JSON(http.StatusServiceUnavailable, vo.ErrorResponse{
+				Message: err.Error(),
+				Code:    "LEADER_UNKNOWN",
+			})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"status": "success", "leader": status})
 	})
 
 	// API v1 routes
@@ -278,7 +489,30 @@ func setupRouter(
 		{
 			operator.POST("/decisions/:zone_id/d0", operatorHandler.CreatePreAlert)
 			operator.POST("/decisions/:decision_id/transition", operatorHandler.TransitionState)
+			operator.GET("/decisions/:decision_id/timeline", operatorHandler.GetTimeline)
+			operator.GET("/decisions/:decision_id/state", operatorHandler.GetStateAt)
 			operator.GET("/zones/:zone_id/state", operatorHandler.GetLatestState)
+			operator.POST("/zones/:zone_id/evaluate", operatorHandler.EvaluateZone)
+			operator.GET("/zones/:zone_id/evaluations", operatorHandler.ListEvaluations)
+			operator.POST("/zones/:zone_id/whatif", operatorHandler.WhatIf)
+			operator.GET("/evaluations/:evaluation_id", operatorHandler.GetEvaluation)
+			operator.GET("/deescalations", deEscalationHandler.ListProposals)
+			operator.POST("/deescalations/:proposal_id/confirm", deEscalationHandler.ConfirmProposal)
+			operator.POST("/deescalations/:proposal_id/reject", deEscalationHandler.RejectProposal)
+			operator.POST("/incidents", incidentHandler.CreateIncident)
+			operator.GET("/incidents", incidentHandler.ListIncidents)
+			operator.GET("/incidents/:incident_id", incidentHandler.GetIncident)
+			operator.POST("/incidents/:incident_id/status", incidentHandler.UpdateStatus)
+			operator.PUT("/incidents/:incident_id/commander", incidentHandler.AssignCommander)
+			operator.POST("/incidents/:incident_id/links", incidentHandler.LinkItem)
+			operator.DELETE("/incidents/:incident_id/links/:item_type/:item_id", incidentHandler.UnlinkItem)
+			operator.GET("/decisions/:decision_id/checklists", playbookHandler.GetChecklists)
+			operator.POST("/checklist-items/:item_id/complete", playbookHandler.CompleteItem)
+			operator.POST("/checklist-items/:item_id/waive", playbookHandler.WaiveItem)
+			operator.POST("/decisions/:decision_id/acknowledge", ackHandler.Acknowledge)
+			operator.GET("/decisions/:decision_id/escalations", ackHandler.GetEscalations)
+			operator.GET("/acknowledgements/pending", ackHandler.ListPending)
+			operator.GET("/kpi/tta", ackHandler.GetTTAStats)
 		}
 		
 		// Dashboard endpoints
@@ -291,9 +525,27 @@ func setupRouter(
 		approvals := v1.Group("/approvals")
 		{
 			approvals.POST("", approvalHandler.CreateApprovalRequest)
+			approvals.GET("", approvalHandler.ListApprovalRequests)
+			approvals.GET("/inbox", approvalHandler.Inbox)
+			approvals.GET("/summary", approvalHandler.GetSummary)
+			approvals.GET("/stream", approvalHandler.Stream)
+			approvals.GET("/metrics", approvalHandler.GetMetrics)
+			approvals.GET("/:id/summary", approvalHandler.GetApprovalSummary)
 			approvals.GET("/:id", approvalHandler.GetApprovalRequest)
 			approvals.POST("/:id/approve", approvalHandler.Approve)
 			approvals.POST("/:id/reject", approvalHandler.Reject)
+			approvals.POST("/:id/extend", approvalHandler.RequestTTLExtension)
+			approvals.GET("/:id/rollback/preview", approvalHandler.PreviewRollback)
+		}
+		
+		// Approver profiles and shifts
+		approvers := v1.Group("/approvers")
+		{
+			approvers.GET("", approverHandler.ListApprovers)
+			approvers.GET("/:operator_id", approverHandler.GetApprover)
+			approvers.PUT("/:operator_id", approverHandler.SaveApprover)
+			approvers.POST("/:operator_id/shift", approverHandler.StartShift)
+			approvers.DELETE("/:operator_id/shift", approverHandler.EndShift)
 		}
 		
 		// Keepalive endpoints
@@ -346,6 +598,23 @@ func setupRouter(
 			auditGroup.GET("/evidence/:evidence_id", auditHandler.GetEvidence)
 			auditGroup.POST("/evidence/archive", auditHandler.ArchiveEvidence)
 		}
+		
+		// System configuration endpoints
+		system := v1.Group("/system")
+		{
+			system.GET("/config", systemHandler.GetConfig)
+			system.POST("/config/reload", systemHandler.ReloadConfig)
+			system.GET("/cache/stats", systemHandler.GetCacheStats)
+			system.GET("/policies", systemHandler.GetPolicies)
+			system.POST("/policies/reload", systemHandler.ReloadPolicies)
+			system.GET("/playbooks", playbookHandler.GetPlaybooks)
+			
+			// Shadow evaluation of a candidate policy
+			system.POST("/shadow/candidate", shadowHandler.RegisterCandidate)
+			system.GET("/shadow/candidate", shadowHandler.GetCandidate)
+			system.DELETE("/shadow/candidate", shadowHandler.ClearCandidate)
+			system.GET("/shadow/divergence", shadowHandler.GetDivergence)
+		}
 	}
 
 	return router
@@ -0,0 +1,184 @@
+import { apiClient } from '../api';
+
+export type ApprovalStatus = 'pending' | 'approved' | 'rejected' | 'expired' | 'invalidated' | 'rolled_back' | 'ended';
+
+export interface ApprovalVote {
+  approver_id: string;
+  role?: string;
+  created_at: string;
+  last_keepalive_at?: string;
+}
+
+export type ApprovalKind = 'action' | 'ttl_extension';
+
+export interface ApprovalRequest {
+  id: string;
+  kind: ApprovalKind;
+  action_type: 'D3' | 'D4' | 'D5';
+  // Action a TTL extension extends, and the TTL it asks for counted from when the action took effect
+  target_id?: string;
+  requested_ttl_seconds?: number;
+  zone_id: string;
+  proposal: Record<string, any>;
+  requester_id: string;
+  required_approvals: number;
+  required_roles: string[] | null;
+  excluded_approvers: string[] | null;
+  votes: ApprovalVote[];
+  remaining_approvals: number;
+  missing_roles: string[];
+  status: ApprovalStatus;
+  version: number;
+  created_at: string;
+  expires_at?: string;
+  approved_at?: string;
+  decision_id?: string;
+  consumed_at?: string;
+}
+
+// Remaining slots and time left on a request
+export interface ApprovalSummary {
+  id: string;
+  kind: ApprovalKind;
+  target_id?: string;
+  action_type: string;
+  zone_id: string;
+  status: ApprovalStatus;
+  requester_id: string;
+  required_approvals: number;
+  approvals: number;
+  remaining_slots: number;
+  missing_roles: string[];
+  approvers: string[];
+  version: number;
+  created_at: string;
+  expires_at?: string;
+  seconds_until_expiry?: number;
+}
+
+export interface ApprovalOverview {
+  by_status: Partial<Record<ApprovalStatus, number>>;
+  expiring: number;
+  pending: ApprovalSummary[];
+}
+
+export interface ApprovalQuery {
+  zone_id?: string;
+  status?: ApprovalStatus;
+  action_type?: string;
+  kind?: ApprovalKind;
+  mine?: boolean;
+  // Go duration, e.g. "5m"
+  expiring_within?: string;
+  limit?: number;
+  offset?: number;
+}
+
+export interface ApprovalPage {
+  approvals: ApprovalRequest[];
+  summaries: ApprovalSummary[];
+  count: number;
+  total: number;
+  offset: number;
+}
+
+export interface ApprovalMetrics {
+  action_type: string;
+  requested: number;
+  approved: number;
+  rejected: number;
+  expired: number;
+  invalidated: number;
+  pending: number;
+  // Share of resolved requests that expired
+  expiry_rate: number;
+  latency_mean_seconds: number;
+  latency_median_seconds: number;
+  latency_p95_seconds: number;
+}
+
+export interface Retraction {
+  msg_type: 'Update' | 'Cancel';
+  identifier?: string;
+  references: string;
+  zone_id: string;
+  headline: Record<string, string>;
+  description: Record<string, string>;
+  instruction: Record<string, string>;
+  channels?: string[];
+}
+
+export interface RollbackPreview {
+  approval_id: string;
+  action_type: string;
+  zone_id: string;
+  decision_id: string;
+  current_state: string;
+  target_state: string;
+  retraction?: Retraction;
+}
+
+export const approvalApi = {
+  // Query approval requests with pagination
+  list: async (query: ApprovalQuery = {}): Promise<ApprovalPage> => {
+    const response = await apiClient.get<ApprovalPage>('/approvals', { params: query });
+    return response.data;
+  },
+
+  // Pending requests the current operator could approve now
+  inbox: async (query: Pick<ApprovalQuery, 'zone_id' | 'action_type' | 'expiring_within' | 'limit' | 'offset'> = {}): Promise<ApprovalPage> => {
+    const response = await apiClient.get<ApprovalPage>('/approvals/inbox', { params: query });
+    return response.data;
+  },
+
+  // Counts by status and the pending requests, soonest to expire first
+  overview: async (zoneId?: string, expiringWithin?: string): Promise<ApprovalOverview> => {
+    const response = await apiClient.get<{ summary: ApprovalOverview }>('/approvals/summary', {
+      params: { zone_id: zoneId, expiring_within: expiringWithin },
+    });
+    return response.data.summary;
+  },
+
+  get: async (id: string): Promise<ApprovalRequest> => {
+    const response = await apiClient.get<{ approval: ApprovalRequest }>(`/approvals/${id}`);
+    return response.data.approval;
+  },
+
+  // Propose a longer TTL for an action in effect; it needs the action's quorum before it applies
+  requestExtension: async (id: string, ttlSeconds: number, reason: string): Promise<ApprovalRequest> => {
+    const response = await apiClient.post<{ approval: ApprovalRequest }>(`/approvals/${id}/extend`, {
+      ttl_seconds: ttlSeconds,
+      reason,
+    });
+    return response.data.approval;
+  },
+
+  previewRollback: async (id: string): Promise<RollbackPreview> => {
+    const response = await apiClient.get<{ preview: RollbackPreview }>(`/approvals/${id}/rollback/preview`);
+    return response.data.preview;
+  },
+
+  getSummary: async (id: string): Promise<ApprovalSummary> => {
+    const response = await apiClient.get<{ summary: ApprovalSummary }>(`/approvals/${id}/summary`);
+    return response.data.summary;
+  },
+
+  // Latency and expiry rate per action type; defaults to the last 24 hours
+  metrics: async (zoneId?: string, startTime?: string, endTime?: string): Promise<ApprovalMetrics[]> => {
+    const response = await apiClient.get<{ metrics: ApprovalMetrics[] }>('/approvals/metrics', {
+      params: { zone_id: zoneId, start_time: startTime, end_time: endTime },
+    });
+    return response.data.metrics;
+  },
+
+  // Live feed of approval.requested, approval.voted, approval.applied, approval.invalidated and approval.expired events
+  stream: (onEvent: (type: string, event: any) => void, zoneId?: string): EventSource => {
+    const base = apiClient.defaults.baseURL ?? '';
+    const url = `${base}/approvals/stream${zoneId ? `?zone_id=${encodeURIComponent(zoneId)}` : ''}`;
+    const source = new EventSource(url);
+    ['approval.requested', 'approval.voted', 'approval.applied', 'approval.invalidated', 'approval.expired'].forEach((type) => {
+      source.addEventListener(type, (message) => onEvent(type, JSON.parse((message as MessageEvent).data)));
+    });
+    return source;
+  },
+};
@@ -1,4 +1,5 @@
 import { apiClient, ApiResponse } from '../api';
+import { EvaluationRecord } from './decision';
 
 export interface DashboardData {
   zone_id: string;
@@ -26,6 +27,7 @@ export interface DashboardData {
     bias_prime: number;
     integrity_prime: number;
   } | null;
+  latest_evaluation: EvaluationRecord | null;
 }
 
 export const dashboardApi = {
@@ -6,13 +6,173 @@ export interface DecisionState {
   current_state: string;
   previous_state?: string;
   reason?: string;
+  // Zone-type policy the latest transition was made under, e.g. "station_interior@v2"
+  policy_version?: string;
+  version: number;
+  first_signal_at?: string;
+  acknowledged_by?: string;
+  acknowledged_at?: string;
+  // Seconds from the first signal to acknowledgement
+  time_to_acknowledge?: number;
   created_at: string;
   updated_at: string;
 }
 
+// A step of the acknowledgement ladder that was notified
+export interface AckEscalation {
+  id: string;
+  decision_id: string;
+  zone_id: string;
+  step: number;
+  role: 'duty_operator' | 'supervisor' | 'incident_commander' | string;
+  recipient: string;
+  state: string;
+  channels: Record<string, 'delivered' | 'failed'>;
+  created_at: string;
+}
+
+export interface AcknowledgementStats {
+  zone_id?: string;
+  since: string;
+  until: string;
+  acknowledged: number;
+  unacknowledged: number;
+  mean_seconds: number;
+  median_seconds: number;
+  p95_seconds: number;
+}
+
+// Why the evaluator proposed a target state
+export interface EvaluationExplanation {
+  rule: string;
+  source_counts: Record<string, number>;
+  corroboration: {
+    sources: string[];
+    required: number;
+    sufficient: boolean;
+  };
+  thresholds: {
+    name: string;
+    value: number;
+    threshold: number;
+    crossed: boolean;
+  }[];
+  confidence: {
+    sources: {
+      source_type: string;
+      weight: number;
+      signals: number;
+      mean_quality: number;
+      weighted: number;
+    }[];
+    weight_sum: number;
+    base: number;
+    neighbour_pressure: number;
+    final: number;
+  };
+  top_signals: {
+    signal_id: string;
+    source_type: string;
+    signal_type: string;
+    quality: number;
+    timestamp: string;
+    contribution: number;
+  }[];
+}
+
+export interface EvaluationResult {
+  should_escalate: boolean;
+  target_state: string;
+  reason: string;
+  requires_approval: boolean;
+  quorum?: ApprovalQuorum;
+  corroboration_sufficient: boolean;
+  neighbour_pressure: number;
+  policy_version: string;
+  explanation: EvaluationExplanation | null;
+  evaluation_id?: string;
+}
+
+export interface EvaluationRecord {
+  id: string;
+  zone_id: string;
+  summary_id: string;
+  current_state: string;
+  target_state: string;
+  should_escalate: boolean;
+  reason: string;
+  policy_version: string;
+  result: EvaluationResult;
+  created_at: string;
+}
+
 export interface DecisionTransitionRequest {
   target_state: string;
   reason: string;
+  // Evaluation the operator acted on; defaults to the latest for the decision's summary
+  evaluation_id?: string;
+  // Version the operator based the transition on; the API answers 409 if it is stale
+  expected_version?: number;
+}
+
+export interface DeEscalationProposal {
+  id: string;
+  decision_id: string;
+  zone_id: string;
+  from_state: string;
+  target_state: string;
+  decision_version: number;
+  summary_id: string;
+  calm_windows: number;
+  reason: string;
+  status: 'pending' | 'confirmed' | 'rejected' | 'withdrawn';
+  resolved_by?: string;
+  resolved_at?: string;
+  created_at: string;
+}
+
+export interface WhatIfSignal {
+  source_type: 'infrastructure' | 'staff' | 'crowd' | 'emergency';
+  source_id: string;
+  signal_type?: string;
+  value?: Record<string, any>;
+  quality_score: number;
+  timestamp?: string;
+}
+
+export interface WhatIfRequest {
+  current_state?: string;
+  add?: WhatIfSignal[];
+  remove_signal_ids?: string[];
+  remove_source_ids?: string[];
+}
+
+export interface ComplexitySnapshot {
+  signal_sources: number;
+  decision_depth: number;
+  context_states: number;
+  complexity_total: number;
+}
+
+export interface ApprovalQuorum {
+  required: number;
+  roles?: string[];
+}
+
+export interface WhatIfResult {
+  zone_id: string;
+  current_state: string;
+  target_state: string;
+  evaluation: EvaluationResult;
+  summary: Record<string, any>;
+  approval: {
+    required: boolean;
+    quorum?: ApprovalQuorum;
+    corroboration_needed: number;
+  };
+  complexity: ComplexitySnapshot;
+  complexity_after: ComplexitySnapshot;
+  complexity_delta: ComplexitySnapshot;
 }
 
 export const decisionApi = {
@@ -31,6 +191,79 @@ export const decisionApi = {
     return response.data.data!;
   },
 
+  // Evaluate the zone's latest summary and record the explanation
+  evaluateZone: async (zoneId: string): Promise<EvaluationResult> => {
+    const response = await apiClient.post<ApiResponse<EvaluationResult>>(`/operator/zones/${zoneId}/evaluate`);
+    return response.data.data!;
+  },
+
+  // List a zone's recent evaluations, newest first
+  listEvaluations: async (zoneId: string, limit = 20): Promise<EvaluationRecord[]> => {
+    const response = await apiClient.get<ApiResponse<EvaluationRecord[]>>(
+      `/operator/zones/${zoneId}/evaluations`,
+      { params: { limit } }
+    );
+    return response.data.data!;
+  },
+
+  // Dry-run the evaluator with hypothetical signal changes; nothing is stored
+  whatIf: async (zoneId: string, data: WhatIfRequest): Promise<WhatIfResult> => {
+    const response = await apiClient.post<ApiResponse<WhatIfResult>>(`/operator/zones/${zoneId}/whatif`, data);
+    return response.data.data!;
+  },
+
+  // List de-escalation proposals awaiting operator confirmation
+  listDeEscalations: async (zoneId?: string, status = 'pending'): Promise<DeEscalationProposal[]> => {
+    const response = await apiClient.get<{ proposals: DeEscalationProposal[] }>(
+      '/operator/deescalations',
+      { params: { zone_id: zoneId, status } }
+    );
+    return response.data.proposals;
+  },
+
+  // Confirm a proposed stand-down
+  confirmDeEscalation: async (proposalId: string): Promise<DecisionState> => {
+    const response = await apiClient.post<{ decision: DecisionState }>(
+      `/operator/deescalations/${proposalId}/confirm`
+    );
+    return response.data.decision;
+  },
+
+  // Reject a proposed stand-down; calm windows are counted afresh
+  rejectDeEscalation: async (proposalId: string): Promise<void> => {
+    await apiClient.post(`/operator/deescalations/${proposalId}/reject`);
+  },
+
+  // Acknowledge a decision and stop its escalation ladder
+  acknowledge: async (decisionId: string): Promise<DecisionState> => {
+    const response = await apiClient.post<{ decision: DecisionState }>(
+      `/operator/decisions/${decisionId}/acknowledge`
+    );
+    return response.data.decision;
+  },
+
+  // List active decisions nobody has acknowledged yet, oldest first
+  listUnacknowledged: async (): Promise<DecisionState[]> => {
+    const response = await apiClient.get<{ decisions: DecisionState[] }>('/operator/acknowledgements/pending');
+    return response.data.decisions;
+  },
+
+  // Notifications the acknowledgement ladder sent for a decision
+  getEscalations: async (decisionId: string): Promise<AckEscalation[]> => {
+    const response = await apiClient.get<{ escalations: AckEscalation[] }>(
+      `/operator/decisions/${decisionId}/escalations`
+    );
+    return response.data.escalations;
+  },
+
+  // Time-to-acknowledge KPI; defaults to the last 24 hours
+  getTTAStats: async (zoneId?: string, startTime?: string, endTime?: string): Promise<AcknowledgementStats> => {
+    const response = await apiClient.get<{ tta: AcknowledgementStats }>('/operator/kpi/tta', {
+      params: { zone_id: zoneId, start_time: startTime, end_time: endTime },
+    });
+    return response.data.tta;
+  },
+
   // Transition decision state
   transitionState: async (decisionId: string, data: DecisionTransitionRequest): Promise<DecisionState> => {
     const response = await apiClient.post<ApiResponse<DecisionState>>(
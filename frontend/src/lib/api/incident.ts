@@ -0,0 +1,86 @@
+import { apiClient } from '../api';
+
+export type IncidentStatus = 'open' | 'contained' | 'closed';
+export type IncidentItemType = 'decision' | 'approval' | 'cap_message' | 'assistance_request' | 'signal';
+
+export interface Incident {
+  id: string;
+  title: string;
+  description?: string;
+  status: IncidentStatus;
+  commander_id: string;
+  opened_by: string;
+  contained_at?: string;
+  closed_at?: string;
+  closed_by?: string;
+  created_at: string;
+  updated_at: string;
+}
+
+export interface IncidentLink {
+  id: string;
+  incident_id: string;
+  item_type: IncidentItemType;
+  item_id: string;
+  zone_id: string;
+  note?: string;
+  linked_by: string;
+  linked_at: string;
+  unlinked_by?: string;
+  unlinked_at?: string;
+}
+
+export interface IncidentReport {
+  incident: Incident;
+  zones: string[];
+  items: Partial<Record<IncidentItemType, IncidentLink[]>>;
+}
+
+export const incidentApi = {
+  // Open an incident
+  createIncident: async (data: { title: string; description?: string; commander_id?: string }): Promise<Incident> => {
+    const response = await apiClient.post<{ incident: Incident }>('/operator/incidents', data);
+    return response.data.incident;
+  },
+
+  // List incidents, optionally by status or by a zone they have items in
+  listIncidents: async (filters?: { status?: IncidentStatus; zone_id?: string }): Promise<Incident[]> => {
+    const response = await apiClient.get<{ incidents: Incident[] }>('/operator/incidents', { params: filters });
+    return response.data.incidents;
+  },
+
+  // Get an incident with its linked items grouped by type
+  getIncident: async (incidentId: string): Promise<IncidentReport> => {
+    const response = await apiClient.get<{ data: IncidentReport }>(`/operator/incidents/${incidentId}`);
+    return response.data.data;
+  },
+
+  // Move an incident through its lifecycle
+  updateStatus: async (incidentId: string, status: IncidentStatus): Promise<Incident> => {
+    const response = await apiClient.post<{ incident: Incident }>(`/operator/incidents/${incidentId}/status`, { status });
+    return response.data.incident;
+  },
+
+  // Assign the incident commander
+  assignCommander: async (incidentId: string, commanderId: string): Promise<Incident> => {
+    const response = await apiClient.put<{ incident: Incident }>(
+      `/operator/incidents/${incidentId}/commander`,
+      { commander_id: commanderId }
+    );
+    return response.data.incident;
+  },
+
+  // Link an item to an incident
+  linkItem: async (incidentId: string, itemType: IncidentItemType, itemId: string, note?: string): Promise<IncidentLink> => {
+    const response = await apiClient.post<{ link: IncidentLink }>(
+      `/operator/incidents/${incidentId}/links`,
+      { item_type: itemType, item_id: itemId, note }
+    );
+    return response.data.link;
+  },
+
+  // Unlink an item; the link stays in the incident's history
+  unlinkItem: async (incidentId: string, itemType: IncidentItemType, itemId: string): Promise<void> => {
+    await apiClient.delete(`/operator/incidents/${incidentId}/links/${itemType}/${itemId}`);
+  },
+};
@@ -0,0 +1,49 @@
+import { apiClient } from '../api';
+
+export interface ChecklistItem {
+  id: string;
+  checklist_id: string;
+  position: number;
+  key: string;
+  text: string;
+  required: boolean;
+  status: 'pending' | 'done' | 'waived';
+  resolved_by?: string;
+  resolved_at?: string;
+  waiver_reason?: string;
+}
+
+export interface Checklist {
+  id: string;
+  decision_id: string;
+  zone_id: string;
+  state: string;
+  playbook_id: string;
+  transition_id: string;
+  status: 'open' | 'completed' | 'abandoned';
+  closed_at?: string;
+  created_at: string;
+  items: ChecklistItem[];
+}
+
+export const playbookApi = {
+  // Get a decision's checklists; active is the checklist of its current state
+  getChecklists: async (decisionId: string): Promise<{ active: Checklist | null; checklists: Checklist[] }> => {
+    const response = await apiClient.get<{ active: Checklist | null; checklists: Checklist[] }>(
+      `/operator/decisions/${decisionId}/checklists`
+    );
+    return response.data;
+  },
+
+  // Tick off a checklist item
+  completeItem: async (itemId: string): Promise<ChecklistItem> => {
+    const response = await apiClient.post<{ item: ChecklistItem }>(`/operator/checklist-items/${itemId}/complete`);
+    return response.data.item;
+  },
+
+  // Explicitly waive a checklist item
+  waiveItem: async (itemId: string, reason: string): Promise<ChecklistItem> => {
+    const response = await apiClient.post<{ item: ChecklistItem }>(`/operator/checklist-items/${itemId}/waive`, { reason });
+    return response.data.item;
+  },
+};
@@ -3,9 +3,12 @@ package aggregation
 import (
 	"context"
 	"fmt"
+	"log"
 	"math"
+	"sync"
 	"time"
 
+	"github.com/erh-safety-system/poc/internal/cache"
 	"github.com/erh-safety-system/poc/internal/config"
 	"github.com/erh-safety-system/poc/internal/model"
 	"github.com/erh-safety-system/poc/internal/service"
@@ -24,20 +27,89 @@ const (
 
 // AggregationEngine handles signal aggregation
 type AggregationEngine struct {
+	mu          sync.RWMutex
 	timeWindows map[string]time.Duration
 	weights     map[string]map[string]float64
 	db          *gorm.DB
 	signalService *service.SignalService
+	baselines   *BaselineLearner
+	spatial     *SpatialCorrelator
+	cache       *cache.ZoneCache
+	observers   []SummaryObserver
 }
 
+// SummaryObserver is notified of every summary the engine stores
+type SummaryObserver func(ctx context.Context, summary *model.AggregatedSummary)
+
 // NewAggregationEngine creates a new aggregation engine
 func NewAggregationEngine(cfg *config.AggregationConfig, db *gorm.DB, signalService *service.SignalService) *AggregationEngine {
-	return &AggregationEngine{
+	engine := &AggregationEngine{
 		timeWindows:   convertTimeWindows(cfg.TimeWindows),
 		weights:       cfg.Weights,
 		db:            db,
 		signalService: signalService,
+		baselines:     NewBaselineLearner(db),
+		spatial:       NewSpatialCorrelator(db),
 	}
+	engine.baselines.SetWindowSource(engine.Window)
+	return engine
+}
+
+// Baselines returns the baseline learner used for anomaly scoring
+func (e *AggregationEngine) Baselines() *BaselineLearner {
+	return e.baselines
+}
+
+// SetCache enables write-through caching of each zone's latest summary
+func (e *AggregationEngine) SetCache(zoneCache *cache.ZoneCache) {
+	e.cache = zoneCache
+}
+
+// OnSummary registers an observer called after each summary is stored, e.g. shadow evaluation
+func (e *AggregationEngine) OnSummary(observer SummaryObserver) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.observers = append(e.observers, observer)
+}
+
+// UpdateConfig replaces the per-zone windows and weights used by subsequent aggregations
+func (e *AggregationEngine) UpdateConfig(cfg *config.AggregationConfig) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.timeWindows = convertTimeWindows(cfg.TimeWindows)
+	e.weights = cfg.Weights
+}
+
+// zoneWindow returns the configured window duration for a zone
+func (e *AggregationEngine) zoneWindow(zoneID string) (time.Duration, bool) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	window, exists := e.timeWindows[zoneID]
+	return window, exists
+}
+
+// zoneWeights returns the configured source weights for a zone
+func (e *AggregationEngine) zoneWeights(zoneID string) (map[string]float64, bool) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	weights, exists := e.weights[zoneID]
+	return weights, exists
+}
+
+// Weights returns a copy of the source weights used for a zone, e.g. to explain its confidence
+func (e *AggregationEngine) Weights(zoneID string) map[string]float64 {
+	weights, _ := e.zoneWeights(zoneID)
+	result := make(map[string]float64, len(weights))
+	for sourceType, weight := range weights {
+		result[sourceType] = weight
+	}
+	return result
+}
+
+// Window returns the aggregation window duration for a zone, or 0 for an unknown zone
+func (e *AggregationEngine) Window(zoneID string) time.Duration {
+	window, _ := e.zoneWindow(zoneID)
+	return window
 }
 
 // convertTimeWindows converts map[string]time.Duration to map[string]time.Duration (same type, but ensures compatibility)
@@ -48,7 +120,7 @@ func convertTimeWindows(windows map[string]time.Duration) map[string]time.Durati
 // Aggregate aggregates signals for a zone within a time window
 func (e *AggregationEngine) Aggregate(ctx context.Context, zoneID string, windowStart time.Time) (*model.AggregatedSummary, error) {
 	// 1. Get time window duration for this zone
-	windowDuration, exists := e.timeWindows[zoneID]
+	windowDuration, exists := e.zoneWindow(zoneID)
 	if !exists {
 		return nil, fmt.Errorf("unknown zone: %s", zoneID)
 	}
@@ -61,10 +133,69 @@ func (e *AggregationEngine) Aggregate(ctx context.Context, zoneID string, window
 		return nil, fmt.Errorf("failed to fetch signals: %w", err)
 	}
 	
-	// 3. Group signals by source type
+	// 3. Summarize the window
+	summary, err := e.Summarize(ctx, zoneID, windowStart, windowDuration, signals)
+	if err != nil {
+		return nil, err
+	}
+	
+	// 4. Save to database
+	if err := e.db.WithContext(ctx).Create(summary).Error; err != nil {
+		return nil, fmt.Errorf("failed to save aggregated summary: %w", err)
+	}
+	
+	e.cacheSummary(ctx, summary)
+	
+	e.mu.RLock()
+	observers := e.observers
+	e.mu.RUnlock()
+	for _, observer := range observers {
+		observer(ctx, summary)
+	}
+	
+	return summary, nil
+}
+
+// Simulate aggregates a window as if the given signals were added and the listed signals or sources
+// removed. Nothing is stored, cached or observed, so operators can ask what-if questions safely.
+func (e *AggregationEngine) Simulate(ctx context.Context, zoneID string, windowStart time.Time, add []*model.Signal, removeSignalIDs, removeSourceIDs []string) (*model.AggregatedSummary, error) {
+	windowDuration, exists := e.zoneWindow(zoneID)
+	if !exists {
+		return nil, fmt.Errorf("unknown zone: %s", zoneID)
+	}
+	
+	signals, err := e.signalService.GetSignalsByZoneAndWindow(ctx, zoneID, windowStart, windowStart.Add(windowDuration))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch signals: %w", err)
+	}
+	
+	removed := make(map[string]bool, len(removeSignalIDs)+len(removeSourceIDs))
+	for _, id := range removeSignalIDs {
+		removed["signal:"+id] = true
+	}
+	for _, id := range removeSourceIDs {
+		removed["source:"+id] = true
+	}
+	kept := make([]*model.Signal, 0, len(signals)+len(add))
+	for _, sig := range signals {
+		if removed["signal:"+sig.ID] || removed["source:"+sig.SourceID] {
+			continue
+		}
+		kept = append(kept, sig)
+	}
+	kept = append(kept, add...)
+	
+	return e.Summarize(ctx, zoneID, windowStart, windowDuration, kept)
+}
+
+// Summarize computes the summary of a zone's window from the given signals without storing it
+func (e *AggregationEngine) Summarize(ctx context.Context, zoneID string, windowStart time.Time, windowDuration time.Duration, signals []*model.Signal) (*model.AggregatedSummary, error) {
+	windowEnd := windowStart.Add(windowDuration)
+	
+	// 1. Group signals by source type
 	grouped := e.groupBySourceType(signals)
 	
-	// 4. Calculate source counts
+	// 2. Calculate source counts
 	sourceCount := make(model.JSONB)
 	signalIDs := make([]string, 0, len(signals))
 	for sourceType, group := range grouped {
@@ -76,12 +207,27 @@ func (e *AggregationEngine) Aggregate(ctx context.Context, zoneID string, window
 		}
 	}
 	
-	// 5. Calculate weighted aggregate value
+	// 3. Calculate weighted aggregate value
 	weightedValue := e.calculateWeightedValue(grouped, zoneID)
 	
-	// 6. Calculate confidence
+	// 4. Calculate confidence
 	confidence := e.calculateConfidence(grouped, zoneID)
 	
+	// 5. Score deviation from the seasonal baseline
+	anomalyScore, anomalyDetail, err := e.baselines.Score(ctx, zoneID, windowStart, windowDuration, signals)
+	if err != nil {
+		return nil, fmt.Errorf("failed to score anomaly: %w", err)
+	}
+	
+	// 6. Correlate with adjacent zones; rising neighbours raise confidence in corroborated activity here
+	neighbourPressure, neighbourDetail, err := e.spatial.Correlate(ctx, zoneID, windowEnd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to correlate adjacent zones: %w", err)
+	}
+	if len(signalIDs) > 0 {
+		confidence = boostConfidence(confidence, neighbourPressure)
+	}
+	
 	// 7. Create aggregated summary
 	summary := &model.AggregatedSummary{
 		ZoneID:       zoneID,
@@ -90,15 +236,47 @@ func (e *AggregationEngine) Aggregate(ctx context.Context, zoneID string, window
 		SourceCount:  sourceCount,
 		WeightedValue: weightedValue,
 		Confidence:   confidence,
+		AnomalyScore: anomalyScore,
+		AnomalyDetail: anomalyDetail,
+		NeighbourPressure: neighbourPressure,
+		NeighbourDetail: neighbourDetail,
 		SignalIDs:    signalIDs,
 	}
 	
-	// 8. Save to database
-	if err := e.db.WithContext(ctx).Create(summary).Error; err != nil {
-		return nil, fmt.Errorf("failed to save aggregated summary: %w", err)
+	return summary, nil
+}
+
+// GetLatestSummary gets the most recent aggregated summary for a zone
+func (e *AggregationEngine) GetLatestSummary(ctx context.Context, zoneID string) (*model.AggregatedSummary, error) {
+	var summary model.AggregatedSummary
+	if e.cache != nil && e.cache.Get(ctx, cache.KindSummary, zoneID, &summary) {
+		return &summary, nil
 	}
 	
-	return summary, nil
+	err := e.db.WithContext(ctx).
+		Where("zone_id = ?", zoneID).
+		Order("window_end DESC").
+		First(&summary).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get latest summary: %w", err)
+	}
+	
+	e.cacheSummary(ctx, &summary)
+	
+	return &summary, nil
+}
+
+// cacheSummary writes a summary through to the cache unless a newer one is already cached (e.g. during backfill)
+func (e *AggregationEngine) cacheSummary(ctx context.Context, summary *model.AggregatedSummary) {
+	if e.cache == nil {
+		return
+	}
+	if _, err := e.cache.SetIfNewer(ctx, cache.KindSummary, summary.ZoneID, summary, summary.WindowEnd.UnixNano()); err != nil {
+		log.Printf("Failed to cache summary for zone %s: %v", summary.ZoneID, err)
+	}
 }
 
 // groupBySourceType groups signals by their source type
@@ -135,7 +313,7 @@ func (e *AggregationEngine) filterEffectiveSignals(signals []*model.Signal) []*m
 
 // calculateWeightedValue calculates weighted aggregate value
 func (e *AggregationEngine) calculateWeightedValue(grouped map[string][]*model.Signal, zoneID string) float64 {
-	weights, exists := e.weights[zoneID]
+	weights, exists := e.zoneWeights(zoneID)
 	if !exists {
 		return 0.0
 	}
@@ -166,7 +344,7 @@ func (e *AggregationEngine) calculateWeightedValue(grouped map[string][]*model.S
 
 // calculateConfidence calculates confidence score based on signal sources
 func (e *AggregationEngine) calculateConfidence(grouped map[string][]*model.Signal, zoneID string) float64 {
-	weights, exists := e.weights[zoneID]
+	weights, exists := e.zoneWeights(zoneID)
 	if !exists {
 		return 0.0
 	}
@@ -5,6 +5,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/erh-safety-system/poc/internal/cache"
 	"github.com/erh-safety-system/poc/internal/config"
 	"github.com/erh-safety-system/poc/internal/model"
 	"github.com/erh-safety-system/poc/internal/service"
@@ -20,7 +21,7 @@ func setupAggregationTestDB(t *testing.T) (*gorm.DB, *service.SignalService) {
 	}
 	
 	// Auto migrate
-	if err := db.AutoMigrate(&model.Signal{}, &model.AggregatedSummary{}); err != nil {
+	if err := db.AutoMigrate(&model.Signal{}, &model.AggregatedSummary{}, &model.SignalBaseline{}); err != nil {
 		t.Fatalf("Failed to migrate: %v", err)
 	}
 	
@@ -45,6 +46,10 @@ func TestAggregationEngine_Aggregate(t *testing.T) {
 	}
 	
 	engine := NewAggregationEngine(cfg, db, signalService)
+	var observed []*model.AggregatedSummary
+	engine.OnSummary(func(ctx context.Context, summary *model.AggregatedSummary) {
+		observed = append(observed, summary)
+	})
 	
 	windowStart := time.Now().Add(-30 * time.Second)
 	
@@ -55,6 +60,7 @@ func TestAggregationEngine_Aggregate(t *testing.T) {
 	assert.NotNil(t, summary)
 	assert.Equal(t, "Z1", summary.ZoneID)
 	assert.Equal(t, windowStart, summary.WindowStart)
+	assert.Equal(t, []*model.AggregatedSummary{summary}, observed, "observers see every stored summary")
 }
 
 func TestAggregationEngine_filterEffectiveSignals(t *testing.T) {
@@ -79,3 +85,124 @@ func TestAggregationEngine_filterEffectiveSignals(t *testing.T) {
 	assert.Len(t, effective, 2)
 }
 
+
+func TestBaselineLearner_LearnAndScore(t *testing.T) {
+	db, _ := setupAggregationTestDB(t)
+	learner := NewBaselineLearner(db)
+	ctx := context.Background()
+	
+	// Four weeks of history: one density reading per week at the same hour of week
+	now := time.Now().Truncate(time.Hour)
+	slot := now.Add(-7 * 24 * time.Hour).Add(-2 * time.Hour)
+	for week := 0; week < 4; week++ {
+		signal := &model.Signal{
+			SourceType:   "infrastructure",
+			SourceID:     "sensor_001",
+			Timestamp:    slot.Add(-time.Duration(week) * 7 * 24 * time.Hour).Add(10 * time.Minute),
+			ZoneID:       "Z1",
+			SignalType:   "crowd_density",
+			Value:        model.JSONB{"density": 2.0},
+			QualityScore: 0.9,
+		}
+		assert.NoError(t, db.Create(signal).Error)
+	}
+	
+	count, err := learner.Learn(ctx, now.Add(-5*7*24*time.Hour), now)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+	
+	var baseline model.SignalBaseline
+	assert.NoError(t, db.First(&baseline).Error)
+	assert.Equal(t, hourOfWeek(slot), baseline.HourOfWeek)
+	assert.Equal(t, 5, baseline.SampleCount)
+	assert.InDelta(t, 2.0, baseline.ValueMean, 0.0001)
+	assert.InDelta(t, 4.0/300.0, baseline.RateMean, 0.0001, "rates are learned per one-minute window")
+	
+	// A burst of high-density readings in the same hour of week is anomalous
+	burst := make([]*model.Signal, 0)
+	for i := 0; i < 10; i++ {
+		burst = append(burst, &model.Signal{SignalType: "crowd_density", Value: model.JSONB{"density": 5.0}})
+	}
+	score, detail, err := learner.Score(ctx, "Z1", slot.Add(7*24*time.Hour), 60*time.Second, burst)
+	assert.NoError(t, err)
+	assert.Greater(t, score, 3.0)
+	assert.Contains(t, detail, "crowd_density")
+	
+	// A single reading is reported but too few signals to drive the score
+	score, detail, err = learner.Score(ctx, "Z1", slot.Add(7*24*time.Hour), 60*time.Second, burst[:1])
+	assert.NoError(t, err)
+	assert.Equal(t, 0.0, score)
+	assert.Equal(t, false, detail["crowd_density"].(map[string]interface{})["scored"])
+	
+	// No baseline for this hour of week means no anomaly score
+	score, _, err = learner.Score(ctx, "Z1", slot.Add(time.Hour), 60*time.Second, burst)
+	assert.NoError(t, err)
+	assert.Equal(t, 0.0, score)
+}
+
+func TestSpatialCorrelator_Correlate(t *testing.T) {
+	db, _ := setupAggregationTestDB(t)
+	correlator := NewSpatialCorrelator(db)
+	ctx := context.Background()
+	
+	now := time.Now()
+	
+	// Z3 (adjacent to Z1) is rising; Z4 (not adjacent) is ignored
+	summaries := []*model.AggregatedSummary{
+		{ZoneID: "Z3", WindowStart: now.Add(-3 * time.Minute), WindowEnd: now.Add(-90 * time.Second), WeightedValue: 1.0, Confidence: 0.6},
+		{ZoneID: "Z3", WindowStart: now.Add(-90 * time.Second), WindowEnd: now, WeightedValue: 3.0, Confidence: 0.8},
+		{ZoneID: "Z4", WindowStart: now.Add(-2 * time.Minute), WindowEnd: now, WeightedValue: 5.0, Confidence: 0.9},
+	}
+	for _, summary := range summaries {
+		assert.NoError(t, db.Create(summary).Error)
+	}
+	
+	pressure, detail, err := correlator.Correlate(ctx, "Z1", now)
+	assert.NoError(t, err)
+	assert.InDelta(t, 0.8, pressure, 0.0001)
+	assert.Contains(t, detail, "Z3")
+	assert.NotContains(t, detail, "Z4")
+	
+	// Z4 is adjacent to Z2 but has no earlier summary to compare with, so it is not rising
+	pressure, detail, err = correlator.Correlate(ctx, "Z2", now)
+	assert.NoError(t, err)
+	assert.Equal(t, 0.0, pressure)
+	assert.Equal(t, false, detail["Z4"].(map[string]interface{})["rising"])
+	
+	assert.InDelta(t, 0.62, boostConfidence(0.5, 0.8), 0.0001)
+	assert.Equal(t, 1.0, boostConfidence(0.95, 1.0))
+}
+
+func TestAggregationEngine_GetLatestSummaryCached(t *testing.T) {
+	db, signalService := setupAggregationTestDB(t)
+	cfg := &config.AggregationConfig{
+		TimeWindows: map[string]time.Duration{"Z1": 60 * time.Second},
+		Weights:     map[string]map[string]float64{"Z1": {"infrastructure": 1.0}},
+	}
+	engine := NewAggregationEngine(cfg, db, signalService)
+	zoneCache := cache.NewZoneCache(nil)
+	engine.SetCache(zoneCache)
+	ctx := context.Background()
+	
+	now := time.Now()
+	latest, err := engine.Aggregate(ctx, "Z1", now.Add(-time.Minute))
+	assert.NoError(t, err)
+	
+	// Backfilling an older window must not replace the cached latest summary
+	_, err = engine.Aggregate(ctx, "Z1", now.Add(-time.Hour))
+	assert.NoError(t, err)
+	
+	summary, err := engine.GetLatestSummary(ctx, "Z1")
+	assert.NoError(t, err)
+	assert.Equal(t, latest.ID, summary.ID)
+	
+	stats := zoneCache.Stats()[cache.KindSummary]
+	assert.Equal(t, int64(1), stats.Hits)
+	assert.Equal(t, int64(0), stats.Misses)
+	
+	// Unknown zones fall through to the database
+	summary, err = engine.GetLatestSummary(ctx, "Z2")
+	assert.NoError(t, err)
+	assert.Nil(t, summary)
+	assert.Equal(t, int64(1), zoneCache.Stats()[cache.KindSummary].Misses)
+}
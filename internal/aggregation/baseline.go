@@ -0,0 +1,314 @@
+package aggregation
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"github.com/erh-safety-system/poc/internal/leader"
+	"github.com/erh-safety-system/poc/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// Buckets observed fewer times than this are not trusted for scoring
+	minBaselineSamples = 3
+
+	// Signal types with fewer signals than this in a window do not contribute to the anomaly score,
+	// so a single signal in a quiet hour cannot trigger a pre-alert on its own
+	minAnomalySignals = 3
+
+	// Window used for rate variance when a zone has no configured window
+	defaultBaselineWindow = time.Minute
+
+	// Floors to keep z-scores finite for very stable buckets
+	minRateStdDev  = 0.05 // signals per minute
+	minValueStdDev = 0.01
+)
+
+// valueKeys are the JSONB keys checked (in order) for a signal's numeric value
+var valueKeys = []string{"value", "count", "density", "level", "confidence"}
+
+// BaselineLearner builds hour-of-week profiles of signal rates and values per zone and signal type.
+// Rate variance is learned at the zone's aggregation window so it matches the windows being scored.
+type BaselineLearner struct {
+	db      *gorm.DB
+	windows func(zoneID string) time.Duration
+}
+
+// NewBaselineLearner creates a new baseline learner
+func NewBaselineLearner(db *gorm.DB) *BaselineLearner {
+	return &BaselineLearner{
+		db: db,
+	}
+}
+
+// SetWindowSource sets how the aggregation window of a zone is looked up
+func (b *BaselineLearner) SetWindowSource(windows func(zoneID string) time.Duration) {
+	b.windows = windows
+}
+
+// window returns the aggregation window rates are learned at for a zone
+func (b *BaselineLearner) window(zoneID string) time.Duration {
+	if b.windows != nil {
+		if window := b.windows(zoneID); window > 0 {
+			return window
+		}
+	}
+	return defaultBaselineWindow
+}
+
+// baselineKey identifies one seasonal bucket
+type baselineKey struct {
+	zoneID     string
+	signalType string
+	hourOfWeek int
+}
+
+// baselineAccumulator collects running sums for one bucket
+type baselineAccumulator struct {
+	window       time.Duration
+	windowCounts map[int64]int // window slot -> signal count
+	valueSum     float64
+	valueSumSq   float64
+	valueCount   int
+}
+
+// Learn rebuilds baselines from historical signals in [since, until)
+func (b *BaselineLearner) Learn(ctx context.Context, since, until time.Time) (int, error) {
+	since = since.Truncate(time.Hour)
+	until = until.Truncate(time.Hour)
+	if !until.After(since) {
+		return 0, fmt.Errorf("invalid learning range: %s - %s", since, until)
+	}
+
+	accumulators := make(map[baselineKey]*baselineAccumulator)
+
+	var batch []*model.Signal
+	err := b.db.WithContext(ctx).
+		Where("timestamp >= ? AND timestamp < ?", since, until).
+		FindInBatches(&batch, 1000, func(tx *gorm.DB, _ int) error {
+			for _, signal := range batch {
+				key := baselineKey{
+					zoneID:     signal.ZoneID,
+					signalType: signal.SignalType,
+					hourOfWeek: hourOfWeek(signal.Timestamp),
+				}
+				acc, exists := accumulators[key]
+				if !exists {
+					acc = &baselineAccumulator{window: b.window(signal.ZoneID), windowCounts: make(map[int64]int)}
+					accumulators[key] = acc
+				}
+				acc.windowCounts[signal.Timestamp.UnixNano()/int64(acc.window)]++
+
+				if value, ok := numericValue(signal); ok {
+					acc.valueSum += value
+					acc.valueSumSq += value * value
+					acc.valueCount++
+				}
+			}
+			return nil
+		}).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to load historical signals: %w", err)
+	}
+
+	// Count how often each hour of week occurs in the range so that hours without signals count as zero
+	slotsPerHour := make(map[int]int)
+	for t := since; t.Before(until); t = t.Add(time.Hour) {
+		slotsPerHour[hourOfWeek(t)]++
+	}
+
+	baselines := make([]*model.SignalBaseline, 0, len(accumulators))
+	for key, acc := range accumulators {
+		samples := slotsPerHour[key.hourOfWeek]
+		if samples == 0 {
+			continue
+		}
+
+		// Rates are signals per minute within each window; windows without signals count as zero
+		windowsPerHour := int(time.Hour / acc.window)
+		if windowsPerHour < 1 {
+			windowsPerHour = 1
+		}
+		var rateSum, rateSumSq float64
+		for _, count := range acc.windowCounts {
+			rate := float64(count) / acc.window.Minutes()
+			rateSum += rate
+			rateSumSq += rate * rate
+		}
+		rateMean, rateStdDev := meanStdDev(rateSum, rateSumSq, samples*windowsPerHour)
+
+		baseline := &model.SignalBaseline{
+			ID:          fmt.Sprintf("baseline_%s_%s_%d", key.zoneID, key.signalType, key.hourOfWeek),
+			ZoneID:      key.zoneID,
+			SignalType:  key.signalType,
+			HourOfWeek:  key.hourOfWeek,
+			SampleCount: samples,
+			RateMean:    rateMean,
+			RateStdDev:  rateStdDev,
+			ValueCount:  acc.valueCount,
+			LearnedFrom: since,
+			LearnedTo:   until,
+		}
+		if acc.valueCount > 0 {
+			baseline.ValueMean, baseline.ValueStdDev = meanStdDev(acc.valueSum, acc.valueSumSq, acc.valueCount)
+		}
+
+		baselines = append(baselines, baseline)
+	}
+
+	if len(baselines) == 0 {
+		return 0, nil
+	}
+
+	// A leader that has since been replaced must not overwrite baselines the new leader learned
+	err = b.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := leader.Guard(tx); err != nil {
+			return err
+		}
+		return tx.Clauses(clause.OnConflict{UpdateAll: true}).CreateInBatches(baselines, 500).Error
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to save baselines: %w", err)
+	}
+
+	return len(baselines), nil
+}
+
+// Score calculates how far the signals of a window deviate from the learned baseline.
+// It returns the maximum upward z-score across signal types and a per-type breakdown.
+// Signal types seen fewer than minAnomalySignals times in the window are reported but not scored.
+func (b *BaselineLearner) Score(ctx context.Context, zoneID string, windowStart time.Time, windowDuration time.Duration, signals []*model.Signal) (float64, model.JSONB, error) {
+	var baselines []*model.SignalBaseline
+	if err := b.db.WithContext(ctx).
+		Where("zone_id = ? AND hour_of_week = ?", zoneID, hourOfWeek(windowStart)).
+		Find(&baselines).Error; err != nil {
+		return 0, nil, fmt.Errorf("failed to get baselines: %w", err)
+	}
+
+	if len(baselines) == 0 || windowDuration <= 0 {
+		return 0, nil, nil
+	}
+
+	// Group window signals by signal type
+	counts := make(map[string]int)
+	valueSums := make(map[string]float64)
+	valueCounts := make(map[string]int)
+	for _, signal := range signals {
+		counts[signal.SignalType]++
+		if value, ok := numericValue(signal); ok {
+			valueSums[signal.SignalType] += value
+			valueCounts[signal.SignalType]++
+		}
+	}
+
+	windowMinutes := windowDuration.Minutes()
+	score := 0.0
+	detail := make(model.JSONB)
+
+	for _, baseline := range baselines {
+		if baseline.SampleCount < minBaselineSamples {
+			continue
+		}
+
+		rate := float64(counts[baseline.SignalType]) / windowMinutes
+		rateZ := (rate - baseline.RateMean) / math.Max(baseline.RateStdDev, minRateStdDev)
+		typeDetail := map[string]interface{}{
+			"rate":      rate,
+			"rate_mean": baseline.RateMean,
+			"rate_z":    rateZ,
+		}
+		scored := counts[baseline.SignalType] >= minAnomalySignals
+		typeDetail["scored"] = scored
+		if scored {
+			score = math.Max(score, rateZ)
+		}
+
+		if n := valueCounts[baseline.SignalType]; n > 0 && baseline.ValueCount >= minBaselineSamples {
+			value := valueSums[baseline.SignalType] / float64(n)
+			valueFloor := math.Max(math.Abs(baseline.ValueMean)*0.1, minValueStdDev)
+			valueZ := (value - baseline.ValueMean) / math.Max(baseline.ValueStdDev, valueFloor)
+			typeDetail["value"] = value
+			typeDetail["value_mean"] = baseline.ValueMean
+			typeDetail["value_z"] = valueZ
+			if scored {
+				score = math.Max(score, valueZ)
+			}
+		}
+
+		detail[baseline.SignalType] = typeDetail
+	}
+
+	return score, detail, nil
+}
+
+// Start periodically relearns baselines until the context is cancelled
+func (b *BaselineLearner) Start(ctx context.Context, interval, lookback time.Duration) {
+	relearn := func() {
+		now := time.Now()
+		count, err := b.Learn(ctx, now.Add(-lookback), now)
+		if err != nil {
+			log.Printf("Error learning signal baselines: %v", err)
+			return
+		}
+		log.Printf("Learned %d signal baselines", count)
+	}
+
+	relearn()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("Baseline learner stopped")
+			return
+		case <-ticker.C:
+			relearn()
+		}
+	}
+}
+
+// hourOfWeek returns the hour of week (0-167) of a timestamp in local time
+func hourOfWeek(t time.Time) int {
+	t = t.Local()
+	return int(t.Weekday())*24 + t.Hour()
+}
+
+// numericValue extracts a numeric value from a signal's JSONB value
+func numericValue(signal *model.Signal) (float64, bool) {
+	if signal.Value == nil {
+		return 0, false
+	}
+	for _, key := range valueKeys {
+		switch v := signal.Value[key].(type) {
+		case float64:
+			return v, true
+		case float32:
+			return float64(v), true
+		case int:
+			return float64(v), true
+		case int64:
+			return float64(v), true
+		}
+	}
+	return 0, false
+}
+
+// meanStdDev calculates mean and population standard deviation from running sums
+func meanStdDev(sum, sumSq float64, n int) (float64, float64) {
+	if n == 0 {
+		return 0, 0
+	}
+	mean := sum / float64(n)
+	variance := sumSq/float64(n) - mean*mean
+	if variance < 0 {
+		variance = 0
+	}
+	return mean, math.Sqrt(variance)
+}
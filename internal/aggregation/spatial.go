@@ -0,0 +1,106 @@
+package aggregation
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/erh-safety-system/poc/internal/model"
+	"gorm.io/gorm"
+)
+
+const (
+	// Only neighbour summaries this recent are considered for correlation
+	spatialHorizon = 5 * time.Minute
+
+	// Maximum confidence added to a zone when its neighbours are rising
+	spatialConfidenceBoost = 0.15
+)
+
+// zoneAdjacency describes which zones are directly connected (walkable without crossing another zone)
+var zoneAdjacency = map[string][]string{
+	"Z1": {"Z2", "Z3"},
+	"Z2": {"Z1", "Z4"},
+	"Z3": {"Z1", "Z4"},
+	"Z4": {"Z2", "Z3"},
+}
+
+// Neighbours returns the zones adjacent to a zone
+func Neighbours(zoneID string) []string {
+	return append([]string(nil), zoneAdjacency[zoneID]...)
+}
+
+// Adjacency returns a copy of the zone connectivity graph
+func Adjacency() map[string][]string {
+	graph := make(map[string][]string, len(zoneAdjacency))
+	for zoneID := range zoneAdjacency {
+		graph[zoneID] = Neighbours(zoneID)
+	}
+	return graph
+}
+
+// SpatialCorrelator correlates a zone's signals with recent activity in adjacent zones
+type SpatialCorrelator struct {
+	db *gorm.DB
+}
+
+// NewSpatialCorrelator creates a new spatial correlator
+func NewSpatialCorrelator(db *gorm.DB) *SpatialCorrelator {
+	return &SpatialCorrelator{
+		db: db,
+	}
+}
+
+// Correlate calculates neighbour pressure (0-1) for a zone at the end of a window.
+// A neighbour contributes pressure when its latest summary is rising compared to the one before it;
+// a neighbour with no earlier window in the horizon is not rising.
+func (c *SpatialCorrelator) Correlate(ctx context.Context, zoneID string, windowEnd time.Time) (float64, model.JSONB, error) {
+	pressure := 0.0
+	detail := make(model.JSONB)
+
+	for _, neighbour := range Neighbours(zoneID) {
+		var summaries []*model.AggregatedSummary
+		if err := c.db.WithContext(ctx).
+			Where("zone_id = ? AND window_end <= ? AND window_end > ?", neighbour, windowEnd, windowEnd.Add(-spatialHorizon)).
+			Order("window_end DESC").
+			Limit(2).
+			Find(&summaries).Error; err != nil {
+			return 0, nil, fmt.Errorf("failed to get summaries for neighbour %s: %w", neighbour, err)
+		}
+
+		if len(summaries) == 0 {
+			continue
+		}
+
+		latest := summaries[0]
+		var previousValue *float64
+		rising := false
+		if len(summaries) > 1 {
+			previousValue = &summaries[1].WeightedValue
+			rising = latest.WeightedValue > *previousValue
+		}
+
+		neighbourPressure := 0.0
+		if rising {
+			neighbourPressure = math.Min(latest.Confidence, 1.0)
+		}
+		pressure = math.Max(pressure, neighbourPressure)
+
+		detail[neighbour] = map[string]interface{}{
+			"summary_id":              latest.ID,
+			"weighted_value":          latest.WeightedValue,
+			"previous_weighted_value": previousValue,
+			"confidence":              latest.Confidence,
+			"rising":                  rising,
+			"pressure":                neighbourPressure,
+		}
+	}
+
+	return pressure, detail, nil
+}
+
+// boostConfidence raises a zone's confidence in proportion to neighbour pressure
+func boostConfidence(confidence, pressure float64) float64 {
+	return math.Min(confidence+spatialConfidenceBoost*pressure, 1.0)
+}
@@ -95,7 +95,7 @@ func determineOperationType(method, path string) string {
 	if contains(path, "/cap") {
 		return "cap_message"
 	}
-	if contains(path, "/erh") {
+	if contains(path, "/erh") || contains(path, "/system") {
 		return "system_config"
 	}
 	if contains(path, "/route2") {
@@ -0,0 +1,105 @@
+package backtest
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/erh-safety-system/poc/internal/config"
+	"github.com/erh-safety-system/poc/internal/decision"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const quietScenario = `
+scenario_id: z2_quiet_evening
+zone: Z2
+duration: 2m
+signals:
+  - at: 30s
+    source_type: infrastructure
+    signal_type: occupancy
+    quality: 0.55
+    repeat: 6
+    every: 5s
+  - at: 30s
+    source_type: staff
+    signal_type: crowding_report
+    quality: 0.55
+labels:
+  - at: 0s
+    state: inactive
+    note: Normal evening peak
+`
+
+func TestLoadScenario(t *testing.T) {
+	scenario, err := LoadScenario("../../scenarios/z2_platform_crowding.md")
+	require.NoError(t, err)
+	assert.Equal(t, "z2_platform_crowding", scenario.ID)
+	assert.Equal(t, 5*time.Minute, scenario.End().Sub(scenario.Start))
+	assert.Equal(t, decision.StateInactive, scenario.ExpectedState(59*time.Second))
+	assert.Equal(t, decision.StateD1, scenario.ExpectedState(2*time.Minute))
+	assert.Len(t, scenario.BuildSignals(), 36)
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "bad.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("scenario_id: x\nzone: Z2\nduration: 1m\nlabels:\n  - at: 0s\n    state: D9\n"), 0o600))
+	_, err = LoadScenario(path)
+	assert.ErrorContains(t, err, "unknown state D9")
+
+	_, err = LoadScenario("../../templates/scenario_template.md")
+	assert.ErrorContains(t, err, "scenario_id is required")
+}
+
+func TestRunner_ScoresAgainstLabels(t *testing.T) {
+	scenario, err := LoadScenario("../../scenarios/z2_platform_crowding.md")
+	require.NoError(t, err)
+
+	runner := NewRunner(config.Load(), nil)
+	result, err := runner.Run(context.Background(), scenario)
+	require.NoError(t, err)
+
+	assert.Equal(t, "builtin@v1", result.Policy)
+	require.NotNil(t, result.TimeToD0)
+	assert.Equal(t, 10*time.Second, time.Duration(*result.TimeToD0))
+	assert.Empty(t, result.FalseEscalations)
+	assert.Equal(t, decision.StateD2, result.FinalState)
+
+	// The evaluator holds D0 while the window still has two sources, so D1 is only reached once D2 is expected
+	assert.Equal(t, 1, result.MissedEscalations)
+	assert.True(t, result.Labels[2].Missed)
+	assert.Nil(t, result.Labels[2].TimeToCorrect)
+	require.NotNil(t, result.Labels[3].TimeToCorrect)
+}
+
+func TestRunner_FalseEscalation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "quiet.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(quietScenario), 0o600))
+	scenario, err := LoadScenario(path)
+	require.NoError(t, err)
+
+	result, err := NewRunner(config.Load(), nil).Run(context.Background(), scenario)
+	require.NoError(t, err)
+
+	assert.Nil(t, result.TimeToD0, "no escalation was expected")
+	assert.Equal(t, 0, result.MissedEscalations)
+	require.NotEmpty(t, result.FalseEscalations)
+	assert.Equal(t, decision.StateD0, result.FalseEscalations[0].To)
+	assert.Equal(t, decision.StateInactive, result.FalseEscalations[0].Expected)
+
+	report := NewReport(DefaultStep, []*Result{result})
+	assert.Equal(t, []string{"builtin@v1"}, report.Policies)
+	assert.Equal(t, len(result.FalseEscalations), report.Totals.FalseEscalations)
+
+	var markdown bytes.Buffer
+	require.NoError(t, report.WriteMarkdown(&markdown))
+	assert.Contains(t, markdown.String(), "| z2_quiet_evening | Z2 | builtin@v1 | never |")
+	assert.Contains(t, markdown.String(), "inactive -> D0 while inactive was expected")
+
+	var encoded bytes.Buffer
+	require.NoError(t, report.WriteJSON(&encoded))
+	assert.Contains(t, encoded.String(), `"step": "10s"`)
+}
@@ -0,0 +1,266 @@
+package backtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/erh-safety-system/poc/internal/decision"
+)
+
+// Duration is a time.Duration that reads as "1m30s" in JSON reports
+type Duration time.Duration
+
+// MarshalJSON implements json.Marshaler
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// LabelResult scores one labelled segment of a scenario
+type LabelResult struct {
+	At            Duration               `json:"at"`
+	State         decision.DecisionState `json:"state"`
+	Note          string                 `json:"note,omitempty"`
+	TimeToCorrect *Duration              `json:"time_to_correct_state"` // nil if the state was never reached in the segment
+	Missed        bool                   `json:"missed_escalation"`
+}
+
+// Escalation is a simulated escalation beyond the labelled state
+type Escalation struct {
+	At       Duration               `json:"at"`
+	From     decision.DecisionState `json:"from"`
+	To       decision.DecisionState `json:"to"`
+	Expected decision.DecisionState `json:"expected"`
+}
+
+// Result is the outcome of replaying one scenario
+type Result struct {
+	ScenarioID        string                 `json:"scenario_id"`
+	Zone              string                 `json:"zone"`
+	Source            string                 `json:"source"`
+	Policy            string                 `json:"policy"`
+	TimeToD0          *Duration              `json:"time_to_d0"` // From the first escalated label; nil if D0 was never reached or not expected
+	Labels            []LabelResult          `json:"labels"`
+	MissedEscalations int                    `json:"missed_escalations"`
+	FalseEscalations  []Escalation           `json:"false_escalations"`
+	Agreement         float64                `json:"agreement"` // Fraction of steps in the labelled state
+	FinalState        decision.DecisionState `json:"final_state"`
+	Steps             []Step                 `json:"steps"`
+}
+
+// Totals aggregates results across scenarios
+type Totals struct {
+	Scenarios         int       `json:"scenarios"`
+	ReachedD0         int       `json:"reached_d0"`
+	MeanTimeToD0      *Duration `json:"mean_time_to_d0"`
+	MissedEscalations int       `json:"missed_escalations"`
+	FalseEscalations  int       `json:"false_escalations"`
+	Agreement         float64   `json:"agreement"`
+}
+
+// Report collects backtest results for attaching to a policy change request
+type Report struct {
+	GeneratedAt time.Time `json:"generated_at"`
+	Step        Duration  `json:"step"`
+	Policies    []string  `json:"policies"`
+	Totals      Totals    `json:"totals"`
+	Results     []*Result `json:"results"`
+}
+
+// NewReport summarizes the results of a backtest run
+func NewReport(step time.Duration, results []*Result) *Report {
+	report := &Report{
+		GeneratedAt: time.Now().UTC(),
+		Step:        Duration(step),
+		Policies:    make([]string, 0),
+		Results:     results,
+	}
+
+	policies := make(map[string]bool)
+	var timeToD0 time.Duration
+	for _, result := range results {
+		report.Totals.Scenarios++
+		report.Totals.MissedEscalations += result.MissedEscalations
+		report.Totals.FalseEscalations += len(result.FalseEscalations)
+		report.Totals.Agreement += result.Agreement
+		if result.TimeToD0 != nil {
+			report.Totals.ReachedD0++
+			timeToD0 += time.Duration(*result.TimeToD0)
+		}
+		if !policies[result.Policy] {
+			policies[result.Policy] = true
+			report.Policies = append(report.Policies, result.Policy)
+		}
+	}
+	if report.Totals.Scenarios > 0 {
+		report.Totals.Agreement /= float64(report.Totals.Scenarios)
+	}
+	if report.Totals.ReachedD0 > 0 {
+		mean := Duration(timeToD0 / time.Duration(report.Totals.ReachedD0))
+		report.Totals.MeanTimeToD0 = &mean
+	}
+	sort.Strings(report.Policies)
+
+	return report
+}
+
+// WriteJSON writes the report as indented JSON
+func (r *Report) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(r)
+}
+
+// WriteMarkdown writes the report as Markdown. Per-step traces are left to the JSON report.
+func (r *Report) WriteMarkdown(w io.Writer) error {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "# Decision Backtest Report\n\n")
+	fmt.Fprintf(&b, "- generated: %s\n", r.GeneratedAt.Format(time.RFC3339))
+	fmt.Fprintf(&b, "- policies: %s\n", strings.Join(r.Policies, ", "))
+	fmt.Fprintf(&b, "- evaluation step: %s\n\n", time.Duration(r.Step))
+
+	b.WriteString("## Totals\n\n")
+	b.WriteString("| Scenarios | Reached D0 | Mean time-to-D0 | Missed escalations | False escalations | Agreement |\n")
+	b.WriteString("|---|---|---|---|---|---|\n")
+	fmt.Fprintf(&b, "| %d | %d | %s | %d | %d | %.0f%% |\n\n",
+		r.Totals.Scenarios, r.Totals.ReachedD0, formatDuration(r.Totals.MeanTimeToD0),
+		r.Totals.MissedEscalations, r.Totals.FalseEscalations, r.Totals.Agreement*100)
+
+	b.WriteString("## Scenarios\n\n")
+	b.WriteString("| Scenario | Zone | Policy | Time-to-D0 | Missed | False | Agreement | Final state |\n")
+	b.WriteString("|---|---|---|---|---|---|---|---|\n")
+	for _, result := range r.Results {
+		fmt.Fprintf(&b, "| %s | %s | %s | %s | %d | %d | %.0f%% | %s |\n",
+			result.ScenarioID, result.Zone, result.Policy, formatDuration(result.TimeToD0),
+			result.MissedEscalations, len(result.FalseEscalations), result.Agreement*100, result.FinalState)
+	}
+
+	for _, result := range r.Results {
+		fmt.Fprintf(&b, "\n### %s\n\n", result.ScenarioID)
+		fmt.Fprintf(&b, "Source: `%s`\n\n", result.Source)
+		b.WriteString("| Label at | Expected state | Time-to-correct-state | Missed escalation | Note |\n")
+		b.WriteString("|---|---|---|---|---|\n")
+		for _, label := range result.Labels {
+			missed := ""
+			if label.Missed {
+				missed = "yes"
+			}
+			fmt.Fprintf(&b, "| %s | %s | %s | %s | %s |\n",
+				time.Duration(label.At), label.State, formatDuration(label.TimeToCorrect), missed, label.Note)
+		}
+
+		if len(result.FalseEscalations) > 0 {
+			b.WriteString("\nFalse escalations:\n\n")
+			for _, escalation := range result.FalseEscalations {
+				fmt.Fprintf(&b, "- at %s: %s -> %s while %s was expected\n",
+					time.Duration(escalation.At), escalation.From, escalation.To, escalation.Expected)
+			}
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// formatDuration renders an optional duration, "never" when absent
+func formatDuration(d *Duration) string {
+	if d == nil {
+		return "never"
+	}
+	return time.Duration(*d).String()
+}
+
+// score compares the simulated states of a replay with the scenario's labels
+func score(scenario *Scenario, policyID string, step time.Duration, steps []Step) *Result {
+	result := &Result{
+		ScenarioID:       scenario.ID,
+		Zone:             scenario.Zone,
+		Source:           scenario.Source,
+		Policy:           policyID,
+		Labels:           make([]LabelResult, 0, len(scenario.Labels)),
+		FalseEscalations: make([]Escalation, 0),
+		FinalState:       decision.StateInactive,
+		Steps:            steps,
+	}
+
+	// Time-to-D0 runs from the first label expecting the zone to leave inactive
+	var escalatedAt *time.Duration
+	for _, label := range scenario.Labels {
+		if decision.DecisionState(label.State).Severity() > 0 {
+			at := label.offset
+			escalatedAt = &at
+			break
+		}
+	}
+
+	agreeing := 0
+	previous := decision.StateInactive
+	for _, s := range steps {
+		offset := time.Duration(s.Offset)
+		if s.State == s.Expected {
+			agreeing++
+		}
+		if escalatedAt != nil && result.TimeToD0 == nil && s.State.Severity() > 0 && offset >= *escalatedAt {
+			d := Duration(offset - *escalatedAt)
+			result.TimeToD0 = &d
+		}
+		if s.State.Severity() > previous.Severity() && s.State.Severity() > s.Expected.Severity() {
+			result.FalseEscalations = append(result.FalseEscalations, Escalation{
+				At:       s.Offset,
+				From:     previous,
+				To:       s.State,
+				Expected: s.Expected,
+			})
+		}
+		previous = s.State
+	}
+	if len(steps) > 0 {
+		result.Agreement = float64(agreeing) / float64(len(steps))
+		result.FinalState = steps[len(steps)-1].State
+	}
+
+	expectedBefore := decision.StateInactive
+	for i, label := range scenario.Labels {
+		end := scenario.End().Sub(scenario.Start) + step
+		if i+1 < len(scenario.Labels) {
+			end = scenario.Labels[i+1].offset
+		}
+
+		state := decision.DecisionState(label.State)
+		labelResult := LabelResult{
+			At:    Duration(label.offset),
+			State: state,
+			Note:  label.Note,
+		}
+
+		reached := false
+		for _, s := range steps {
+			offset := time.Duration(s.Offset)
+			if offset < label.offset || offset >= end {
+				continue
+			}
+			if s.State.Severity() >= state.Severity() {
+				reached = true
+			}
+			if s.State == state && labelResult.TimeToCorrect == nil {
+				d := Duration(offset - label.offset)
+				labelResult.TimeToCorrect = &d
+			}
+		}
+
+		// An escalation is missed if the segment ends without the simulation getting at least that far
+		if state.Severity() > expectedBefore.Severity() && !reached {
+			labelResult.Missed = true
+			result.MissedEscalations++
+		}
+
+		result.Labels = append(result.Labels, labelResult)
+		expectedBefore = state
+	}
+
+	return result
+}
@@ -0,0 +1,198 @@
+package backtest
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/erh-safety-system/poc/internal/aggregation"
+	"github.com/erh-safety-system/poc/internal/config"
+	"github.com/erh-safety-system/poc/internal/decision"
+	"github.com/erh-safety-system/poc/internal/model"
+	"github.com/erh-safety-system/poc/internal/service"
+	"github.com/google/uuid"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// DefaultStep is how often the replay aggregates and evaluates
+const DefaultStep = 10 * time.Second
+
+// Step is one aggregation and evaluation during a replay
+type Step struct {
+	Offset     Duration               `json:"offset"`
+	Expected   decision.DecisionState `json:"expected"`
+	Proposed   decision.DecisionState `json:"proposed"`
+	State      decision.DecisionState `json:"state"` // Simulated state after applying the proposal
+	Confidence float64                `json:"confidence"`
+	Reason     string                 `json:"reason"`
+}
+
+// Runner replays scenarios through aggregation and the decision evaluator. Each scenario runs
+// against its own in-memory store, so replays never touch live summaries or decisions.
+type Runner struct {
+	cfg      *config.Config
+	policies *decision.PolicyRegistry
+	history  *gorm.DB
+	step     time.Duration
+}
+
+// NewRunner creates a new runner using the given thresholds, windows and policies
+func NewRunner(cfg *config.Config, policies *decision.PolicyRegistry) *Runner {
+	if policies == nil {
+		policies = decision.NewPolicyRegistry()
+	}
+	return &Runner{
+		cfg:      cfg,
+		policies: policies,
+		step:     DefaultStep,
+	}
+}
+
+// SetStep sets how often the replay aggregates and evaluates
+func (r *Runner) SetStep(step time.Duration) {
+	if step > 0 {
+		r.step = step
+	}
+}
+
+// SetHistory sets the signal store that scenarios without listed signals are replayed from
+func (r *Runner) SetHistory(db *gorm.DB) {
+	r.history = db
+}
+
+// Run replays a scenario and scores the simulated decisions against its labels
+func (r *Runner) Run(ctx context.Context, scenario *Scenario) (*Result, error) {
+	if _, exists := r.cfg.Aggregation.TimeWindows[scenario.Zone]; !exists {
+		return nil, fmt.Errorf("scenario %s: unknown zone: %s", scenario.ID, scenario.Zone)
+	}
+
+	db, err := openSandbox()
+	if err != nil {
+		return nil, fmt.Errorf("scenario %s: %w", scenario.ID, err)
+	}
+	defer closeSandbox(db)
+
+	if err := r.seed(ctx, db, scenario); err != nil {
+		return nil, fmt.Errorf("scenario %s: %w", scenario.ID, err)
+	}
+
+	engine := aggregation.NewAggregationEngine(&r.cfg.Aggregation, db, service.NewSignalService(db))
+	evaluator := decision.NewDecisionEvaluator(&r.cfg.Evaluator, db, engine)
+	evaluator.SetPolicies(r.policies)
+	policy := r.policies.For(scenario.Zone)
+	window := r.cfg.Aggregation.TimeWindows[scenario.Zone]
+
+	steps := make([]Step, 0)
+	current := decision.StateInactive
+	for at := scenario.Start.Add(r.step); !at.After(scenario.End()); at = at.Add(r.step) {
+		summary, err := engine.Aggregate(ctx, scenario.Zone, at.Add(-window))
+		if err != nil {
+			return nil, fmt.Errorf("scenario %s: %w", scenario.ID, err)
+		}
+
+		// Proposals are applied as if every required approval were granted immediately
+		result := evaluator.EvaluateSummary(ctx, summary, current)
+		if result.TargetState != current && policy.StateMachine().CanTransition(current, result.TargetState) {
+			current = result.TargetState
+			if err := r.record(ctx, db, scenario.Zone, current, summary.ID, policy.ID()); err != nil {
+				return nil, fmt.Errorf("scenario %s: %w", scenario.ID, err)
+			}
+		}
+
+		offset := at.Sub(scenario.Start)
+		steps = append(steps, Step{
+			Offset:     Duration(offset),
+			Expected:   scenario.ExpectedState(offset),
+			Proposed:   result.TargetState,
+			State:      current,
+			Confidence: summary.Confidence,
+			Reason:     result.Reason,
+		})
+	}
+
+	return score(scenario, policy.ID(), r.step, steps), nil
+}
+
+// seed loads the scenario's signals, and for historical replays the zone's learned baselines
+func (r *Runner) seed(ctx context.Context, db *gorm.DB, scenario *Scenario) error {
+	signals := scenario.BuildSignals()
+	if len(scenario.Signals) == 0 {
+		if r.history == nil {
+			return fmt.Errorf("no signals listed and no signal history configured")
+		}
+		window := r.cfg.Aggregation.TimeWindows[scenario.Zone]
+		if err := r.history.WithContext(ctx).
+			Where("zone_id = ? AND timestamp >= ? AND timestamp < ?", scenario.Zone, scenario.Start.Add(-window), scenario.End()).
+			Order("timestamp ASC").
+			Find(&signals).Error; err != nil {
+			return fmt.Errorf("failed to load signal history: %w", err)
+		}
+
+		var baselines []*model.SignalBaseline
+		if err := r.history.WithContext(ctx).Where("zone_id = ?", scenario.Zone).Find(&baselines).Error; err != nil {
+			return fmt.Errorf("failed to load signal baselines: %w", err)
+		}
+		if len(baselines) > 0 {
+			if err := db.WithContext(ctx).Create(&baselines).Error; err != nil {
+				return fmt.Errorf("failed to copy signal baselines: %w", err)
+			}
+		}
+	}
+
+	if len(signals) > 0 {
+		if err := db.WithContext(ctx).CreateInBatches(signals, 500).Error; err != nil {
+			return fmt.Errorf("failed to store scenario signals: %w", err)
+		}
+	}
+	return nil
+}
+
+// record stores the simulated state so neighbour lookups see it, as they would in production
+func (r *Runner) record(ctx context.Context, db *gorm.DB, zoneID string, state decision.DecisionState, summaryID, policyID string) error {
+	record := &decision.DecisionStateRecord{
+		ID:                  fmt.Sprintf("bt_%s", uuid.New().String()),
+		ZoneID:              zoneID,
+		CurrentState:        string(state),
+		AggregatedSummaryID: summaryID,
+		PolicyVersion:       policyID,
+	}
+	if err := db.WithContext(ctx).Create(record).Error; err != nil {
+		return fmt.Errorf("failed to record simulated state: %w", err)
+	}
+	return nil
+}
+
+// openSandbox opens an isolated in-memory store for one replay
+func openSandbox() (*gorm.DB, error) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open replay database: %w", err)
+	}
+
+	// Every connection to :memory: is a separate database
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get replay database instance: %w", err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+
+	if err := db.AutoMigrate(
+		&model.Signal{},
+		&model.AggregatedSummary{},
+		&model.SignalBaseline{},
+		&decision.DecisionStateRecord{},
+	); err != nil {
+		return nil, fmt.Errorf("failed to migrate replay database: %w", err)
+	}
+	return db, nil
+}
+
+func closeSandbox(db *gorm.DB) {
+	if sqlDB, err := db.DB(); err == nil {
+		sqlDB.Close()
+	}
+}
@@ -0,0 +1,244 @@
+package backtest
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/erh-safety-system/poc/internal/decision"
+	"github.com/erh-safety-system/poc/internal/model"
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultStart anchors scenarios that do not give an absolute start time
+var DefaultStart = time.Date(2025, 1, 6, 18, 0, 0, 0, time.UTC)
+
+// Scenario is a labelled incident to replay. Signals are either listed in the scenario
+// or loaded from the historical signal store for the scenario's zone and time range.
+type Scenario struct {
+	ID          string           `yaml:"scenario_id"`
+	Zone        string           `yaml:"zone"`
+	Description string           `yaml:"description"`
+	Start       time.Time        `yaml:"start"`    // Absolute start; required for historical signals
+	Duration    string           `yaml:"duration"` // How long to replay, e.g. "20m"
+	Signals     []ScenarioSignal `yaml:"signals"`
+	Labels      []Label          `yaml:"labels"`
+
+	Source   string        `yaml:"-"` // File the scenario was loaded from
+	duration time.Duration `yaml:"-"`
+}
+
+// ScenarioSignal describes one signal, or a burst of identical signals from distinct sources
+type ScenarioSignal struct {
+	At         string                 `yaml:"at"` // Offset from the scenario start, e.g. "90s"
+	SourceType string                 `yaml:"source_type"`
+	SourceID   string                 `yaml:"source_id"`
+	SignalType string                 `yaml:"signal_type"`
+	Quality    float64                `yaml:"quality"`
+	Value      map[string]interface{} `yaml:"value"`
+	Metadata   map[string]interface{} `yaml:"metadata"`
+	Repeat     int                    `yaml:"repeat"` // Number of signals; defaults to 1
+	Every      string                 `yaml:"every"`  // Spacing between repeated signals; defaults to 1s
+}
+
+// Label states the decision state operators judged correct from an offset onwards
+type Label struct {
+	At    string `yaml:"at"`
+	State string `yaml:"state"`
+	Note  string `yaml:"note"`
+
+	offset time.Duration `yaml:"-"`
+}
+
+// LoadScenario reads a scenario from a YAML file, or from the first ```yaml block of a
+// Markdown file written from templates/scenario_template.md
+func LoadScenario(path string) (*Scenario, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read scenario: %w", err)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+	case ".md":
+		data, err = yamlBlock(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported scenario file extension: %s", filepath.Ext(path))
+	}
+
+	scenario := &Scenario{}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(scenario); err != nil {
+		return nil, fmt.Errorf("failed to parse scenario %s: %w", path, err)
+	}
+	scenario.Source = path
+
+	if err := scenario.Validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return scenario, nil
+}
+
+// LoadScenarios loads every scenario in the given files and directories
+func LoadScenarios(paths []string) ([]*Scenario, error) {
+	scenarios := make([]*Scenario, 0)
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read scenario path: %w", err)
+		}
+
+		files := []string{path}
+		if info.IsDir() {
+			files = files[:0]
+			entries, err := os.ReadDir(path)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read scenario directory: %w", err)
+			}
+			for _, entry := range entries {
+				ext := strings.ToLower(filepath.Ext(entry.Name()))
+				if !entry.IsDir() && (ext == ".yaml" || ext == ".yml" || ext == ".md") {
+					files = append(files, filepath.Join(path, entry.Name()))
+				}
+			}
+		}
+
+		for _, file := range files {
+			scenario, err := LoadScenario(file)
+			if err != nil {
+				return nil, err
+			}
+			scenarios = append(scenarios, scenario)
+		}
+	}
+	return scenarios, nil
+}
+
+// yamlBlock extracts the first fenced YAML block from a Markdown document
+func yamlBlock(data []byte) ([]byte, error) {
+	lines := strings.Split(string(data), "\n")
+	for i, line := range lines {
+		if strings.TrimSpace(line) != "```yaml" {
+			continue
+		}
+		for j := i + 1; j < len(lines); j++ {
+			if strings.TrimSpace(lines[j]) == "```" {
+				return []byte(strings.Join(lines[i+1:j], "\n")), nil
+			}
+		}
+		return nil, fmt.Errorf("unterminated yaml block")
+	}
+	return nil, fmt.Errorf("no yaml block found")
+}
+
+// Validate checks the scenario and resolves its offsets
+func (s *Scenario) Validate() error {
+	if s.ID == "" {
+		return fmt.Errorf("scenario_id is required")
+	}
+	if s.Zone == "" {
+		return fmt.Errorf("zone is required")
+	}
+	if s.Start.IsZero() {
+		s.Start = DefaultStart
+	}
+
+	duration, err := time.ParseDuration(s.Duration)
+	if err != nil || duration <= 0 {
+		return fmt.Errorf("duration must be a positive duration")
+	}
+	s.duration = duration
+
+	if len(s.Labels) == 0 {
+		return fmt.Errorf("at least one label is required")
+	}
+	var previous time.Duration
+	for i := range s.Labels {
+		offset, err := time.ParseDuration(s.Labels[i].At)
+		if err != nil {
+			return fmt.Errorf("labels[%d].at: %w", i, err)
+		}
+		if i > 0 && offset <= previous {
+			return fmt.Errorf("labels[%d].at: labels must be in time order", i)
+		}
+		if decision.DecisionState(s.Labels[i].State).DecisionDepth() == 0 {
+			return fmt.Errorf("labels[%d].state: unknown state %s", i, s.Labels[i].State)
+		}
+		s.Labels[i].offset = offset
+		previous = offset
+	}
+
+	for i, signal := range s.Signals {
+		if _, err := time.ParseDuration(signal.At); err != nil {
+			return fmt.Errorf("signals[%d].at: %w", i, err)
+		}
+		if signal.Every != "" {
+			if _, err := time.ParseDuration(signal.Every); err != nil {
+				return fmt.Errorf("signals[%d].every: %w", i, err)
+			}
+		}
+		if signal.SourceType == "" {
+			return fmt.Errorf("signals[%d].source_type is required", i)
+		}
+	}
+
+	return nil
+}
+
+// End returns when the replay stops
+func (s *Scenario) End() time.Time {
+	return s.Start.Add(s.duration)
+}
+
+// ExpectedState returns the labelled state at an offset from the start
+func (s *Scenario) ExpectedState(offset time.Duration) decision.DecisionState {
+	expected := decision.StateInactive
+	for _, label := range s.Labels {
+		if label.offset > offset {
+			break
+		}
+		expected = decision.DecisionState(label.State)
+	}
+	return expected
+}
+
+// BuildSignals expands the scenario's signals into signal records
+func (s *Scenario) BuildSignals() []*model.Signal {
+	signals := make([]*model.Signal, 0)
+	for i, spec := range s.Signals {
+		at, _ := time.ParseDuration(spec.At)
+		every := time.Second
+		if spec.Every != "" {
+			every, _ = time.ParseDuration(spec.Every)
+		}
+		repeat := spec.Repeat
+		if repeat < 1 {
+			repeat = 1
+		}
+
+		for n := 0; n < repeat; n++ {
+			sourceID := spec.SourceID
+			if sourceID == "" || repeat > 1 {
+				sourceID = fmt.Sprintf("%s%s_%d_%d", spec.SourceID, spec.SourceType, i, n)
+			}
+			signals = append(signals, &model.Signal{
+				SourceType:   spec.SourceType,
+				SourceID:     sourceID,
+				Timestamp:    s.Start.Add(at + time.Duration(n)*every),
+				ZoneID:       s.Zone,
+				SignalType:   spec.SignalType,
+				Value:        model.JSONB(spec.Value),
+				Metadata:     model.JSONB(spec.Metadata),
+				QualityScore: spec.Quality,
+			})
+		}
+	}
+	return signals
+}
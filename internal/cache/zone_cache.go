@@ -0,0 +1,326 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+)
+
+// Kind identifies what is cached for a zone
+type Kind string
+
+const (
+	KindSummary Kind = "summary" // Latest aggregated summary
+	KindState   Kind = "state"   // Latest decision state
+)
+
+const (
+	// InvalidationChannel is the Redis pub/sub channel used to drop stale local copies on other instances
+	InvalidationChannel = "erh:cache:invalidate"
+
+	// DefaultRemoteTTL bounds how long an entry lives in Redis without being rewritten
+	DefaultRemoteTTL = 10 * time.Minute
+
+	// DefaultLocalTTL bounds staleness of the in-process copy if an invalidation message is missed
+	DefaultLocalTTL = 30 * time.Second
+)
+
+// localEntry is an in-process copy of a cached value
+type localEntry struct {
+	data     []byte
+	order    int64 // Order the value was written with by SetIfNewer; 0 otherwise
+	storedAt time.Time
+}
+
+// setIfNewerScript writes a value and its order unless a higher order is stored.
+// KEYS: value key, order key. ARGV: value, order, TTL in milliseconds.
+var setIfNewerScript = redis.NewScript(`
+local current = redis.call("GET", KEYS[2])
+if current and tonumber(current) > tonumber(ARGV[2]) then
+	return 0
+end
+redis.call("SET", KEYS[1], ARGV[1], "PX", ARGV[3])
+redis.call("SET", KEYS[2], ARGV[2], "PX", ARGV[3])
+return 1
+`)
+
+// counters holds hit/miss counters for one kind
+type counters struct {
+	localHits  int64
+	remoteHits int64
+	misses     int64
+	errors     int64
+	writes     int64
+}
+
+// Stats summarizes cache effectiveness for one kind
+type Stats struct {
+	Hits       int64   `json:"hits"`
+	LocalHits  int64   `json:"local_hits"`
+	RemoteHits int64   `json:"remote_hits"`
+	Misses     int64   `json:"misses"`
+	Errors     int64   `json:"errors"`
+	Writes     int64   `json:"writes"`
+	HitRate    float64 `json:"hit_rate"`
+}
+
+// ZoneCache is a two-level (in-process + Redis) write-through cache of per-zone latest values.
+// With a nil Redis client it degrades to a process-local cache.
+type ZoneCache struct {
+	client     *redis.Client
+	instanceID string
+	remoteTTL  time.Duration
+	localTTL   time.Duration
+
+	mu    sync.RWMutex
+	local map[string]*localEntry
+	stats map[Kind]*counters
+}
+
+// NewZoneCache creates a new zone cache
+func NewZoneCache(client *redis.Client) *ZoneCache {
+	return &ZoneCache{
+		client:     client,
+		instanceID: uuid.New().String(),
+		remoteTTL:  DefaultRemoteTTL,
+		localTTL:   DefaultLocalTTL,
+		local:      make(map[string]*localEntry),
+		stats:      make(map[Kind]*counters),
+	}
+}
+
+// Key returns the Redis key for a zone's cached value
+func Key(kind Kind, zoneID string) string {
+	return fmt.Sprintf("erh:zone:%s:%s", zoneID, kind)
+}
+
+// orderKey returns the Redis key holding the order of a value written by SetIfNewer
+func orderKey(key string) string {
+	return key + ":order"
+}
+
+// Get loads a cached value into dest. It returns false on a miss; Redis errors are counted and treated as misses.
+func (c *ZoneCache) Get(ctx context.Context, kind Kind, zoneID string, dest interface{}) bool {
+	return c.lookup(ctx, kind, zoneID, dest, true)
+}
+
+// Peek is like Get but does not affect hit/miss statistics
+func (c *ZoneCache) Peek(ctx context.Context, kind Kind, zoneID string, dest interface{}) bool {
+	return c.lookup(ctx, kind, zoneID, dest, false)
+}
+
+// lookup checks the local copy, then Redis
+func (c *ZoneCache) lookup(ctx context.Context, kind Kind, zoneID string, dest interface{}, record bool) bool {
+	key := Key(kind, zoneID)
+	count := func(update func(*counters)) {
+		if record {
+			c.count(kind, update)
+		}
+	}
+
+	c.mu.RLock()
+	entry, exists := c.local[key]
+	c.mu.RUnlock()
+
+	if exists && time.Since(entry.storedAt) < c.localTTL {
+		if err := json.Unmarshal(entry.data, dest); err == nil {
+			count(func(s *counters) { s.localHits++ })
+			return true
+		}
+	}
+
+	if c.client == nil {
+		count(func(s *counters) { s.misses++ })
+		return false
+	}
+
+	data, err := c.client.Get(ctx, key).Bytes()
+	if err != nil {
+		if err != redis.Nil {
+			count(func(s *counters) { s.errors++ })
+		}
+		count(func(s *counters) { s.misses++ })
+		return false
+	}
+
+	if err := json.Unmarshal(data, dest); err != nil {
+		count(func(s *counters) { s.errors++; s.misses++ })
+		return false
+	}
+
+	c.storeLocal(key, data, 0)
+	count(func(s *counters) { s.remoteHits++ })
+	return true
+}
+
+// Set writes a value through to Redis and notifies other instances to drop their local copy
+func (c *ZoneCache) Set(ctx context.Context, kind Kind, zoneID string, value interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to marshal cache value: %w", err)
+	}
+
+	key := Key(kind, zoneID)
+	c.storeLocal(key, data, 0)
+	c.count(kind, func(s *counters) { s.writes++ })
+
+	if c.client == nil {
+		return nil
+	}
+
+	if err := c.client.Set(ctx, key, data, c.remoteTTL).Err(); err != nil {
+		c.count(kind, func(s *counters) { s.errors++ })
+		return fmt.Errorf("failed to write cache: %w", err)
+	}
+
+	return c.publish(ctx, key)
+}
+
+// SetIfNewer writes a value through like Set unless a value with a higher order (e.g. a later update time) is
+// already cached, so a writer that loaded its value before a concurrent update cannot replace the newer value.
+// It reports whether the value was written.
+func (c *ZoneCache) SetIfNewer(ctx context.Context, kind Kind, zoneID string, value interface{}, order int64) (bool, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return false, fmt.Errorf("failed to marshal cache value: %w", err)
+	}
+
+	key := Key(kind, zoneID)
+	if c.client == nil {
+		if !c.storeLocalIfNewer(key, data, order) {
+			return false, nil
+		}
+		c.count(kind, func(s *counters) { s.writes++ })
+		return true, nil
+	}
+
+	written, err := setIfNewerScript.Run(ctx, c.client, []string{key, orderKey(key)}, data, order, c.remoteTTL.Milliseconds()).Int()
+	if err != nil {
+		c.count(kind, func(s *counters) { s.errors++ })
+		return false, fmt.Errorf("failed to write cache: %w", err)
+	}
+	if written == 0 {
+		return false, nil
+	}
+
+	c.storeLocal(key, data, order)
+	c.count(kind, func(s *counters) { s.writes++ })
+	return true, c.publish(ctx, key)
+}
+
+// Invalidate removes a value from both cache levels on all instances
+func (c *ZoneCache) Invalidate(ctx context.Context, kind Kind, zoneID string) error {
+	key := Key(kind, zoneID)
+	c.dropLocal(key)
+
+	if c.client == nil {
+		return nil
+	}
+
+	if err := c.client.Del(ctx, key, orderKey(key)).Err(); err != nil {
+		return fmt.Errorf("failed to delete cache entry: %w", err)
+	}
+
+	return c.publish(ctx, key)
+}
+
+// Subscribe drops local copies when other instances update a key, until the context is cancelled
+func (c *ZoneCache) Subscribe(ctx context.Context) {
+	if c.client == nil {
+		return
+	}
+
+	pubsub := c.client.Subscribe(ctx, InvalidationChannel)
+	defer pubsub.Close()
+
+	messages := pubsub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("Cache invalidation listener stopped")
+			return
+		case msg, ok := <-messages:
+			if !ok {
+				return
+			}
+			origin, key, found := strings.Cut(msg.Payload, "|")
+			if !found || origin == c.instanceID {
+				continue
+			}
+			c.dropLocal(key)
+		}
+	}
+}
+
+// Stats returns hit/miss statistics per kind
+func (c *ZoneCache) Stats() map[Kind]Stats {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	result := make(map[Kind]Stats, len(c.stats))
+	for kind, s := range c.stats {
+		hits := s.localHits + s.remoteHits
+		stats := Stats{
+			Hits:       hits,
+			LocalHits:  s.localHits,
+			RemoteHits: s.remoteHits,
+			Misses:     s.misses,
+			Errors:     s.errors,
+			Writes:     s.writes,
+		}
+		if total := hits + s.misses; total > 0 {
+			stats.HitRate = float64(hits) / float64(total)
+		}
+		result[kind] = stats
+	}
+	return result
+}
+
+// publish announces that a key changed
+func (c *ZoneCache) publish(ctx context.Context, key string) error {
+	if err := c.client.Publish(ctx, InvalidationChannel, c.instanceID+"|"+key).Err(); err != nil {
+		return fmt.Errorf("failed to publish cache invalidation: %w", err)
+	}
+	return nil
+}
+
+func (c *ZoneCache) storeLocal(key string, data []byte, order int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.local[key] = &localEntry{data: data, order: order, storedAt: time.Now()}
+}
+
+// storeLocalIfNewer stores a local copy unless an unexpired one with a higher order is held
+func (c *ZoneCache) storeLocalIfNewer(key string, data []byte, order int64) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if entry, exists := c.local[key]; exists && entry.order > order && time.Since(entry.storedAt) < c.localTTL {
+		return false
+	}
+	c.local[key] = &localEntry{data: data, order: order, storedAt: time.Now()}
+	return true
+}
+
+func (c *ZoneCache) dropLocal(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.local, key)
+}
+
+func (c *ZoneCache) count(kind Kind, update func(*counters)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	s, exists := c.stats[kind]
+	if !exists {
+		s = &counters{}
+		c.stats[kind] = s
+	}
+	update(s)
+}
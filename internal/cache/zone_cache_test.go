@@ -0,0 +1,77 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type cachedState struct {
+	ZoneID       string `json:"zone_id"`
+	CurrentState string `json:"current_state"`
+}
+
+func TestZoneCache_LocalOnly(t *testing.T) {
+	zoneCache := NewZoneCache(nil)
+	ctx := context.Background()
+
+	var state cachedState
+	assert.False(t, zoneCache.Get(ctx, KindState, "Z1", &state))
+
+	assert.NoError(t, zoneCache.Set(ctx, KindState, "Z1", &cachedState{ZoneID: "Z1", CurrentState: "D2"}))
+	assert.True(t, zoneCache.Get(ctx, KindState, "Z1", &state))
+	assert.Equal(t, "D2", state.CurrentState)
+
+	// Peek does not count towards statistics
+	assert.True(t, zoneCache.Peek(ctx, KindState, "Z1", &state))
+
+	stats := zoneCache.Stats()[KindState]
+	assert.Equal(t, int64(1), stats.Hits)
+	assert.Equal(t, int64(1), stats.Misses)
+	assert.Equal(t, int64(1), stats.Writes)
+	assert.InDelta(t, 0.5, stats.HitRate, 0.0001)
+
+	assert.NoError(t, zoneCache.Invalidate(ctx, KindState, "Z1"))
+	assert.False(t, zoneCache.Get(ctx, KindState, "Z1", &state))
+}
+
+func TestZoneCache_SetIfNewerKeepsNewest(t *testing.T) {
+	zoneCache := NewZoneCache(nil)
+	ctx := context.Background()
+
+	written, err := zoneCache.SetIfNewer(ctx, KindState, "Z1", &cachedState{ZoneID: "Z1", CurrentState: "D2"}, 20)
+	assert.NoError(t, err)
+	assert.True(t, written)
+
+	// A value loaded before the newer one was written arrives late and is dropped
+	written, err = zoneCache.SetIfNewer(ctx, KindState, "Z1", &cachedState{ZoneID: "Z1", CurrentState: "D1"}, 10)
+	assert.NoError(t, err)
+	assert.False(t, written)
+
+	var state cachedState
+	assert.True(t, zoneCache.Peek(ctx, KindState, "Z1", &state))
+	assert.Equal(t, "D2", state.CurrentState)
+
+	// Equal orders are rewritten, e.g. a decision acknowledged without bumping its update time
+	written, err = zoneCache.SetIfNewer(ctx, KindState, "Z1", &cachedState{ZoneID: "Z1", CurrentState: "D2+ack"}, 20)
+	assert.NoError(t, err)
+	assert.True(t, written)
+	assert.True(t, zoneCache.Peek(ctx, KindState, "Z1", &state))
+	assert.Equal(t, "D2+ack", state.CurrentState)
+
+	assert.Equal(t, int64(2), zoneCache.Stats()[KindState].Writes)
+}
+
+func TestZoneCache_LocalEntriesExpire(t *testing.T) {
+	zoneCache := NewZoneCache(nil)
+	zoneCache.localTTL = 10 * time.Millisecond
+	ctx := context.Background()
+
+	assert.NoError(t, zoneCache.Set(ctx, KindSummary, "Z2", map[string]string{"id": "sum_1"}))
+	time.Sleep(20 * time.Millisecond)
+
+	var summary map[string]string
+	assert.False(t, zoneCache.Get(ctx, KindSummary, "Z2", &summary))
+}
@@ -44,6 +44,11 @@ func (a *CAPMessageAdapter) GetScope() string {
 	return a.msg.Scope
 }
 
+// GetReferences returns the messages this one supersedes
+func (a *CAPMessageAdapter) GetReferences() string {
+	return a.msg.References
+}
+
 // GetInfoBlocks returns the info blocks
 func (a *CAPMessageAdapter) GetInfoBlocks() []route1.InfoBlock {
 	blocks := make([]route1.InfoBlock, len(a.msg.Info))
@@ -142,6 +142,11 @@ func (g *CAPGenerator) Save(ctx context.Context, capMsg *CAPMessage, publishedCh
 		signatureText = capMsg.Signature.Value
 	}
 	
+	zoneID := ""
+	if len(capMsg.Area.ZoneID) > 0 {
+		zoneID = capMsg.Area.ZoneID[0]
+	}
+	
 	record := &CAPMessageRecord{
 		ID:               fmt.Sprintf("cap_%s", uuid.New().String()),
 		Identifier:       capMsg.Identifier,
@@ -150,6 +155,8 @@ func (g *CAPGenerator) Save(ctx context.Context, capMsg *CAPMessage, publishedCh
 		Status:           capMsg.Status,
 		MsgType:          capMsg.MsgType,
 		Scope:            capMsg.Scope,
+		ZoneID:           zoneID,
+		References:       capMsg.References,
 		Info:             infoJSONB,
 		Area:             areaJSONB,
 		Signature:        signatureText,
@@ -182,6 +189,7 @@ func (c *CAPMessage) ToJSON() (map[string]interface{}, error) {
 		"status":     c.Status,
 		"msg_type":   c.MsgType,
 		"scope":      c.Scope,
+		"references": c.References,
 		"info":       c.Info,
 		"area":       c.Area,
 	}
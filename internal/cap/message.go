@@ -17,6 +17,7 @@ type CAPMessage struct {
 	Status     string    `xml:"status" json:"status"` // Actual|Test|Exercise
 	MsgType    string    `xml:"msgType" json:"msg_type"` // Alert|Update|Cancel
 	Scope      string    `xml:"scope" json:"scope"` // Public|Restricted
+	References string    `xml:"references,omitempty" json:"references,omitempty"` // sender,identifier,sent of superseded messages
 	Info       []Info    `xml:"info" json:"info"`
 	Area       Area      `xml:"area" json:"area"`
 	Signature  *Signature `xml:"signature,omitempty" json:"signature,omitempty"`
@@ -65,6 +66,8 @@ type CAPMessageRecord struct {
 	Status         string          `gorm:"type:varchar(20);not null" json:"status"`
 	MsgType        string          `gorm:"type:varchar(20);not null" json:"msg_type"`
 	Scope          string          `gorm:"type:varchar(20);not null" json:"scope"`
+	ZoneID         string          `gorm:"type:varchar(10);index" json:"zone_id"`
+	References     string          `gorm:"type:text" json:"references,omitempty"`
 	Info           model.JSONB     `gorm:"type:jsonb" json:"info"`
 	Area           model.JSONB     `gorm:"type:jsonb" json:"area"`
 	Signature      string          `gorm:"type:text" json:"signature"`
@@ -0,0 +1,228 @@
+package cap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/erh-safety-system/poc/internal/decision"
+	"github.com/erh-safety-system/poc/internal/gate"
+	"gorm.io/gorm"
+)
+
+// cancelTTL is how long a Cancel stays on channels that display messages until they expire
+const cancelTTL = time.Hour
+
+// retractionTemplates holds the English retraction text per message type; other languages are translated
+var retractionTemplates = map[string]struct {
+	Headline    string
+	Description string
+	Instruction string
+}{
+	"Update": {
+		Headline:    "Situation updated",
+		Description: "The alert issued at %s (UTC) for zone %s has been updated. Measures in this zone have been reduced.",
+		Instruction: "Continue to follow directions from on-site staff and check for further updates.",
+	},
+	"Cancel": {
+		Headline:    "Alert cancelled",
+		Description: "The alert issued at %s (UTC) for zone %s has been cancelled. Emergency measures in this zone have been stood down.",
+		Instruction: "No further action is required. Continue to follow directions from on-site staff.",
+	},
+}
+
+// PreviewRetraction builds the Update or Cancel a rollback would publish, without publishing it
+func (s *CAPService) PreviewRetraction(ctx context.Context, req *gate.RetractionRequest) (*gate.Retraction, error) {
+	capMsg, err := s.buildRetraction(ctx, req)
+	if err != nil || capMsg == nil {
+		return nil, err
+	}
+	return toRetraction(capMsg, "", nil), nil
+}
+
+// Retract publishes an Update or Cancel referencing the zone's live alert on every Route 1 channel
+func (s *CAPService) Retract(ctx context.Context, req *gate.RetractionRequest) (*gate.Retraction, error) {
+	capMsg, err := s.buildRetraction(ctx, req)
+	if err != nil || capMsg == nil {
+		return nil, err
+	}
+
+	record, err := s.publish(ctx, capMsg, req.ZoneID)
+	if err != nil {
+		return nil, err
+	}
+	return toRetraction(capMsg, record.Identifier, record.PublishedChannels), nil
+}
+
+// liveAlert returns the zone's latest message if it is an unexpired Alert or Update, or nil
+func (s *CAPService) liveAlert(ctx context.Context, zoneID string) (*CAPMessageRecord, error) {
+	var record CAPMessageRecord
+	err := s.db.WithContext(ctx).
+		Where("zone_id = ? AND status = ?", zoneID, "Actual").
+		Order("sent DESC, created_at DESC").
+		First(&record).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get live alert: %w", err)
+	}
+
+	if record.MsgType == "Cancel" || record.IsExpired() {
+		return nil, nil
+	}
+	return &record, nil
+}
+
+// BackfillZoneIDs fills in the zone of messages stored before the zone_id column existed from their area,
+// so liveAlert also finds alerts published before the upgrade. It returns the number of messages updated.
+func BackfillZoneIDs(ctx context.Context, db *gorm.DB) (int, error) {
+	var records []CAPMessageRecord
+	if err := db.WithContext(ctx).
+		Select("id", "area").
+		Where("zone_id = ? OR zone_id IS NULL", "").
+		Find(&records).Error; err != nil {
+		return 0, fmt.Errorf("failed to get messages without a zone: %w", err)
+	}
+
+	filled := 0
+	for _, record := range records {
+		zones, _ := record.Area["zone_id"].([]interface{})
+		if len(zones) == 0 {
+			continue
+		}
+		zoneID, _ := zones[0].(string)
+		if zoneID == "" {
+			continue
+		}
+		if err := db.WithContext(ctx).Model(&CAPMessageRecord{}).
+			Where("id = ?", record.ID).
+			Update("zone_id", zoneID).Error; err != nil {
+			return filled, fmt.Errorf("failed to backfill zone of message %s: %w", record.ID, err)
+		}
+		filled++
+	}
+	return filled, nil
+}
+
+// buildRetraction generates the retraction for the zone's live alert. Rolling back to a level that
+// still warrants public messaging issues an Update, anything lower a Cancel.
+func (s *CAPService) buildRetraction(ctx context.Context, req *gate.RetractionRequest) (*CAPMessage, error) {
+	original, err := s.liveAlert(ctx, req.ZoneID)
+	if err != nil || original == nil {
+		return nil, err
+	}
+
+	msgType := "Cancel"
+	ttl := cancelTTL
+	if state := decision.DecisionState(req.TargetState); state == decision.StateD4 || state == decision.StateD5 {
+		msgType = "Update"
+		ttl = time.Until(original.Expires)
+	}
+	template := retractionTemplates[msgType]
+	sent := original.Sent.UTC().Format(time.RFC3339)
+
+	languages := make([]string, 0, len(original.Info))
+	for lang := range original.Info {
+		languages = append(languages, lang)
+	}
+	sort.Strings(languages)
+	if len(languages) == 0 {
+		return nil, fmt.Errorf("live alert %s has no info blocks", original.Identifier)
+	}
+
+	genReq := &GenerateRequest{
+		ZoneID:          req.ZoneID,
+		DecisionStateID: req.DecisionID,
+		MsgType:         msgType,
+		Languages:       languages,
+		EventType:       infoField(original, languages[0], "event"),
+		Urgency:         infoField(original, languages[0], "urgency"),
+		Severity:        infoField(original, languages[0], "severity"),
+		Certainty:       infoField(original, languages[0], "certainty"),
+		Headline:        make(map[string]string),
+		Description:     make(map[string]string),
+		Instruction:     make(map[string]string),
+		Contact:         infoField(original, languages[0], "contact"),
+		TTL:             ttl,
+	}
+	if msgType == "Cancel" {
+		genReq.Urgency = "Past"
+		genReq.Severity = "Minor"
+	}
+
+	for _, lang := range languages {
+		headline, err := s.localize(template.Headline, lang)
+		if err != nil {
+			return nil, err
+		}
+		description, err := s.localize(fmt.Sprintf(template.Description, sent, req.ZoneID), lang)
+		if err != nil {
+			return nil, err
+		}
+		instruction, err := s.localize(template.Instruction, lang)
+		if err != nil {
+			return nil, err
+		}
+
+		if originalHeadline := infoField(original, lang, "headline"); originalHeadline != "" {
+			headline = fmt.Sprintf("%s: %s", headline, originalHeadline)
+		}
+		genReq.Headline[lang] = headline
+		genReq.Description[lang] = description
+		genReq.Instruction[lang] = instruction
+	}
+
+	capMsg, err := s.generator.Generate(ctx, genReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate retraction: %w", err)
+	}
+	capMsg.References = fmt.Sprintf("%s,%s,%s", original.Sender, original.Identifier, sent)
+	return capMsg, nil
+}
+
+// localize translates English template text into lang
+func (s *CAPService) localize(text, lang string) (string, error) {
+	if lang == "en" || s.translator == nil {
+		return text, nil
+	}
+	translated, err := s.translator.Translate(text, "en", lang)
+	if err != nil {
+		return "", fmt.Errorf("failed to translate retraction to %s: %w", lang, err)
+	}
+	return translated, nil
+}
+
+// infoField reads a field of a stored info block
+func infoField(record *CAPMessageRecord, lang, field string) string {
+	info, ok := record.Info[lang].(map[string]interface{})
+	if !ok {
+		return ""
+	}
+	value, _ := info[field].(string)
+	return value
+}
+
+// toRetraction describes a retraction message for the rollback service
+func toRetraction(capMsg *CAPMessage, identifier string, channels []string) *gate.Retraction {
+	retraction := &gate.Retraction{
+		MsgType:     capMsg.MsgType,
+		Identifier:  identifier,
+		References:  capMsg.References,
+		Headline:    make(map[string]string),
+		Description: make(map[string]string),
+		Instruction: make(map[string]string),
+		Channels:    channels,
+	}
+	if len(capMsg.Area.ZoneID) > 0 {
+		retraction.ZoneID = capMsg.Area.ZoneID[0]
+	}
+	for _, info := range capMsg.Info {
+		retraction.Headline[info.Language] = info.Headline
+		retraction.Description[info.Language] = info.Description
+		retraction.Instruction[info.Language] = info.Instruction
+	}
+	return retraction
+}
@@ -0,0 +1,208 @@
+package cap
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/erh-safety-system/poc/internal/gate"
+	"github.com/erh-safety-system/poc/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupCAPTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to open test database: %v", err)
+	}
+	if err := db.AutoMigrate(&CAPMessageRecord{}); err != nil {
+		t.Fatalf("Failed to migrate: %v", err)
+	}
+	return db
+}
+
+// storedMessage builds a published message for a zone as the generator would have saved it
+func storedMessage(identifier, zoneID, msgType string, sent time.Time, ttl time.Duration) *CAPMessageRecord {
+	return &CAPMessageRecord{
+		ID:         "cap_" + identifier,
+		Identifier: identifier,
+		Sender:     "erh-safety-system",
+		Sent:       sent,
+		Status:     "Actual",
+		MsgType:    msgType,
+		Scope:      "Public",
+		ZoneID:     zoneID,
+		Info: model.JSONB{
+			"en": map[string]interface{}{
+				"language":  "en",
+				"event":     "Crowd Safety",
+				"urgency":   "Immediate",
+				"severity":  "Severe",
+				"certainty": "Observed",
+				"headline":  "Evacuate concourse",
+				"contact":   "control room",
+			},
+		},
+		Area:    model.JSONB{"zone_id": []string{zoneID}, "zone_type": []string{zoneID}},
+		Expires: sent.Add(ttl),
+	}
+}
+
+func TestCAPService_PreviewRetraction(t *testing.T) {
+	sent := time.Now().Add(-10 * time.Minute).Truncate(time.Second).In(time.FixedZone("UTC+9", 9*60*60))
+	references := "erh-safety-system,CAP-alert," + sent.UTC().Format(time.RFC3339)
+
+	tests := []struct {
+		name         string
+		stored       []*CAPMessageRecord
+		targetState  string
+		wantType     string // Empty when no retraction is issued
+		wantHeadline string
+	}{
+		{
+			name:        "no alert published",
+			targetState: "D2",
+		},
+		{
+			name:        "alert for another zone",
+			stored:      []*CAPMessageRecord{storedMessage("CAP-alert", "Z2", "Alert", sent, time.Hour)},
+			targetState: "D2",
+		},
+		{
+			name:        "alert already expired",
+			stored:      []*CAPMessageRecord{storedMessage("CAP-alert", "Z1", "Alert", sent, 5*time.Minute)},
+			targetState: "D2",
+		},
+		{
+			name: "alert already cancelled",
+			stored: []*CAPMessageRecord{
+				storedMessage("CAP-alert", "Z1", "Alert", sent, time.Hour),
+				storedMessage("CAP-cancel", "Z1", "Cancel", sent.Add(time.Minute), time.Hour),
+			},
+			targetState: "D2",
+		},
+		{
+			name:         "rollback below public messaging cancels",
+			stored:       []*CAPMessageRecord{storedMessage("CAP-alert", "Z1", "Alert", sent, time.Hour)},
+			targetState:  "D2",
+			wantType:     "Cancel",
+			wantHeadline: "Alert cancelled: Evacuate concourse",
+		},
+		{
+			name:         "rollback to D3 cancels",
+			stored:       []*CAPMessageRecord{storedMessage("CAP-alert", "Z1", "Alert", sent, time.Hour)},
+			targetState:  "D3",
+			wantType:     "Cancel",
+			wantHeadline: "Alert cancelled: Evacuate concourse",
+		},
+		{
+			name:         "rollback to D4 updates",
+			stored:       []*CAPMessageRecord{storedMessage("CAP-alert", "Z1", "Alert", sent, time.Hour)},
+			targetState:  "D4",
+			wantType:     "Update",
+			wantHeadline: "Situation updated: Evacuate concourse",
+		},
+		{
+			name:         "update of a live alert is superseded again",
+			stored:       []*CAPMessageRecord{storedMessage("CAP-alert", "Z1", "Update", sent, time.Hour)},
+			targetState:  "D2",
+			wantType:     "Cancel",
+			wantHeadline: "Alert cancelled: Evacuate concourse",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := setupCAPTestDB(t)
+			for _, record := range tt.stored {
+				require.NoError(t, db.Create(record).Error)
+			}
+			service := NewCAPService(db, NewCAPGenerator(db), nil, nil, nil, nil, nil)
+
+			retraction, err := service.PreviewRetraction(context.Background(), &gate.RetractionRequest{
+				ZoneID:      "Z1",
+				DecisionID:  "dec_1",
+				ActionType:  "D5",
+				TargetState: tt.targetState,
+				Reason:      gate.RollbackReasonTTLExpired,
+			})
+			require.NoError(t, err)
+
+			if tt.wantType == "" {
+				assert.Nil(t, retraction)
+				return
+			}
+			require.NotNil(t, retraction)
+			assert.Equal(t, tt.wantType, retraction.MsgType)
+			assert.Equal(t, references, retraction.References)
+			assert.Equal(t, "Z1", retraction.ZoneID)
+			assert.Empty(t, retraction.Identifier, "previews are not published")
+			assert.Equal(t, tt.wantHeadline, retraction.Headline["en"])
+			assert.Contains(t, retraction.Description["en"], sent.UTC().Format(time.RFC3339))
+		})
+	}
+}
+
+func TestCAPService_RetractionUrgency(t *testing.T) {
+	db := setupCAPTestDB(t)
+	sent := time.Now().Add(-time.Minute).Truncate(time.Second)
+	require.NoError(t, db.Create(storedMessage("CAP-alert", "Z1", "Alert", sent, time.Hour)).Error)
+	service := NewCAPService(db, NewCAPGenerator(db), nil, nil, nil, nil, nil)
+	ctx := context.Background()
+
+	for _, tt := range []struct {
+		targetState  string
+		wantUrgency  string
+		wantSeverity string
+		wantExpiry   time.Time
+	}{
+		{"D2", "Past", "Minor", time.Now().Add(cancelTTL)},
+		{"D4", "Immediate", "Severe", sent.Add(time.Hour)},
+	} {
+		original, err := service.liveAlert(ctx, "Z1")
+		require.NoError(t, err)
+		capMsg, err := service.buildRetraction(ctx, &gate.RetractionRequest{ZoneID: "Z1", TargetState: tt.targetState})
+		require.NoError(t, err)
+		require.Len(t, capMsg.Info, 1)
+
+		info := capMsg.Info[0]
+		assert.Equal(t, tt.wantUrgency, info.Urgency, tt.targetState)
+		assert.Equal(t, tt.wantSeverity, info.Severity, tt.targetState)
+		assert.Equal(t, infoField(original, "en", "event"), info.Event, tt.targetState)
+		expires, err := time.Parse(time.RFC3339, info.Expires)
+		require.NoError(t, err)
+		assert.WithinDuration(t, tt.wantExpiry, expires, 2*time.Second, tt.targetState)
+	}
+}
+
+func TestBackfillZoneIDs(t *testing.T) {
+	db := setupCAPTestDB(t)
+	sent := time.Now().Add(-time.Minute).Truncate(time.Second)
+	legacy := storedMessage("CAP-legacy", "Z1", "Alert", sent, time.Hour)
+	legacy.ZoneID = ""
+	require.NoError(t, db.Create(legacy).Error)
+	require.NoError(t, db.Create(storedMessage("CAP-current", "Z2", "Alert", sent, time.Hour)).Error)
+	service := NewCAPService(db, NewCAPGenerator(db), nil, nil, nil, nil, nil)
+	ctx := context.Background()
+
+	// Alerts stored before the zone column existed are not found until backfilled
+	live, err := service.liveAlert(ctx, "Z1")
+	require.NoError(t, err)
+	assert.Nil(t, live)
+
+	filled, err := BackfillZoneIDs(ctx, db)
+	require.NoError(t, err)
+	assert.Equal(t, 1, filled)
+
+	live, err = service.liveAlert(ctx, "Z1")
+	require.NoError(t, err)
+	require.NotNil(t, live)
+	assert.Equal(t, "CAP-legacy", live.Identifier)
+
+	filled, err = BackfillZoneIDs(ctx, db)
+	require.NoError(t, err)
+	assert.Zero(t, filled)
+}
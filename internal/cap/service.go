@@ -83,8 +83,13 @@ func (s *CAPService) GenerateAndPublish(ctx context.Context, req *GenerateAndPub
 		return nil, fmt.Errorf("failed to generate CAP message: %w", err)
 	}
 	
+	return s.publish(ctx, capMsg, req.ZoneID)
+}
+
+// publish checks, signs and publishes a generated message on every Route 1 channel, then saves it
+func (s *CAPService) publish(ctx context.Context, capMsg *CAPMessage, zoneID string) (*CAPMessageRecord, error) {
 	// Step 2: Consistency check
-	checkResult, err := s.consistencyChecker.Check(ctx, capMsg, req.ZoneID)
+	checkResult, err := s.consistencyChecker.Check(ctx, capMsg, zoneID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to check consistency: %w", err)
 	}
@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -13,6 +14,13 @@ type Config struct {
 	Redis    RedisConfig
 	Auth     AuthConfig
 	Aggregation AggregationConfig
+	Evaluator EvaluatorConfig
+	Gate     GateConfig
+	Notify   NotifyConfig
+	Leader   LeaderConfig
+	ConfigFile string // Optional YAML/TOML file overriding aggregation, evaluator and gate settings
+	PolicyDir  string // Optional directory of per-zone-type decision policy documents
+	PlaybookDir string // Optional directory of per-zone-type SOP playbook documents
 }
 
 // ServerConfig holds server configuration
@@ -44,6 +52,12 @@ type RedisConfig struct {
 	DB       int
 }
 
+// LeaderConfig holds the lease used to elect the instance that runs background loops
+type LeaderConfig struct {
+	LeaseTTL   time.Duration // Lease lifetime; the leader renews it every third of this
+	InstanceID string        // Identifies this instance in the lease; defaults to host name and process ID
+}
+
 // AuthConfig holds authentication configuration
 type AuthConfig struct {
 	JWTSecret     string
@@ -53,8 +67,67 @@ type AuthConfig struct {
 
 // AggregationConfig holds aggregation configuration
 type AggregationConfig struct {
-	TimeWindows map[string]time.Duration // zone_id -> window duration
-	Weights     map[string]map[string]float64 // zone_id -> source_type -> weight
+	TimeWindows             map[string]time.Duration      // zone_id -> window duration
+	Weights                 map[string]map[string]float64 // zone_id -> source_type -> weight
+	BaselineRelearnInterval time.Duration                 // How often signal baselines are relearned
+	BaselineLookback        time.Duration                 // How much history baselines are learned from
+}
+
+// EvaluatorConfig holds decision evaluator thresholds
+type EvaluatorConfig struct {
+	EscalationConfidence        float64 // Confidence above which high-impact escalation is considered
+	EscalationWeightedValue     float64 // Weighted value above which high-impact escalation is considered
+	ModerateConfidence          float64 // Confidence above which D2 is proposed
+	UncorroboratedConfidence    float64 // Confidence above which D2 is proposed without corroboration
+	PreAlertConfidence          float64 // Confidence above which D0 is proposed
+	PressuredPreAlertConfidence float64 // D0 threshold when adjacent zones are under pressure
+	NeighbourPreAlertPressure   float64 // Neighbour pressure that lowers the D0 threshold
+	AnomalyPreAlertScore        float64 // Baseline z-score that alone triggers D0
+	AnomalyEscalationScore      float64 // Baseline z-score that substitutes for weighted value
+	ExitConfidence              float64 // Confidence below which a window counts as calm
+	ExitAnomalyScore            float64 // Baseline z-score below which a window counts as calm
+	DeEscalationWindows         int     // Consecutive calm windows before a zone steps down
+}
+
+// GateConfig holds approval, TTL and keepalive configuration
+type GateConfig struct {
+	TTLs               map[string]time.Duration // action_type -> TTL
+	MaxTTLs            map[string]time.Duration // action_type -> cap on the total TTL including approved extensions
+	KeepaliveInterval  time.Duration
+	KeepaliveTimeout   time.Duration
+	ApprovalExpiration time.Duration
+	Quorums            map[string]QuorumPolicy            // action_type -> quorum
+	ZoneQuorums        map[string]map[string]QuorumPolicy // zone_id -> action_type -> quorum overriding Quorums
+	ApproverRoles      map[string][]string                // role -> action types its holders may approve
+}
+
+// QuorumPolicy is the set of approvals an action needs before it takes effect
+type QuorumPolicy struct {
+	Required int      // Distinct approvers
+	Roles    []string // Roles that must each be held by at least one approver
+	Excluded []string // Operators who may not approve, e.g. because of a conflict of interest
+}
+
+// Quorum returns the quorum for an action type in a zone
+func (g *GateConfig) Quorum(zoneID, actionType string) QuorumPolicy {
+	if quorum, exists := g.ZoneQuorums[zoneID][actionType]; exists {
+		return quorum
+	}
+	return g.Quorums[actionType]
+}
+
+// NotifyConfig holds the escalation ladder for unacknowledged decisions and the notification channels
+type NotifyConfig struct {
+	Ladder     []LadderStep
+	WebhookURL string // Endpoint of the webhook channel; the channel is disabled when empty
+}
+
+// LadderStep notifies a role once a decision has gone unacknowledged for Delay
+type LadderStep struct {
+	Role      string        // duty_operator|supervisor|incident_commander
+	Recipient string        // Operator ID, phone number or other address understood by the channels
+	Delay     time.Duration // Since the decision was raised
+	Channels  []string      // console|sms|webhook
 }
 
 // Load reads configuration from environment variables
@@ -83,6 +156,10 @@ func Load() *Config {
 			Password: getEnv("REDIS_PASSWORD", ""),
 			DB:       getIntEnv("REDIS_DB", 0),
 		},
+		Leader: LeaderConfig{
+			LeaseTTL:   getDurationEnv("LEADER_LEASE_TTL", 15*time.Second),
+			InstanceID: getEnv("INSTANCE_ID", ""),
+		},
 		Auth: AuthConfig{
 			JWTSecret:     getEnv("JWT_SECRET", "change-me-in-production"),
 			JWTExpiration: getDurationEnv("JWT_EXPIRATION", 24*time.Hour),
@@ -121,7 +198,76 @@ func Load() *Config {
 					"emergency":      0.5,
 				},
 			},
+			BaselineRelearnInterval: getDurationEnv("BASELINE_RELEARN_INTERVAL", 6*time.Hour),
+			BaselineLookback:        getDurationEnv("BASELINE_LOOKBACK", 8*7*24*time.Hour),
 		},
+		Evaluator: EvaluatorConfig{
+			EscalationConfidence:        0.8,
+			EscalationWeightedValue:     0.7,
+			ModerateConfidence:          0.6,
+			UncorroboratedConfidence:    0.7,
+			PreAlertConfidence:          0.4,
+			PressuredPreAlertConfidence: 0.25,
+			NeighbourPreAlertPressure:   0.5,
+			AnomalyPreAlertScore:        2.0,
+			AnomalyEscalationScore:      3.0,
+			ExitConfidence:              0.2,
+			ExitAnomalyScore:            1.0,
+			DeEscalationWindows:         getIntEnv("DEESCALATION_WINDOWS", 3),
+		},
+		Gate: GateConfig{
+			TTLs: map[string]time.Duration{
+				"D3": 30 * time.Minute,
+				"D4": 20 * time.Minute,
+				"D5": 60 * time.Minute,
+			},
+			MaxTTLs: map[string]time.Duration{
+				"D3": 2 * time.Hour,
+				"D4": 1 * time.Hour,
+				"D5": 3 * time.Hour,
+			},
+			KeepaliveInterval:  getDurationEnv("KEEPALIVE_INTERVAL", 60*time.Second),
+			KeepaliveTimeout:   getDurationEnv("KEEPALIVE_TIMEOUT", 120*time.Second),
+			ApprovalExpiration: getDurationEnv("APPROVAL_EXPIRATION", 10*time.Minute),
+			Quorums: map[string]QuorumPolicy{
+				"D3": {Required: 2},
+				"D4": {Required: 3},
+				"D5": {Required: 2},
+			},
+			ZoneQuorums: map[string]map[string]QuorumPolicy{},
+			ApproverRoles: map[string][]string{
+				"station_master":     {"D3", "D4", "D5"},
+				"control_supervisor": {"D3", "D4", "D5"},
+				"incident_commander": {"D3", "D4", "D5"},
+				"duty_operator":      {"D3"},
+			},
+		},
+		ConfigFile: getEnv("CONFIG_FILE", ""),
+		Notify: NotifyConfig{
+			Ladder: []LadderStep{
+				{
+					Role:      "duty_operator",
+					Recipient: getEnv("ACK_DUTY_OPERATOR", "duty_operator"),
+					Delay:     getDurationEnv("ACK_DUTY_OPERATOR_DELAY", 1*time.Minute),
+					Channels:  getListEnv("ACK_DUTY_OPERATOR_CHANNELS", []string{"console"}),
+				},
+				{
+					Role:      "supervisor",
+					Recipient: getEnv("ACK_SUPERVISOR", "supervisor"),
+					Delay:     getDurationEnv("ACK_SUPERVISOR_DELAY", 3*time.Minute),
+					Channels:  getListEnv("ACK_SUPERVISOR_CHANNELS", []string{"console", "sms"}),
+				},
+				{
+					Role:      "incident_commander",
+					Recipient: getEnv("ACK_INCIDENT_COMMANDER", "incident_commander"),
+					Delay:     getDurationEnv("ACK_INCIDENT_COMMANDER_DELAY", 5*time.Minute),
+					Channels:  getListEnv("ACK_INCIDENT_COMMANDER_CHANNELS", []string{"console", "sms", "webhook"}),
+				},
+			},
+			WebhookURL: getEnv("NOTIFY_WEBHOOK_URL", ""),
+		},
+		PolicyDir:  getEnv("POLICY_DIR", ""),
+		PlaybookDir: getEnv("PLAYBOOK_DIR", ""),
 	}
 }
 
@@ -150,3 +296,17 @@ func getDurationEnv(key string, defaultValue time.Duration) time.Duration {
 	return defaultValue
 }
 
+// getListEnv reads a comma-separated list, e.g. "console,sms"
+func getListEnv(key string, defaultValue []string) []string {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	items := make([]string, 0)
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
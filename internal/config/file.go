@@ -0,0 +1,539 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	knownZones       = []string{"Z1", "Z2", "Z3", "Z4"}
+	knownSourceTypes = []string{"infrastructure", "staff", "crowd", "emergency"}
+	knownActionTypes = []string{"D3", "D4", "D5"}
+	knownChannels    = []string{"console", "sms", "webhook"}
+)
+
+// FileConfig is the schema of the YAML/TOML configuration file.
+// Every section is optional; omitted values keep their environment/default value.
+type FileConfig struct {
+	Zones              map[string]ZoneFileConfig   `yaml:"zones" toml:"zones"`
+	Evaluator          *EvaluatorFileConfig        `yaml:"evaluator" toml:"evaluator"`
+	TTLs               map[string]string           `yaml:"ttls" toml:"ttls"`         // action_type -> duration
+	MaxTTLs            map[string]string           `yaml:"max_ttls" toml:"max_ttls"` // action_type -> cap on the TTL including extensions
+	Keepalive          *KeepaliveFileConfig        `yaml:"keepalive" toml:"keepalive"`
+	ApprovalExpiration string                      `yaml:"approval_expiration" toml:"approval_expiration"`
+	Quorums            map[string]QuorumFileConfig `yaml:"quorums" toml:"quorums"`               // action_type -> quorum
+	ApproverRoles      map[string][]string         `yaml:"approver_roles" toml:"approver_roles"` // role -> action types; replaces the role's list
+}
+
+// QuorumFileConfig holds the quorum for an action type and its per-zone overrides.
+// Omitted fields keep their current value.
+type QuorumFileConfig struct {
+	Required int                         `yaml:"required" toml:"required"`
+	Roles    []string                    `yaml:"roles" toml:"roles"`
+	Excluded []string                    `yaml:"excluded" toml:"excluded"`
+	Zones    map[string]QuorumFileConfig `yaml:"zones" toml:"zones"` // zone_id -> override
+}
+
+// ZoneFileConfig holds per-zone aggregation settings
+type ZoneFileConfig struct {
+	Window  string             `yaml:"window" toml:"window"`   // e.g. "60s"
+	Weights map[string]float64 `yaml:"weights" toml:"weights"` // source_type -> weight
+}
+
+// EvaluatorFileConfig holds evaluator threshold overrides
+type EvaluatorFileConfig struct {
+	EscalationConfidence        *float64 `yaml:"escalation_confidence" toml:"escalation_confidence"`
+	EscalationWeightedValue     *float64 `yaml:"escalation_weighted_value" toml:"escalation_weighted_value"`
+	ModerateConfidence          *float64 `yaml:"moderate_confidence" toml:"moderate_confidence"`
+	UncorroboratedConfidence    *float64 `yaml:"uncorroborated_confidence" toml:"uncorroborated_confidence"`
+	PreAlertConfidence          *float64 `yaml:"pre_alert_confidence" toml:"pre_alert_confidence"`
+	PressuredPreAlertConfidence *float64 `yaml:"pressured_pre_alert_confidence" toml:"pressured_pre_alert_confidence"`
+	NeighbourPreAlertPressure   *float64 `yaml:"neighbour_pre_alert_pressure" toml:"neighbour_pre_alert_pressure"`
+	AnomalyPreAlertScore        *float64 `yaml:"anomaly_pre_alert_score" toml:"anomaly_pre_alert_score"`
+	AnomalyEscalationScore      *float64 `yaml:"anomaly_escalation_score" toml:"anomaly_escalation_score"`
+	ExitConfidence              *float64 `yaml:"exit_confidence" toml:"exit_confidence"`
+	ExitAnomalyScore            *float64 `yaml:"exit_anomaly_score" toml:"exit_anomaly_score"`
+	DeEscalationWindows         *int     `yaml:"de_escalation_windows" toml:"de_escalation_windows"`
+}
+
+// KeepaliveFileConfig holds keepalive overrides
+type KeepaliveFileConfig struct {
+	Interval string `yaml:"interval" toml:"interval"`
+	Timeout  string `yaml:"timeout" toml:"timeout"`
+}
+
+// ValidationError lists every problem found in a configuration
+type ValidationError struct {
+	Problems []string
+}
+
+// Error implements the error interface
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("invalid configuration: %s", strings.Join(e.Problems, "; "))
+}
+
+// LoadFile parses a configuration file. The format is chosen by extension (.yaml, .yml or .toml);
+// unknown keys are rejected so that typos do not silently fall back to defaults.
+func LoadFile(path string) (*FileConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	fc := &FileConfig{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		if err := decoder.Decode(fc); err != nil && !errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("failed to parse YAML config: %w", err)
+		}
+	case ".toml":
+		decoder := toml.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(fc); err != nil {
+			return nil, fmt.Errorf("failed to parse TOML config: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported config file extension: %s", filepath.Ext(path))
+	}
+
+	return fc, nil
+}
+
+// WithFile returns a copy of the configuration with the file's values applied and validated
+func (c *Config) WithFile(fc *FileConfig) (*Config, error) {
+	merged := c.Clone()
+	problems := make([]string, 0)
+
+	for zoneID, zone := range fc.Zones {
+		if !containsString(knownZones, zoneID) {
+			problems = append(problems, fmt.Sprintf("zones.%s: unknown zone", zoneID))
+			continue
+		}
+		if zone.Window != "" {
+			window, err := time.ParseDuration(zone.Window)
+			if err != nil {
+				problems = append(problems, fmt.Sprintf("zones.%s.window: %v", zoneID, err))
+			} else {
+				merged.Aggregation.TimeWindows[zoneID] = window
+			}
+		}
+		if len(zone.Weights) > 0 {
+			weights := make(map[string]float64, len(zone.Weights))
+			for sourceType, weight := range zone.Weights {
+				weights[sourceType] = weight
+			}
+			merged.Aggregation.Weights[zoneID] = weights
+		}
+	}
+
+	fc.Evaluator.ApplyTo(&merged.Evaluator)
+
+	for actionType, value := range fc.TTLs {
+		ttl, err := time.ParseDuration(value)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("ttls.%s: %v", actionType, err))
+			continue
+		}
+		merged.Gate.TTLs[actionType] = ttl
+	}
+	for actionType, value := range fc.MaxTTLs {
+		ttl, err := time.ParseDuration(value)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("max_ttls.%s: %v", actionType, err))
+			continue
+		}
+		merged.Gate.MaxTTLs[actionType] = ttl
+	}
+
+	if ka := fc.Keepalive; ka != nil {
+		applyDuration(&merged.Gate.KeepaliveInterval, ka.Interval, "keepalive.interval", &problems)
+		applyDuration(&merged.Gate.KeepaliveTimeout, ka.Timeout, "keepalive.timeout", &problems)
+	}
+	applyDuration(&merged.Gate.ApprovalExpiration, fc.ApprovalExpiration, "approval_expiration", &problems)
+
+	for actionType, quorum := range fc.Quorums {
+		if !containsString(knownActionTypes, actionType) {
+			problems = append(problems, fmt.Sprintf("quorums.%s: unknown action type", actionType))
+			continue
+		}
+		base := quorum.ApplyTo(merged.Gate.Quorums[actionType])
+		merged.Gate.Quorums[actionType] = base
+		for zoneID, override := range quorum.Zones {
+			if !containsString(knownZones, zoneID) {
+				problems = append(problems, fmt.Sprintf("quorums.%s.zones.%s: unknown zone", actionType, zoneID))
+				continue
+			}
+			if len(override.Zones) > 0 {
+				problems = append(problems, fmt.Sprintf("quorums.%s.zones.%s.zones: zone overrides cannot be nested", actionType, zoneID))
+			}
+			if merged.Gate.ZoneQuorums[zoneID] == nil {
+				merged.Gate.ZoneQuorums[zoneID] = make(map[string]QuorumPolicy)
+			}
+			merged.Gate.ZoneQuorums[zoneID][actionType] = override.ApplyTo(base)
+		}
+	}
+
+	for role, actionTypes := range fc.ApproverRoles {
+		merged.Gate.ApproverRoles[role] = append([]string(nil), actionTypes...)
+	}
+
+	if err := merged.Validate(); err != nil {
+		var validationErr *ValidationError
+		if errors.As(err, &validationErr) {
+			problems = append(problems, validationErr.Problems...)
+		}
+	}
+	if len(problems) > 0 {
+		return nil, &ValidationError{Problems: problems}
+	}
+
+	return merged, nil
+}
+
+// Validate checks the aggregation, evaluator, gate and notification settings for consistency
+func (c *Config) Validate() error {
+	problems := make([]string, 0)
+
+	for _, zoneID := range knownZones {
+		window, exists := c.Aggregation.TimeWindows[zoneID]
+		if !exists || window <= 0 {
+			problems = append(problems, fmt.Sprintf("zones.%s.window: must be positive", zoneID))
+		}
+		weights, exists := c.Aggregation.Weights[zoneID]
+		if !exists || len(weights) == 0 {
+			problems = append(problems, fmt.Sprintf("zones.%s.weights: must not be empty", zoneID))
+		}
+		for sourceType, weight := range weights {
+			if !containsString(knownSourceTypes, sourceType) {
+				problems = append(problems, fmt.Sprintf("zones.%s.weights.%s: unknown source type", zoneID, sourceType))
+			}
+			if weight < 0 || weight > 1 {
+				problems = append(problems, fmt.Sprintf("zones.%s.weights.%s: must be within [0, 1]", zoneID, sourceType))
+			}
+		}
+	}
+
+	if c.Aggregation.BaselineRelearnInterval <= 0 {
+		problems = append(problems, "BASELINE_RELEARN_INTERVAL: must be positive")
+	}
+	if c.Aggregation.BaselineLookback < 7*24*time.Hour {
+		problems = append(problems, "BASELINE_LOOKBACK: must cover at least one week")
+	}
+
+	problems = append(problems, c.Evaluator.Problems("evaluator")...)
+
+	for _, actionType := range knownActionTypes {
+		if ttl, exists := c.Gate.TTLs[actionType]; !exists || ttl <= 0 {
+			problems = append(problems, fmt.Sprintf("ttls.%s: must be positive", actionType))
+		}
+	}
+	for actionType := range c.Gate.TTLs {
+		if !containsString(knownActionTypes, actionType) {
+			problems = append(problems, fmt.Sprintf("ttls.%s: unknown action type", actionType))
+		}
+	}
+	for _, actionType := range knownActionTypes {
+		if maxTTL, exists := c.Gate.MaxTTLs[actionType]; !exists || maxTTL < c.Gate.TTLs[actionType] {
+			problems = append(problems, fmt.Sprintf("max_ttls.%s: must be at least the action type's TTL", actionType))
+		}
+	}
+	for actionType := range c.Gate.MaxTTLs {
+		if !containsString(knownActionTypes, actionType) {
+			problems = append(problems, fmt.Sprintf("max_ttls.%s: unknown action type", actionType))
+		}
+	}
+	if c.Gate.KeepaliveInterval <= 0 || c.Gate.KeepaliveTimeout <= c.Gate.KeepaliveInterval {
+		problems = append(problems, "keepalive: must satisfy 0 < interval < timeout")
+	}
+	if c.Gate.ApprovalExpiration <= 0 {
+		problems = append(problems, "approval_expiration: must be positive")
+	}
+
+	for _, actionType := range knownActionTypes {
+		quorum, exists := c.Gate.Quorums[actionType]
+		if !exists {
+			problems = append(problems, fmt.Sprintf("quorums.%s: must be set", actionType))
+			continue
+		}
+		problems = append(problems, quorum.Problems(fmt.Sprintf("quorums.%s", actionType))...)
+	}
+	for zoneID, quorums := range c.Gate.ZoneQuorums {
+		for actionType, quorum := range quorums {
+			problems = append(problems, quorum.Problems(fmt.Sprintf("quorums.%s.zones.%s", actionType, zoneID))...)
+		}
+	}
+
+	for role, actionTypes := range c.Gate.ApproverRoles {
+		for _, actionType := range actionTypes {
+			if !containsString(knownActionTypes, actionType) {
+				problems = append(problems, fmt.Sprintf("approver_roles.%s: unknown action type %s", role, actionType))
+			}
+		}
+	}
+
+	var previous time.Duration
+	for _, step := range c.Notify.Ladder {
+		if step.Delay <= 0 || step.Delay < previous {
+			problems = append(problems, fmt.Sprintf("notify.ladder.%s.delay: must be positive and not shorter than the previous step", step.Role))
+		}
+		previous = step.Delay
+		for _, channel := range step.Channels {
+			if !containsString(knownChannels, channel) {
+				problems = append(problems, fmt.Sprintf("notify.ladder.%s.channels: unknown channel %s", step.Role, channel))
+			}
+		}
+	}
+
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+// ApplyTo overrides the thresholds set in the file. A nil receiver changes nothing.
+func (ev *EvaluatorFileConfig) ApplyTo(cfg *EvaluatorConfig) {
+	if ev == nil {
+		return
+	}
+	applyFloat(&cfg.EscalationConfidence, ev.EscalationConfidence)
+	applyFloat(&cfg.EscalationWeightedValue, ev.EscalationWeightedValue)
+	applyFloat(&cfg.ModerateConfidence, ev.ModerateConfidence)
+	applyFloat(&cfg.UncorroboratedConfidence, ev.UncorroboratedConfidence)
+	applyFloat(&cfg.PreAlertConfidence, ev.PreAlertConfidence)
+	applyFloat(&cfg.PressuredPreAlertConfidence, ev.PressuredPreAlertConfidence)
+	applyFloat(&cfg.NeighbourPreAlertPressure, ev.NeighbourPreAlertPressure)
+	applyFloat(&cfg.AnomalyPreAlertScore, ev.AnomalyPreAlertScore)
+	applyFloat(&cfg.AnomalyEscalationScore, ev.AnomalyEscalationScore)
+	applyFloat(&cfg.ExitConfidence, ev.ExitConfidence)
+	applyFloat(&cfg.ExitAnomalyScore, ev.ExitAnomalyScore)
+	if ev.DeEscalationWindows != nil {
+		cfg.DeEscalationWindows = *ev.DeEscalationWindows
+	}
+}
+
+// ApplyTo returns base with the fields set in the file overridden
+func (q QuorumFileConfig) ApplyTo(base QuorumPolicy) QuorumPolicy {
+	if q.Required != 0 {
+		base.Required = q.Required
+	}
+	if q.Roles != nil {
+		base.Roles = append([]string(nil), q.Roles...)
+	}
+	if q.Excluded != nil {
+		base.Excluded = append([]string(nil), q.Excluded...)
+	}
+	return base
+}
+
+// Problems checks that a quorum can be met; each problem is prefixed with the given field path
+func (q QuorumPolicy) Problems(prefix string) []string {
+	problems := make([]string, 0)
+	if q.Required < 2 {
+		problems = append(problems, fmt.Sprintf("%s.required: must be at least 2", prefix))
+	}
+	if len(q.Roles) > q.Required {
+		problems = append(problems, fmt.Sprintf("%s.roles: more roles than required approvers", prefix))
+	}
+	seen := make(map[string]bool, len(q.Roles))
+	for _, role := range q.Roles {
+		if role == "" || seen[role] {
+			problems = append(problems, fmt.Sprintf("%s.roles: roles must be non-empty and distinct", prefix))
+			break
+		}
+		seen[role] = true
+	}
+	return problems
+}
+
+// Problems checks the thresholds for consistency; each problem is prefixed with the given field path
+func (ev EvaluatorConfig) Problems(prefix string) []string {
+	problems := make([]string, 0)
+
+	for name, value := range map[string]float64{
+		"escalation_confidence":          ev.EscalationConfidence,
+		"moderate_confidence":            ev.ModerateConfidence,
+		"uncorroborated_confidence":      ev.UncorroboratedConfidence,
+		"pre_alert_confidence":           ev.PreAlertConfidence,
+		"pressured_pre_alert_confidence": ev.PressuredPreAlertConfidence,
+		"neighbour_pre_alert_pressure":   ev.NeighbourPreAlertPressure,
+		"exit_confidence":                ev.ExitConfidence,
+	} {
+		if value < 0 || value > 1 {
+			problems = append(problems, fmt.Sprintf("%s.%s: must be within [0, 1]", prefix, name))
+		}
+	}
+	if ev.EscalationWeightedValue < 0 {
+		problems = append(problems, fmt.Sprintf("%s.escalation_weighted_value: must not be negative", prefix))
+	}
+	if !(ev.PreAlertConfidence < ev.ModerateConfidence && ev.ModerateConfidence < ev.EscalationConfidence) {
+		problems = append(problems, fmt.Sprintf("%s: thresholds must satisfy pre_alert_confidence < moderate_confidence < escalation_confidence", prefix))
+	}
+	if ev.PressuredPreAlertConfidence > ev.PreAlertConfidence {
+		problems = append(problems, fmt.Sprintf("%s.pressured_pre_alert_confidence: must not exceed pre_alert_confidence", prefix))
+	}
+	if ev.AnomalyPreAlertScore <= 0 || ev.AnomalyEscalationScore < ev.AnomalyPreAlertScore {
+		problems = append(problems, fmt.Sprintf("%s: anomaly scores must satisfy 0 < anomaly_pre_alert_score <= anomaly_escalation_score", prefix))
+	}
+	if ev.ExitConfidence > ev.PressuredPreAlertConfidence || ev.ExitAnomalyScore > ev.AnomalyPreAlertScore {
+		problems = append(problems, fmt.Sprintf("%s: exit thresholds must not exceed the pre-alert thresholds (exit_confidence <= pressured_pre_alert_confidence, exit_anomaly_score <= anomaly_pre_alert_score)", prefix))
+	}
+	if ev.DeEscalationWindows < 1 {
+		problems = append(problems, fmt.Sprintf("%s.de_escalation_windows: must be at least 1", prefix))
+	}
+
+	return problems
+}
+
+// Clone returns a deep copy of the configuration
+func (c *Config) Clone() *Config {
+	clone := *c
+
+	clone.Aggregation.TimeWindows = make(map[string]time.Duration, len(c.Aggregation.TimeWindows))
+	for zoneID, window := range c.Aggregation.TimeWindows {
+		clone.Aggregation.TimeWindows[zoneID] = window
+	}
+	clone.Aggregation.Weights = make(map[string]map[string]float64, len(c.Aggregation.Weights))
+	for zoneID, weights := range c.Aggregation.Weights {
+		zoneWeights := make(map[string]float64, len(weights))
+		for sourceType, weight := range weights {
+			zoneWeights[sourceType] = weight
+		}
+		clone.Aggregation.Weights[zoneID] = zoneWeights
+	}
+	clone.Gate.TTLs = make(map[string]time.Duration, len(c.Gate.TTLs))
+	for actionType, ttl := range c.Gate.TTLs {
+		clone.Gate.TTLs[actionType] = ttl
+	}
+	clone.Gate.MaxTTLs = make(map[string]time.Duration, len(c.Gate.MaxTTLs))
+	for actionType, ttl := range c.Gate.MaxTTLs {
+		clone.Gate.MaxTTLs[actionType] = ttl
+	}
+	clone.Gate.Quorums = make(map[string]QuorumPolicy, len(c.Gate.Quorums))
+	for actionType, quorum := range c.Gate.Quorums {
+		clone.Gate.Quorums[actionType] = quorum.clone()
+	}
+	clone.Gate.ZoneQuorums = make(map[string]map[string]QuorumPolicy, len(c.Gate.ZoneQuorums))
+	for zoneID, quorums := range c.Gate.ZoneQuorums {
+		zoneQuorums := make(map[string]QuorumPolicy, len(quorums))
+		for actionType, quorum := range quorums {
+			zoneQuorums[actionType] = quorum.clone()
+		}
+		clone.Gate.ZoneQuorums[zoneID] = zoneQuorums
+	}
+	clone.Gate.ApproverRoles = make(map[string][]string, len(c.Gate.ApproverRoles))
+	for role, actionTypes := range c.Gate.ApproverRoles {
+		clone.Gate.ApproverRoles[role] = append([]string(nil), actionTypes...)
+	}
+	clone.Notify.Ladder = make([]LadderStep, len(c.Notify.Ladder))
+	for i, step := range c.Notify.Ladder {
+		step.Channels = append([]string(nil), step.Channels...)
+		clone.Notify.Ladder[i] = step
+	}
+
+	return &clone
+}
+
+// Diff describes the reloadable settings that differ between two configurations
+func Diff(old, new *Config) []string {
+	changes := make([]string, 0)
+
+	for _, zoneID := range knownZones {
+		if old.Aggregation.TimeWindows[zoneID] != new.Aggregation.TimeWindows[zoneID] {
+			changes = append(changes, fmt.Sprintf("zones.%s.window: %s -> %s", zoneID, old.Aggregation.TimeWindows[zoneID], new.Aggregation.TimeWindows[zoneID]))
+		}
+		if !reflect.DeepEqual(old.Aggregation.Weights[zoneID], new.Aggregation.Weights[zoneID]) {
+			changes = append(changes, fmt.Sprintf("zones.%s.weights: %v -> %v", zoneID, old.Aggregation.Weights[zoneID], new.Aggregation.Weights[zoneID]))
+		}
+	}
+
+	oldEv := reflect.ValueOf(old.Evaluator)
+	newEv := reflect.ValueOf(new.Evaluator)
+	for i := 0; i < oldEv.NumField(); i++ {
+		if oldEv.Field(i).Interface() != newEv.Field(i).Interface() {
+			changes = append(changes, fmt.Sprintf("evaluator.%s: %v -> %v", oldEv.Type().Field(i).Name, oldEv.Field(i).Interface(), newEv.Field(i).Interface()))
+		}
+	}
+
+	for _, actionType := range knownActionTypes {
+		if old.Gate.TTLs[actionType] != new.Gate.TTLs[actionType] {
+			changes = append(changes, fmt.Sprintf("ttls.%s: %s -> %s", actionType, old.Gate.TTLs[actionType], new.Gate.TTLs[actionType]))
+		}
+		if old.Gate.MaxTTLs[actionType] != new.Gate.MaxTTLs[actionType] {
+			changes = append(changes, fmt.Sprintf("max_ttls.%s: %s -> %s", actionType, old.Gate.MaxTTLs[actionType], new.Gate.MaxTTLs[actionType]))
+		}
+	}
+	if old.Gate.KeepaliveInterval != new.Gate.KeepaliveInterval {
+		changes = append(changes, fmt.Sprintf("keepalive.interval: %s -> %s", old.Gate.KeepaliveInterval, new.Gate.KeepaliveInterval))
+	}
+	if old.Gate.KeepaliveTimeout != new.Gate.KeepaliveTimeout {
+		changes = append(changes, fmt.Sprintf("keepalive.timeout: %s -> %s", old.Gate.KeepaliveTimeout, new.Gate.KeepaliveTimeout))
+	}
+	if old.Gate.ApprovalExpiration != new.Gate.ApprovalExpiration {
+		changes = append(changes, fmt.Sprintf("approval_expiration: %s -> %s", old.Gate.ApprovalExpiration, new.Gate.ApprovalExpiration))
+	}
+	for _, actionType := range knownActionTypes {
+		if !reflect.DeepEqual(old.Gate.Quorums[actionType], new.Gate.Quorums[actionType]) {
+			changes = append(changes, fmt.Sprintf("quorums.%s: %+v -> %+v", actionType, old.Gate.Quorums[actionType], new.Gate.Quorums[actionType]))
+		}
+		for _, zoneID := range knownZones {
+			oldQuorum, oldExists := old.Gate.ZoneQuorums[zoneID][actionType]
+			newQuorum, newExists := new.Gate.ZoneQuorums[zoneID][actionType]
+			if oldExists != newExists || !reflect.DeepEqual(oldQuorum, newQuorum) {
+				changes = append(changes, fmt.Sprintf("quorums.%s.zones.%s: %+v -> %+v", actionType, zoneID, oldQuorum, newQuorum))
+			}
+		}
+	}
+	if !reflect.DeepEqual(old.Gate.ApproverRoles, new.Gate.ApproverRoles) {
+		changes = append(changes, fmt.Sprintf("approver_roles: %v -> %v", old.Gate.ApproverRoles, new.Gate.ApproverRoles))
+	}
+
+	return changes
+}
+
+// clone returns a copy that shares no slices with q
+func (q QuorumPolicy) clone() QuorumPolicy {
+	q.Roles = append([]string(nil), q.Roles...)
+	q.Excluded = append([]string(nil), q.Excluded...)
+	return q
+}
+
+func applyFloat(target *float64, value *float64) {
+	if value != nil {
+		*target = *value
+	}
+}
+
+func applyDuration(target *time.Duration, value string, field string, problems *[]string) {
+	if value == "" {
+		return
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		*problems = append(*problems, fmt.Sprintf("%s: %v", field, err))
+		return
+	}
+	*target = duration
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
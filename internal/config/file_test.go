@@ -0,0 +1,164 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeConfigFile(t *testing.T, name, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+	return path
+}
+
+func TestConfig_DefaultsAreValid(t *testing.T) {
+	assert.NoError(t, Load().Validate())
+}
+
+func TestLoadFile_YAMLAndTOML(t *testing.T) {
+	yamlPath := writeConfigFile(t, "erh.yaml", `
+zones:
+  Z2:
+    window: 45s
+    weights:
+      infrastructure: 0.6
+      staff: 0.3
+evaluator:
+  pre_alert_confidence: 0.35
+ttls:
+  D4: 15m
+max_ttls:
+  D4: 45m
+quorums:
+  D4:
+    roles: [station_master, control_supervisor]
+    zones:
+      Z1:
+        required: 4
+        excluded: [op_7]
+`)
+	fc, err := LoadFile(yamlPath)
+	require.NoError(t, err)
+
+	cfg, err := Load().WithFile(fc)
+	require.NoError(t, err)
+	assert.Equal(t, 45*time.Second, cfg.Aggregation.TimeWindows["Z2"])
+	assert.Equal(t, map[string]float64{"infrastructure": 0.6, "staff": 0.3}, cfg.Aggregation.Weights["Z2"])
+	assert.Equal(t, 0.35, cfg.Evaluator.PreAlertConfidence)
+	assert.Equal(t, 15*time.Minute, cfg.Gate.TTLs["D4"])
+	assert.Equal(t, 30*time.Minute, cfg.Gate.TTLs["D3"], "unset values keep their defaults")
+	assert.Equal(t, 45*time.Minute, cfg.Gate.MaxTTLs["D4"])
+	assert.Equal(t, QuorumPolicy{Required: 3, Roles: []string{"station_master", "control_supervisor"}}, cfg.Gate.Quorum("Z2", "D4"))
+	assert.Equal(t, QuorumPolicy{Required: 4, Roles: []string{"station_master", "control_supervisor"}, Excluded: []string{"op_7"}}, cfg.Gate.Quorum("Z1", "D4"),
+		"zone overrides start from the action type's quorum")
+	assert.Equal(t, 2, cfg.Gate.Quorum("Z1", "D3").Required)
+
+	tomlPath := writeConfigFile(t, "erh.toml", `
+approval_expiration = "5m"
+
+[keepalive]
+interval = "30s"
+timeout = "90s"
+`)
+	fc, err = LoadFile(tomlPath)
+	require.NoError(t, err)
+
+	cfg, err = Load().WithFile(fc)
+	require.NoError(t, err)
+	assert.Equal(t, 5*time.Minute, cfg.Gate.ApprovalExpiration)
+	assert.Equal(t, 30*time.Second, cfg.Gate.KeepaliveInterval)
+	assert.Equal(t, 90*time.Second, cfg.Gate.KeepaliveTimeout)
+}
+
+func TestLoadFile_ExampleConfig(t *testing.T) {
+	fc, err := LoadFile("../../configs/erh.example.yaml")
+	require.NoError(t, err)
+
+	_, err = Load().WithFile(fc)
+	assert.NoError(t, err)
+}
+
+func TestLoadFile_RejectsInvalidConfig(t *testing.T) {
+	// Unknown keys are rejected at parse time
+	_, err := LoadFile(writeConfigFile(t, "typo.yaml", "evaluator:\n  pre_alert_confidance: 0.3\n"))
+	assert.Error(t, err)
+
+	// Out-of-range and inconsistent values fail validation
+	fc, err := LoadFile(writeConfigFile(t, "bad.yaml", `
+zones:
+  Z9:
+    window: 10s
+  Z1:
+    weights:
+      crowd: 1.5
+evaluator:
+  pre_alert_confidence: 0.9
+max_ttls:
+  D3: 10m
+keepalive:
+  interval: 2m
+  timeout: 1m
+quorums:
+  D3:
+    required: 1
+    roles: [station_master, control_supervisor]
+`))
+	require.NoError(t, err)
+
+	_, err = Load().WithFile(fc)
+	var validationErr *ValidationError
+	require.ErrorAs(t, err, &validationErr)
+	assert.GreaterOrEqual(t, len(validationErr.Problems), 7)
+}
+
+func TestReloader_Reload(t *testing.T) {
+	path := writeConfigFile(t, "erh.yaml", "ttls:\n  D3: 30m\n")
+	reloader := NewReloader(Load(), path)
+	_, err := reloader.Load()
+	require.NoError(t, err)
+
+	var notified []string
+	var failures int
+	reloader.OnChange(func(old, new *Config, changes []string, err error) {
+		if err != nil {
+			failures++
+			return
+		}
+		notified = changes
+	})
+
+	require.NoError(t, os.WriteFile(path, []byte("ttls:\n  D3: 45m\n"), 0o600))
+	changes, err := reloader.Reload()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ttls.D3: 30m0s -> 45m0s"}, changes)
+	assert.Equal(t, changes, notified)
+	assert.Equal(t, 45*time.Minute, reloader.Current().Gate.TTLs["D3"])
+
+	// An invalid file keeps the previous configuration active
+	require.NoError(t, os.WriteFile(path, []byte("ttls:\n  D3: -1m\n"), 0o600))
+	_, err = reloader.Reload()
+	assert.Error(t, err)
+	assert.Equal(t, 1, failures)
+	assert.Equal(t, 45*time.Minute, reloader.Current().Gate.TTLs["D3"])
+
+	// So does a valid file a registered check refuses
+	reloader.AddValidator(func(cfg *Config) error {
+		if cfg.Gate.TTLs["D3"] > time.Hour {
+			return errors.New("D3 TTL too long for zone policies")
+		}
+		return nil
+	})
+	require.NoError(t, os.WriteFile(path, []byte("ttls:\n  D3: 90m\n"), 0o600))
+	_, err = reloader.Reload()
+	assert.Error(t, err)
+	assert.Equal(t, 2, failures)
+	assert.Equal(t, 45*time.Minute, reloader.Current().Gate.TTLs["D3"])
+}
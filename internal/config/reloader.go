@@ -0,0 +1,202 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// ChangeFunc is called after a reload attempt. On success err is nil and changes lists what differs;
+// on failure the previous configuration stays active and err describes why.
+type ChangeFunc func(old, new *Config, changes []string, err error)
+
+// ValidateFunc checks a candidate configuration against state outside the file before a reload is accepted
+type ValidateFunc func(cfg *Config) error
+
+// Reloader keeps the effective configuration in sync with the configuration file
+type Reloader struct {
+	base       *Config // configuration from environment/defaults, before the file is applied
+	path       string
+	mu         sync.RWMutex
+	current    *Config
+	modTime    time.Time
+	handlers   []ChangeFunc
+	validators []ValidateFunc
+}
+
+// NewReloader creates a new reloader for the given file. An empty path disables file configuration.
+func NewReloader(base *Config, path string) *Reloader {
+	return &Reloader{
+		base:    base,
+		path:    path,
+		current: base,
+	}
+}
+
+// Load reads and validates the configuration file, returning the effective configuration
+func (r *Reloader) Load() (*Config, error) {
+	if r.path == "" {
+		if err := r.base.Validate(); err != nil {
+			return nil, err
+		}
+		return r.base, nil
+	}
+
+	cfg, modTime, err := r.read()
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	r.current = cfg
+	r.modTime = modTime
+	r.mu.Unlock()
+
+	return cfg, nil
+}
+
+// Current returns the effective configuration
+func (r *Reloader) Current() *Config {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.current
+}
+
+// Path returns the configuration file path
+func (r *Reloader) Path() string {
+	return r.path
+}
+
+// OnChange registers a function called after every reload attempt
+func (r *Reloader) OnChange(fn ChangeFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.handlers = append(r.handlers, fn)
+}
+
+// AddValidator registers a check every reloaded configuration must pass; a failing check rejects the reload
+func (r *Reloader) AddValidator(fn ValidateFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.validators = append(r.validators, fn)
+}
+
+// Reload re-reads the configuration file and notifies handlers.
+// An invalid file is rejected and the previous configuration stays active.
+func (r *Reloader) Reload() ([]string, error) {
+	if r.path == "" {
+		return nil, fmt.Errorf("no configuration file configured")
+	}
+
+	r.mu.RLock()
+	old := r.current
+	handlers := append([]ChangeFunc(nil), r.handlers...)
+	r.mu.RUnlock()
+
+	cfg, modTime, err := r.read()
+	if err != nil {
+		// Remember the rejected file so polling does not retry it until it changes again
+		if !modTime.IsZero() {
+			r.mu.Lock()
+			r.modTime = modTime
+			r.mu.Unlock()
+		}
+		for _, fn := range handlers {
+			fn(old, nil, nil, err)
+		}
+		return nil, err
+	}
+
+	changes := Diff(old, cfg)
+
+	r.mu.Lock()
+	r.current = cfg
+	r.modTime = modTime
+	r.mu.Unlock()
+
+	if len(changes) > 0 {
+		for _, fn := range handlers {
+			fn(old, cfg, changes, nil)
+		}
+	}
+
+	return changes, nil
+}
+
+// Watch reloads on SIGHUP and whenever the file's modification time changes, until the context is cancelled
+func (r *Reloader) Watch(ctx context.Context, pollInterval time.Duration) {
+	if r.path == "" {
+		return
+	}
+
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	reload := func(reason string) {
+		changes, err := r.Reload()
+		if err != nil {
+			log.Printf("Configuration reload (%s) rejected: %v", reason, err)
+			return
+		}
+		log.Printf("Configuration reloaded (%s): %d change(s)", reason, len(changes))
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("Configuration watcher stopped")
+			return
+		case <-hup:
+			reload("SIGHUP")
+		case <-ticker.C:
+			info, err := os.Stat(r.path)
+			if err != nil {
+				continue
+			}
+			r.mu.RLock()
+			changed := !info.ModTime().Equal(r.modTime)
+			r.mu.RUnlock()
+			if changed {
+				reload("file change")
+			}
+		}
+	}
+}
+
+// read loads the file and applies it onto the base configuration
+func (r *Reloader) read() (*Config, time.Time, error) {
+	info, err := os.Stat(r.path)
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("failed to stat config file: %w", err)
+	}
+
+	fc, err := LoadFile(r.path)
+	if err != nil {
+		return nil, info.ModTime(), err
+	}
+
+	cfg, err := r.base.WithFile(fc)
+	if err != nil {
+		return nil, info.ModTime(), err
+	}
+
+	r.mu.RLock()
+	validators := append([]ValidateFunc(nil), r.validators...)
+	r.mu.RUnlock()
+	for _, validate := range validators {
+		if err := validate(cfg); err != nil {
+			return nil, info.ModTime(), err
+		}
+	}
+
+	return cfg, info.ModTime(), nil
+}
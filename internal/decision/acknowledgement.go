@@ -0,0 +1,155 @@
+package decision
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/erh-safety-system/poc/internal/model"
+	"gorm.io/gorm"
+)
+
+// AcknowledgementStats summarises time to acknowledge (TTA) as defined in docs/09
+type AcknowledgementStats struct {
+	ZoneID         string    `json:"zone_id,omitempty"`
+	Since          time.Time `json:"since"`
+	Until          time.Time `json:"until"`
+	Acknowledged   int       `json:"acknowledged"`
+	Unacknowledged int       `json:"unacknowledged"`
+	MeanSeconds    float64   `json:"mean_seconds"`
+	MedianSeconds  float64   `json:"median_seconds"`
+	P95Seconds     float64   `json:"p95_seconds"`
+}
+
+// Acknowledge records that an operator has seen a decision and stops its escalation ladder.
+// TTA is measured from the first signal behind the pre-alert, or from the pre-alert itself.
+func (s *DecisionService) Acknowledge(ctx context.Context, decisionID, operatorID string) (*DecisionStateRecord, error) {
+	var state DecisionStateRecord
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("id = ?", decisionID).First(&state).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrDecisionNotFound
+			}
+			return fmt.Errorf("failed to get decision state: %w", err)
+		}
+		if state.AcknowledgedAt != nil {
+			return ErrAlreadyAcknowledged
+		}
+
+		now := time.Now()
+		start := state.CreatedAt
+		if state.FirstSignalAt != nil && state.FirstSignalAt.Before(start) {
+			start = *state.FirstSignalAt
+		}
+		tta := now.Sub(start).Seconds()
+
+		// UpdateColumns leaves updated_at alone: acknowledging an older decision must not make it the zone's latest
+		result := tx.Model(&DecisionStateRecord{}).
+			Where("id = ? AND acknowledged_at IS NULL", state.ID).
+			UpdateColumns(map[string]interface{}{
+				"acknowledged_by":     operatorID,
+				"acknowledged_at":     now,
+				"time_to_acknowledge": tta,
+			})
+		if result.Error != nil {
+			return fmt.Errorf("failed to acknowledge decision: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return ErrAlreadyAcknowledged
+		}
+		state.AcknowledgedBy = operatorID
+		state.AcknowledgedAt = &now
+		state.TimeToAcknowledge = &tta
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Only the zone's latest decision is cached as the zone's state
+	latest, err := s.GetLatestState(ctx, state.ZoneID)
+	if err == nil && latest != nil && latest.ID == state.ID {
+		s.CacheState(ctx, &state)
+	}
+	return &state, nil
+}
+
+// ListUnacknowledged returns active decisions no operator has acknowledged yet, oldest first
+func (s *DecisionService) ListUnacknowledged(ctx context.Context) ([]*DecisionStateRecord, error) {
+	var states []*DecisionStateRecord
+	if err := s.db.WithContext(ctx).
+		Where("acknowledged_at IS NULL AND current_state NOT IN ?", []string{string(StateInactive), string(StateD6)}).
+		Order("created_at ASC").
+		Find(&states).Error; err != nil {
+		return nil, fmt.Errorf("failed to list unacknowledged decisions: %w", err)
+	}
+	return states, nil
+}
+
+// AcknowledgementStats computes TTA statistics for decisions raised within [since, until], optionally for one zone
+func (s *DecisionService) AcknowledgementStats(ctx context.Context, zoneID string, since, until time.Time) (*AcknowledgementStats, error) {
+	query := s.db.WithContext(ctx).Where("created_at >= ? AND created_at <= ?", since, until)
+	if zoneID != "" {
+		query = query.Where("zone_id = ?", zoneID)
+	}
+
+	var states []*DecisionStateRecord
+	if err := query.Find(&states).Error; err != nil {
+		return nil, fmt.Errorf("failed to get decisions: %w", err)
+	}
+
+	stats := &AcknowledgementStats{ZoneID: zoneID, Since: since, Until: until}
+	samples := make([]float64, 0, len(states))
+	for _, state := range states {
+		if state.TimeToAcknowledge == nil {
+			stats.Unacknowledged++
+			continue
+		}
+		samples = append(samples, *state.TimeToAcknowledge)
+	}
+	stats.Acknowledged = len(samples)
+	if len(samples) == 0 {
+		return stats, nil
+	}
+
+	sort.Float64s(samples)
+	total := 0.0
+	for _, sample := range samples {
+		total += sample
+	}
+	stats.MeanSeconds = total / float64(len(samples))
+	stats.MedianSeconds = percentile(samples, 50)
+	stats.P95Seconds = percentile(samples, 95)
+	return stats, nil
+}
+
+// firstSignalAt returns the timestamp of the earliest signal behind a summary, falling back to its window start
+func (s *DecisionService) firstSignalAt(ctx context.Context, summary *model.AggregatedSummary) *time.Time {
+	if len(summary.SignalIDs) > 0 {
+		var first model.Signal
+		err := s.db.WithContext(ctx).
+			Where("id IN ?", []string(summary.SignalIDs)).
+			Order("timestamp ASC").
+			First(&first).Error
+		if err == nil {
+			return &first.Timestamp
+		}
+	}
+	if summary.WindowStart.IsZero() {
+		return nil
+	}
+	start := summary.WindowStart
+	return &start
+}
+
+// percentile returns the nearest-rank percentile of sorted samples
+func percentile(sorted []float64, p float64) float64 {
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
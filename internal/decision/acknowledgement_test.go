@@ -0,0 +1,117 @@
+package decision
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/erh-safety-system/poc/internal/cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecisionService_Acknowledge(t *testing.T) {
+	db := setupDecisionTestDB(t)
+	service := NewDecisionService(db, nil)
+	ctx := context.Background()
+
+	summary := createTestSummary(t, db, "Z1")
+	state, err := service.CreatePreAlert(ctx, "Z1", "op_1", summary.ID)
+	require.NoError(t, err)
+	require.NotNil(t, state.FirstSignalAt)
+	assert.WithinDuration(t, summary.WindowStart, *state.FirstSignalAt, time.Millisecond, "without signals TTA starts at the window")
+
+	pending, err := service.ListUnacknowledged(ctx)
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+
+	acked, err := service.Acknowledge(ctx, state.ID, "op_2")
+	require.NoError(t, err)
+	assert.Equal(t, "op_2", acked.AcknowledgedBy)
+	require.NotNil(t, acked.TimeToAcknowledge)
+	assert.InDelta(t, 60, *acked.TimeToAcknowledge, 5)
+
+	_, err = service.Acknowledge(ctx, state.ID, "op_3")
+	assert.ErrorIs(t, err, ErrAlreadyAcknowledged)
+	_, err = service.Acknowledge(ctx, "missing", "op_3")
+	assert.ErrorIs(t, err, ErrDecisionNotFound)
+
+	// Transitions keep the acknowledgement
+	_, err = service.TransitionState(ctx, state.ID, StateD1, "op_2", 0)
+	require.NoError(t, err)
+	current, err := service.GetDecision(ctx, state.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "op_2", current.AcknowledgedBy)
+
+	pending, err = service.ListUnacknowledged(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, pending)
+}
+
+func TestDecisionService_AcknowledgeKeepsLatestDecision(t *testing.T) {
+	db := setupDecisionTestDB(t)
+	service := NewDecisionService(db, nil)
+	zoneCache := cache.NewZoneCache(nil)
+	service.SetCache(zoneCache)
+	ctx := context.Background()
+
+	older, err := service.CreatePreAlert(ctx, "Z1", "op_1", createTestSummary(t, db, "Z1").ID)
+	require.NoError(t, err)
+	newer, err := service.CreatePreAlert(ctx, "Z1", "op_1", createTestSummary(t, db, "Z1").ID)
+	require.NoError(t, err)
+	_, err = service.TransitionState(ctx, newer.ID, StateD1, "op_1", 0)
+	require.NoError(t, err)
+
+	// Acknowledging the older decision must not make it the zone's state
+	acked, err := service.Acknowledge(ctx, older.ID, "op_2")
+	require.NoError(t, err)
+	assert.Equal(t, older.UpdatedAt.UnixNano(), acked.UpdatedAt.UnixNano())
+
+	var cached DecisionStateRecord
+	require.True(t, zoneCache.Peek(ctx, cache.KindState, "Z1", &cached))
+	assert.Equal(t, newer.ID, cached.ID)
+
+	service.SetCache(nil)
+	latest, err := service.GetLatestState(ctx, "Z1")
+	require.NoError(t, err)
+	assert.Equal(t, newer.ID, latest.ID)
+	assert.Equal(t, string(StateD1), latest.CurrentState)
+
+	// Acknowledging the latest decision updates the cached state
+	service.SetCache(zoneCache)
+	_, err = service.Acknowledge(ctx, newer.ID, "op_2")
+	require.NoError(t, err)
+	require.True(t, zoneCache.Peek(ctx, cache.KindState, "Z1", &cached))
+	assert.Equal(t, "op_2", cached.AcknowledgedBy)
+}
+
+func TestDecisionService_AcknowledgementStats(t *testing.T) {
+	db := setupDecisionTestDB(t)
+	service := NewDecisionService(db, nil)
+	ctx := context.Background()
+
+	for i, tta := range []float64{10, 20, 30, 100} {
+		tta := tta
+		record := &DecisionStateRecord{
+			ID:                "dec_tta_" + string(rune('a'+i)),
+			ZoneID:            "Z1",
+			CurrentState:      string(StateD1),
+			TimeToAcknowledge: &tta,
+		}
+		require.NoError(t, db.Create(record).Error)
+	}
+	require.NoError(t, db.Create(&DecisionStateRecord{ID: "dec_tta_open", ZoneID: "Z1", CurrentState: string(StateD0)}).Error)
+	require.NoError(t, db.Create(&DecisionStateRecord{ID: "dec_tta_other", ZoneID: "Z2", CurrentState: string(StateD0)}).Error)
+
+	stats, err := service.AcknowledgementStats(ctx, "Z1", time.Now().Add(-time.Hour), time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, 4, stats.Acknowledged)
+	assert.Equal(t, 1, stats.Unacknowledged)
+	assert.InDelta(t, 40, stats.MeanSeconds, 1e-9)
+	assert.InDelta(t, 20, stats.MedianSeconds, 1e-9)
+	assert.InDelta(t, 100, stats.P95Seconds, 1e-9)
+
+	all, err := service.AcknowledgementStats(ctx, "", time.Now().Add(-time.Hour), time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, 2, all.Unacknowledged)
+}
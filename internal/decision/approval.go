@@ -0,0 +1,15 @@
+package decision
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+// ApprovalGate authorizes high-impact transitions against approved approval requests
+type ApprovalGate interface {
+	// ConsumeApproval binds an approved, unexpired and unconsumed approval for the zone and target state
+	// to the decision within the transition's transaction, and returns its ID.
+	// A non-empty approvalID selects a specific approval; otherwise the most recent eligible one is used.
+	ConsumeApproval(ctx context.Context, tx *gorm.DB, decisionID, zoneID string, target DecisionState, approvalID string) (string, error)
+}
@@ -0,0 +1,129 @@
+package decision
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/erh-safety-system/poc/internal/model"
+	"gorm.io/gorm"
+)
+
+// ComplexityScorer combines x_s, x_d and x_c into the ERH complexity total (x_total)
+type ComplexityScorer interface {
+	ComplexityTotal(signalSources, decisionDepth, contextStates int) float64
+}
+
+// SetComplexityScorer sets the scorer used to compute x_total on every transition.
+// Without a scorer, x_total is left at 0.
+func (s *DecisionService) SetComplexityScorer(scorer ComplexityScorer) {
+	s.complexity = scorer
+}
+
+// updateComplexity recomputes a decision's x_c and x_total for its new state within a transaction
+func (s *DecisionService) updateComplexity(tx *gorm.DB, state *DecisionStateRecord, summary *model.AggregatedSummary) error {
+	contextStates, err := countContextStates(tx, state, summary)
+	if err != nil {
+		return err
+	}
+	state.ContextStates = contextStates
+	if s.complexity != nil {
+		state.ComplexityTotal = s.complexity.ComplexityTotal(state.SignalCount, state.DecisionDepth, state.ContextStates)
+	}
+	return nil
+}
+
+// countContextStates computes x_c as described in docs/10: active zones x active decision points
+// x distinct signal-type combinations. Each factor counts at least 1.
+func countContextStates(tx *gorm.DB, state *DecisionStateRecord, summary *model.AggregatedSummary) (int, error) {
+	// Active zones: other zones whose latest decision is escalated, plus this one.
+	// Only each zone's latest decision is read; older ones no longer describe the zone.
+	latest := tx.Model(&DecisionStateRecord{}).
+		Select("zone_id, MAX(updated_at) AS updated_at").
+		Where("zone_id <> ?", state.ZoneID).
+		Group("zone_id")
+	var others []struct {
+		ZoneID       string
+		CurrentState string
+	}
+	if err := tx.Table("decision_states AS states").
+		Select("states.zone_id, states.current_state").
+		Joins("JOIN (?) AS latest ON latest.zone_id = states.zone_id AND latest.updated_at = states.updated_at", latest).
+		Scan(&others).Error; err != nil {
+		return 0, fmt.Errorf("failed to get active zones: %w", err)
+	}
+	seen := map[string]bool{state.ZoneID: true}
+	activeZones := make([]string, 0)
+	if DecisionState(state.CurrentState).Severity() > 0 {
+		activeZones = append(activeZones, state.ZoneID)
+	}
+	for _, other := range others {
+		if seen[other.ZoneID] {
+			continue
+		}
+		seen[other.ZoneID] = true
+		if DecisionState(other.CurrentState).Severity() > 0 {
+			activeZones = append(activeZones, other.ZoneID)
+		}
+	}
+
+	// Active decision points: escalated states this decision has passed through, including the new one
+	var visited []string
+	if err := tx.Model(&DecisionTransition{}).
+		Where("decision_id = ?", state.ID).
+		Distinct().
+		Pluck("to_state", &visited).Error; err != nil {
+		return 0, fmt.Errorf("failed to get decision points: %w", err)
+	}
+	points := map[string]bool{}
+	for _, to := range append(visited, state.CurrentState) {
+		if DecisionState(to).Severity() > 0 {
+			points[to] = true
+		}
+	}
+
+	// Signal-type combinations: distinct sets of contributing source types across the active zones
+	combinations := map[string]bool{}
+	if summary != nil && DecisionState(state.CurrentState).Severity() > 0 {
+		if key := sourceCombination(summary); key != "" {
+			combinations[key] = true
+		}
+	}
+	for _, zoneID := range activeZones {
+		if zoneID == state.ZoneID {
+			continue
+		}
+		var latest model.AggregatedSummary
+		err := tx.Where("zone_id = ?", zoneID).Order("window_end DESC").First(&latest).Error
+		if err == gorm.ErrRecordNotFound {
+			continue
+		}
+		if err != nil {
+			return 0, fmt.Errorf("failed to get zone summary: %w", err)
+		}
+		if key := sourceCombination(&latest); key != "" {
+			combinations[key] = true
+		}
+	}
+
+	return atLeastOne(len(activeZones)) * atLeastOne(len(points)) * atLeastOne(len(combinations)), nil
+}
+
+// sourceCombination returns a key for the set of source types with effective signals in a summary
+func sourceCombination(summary *model.AggregatedSummary) string {
+	types := make([]string, 0, len(summary.SourceCount))
+	for sourceType, count := range summary.SourceCount {
+		if countValue(count) > 0 {
+			types = append(types, sourceType)
+		}
+	}
+	sort.Strings(types)
+	return strings.Join(types, "+")
+}
+
+func atLeastOne(n int) int {
+	if n < 1 {
+		return 1
+	}
+	return n
+}
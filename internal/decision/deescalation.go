@@ -0,0 +1,346 @@
+package decision
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/erh-safety-system/poc/internal/config"
+	"github.com/erh-safety-system/poc/internal/model"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// DeEscalationOperator is recorded as the operator of automatic step-downs
+const DeEscalationOperator = "system:de-escalation"
+
+// De-escalation proposal statuses
+const (
+	ProposalPending   = "pending"
+	ProposalConfirmed = "confirmed"
+	ProposalRejected  = "rejected"
+	ProposalWithdrawn = "withdrawn" // The zone was no longer calm, or the decision moved on
+)
+
+// DeEscalation actions taken for a summary
+const (
+	DeEscalationNone        = "none"
+	DeEscalationSteppedDown = "stepped_down"
+	DeEscalationProposed    = "proposed"
+	DeEscalationWithdrawn   = "withdrawn"
+)
+
+// DeEscalationProposal asks an operator to confirm standing a high-impact decision down after a calm period
+type DeEscalationProposal struct {
+	ID              string     `gorm:"primaryKey;type:varchar(255)" json:"id"`
+	DecisionID      string     `gorm:"index;type:varchar(255);not null" json:"decision_id"`
+	ZoneID          string     `gorm:"index;type:varchar(10);not null" json:"zone_id"`
+	FromState       string     `gorm:"type:varchar(10);not null" json:"from_state"`
+	TargetState     string     `gorm:"type:varchar(10);not null" json:"target_state"`
+	DecisionVersion int        `gorm:"not null" json:"decision_version"` // Version the proposal was based on
+	SummaryID       string     `gorm:"type:varchar(255)" json:"summary_id"`
+	CalmWindows     int        `json:"calm_windows"`
+	Reason          string     `gorm:"type:text" json:"reason"`
+	Status          string     `gorm:"index;type:varchar(20);not null" json:"status"`
+	ResolvedBy      string     `gorm:"type:varchar(255)" json:"resolved_by,omitempty"`
+	ResolvedAt      *time.Time `json:"resolved_at,omitempty"`
+	CreatedAt       time.Time  `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// TableName specifies the table name
+func (DeEscalationProposal) TableName() string {
+	return "deescalation_proposals"
+}
+
+// DeEscalationResult describes what the de-escalation check did for a zone
+type DeEscalationResult struct {
+	Action   string                `json:"action"`
+	Decision *DecisionStateRecord  `json:"decision,omitempty"`
+	Proposal *DeEscalationProposal `json:"proposal,omitempty"`
+}
+
+// DeEscalator steps zones down after consecutive calm summaries. Low-impact states step down
+// automatically; high-impact states get a proposal to stand down (D6) that an operator confirms.
+type DeEscalator struct {
+	db        *gorm.DB
+	decisions *DecisionService
+	evaluator *DecisionEvaluator
+}
+
+// NewDeEscalator creates a new de-escalator
+func NewDeEscalator(db *gorm.DB, decisions *DecisionService, evaluator *DecisionEvaluator) *DeEscalator {
+	return &DeEscalator{
+		db:        db,
+		decisions: decisions,
+		evaluator: evaluator,
+	}
+}
+
+// Observe checks a zone after each new summary. It matches aggregation.SummaryObserver and never fails the caller.
+func (d *DeEscalator) Observe(ctx context.Context, summary *model.AggregatedSummary) {
+	if _, err := d.Check(ctx, summary); err != nil {
+		log.Printf("De-escalation check failed for zone %s: %v", summary.ZoneID, err)
+	}
+}
+
+// Check looks at the zone of a newly stored summary. A summary above the exit thresholds withdraws pending
+// proposals; once the configured number of consecutive calm windows has passed since the decision last
+// changed, the zone steps down one level or, from a high-impact state, a stand-down is proposed.
+func (d *DeEscalator) Check(ctx context.Context, summary *model.AggregatedSummary) (*DeEscalationResult, error) {
+	result := &DeEscalationResult{Action: DeEscalationNone}
+
+	latest, err := d.decisions.GetLatestState(ctx, summary.ZoneID)
+	if err != nil {
+		return nil, err
+	}
+	if latest == nil || DecisionState(latest.CurrentState) == StateInactive {
+		return result, nil
+	}
+	result.Decision = latest
+
+	_, thresholds := d.evaluator.policy(summary.ZoneID)
+	if !isCalm(summary, thresholds) {
+		withdrawn, err := d.withdraw(ctx, latest.ID)
+		if err != nil {
+			return nil, err
+		}
+		if withdrawn > 0 {
+			result.Action = DeEscalationWithdrawn
+		}
+		return result, nil
+	}
+
+	// Hysteresis: count calm windows since the decision last changed or a proposal was last turned down
+	since := latest.UpdatedAt
+	var last DeEscalationProposal
+	err = d.db.WithContext(ctx).Where("decision_id = ?", latest.ID).Order("created_at DESC").First(&last).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return nil, fmt.Errorf("failed to get de-escalation proposal: %w", err)
+	}
+	if err == nil {
+		if last.Status == ProposalPending {
+			return result, nil
+		}
+		if last.ResolvedAt != nil && last.ResolvedAt.After(since) {
+			since = *last.ResolvedAt
+		}
+	}
+
+	calm, err := d.calmWindows(ctx, summary.ZoneID, since, thresholds)
+	if err != nil {
+		return nil, err
+	}
+	if calm < thresholds.DeEscalationWindows {
+		return result, nil
+	}
+
+	current := DecisionState(latest.CurrentState)
+	policy := d.decisions.policies.For(summary.ZoneID)
+	reason := fmt.Sprintf("%d consecutive calm windows (confidence < %.2f, anomaly score < %.2f)",
+		calm, thresholds.ExitConfidence, thresholds.ExitAnomalyScore)
+
+	if current.IsHighImpact() {
+		if !policy.StateMachine().CanTransition(current, StateD6) {
+			return result, nil
+		}
+		proposal := &DeEscalationProposal{
+			ID:              fmt.Sprintf("dep_%s", uuid.New().String()),
+			DecisionID:      latest.ID,
+			ZoneID:          latest.ZoneID,
+			FromState:       latest.CurrentState,
+			TargetState:     string(StateD6),
+			DecisionVersion: latest.Version,
+			SummaryID:       summary.ID,
+			CalmWindows:     calm,
+			Reason:          reason,
+			Status:          ProposalPending,
+		}
+		if err := d.db.WithContext(ctx).Create(proposal).Error; err != nil {
+			return nil, fmt.Errorf("failed to create de-escalation proposal: %w", err)
+		}
+		result.Action = DeEscalationProposed
+		result.Proposal = proposal
+		return result, nil
+	}
+
+	target := stepDown(current)
+	if !policy.StateMachine().CanTransition(current, target) {
+		if !policy.StateMachine().CanTransition(current, StateInactive) {
+			return result, nil
+		}
+		target = StateInactive
+	}
+
+	state, err := d.decisions.Transition(ctx, &TransitionRequest{
+		DecisionID:      latest.ID,
+		TargetState:     target,
+		OperatorID:      DeEscalationOperator,
+		Reason:          reason,
+		SummaryID:       summary.ID,
+		ExpectedVersion: latest.Version,
+		Automatic:       true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	result.Action = DeEscalationSteppedDown
+	result.Decision = state
+	return result, nil
+}
+
+// ConfirmProposal stands the decision down as proposed. If the decision changed since the proposal
+// was made, the proposal is withdrawn and ErrVersionConflict returned.
+func (d *DeEscalator) ConfirmProposal(ctx context.Context, proposalID, operatorID string) (*DecisionStateRecord, error) {
+	var state *DecisionStateRecord
+	var transitionErr error
+	err := d.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		proposal, err := pendingProposal(tx, proposalID)
+		if err != nil {
+			return err
+		}
+
+		status := ProposalConfirmed
+		state, transitionErr = d.decisions.WithDB(tx).Transition(ctx, &TransitionRequest{
+			DecisionID:      proposal.DecisionID,
+			TargetState:     DecisionState(proposal.TargetState),
+			OperatorID:      operatorID,
+			Reason:          "de-escalation confirmed: " + proposal.Reason,
+			SummaryID:       proposal.SummaryID,
+			ExpectedVersion: proposal.DecisionVersion,
+		})
+		if transitionErr != nil {
+			if !errors.Is(transitionErr, ErrVersionConflict) && !errors.Is(transitionErr, ErrInvalidTransition) {
+				return transitionErr
+			}
+			status = ProposalWithdrawn
+		}
+
+		return resolveProposal(tx, proposal.ID, status, operatorID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if transitionErr != nil {
+		return nil, transitionErr
+	}
+	d.decisions.CacheState(ctx, state)
+	return state, nil
+}
+
+// RejectProposal keeps the decision in its state. Calm windows are counted afresh from now.
+func (d *DeEscalator) RejectProposal(ctx context.Context, proposalID, operatorID string) error {
+	return d.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if _, err := pendingProposal(tx, proposalID); err != nil {
+			return err
+		}
+		return resolveProposal(tx, proposalID, ProposalRejected, operatorID)
+	})
+}
+
+// ListProposals returns proposals, newest first, optionally filtered by zone and status
+func (d *DeEscalator) ListProposals(ctx context.Context, zoneID, status string) ([]*DeEscalationProposal, error) {
+	query := d.db.WithContext(ctx).Order("created_at DESC")
+	if zoneID != "" {
+		query = query.Where("zone_id = ?", zoneID)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var proposals []*DeEscalationProposal
+	if err := query.Find(&proposals).Error; err != nil {
+		return nil, fmt.Errorf("failed to list de-escalation proposals: %w", err)
+	}
+	return proposals, nil
+}
+
+// calmWindows counts the zone's consecutive calm summaries, newest first, that ended after since
+func (d *DeEscalator) calmWindows(ctx context.Context, zoneID string, since time.Time, thresholds config.EvaluatorConfig) (int, error) {
+	var summaries []*model.AggregatedSummary
+	if err := d.db.WithContext(ctx).
+		Where("zone_id = ? AND window_end > ?", zoneID, since).
+		Order("window_end DESC").
+		Limit(thresholds.DeEscalationWindows).
+		Find(&summaries).Error; err != nil {
+		return 0, fmt.Errorf("failed to get recent summaries: %w", err)
+	}
+
+	calm := 0
+	for _, summary := range summaries {
+		if !isCalm(summary, thresholds) {
+			break
+		}
+		calm++
+	}
+	return calm, nil
+}
+
+// withdraw withdraws a decision's pending proposals
+func (d *DeEscalator) withdraw(ctx context.Context, decisionID string) (int64, error) {
+	now := time.Now()
+	result := d.db.WithContext(ctx).
+		Model(&DeEscalationProposal{}).
+		Where("decision_id = ? AND status = ?", decisionID, ProposalPending).
+		Updates(map[string]interface{}{
+			"status":      ProposalWithdrawn,
+			"resolved_by": DeEscalationOperator,
+			"resolved_at": now,
+		})
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to withdraw de-escalation proposals: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
+// pendingProposal loads a proposal that is still awaiting an operator
+func pendingProposal(tx *gorm.DB, proposalID string) (*DeEscalationProposal, error) {
+	var proposal DeEscalationProposal
+	if err := tx.Where("id = ?", proposalID).First(&proposal).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, ErrProposalNotFound
+		}
+		return nil, fmt.Errorf("failed to get de-escalation proposal: %w", err)
+	}
+	if proposal.Status != ProposalPending {
+		return nil, ErrProposalResolved
+	}
+	return &proposal, nil
+}
+
+// resolveProposal records the outcome of a pending proposal
+func resolveProposal(tx *gorm.DB, proposalID, status, operatorID string) error {
+	now := time.Now()
+	result := tx.Model(&DeEscalationProposal{}).
+		Where("id = ? AND status = ?", proposalID, ProposalPending).
+		Updates(map[string]interface{}{
+			"status":      status,
+			"resolved_by": operatorID,
+			"resolved_at": now,
+		})
+	if result.Error != nil {
+		return fmt.Errorf("failed to resolve de-escalation proposal: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrProposalResolved
+	}
+	return nil
+}
+
+// isCalm reports whether a summary is below both exit thresholds
+func isCalm(summary *model.AggregatedSummary, thresholds config.EvaluatorConfig) bool {
+	return summary.Confidence < thresholds.ExitConfidence && summary.AnomalyScore < thresholds.ExitAnomalyScore
+}
+
+// stepDown returns the next lower state for a low-impact state; D0 and D6 return to monitoring
+func stepDown(state DecisionState) DecisionState {
+	switch state {
+	case StateD2:
+		return StateD1
+	case StateD1:
+		return StateD0
+	default:
+		return StateInactive
+	}
+}
@@ -0,0 +1,157 @@
+package decision
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/erh-safety-system/poc/internal/config"
+	"github.com/erh-safety-system/poc/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// createWindowSummary stores a summary for a window ending at windowEnd
+func createWindowSummary(t *testing.T, db *gorm.DB, zoneID string, windowEnd time.Time, confidence float64) *model.AggregatedSummary {
+	summary := &model.AggregatedSummary{
+		ZoneID:      zoneID,
+		WindowStart: windowEnd.Add(-10 * time.Second),
+		WindowEnd:   windowEnd,
+		SourceCount: model.JSONB{"infrastructure": 1},
+		Confidence:  confidence,
+	}
+	require.NoError(t, db.Create(summary).Error)
+	return summary
+}
+
+func setupDeEscalator(t *testing.T) (*gorm.DB, *DecisionService, *DeEscalator) {
+	db := setupDecisionTestDB(t)
+	require.NoError(t, db.AutoMigrate(&DeEscalationProposal{}, &EvaluationRecord{}))
+	cfg := config.Load()
+	evaluator := NewDecisionEvaluator(&cfg.Evaluator, db, nil)
+	service := NewDecisionService(db, evaluator)
+	return db, service, NewDeEscalator(db, service, evaluator)
+}
+
+func TestDeEscalator_StepsDownAfterCalmWindows(t *testing.T) {
+	db, service, deEscalator := setupDeEscalator(t)
+	ctx := context.Background()
+
+	state, err := service.CreatePreAlert(ctx, "Z1", "op_1", createTestSummary(t, db, "Z1").ID)
+	require.NoError(t, err)
+	state, err = service.TransitionState(ctx, state.ID, StateD1, "op_1", 0)
+	require.NoError(t, err)
+
+	// Two calm windows are not enough; the third steps D1 down to D0
+	start := time.Now().Add(time.Second)
+	for i := 0; i < 2; i++ {
+		result, err := deEscalator.Check(ctx, createWindowSummary(t, db, "Z1", start.Add(time.Duration(i)*10*time.Second), 0.1))
+		require.NoError(t, err)
+		assert.Equal(t, DeEscalationNone, result.Action)
+	}
+	result, err := deEscalator.Check(ctx, createWindowSummary(t, db, "Z1", start.Add(20*time.Second), 0.1))
+	require.NoError(t, err)
+	assert.Equal(t, DeEscalationSteppedDown, result.Action)
+	assert.Equal(t, string(StateD0), result.Decision.CurrentState)
+
+	timeline, err := service.GetTimeline(ctx, state.ID)
+	require.NoError(t, err)
+	last := timeline[len(timeline)-1]
+	assert.Equal(t, DeEscalationOperator, last.OperatorID)
+	assert.Contains(t, last.Reason, "3 consecutive calm windows")
+
+	// A summary between the exit and pre-alert thresholds is not calm, so the count starts again
+	result, err = deEscalator.Check(ctx, createWindowSummary(t, db, "Z1", start.Add(30*time.Second), 0.3))
+	require.NoError(t, err)
+	assert.Equal(t, DeEscalationNone, result.Action)
+	current, err := service.GetDecision(ctx, state.ID)
+	require.NoError(t, err)
+	assert.Equal(t, string(StateD0), current.CurrentState)
+}
+
+func TestDeEscalator_ProposesStandDownForHighImpact(t *testing.T) {
+	db, service, deEscalator := setupDeEscalator(t)
+	service.SetApprovalGate(&stubApprovalGate{approvals: map[string]string{"Z2": "approval_1"}})
+	ctx := context.Background()
+
+	state, err := service.CreatePreAlert(ctx, "Z2", "op_1", createTestSummary(t, db, "Z2").ID)
+	require.NoError(t, err)
+	state, err = service.TransitionState(ctx, state.ID, StateD3, "op_1", 0)
+	require.NoError(t, err)
+
+	start := time.Now().Add(time.Second)
+	var result *DeEscalationResult
+	for i := 0; i < 3; i++ {
+		result, err = deEscalator.Check(ctx, createWindowSummary(t, db, "Z2", start.Add(time.Duration(i)*10*time.Second), 0.1))
+		require.NoError(t, err)
+	}
+	require.Equal(t, DeEscalationProposed, result.Action)
+	require.NotNil(t, result.Proposal)
+	assert.Equal(t, string(StateD6), result.Proposal.TargetState)
+	assert.Equal(t, state.Version, result.Proposal.DecisionVersion)
+
+	// High-impact states are never stepped down without an operator
+	current, err := service.GetDecision(ctx, state.ID)
+	require.NoError(t, err)
+	assert.Equal(t, string(StateD3), current.CurrentState)
+
+	// A non-calm window withdraws the proposal
+	result, err = deEscalator.Check(ctx, createWindowSummary(t, db, "Z2", start.Add(30*time.Second), 0.6))
+	require.NoError(t, err)
+	assert.Equal(t, DeEscalationWithdrawn, result.Action)
+	withdrawn, err := deEscalator.ListProposals(ctx, "Z2", ProposalWithdrawn)
+	require.NoError(t, err)
+	require.Len(t, withdrawn, 1)
+	_, err = deEscalator.ConfirmProposal(ctx, withdrawn[0].ID, "op_2")
+	assert.ErrorIs(t, err, ErrProposalResolved)
+
+	// Calm again: a new proposal is made and confirmed
+	start = time.Now().Add(time.Minute)
+	for i := 0; i < 3; i++ {
+		result, err = deEscalator.Check(ctx, createWindowSummary(t, db, "Z2", start.Add(time.Duration(i)*10*time.Second), 0.1))
+		require.NoError(t, err)
+	}
+	require.Equal(t, DeEscalationProposed, result.Action)
+
+	confirmed, err := deEscalator.ConfirmProposal(ctx, result.Proposal.ID, "op_2")
+	require.NoError(t, err)
+	assert.Equal(t, string(StateD6), confirmed.CurrentState)
+
+	pending, err := deEscalator.ListProposals(ctx, "Z2", ProposalPending)
+	require.NoError(t, err)
+	assert.Empty(t, pending)
+
+	_, err = deEscalator.ConfirmProposal(ctx, "dep_missing", "op_2")
+	assert.ErrorIs(t, err, ErrProposalNotFound)
+}
+
+func TestDeEscalator_ConfirmWithdrawsStaleProposal(t *testing.T) {
+	db, service, deEscalator := setupDeEscalator(t)
+	service.SetApprovalGate(&stubApprovalGate{approvals: map[string]string{"Z3": "approval_1"}})
+	ctx := context.Background()
+
+	state, err := service.CreatePreAlert(ctx, "Z3", "op_1", createTestSummary(t, db, "Z3").ID)
+	require.NoError(t, err)
+	state, err = service.TransitionState(ctx, state.ID, StateD3, "op_1", 0)
+	require.NoError(t, err)
+
+	start := time.Now().Add(time.Second)
+	var result *DeEscalationResult
+	for i := 0; i < 3; i++ {
+		result, err = deEscalator.Check(ctx, createWindowSummary(t, db, "Z3", start.Add(time.Duration(i)*10*time.Second), 0.1))
+		require.NoError(t, err)
+	}
+	require.Equal(t, DeEscalationProposed, result.Action)
+
+	// The operator moves the decision on before the proposal is confirmed
+	_, err = service.TransitionState(ctx, state.ID, StateD2, "op_1", 0)
+	require.NoError(t, err)
+
+	_, err = deEscalator.ConfirmProposal(ctx, result.Proposal.ID, "op_2")
+	assert.ErrorIs(t, err, ErrVersionConflict)
+	proposals, err := deEscalator.ListProposals(ctx, "Z3", "")
+	require.NoError(t, err)
+	require.Len(t, proposals, 1)
+	assert.Equal(t, ProposalWithdrawn, proposals[0].Status)
+}
@@ -14,5 +14,32 @@ var (
 	
 	// ErrMissingApproval indicates missing required approvals
 	ErrMissingApproval = errors.New("missing required approvals")
+	
+	// ErrVersionConflict indicates the decision was modified by someone else since it was read
+	ErrVersionConflict = errors.New("decision was modified concurrently")
+	
+	// ErrImmutableTransition indicates an attempt to modify recorded transition history
+	ErrImmutableTransition = errors.New("decision transitions are immutable")
+	
+	// ErrNoSummary indicates a zone has no aggregated summary to evaluate
+	ErrNoSummary = errors.New("no aggregated summary for zone")
+	
+	// ErrProposalNotFound indicates an unknown de-escalation proposal
+	ErrProposalNotFound = errors.New("de-escalation proposal not found")
+	
+	// ErrProposalResolved indicates a de-escalation proposal was already confirmed, rejected or withdrawn
+	ErrProposalResolved = errors.New("de-escalation proposal already resolved")
+	
+	// ErrTransitionBlocked indicates a transition guard refused the transition
+	ErrTransitionBlocked = errors.New("transition blocked")
+	
+	// ErrAlreadyAcknowledged indicates a decision was already acknowledged by an operator
+	ErrAlreadyAcknowledged = errors.New("decision already acknowledged")
+	
+	// ErrDecisionNotFound indicates an unknown decision
+	ErrDecisionNotFound = errors.New("decision not found")
+	
+	// ErrEvaluationNotFound indicates a transition named an evaluation that was not recorded for the zone
+	ErrEvaluationNotFound = errors.New("evaluation not found")
 )
 
@@ -3,40 +3,106 @@ package decision
 import (
 	"context"
 	"fmt"
+	"math"
+	"sync"
 
 	"github.com/erh-safety-system/poc/internal/aggregation"
+	"github.com/erh-safety-system/poc/internal/config"
 	"github.com/erh-safety-system/poc/internal/model"
 	"gorm.io/gorm"
 )
 
 // DecisionEvaluator evaluates whether a decision should be made
 type DecisionEvaluator struct {
+	mu            sync.RWMutex
+	cfg           config.EvaluatorConfig
 	db            *gorm.DB
 	aggEngine     *aggregation.AggregationEngine
-	stateMachine  *StateMachine
+	policies      *PolicyRegistry
+	quorums       func(zoneID, actionType string) config.QuorumPolicy
 }
 
 // NewDecisionEvaluator creates a new decision evaluator
-func NewDecisionEvaluator(db *gorm.DB, aggEngine *aggregation.AggregationEngine) *DecisionEvaluator {
+func NewDecisionEvaluator(cfg *config.EvaluatorConfig, db *gorm.DB, aggEngine *aggregation.AggregationEngine) *DecisionEvaluator {
 	return &DecisionEvaluator{
+		cfg:           *cfg,
 		db:            db,
 		aggEngine:     aggEngine,
-		stateMachine:  NewStateMachine(),
+		policies:      NewPolicyRegistry(),
 	}
 }
 
+// UpdateConfig replaces the thresholds used by subsequent evaluations
+func (e *DecisionEvaluator) UpdateConfig(cfg *config.EvaluatorConfig) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.cfg = *cfg
+}
+
+// Thresholds returns a snapshot of the current thresholds, before any policy overrides
+func (e *DecisionEvaluator) Thresholds() config.EvaluatorConfig {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.cfg
+}
+
+// SetPolicies sets the registry of per-zone-type corroboration requirements and threshold overrides
+func (e *DecisionEvaluator) SetPolicies(policies *PolicyRegistry) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.policies = policies
+}
+
+// SetQuorumSource sets where the approval quorum for a zone and target state is looked up, e.g. the approval service
+func (e *DecisionEvaluator) SetQuorumSource(quorums func(zoneID, actionType string) config.QuorumPolicy) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.quorums = quorums
+}
+
+// quorum returns the approval a transition into a gated state needs, or nil if the state is not gated
+func (e *DecisionEvaluator) quorum(zoneID string, policy *Policy, targetState DecisionState) *ApprovalQuorum {
+	e.mu.RLock()
+	quorums := e.quorums
+	e.mu.RUnlock()
+	if quorums == nil || !policy.IsGated(targetState) {
+		return nil
+	}
+	quorum := quorums(zoneID, string(targetState))
+	return &ApprovalQuorum{Required: quorum.Required, Roles: quorum.Roles}
+}
+
+// policy returns the policy for a zone together with its effective thresholds
+func (e *DecisionEvaluator) policy(zoneID string) (*Policy, config.EvaluatorConfig) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	policy := e.policies.For(zoneID)
+	return policy, policy.ApplyThresholds(e.cfg)
+}
+
+// ApprovalQuorum is the approval a transition needs before it takes effect
+type ApprovalQuorum struct {
+	Required int      `json:"required"`        // Distinct approvers
+	Roles    []string `json:"roles,omitempty"` // Roles that must each be held by at least one approver
+}
+
 // EvaluationResult represents the result of decision evaluation
+
 type EvaluationResult struct {
-	ShouldEscalate bool
-	TargetState    DecisionState
-	Reason         string
-	RequiresApproval bool
-	RequiresDualControl bool
-	RequiresStrictApproval bool
-	CorroborationSufficient bool
+	ShouldEscalate          bool            `json:"should_escalate"`
+	TargetState             DecisionState   `json:"target_state"`
+	Reason                  string          `json:"reason"`
+	RequiresApproval        bool            `json:"requires_approval"`
+	Quorum                  *ApprovalQuorum `json:"quorum,omitempty"` // Approval the target state needs under the zone's quorum; nil when not gated
+	CorroborationSufficient bool            `json:"corroboration_sufficient"`
+	NeighbourPressure       float64         `json:"neighbour_pressure"` // 0-1, rising signals or active incidents in adjacent zones
+	PolicyVersion           string          `json:"policy_version"`
+	Explanation             *Explanation    `json:"explanation"`
+	EvaluationID            string          `json:"evaluation_id,omitempty"` // Set once the evaluation is recorded
 }
 
-// Evaluate evaluates the current context and determines if escalation is needed
+// Evaluate evaluates the current context and determines if escalation is needed.
+// The result and its explanation are recorded.
 func (e *DecisionEvaluator) Evaluate(ctx context.Context, decisionCtx *DecisionContext) (*EvaluationResult, error) {
 	// Get latest aggregated summary
 	var summary model.AggregatedSummary
@@ -46,105 +112,209 @@ func (e *DecisionEvaluator) Evaluate(ctx context.Context, decisionCtx *DecisionC
 		return nil, fmt.Errorf("failed to get aggregated summary: %w", err)
 	}
 
+	result := e.EvaluateSummary(ctx, &summary, decisionCtx.CurrentState)
+	if err := e.record(ctx, &summary, decisionCtx.CurrentState, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// EvaluateZone evaluates a zone's latest summary from its current state and records the result
+func (e *DecisionEvaluator) EvaluateZone(ctx context.Context, zoneID string, currentState DecisionState) (*EvaluationResult, error) {
+	if e.aggEngine == nil {
+		return nil, fmt.Errorf("no aggregation engine configured")
+	}
+	summary, err := e.aggEngine.GetLatestSummary(ctx, zoneID)
+	if err != nil {
+		return nil, err
+	}
+	if summary == nil {
+		return nil, ErrNoSummary
+	}
+
+	result := e.EvaluateSummary(ctx, summary, currentState)
+	if err := e.record(ctx, summary, currentState, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// EvaluateSummary evaluates an in-memory summary against the zone's active policy without recording it
+func (e *DecisionEvaluator) EvaluateSummary(ctx context.Context, summary *model.AggregatedSummary, currentState DecisionState) *EvaluationResult {
+	policy, thresholds := e.policy(summary.ZoneID)
+	return e.evaluateWithPolicy(ctx, summary, currentState, policy, thresholds)
+}
+
+// EvaluateWithPolicy evaluates an in-memory summary as the given policy would, e.g. a candidate in shadow mode.
+// The policy's threshold overrides apply on top of the evaluator's current thresholds.
+func (e *DecisionEvaluator) EvaluateWithPolicy(ctx context.Context, summary *model.AggregatedSummary, currentState DecisionState, policy *Policy) *EvaluationResult {
+	e.mu.RLock()
+	thresholds := policy.ApplyThresholds(e.cfg)
+	e.mu.RUnlock()
+	return e.evaluateWithPolicy(ctx, summary, currentState, policy, thresholds)
+}
+
+// evaluateWithPolicy determines the state a policy would propose for a summary
+func (e *DecisionEvaluator) evaluateWithPolicy(ctx context.Context, summary *model.AggregatedSummary, currentState DecisionState, policy *Policy, thresholds config.EvaluatorConfig) *EvaluationResult {
 	// Check corroboration for high-impact decisions
-	corroborationSufficient := e.checkCorroboration(ctx, &summary, decisionCtx.CurrentState)
+	corroborationSufficient := e.checkCorroboration(ctx, summary, currentState, policy)
+	
+	// Combine rising neighbour signals with active incidents next door
+	neighbourPressure := e.neighbourPressure(ctx, summary)
 	
 	// Determine target state based on signal strength and current state
-	targetState := e.determineTargetState(ctx, &summary, decisionCtx.CurrentState, corroborationSufficient)
+	targetState, rule := e.determineTargetState(ctx, summary, currentState, corroborationSufficient, neighbourPressure, thresholds)
 	
-	shouldEscalate := targetState != decisionCtx.CurrentState && targetState != StateInactive
+	// Only propose transitions the zone's policy allows
+	allowed := targetState == currentState || policy.StateMachine().CanTransition(currentState, targetState)
+	proposed := targetState
+	if !allowed {
+		targetState = currentState
+	}
+	
+	shouldEscalate := targetState != currentState && targetState != StateInactive
+	
+	// Record the inputs behind the decision
+	explanation := e.explain(ctx, summary, currentState, policy, rule, corroborationSufficient, neighbourPressure, thresholds)
+	if !allowed {
+		explanation.Rule = RulePolicyDisallows
+	}
 	
 	result := &EvaluationResult{
 		ShouldEscalate:          shouldEscalate,
 		TargetState:            targetState,
 		RequiresApproval:       shouldEscalate,
-		RequiresDualControl:    targetState.IsHighImpact(),
-		RequiresStrictApproval: targetState.RequiresStrictApproval(),
+		Quorum:                 e.quorum(summary.ZoneID, policy, targetState),
 		CorroborationSufficient: corroborationSufficient,
+		NeighbourPressure:      neighbourPressure,
+		PolicyVersion:          policy.ID(),
+		Explanation:            explanation,
 	}
 	
 	// Set reason
 	if shouldEscalate {
-		result.Reason = fmt.Sprintf("Escalating from %s to %s based on signal analysis", decisionCtx.CurrentState, targetState)
+		result.Reason = fmt.Sprintf("Escalating from %s to %s: %s", currentState, targetState, describeRule(rule, summary, explanation, thresholds))
+	} else if !allowed {
+		result.Reason = fmt.Sprintf("No escalation: policy %s does not allow %s -> %s (%s)", policy.ID(), currentState, proposed, describeRule(rule, summary, explanation, thresholds))
 	} else {
 		result.Reason = "No escalation needed"
 	}
 	
-	return result, nil
+	return result
 }
 
-// checkCorroboration checks if there are sufficient independent signal sources
-func (e *DecisionEvaluator) checkCorroboration(ctx context.Context, summary *model.AggregatedSummary, currentState DecisionState) bool {
+// checkCorroboration checks if there are enough independent signal sources for the zone's policy
+func (e *DecisionEvaluator) checkCorroboration(ctx context.Context, summary *model.AggregatedSummary, currentState DecisionState, policy *Policy) bool {
 	// Count independent sources
 	// For simplicity, count different source types
 	sourceCount := 0
 	if summary.SourceCount != nil {
-		infraCount, _ := summary.SourceCount["infrastructure"].(float64)
-		staffCount, _ := summary.SourceCount["staff"].(float64)
-		crowdCount, _ := summary.SourceCount["crowd"].(float64)
-		emergencyCount, _ := summary.SourceCount["emergency"].(float64)
-		
-		if infraCount > 0 {
-			sourceCount++
-		}
-		if staffCount > 0 {
-			sourceCount++
-		}
-		if crowdCount > 0 {
-			sourceCount++
-		}
-		if emergencyCount > 0 {
-			sourceCount++
+		for _, sourceType := range sourceTypes {
+			if countValue(summary.SourceCount[sourceType]) > 0 {
+				sourceCount++
+			}
 		}
 	}
 	
-	// High-impact decisions require at least 2 independent sources
-	if currentState.IsHighImpact() || currentState == StateD0 {
-		return sourceCount >= 2
+	// By default high-impact states and D0 require at least 2 independent sources, others 1
+	return sourceCount >= policy.MinSources(currentState)
+}
+
+// countValue reads a per-source count from a summary. Counts are ints on freshly aggregated
+// summaries and float64 once they have been through the database.
+func countValue(value interface{}) float64 {
+	switch v := value.(type) {
+	case int:
+		return float64(v)
+	case int64:
+		return float64(v)
+	case float64:
+		return v
+	default:
+		return 0
 	}
+}
+
+// neighbourPressure returns the larger of the summary's neighbour pressure and the pressure
+// from decisions already active in adjacent zones
+func (e *DecisionEvaluator) neighbourPressure(ctx context.Context, summary *model.AggregatedSummary) float64 {
+	pressure := summary.NeighbourPressure
 	
-	// Low-impact decisions can proceed with 1 source
-	return sourceCount >= 1
+	for _, neighbour := range aggregation.Neighbours(summary.ZoneID) {
+		var state DecisionStateRecord
+		err := e.db.WithContext(ctx).
+			Where("zone_id = ?", neighbour).
+			Order("updated_at DESC").
+			First(&state).Error
+		if err != nil {
+			continue
+		}
+		pressure = math.Max(pressure, incidentPressure(DecisionState(state.CurrentState)))
+	}
+	
+	return pressure
+}
+
+// incidentPressure maps a neighbour's decision state to the pressure it puts on adjacent zones
+func incidentPressure(state DecisionState) float64 {
+	switch {
+	case state.IsHighImpact():
+		return 1.0
+	case state == StateD2:
+		return 0.7
+	case state == StateD1:
+		return 0.5
+	case state == StateD0:
+		return 0.3
+	default:
+		return 0.0
+	}
 }
 
 // determineTargetState determines the target state based on signal strength
-func (e *DecisionEvaluator) determineTargetState(ctx context.Context, summary *model.AggregatedSummary, currentState DecisionState, corroborationSufficient bool) DecisionState {
+// and returns the rule that decided it
+func (e *DecisionEvaluator) determineTargetState(ctx context.Context, summary *model.AggregatedSummary, currentState DecisionState, corroborationSufficient bool, neighbourPressure float64, cfg config.EvaluatorConfig) (DecisionState, string) {
 	// Simple logic: use confidence and weighted value to determine escalation
 	
 	// If corroboration is insufficient, can't escalate to high-impact states
 	if !corroborationSufficient && currentState.DecisionDepth() < 3 {
 		// Can only go to D2 max
-		if summary.Confidence > 0.7 {
-			return StateD2
+		if summary.Confidence > cfg.UncorroboratedConfidence {
+			return StateD2, RuleUncorroborated
 		}
-		return StateD1
+		return StateD1, RuleUncorroborated
 	}
 	
-	// High confidence and high weighted value -> escalate
-	if summary.Confidence > 0.8 && summary.WeightedValue > 0.7 {
+	// High confidence and high weighted value (or a strong deviation from baseline) -> escalate
+	if summary.Confidence > cfg.EscalationConfidence && (summary.WeightedValue > cfg.EscalationWeightedValue || summary.AnomalyScore >= cfg.AnomalyEscalationScore) {
 		if currentState.DecisionDepth() < 4 {
-			return StateD3
+			return StateD3, RuleEscalation
 		}
 		if currentState.DecisionDepth() < 5 {
-			return StateD4
+			return StateD4, RuleEscalation
 		}
 		if currentState.DecisionDepth() < 6 {
-			return StateD5
+			return StateD5, RuleEscalation
 		}
 	}
 	
 	// Medium confidence -> moderate escalation
-	if summary.Confidence > 0.6 {
+	if summary.Confidence > cfg.ModerateConfidence {
 		if currentState.DecisionDepth() < 3 {
-			return StateD2
+			return StateD2, RuleModerate
 		}
 	}
 	
-	// Low confidence -> minimal escalation
-	if summary.Confidence > 0.4 && currentState == StateInactive {
-		return StateD0
+	// Low confidence or unusual activity for this time of week -> minimal escalation.
+	// Zones next to an active incident pre-alert earlier.
+	threshold := cfg.PreAlertConfidence
+	if neighbourPressure >= cfg.NeighbourPreAlertPressure {
+		threshold = cfg.PressuredPreAlertConfidence
+	}
+	if (summary.Confidence > threshold || summary.AnomalyScore >= cfg.AnomalyPreAlertScore) && currentState == StateInactive {
+		return StateD0, RulePreAlert
 	}
 	
-	return currentState
+	return currentState, RuleHold
 }
 
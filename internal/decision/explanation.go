@@ -0,0 +1,301 @@
+package decision
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/erh-safety-system/poc/internal/config"
+	"github.com/erh-safety-system/poc/internal/model"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Evaluation rules that can decide the target state
+const (
+	RuleUncorroborated  = "uncorroborated_cap"  // Too few independent sources; capped at D2
+	RuleEscalation      = "high_confidence"     // Confidence and weighted value or anomaly above escalation thresholds
+	RuleModerate        = "moderate_confidence" // Confidence above the moderate threshold
+	RulePreAlert        = "pre_alert"           // Confidence or anomaly above the pre-alert threshold
+	RulePolicyDisallows = "policy_disallows"    // The zone's policy does not allow the proposed transition
+	RuleHold            = "hold"                // No rule applied; the current state is kept
+)
+
+// maxTopSignals bounds the number of signals listed in an explanation
+const maxTopSignals = 5
+
+// Explanation records why the evaluator proposed a target state
+type Explanation struct {
+	Rule          string               `json:"rule"`
+	SourceCounts  map[string]int       `json:"source_counts"` // Effective signals per source type
+	Corroboration CorroborationCheck   `json:"corroboration"`
+	Thresholds    []ThresholdCheck     `json:"thresholds"`
+	Confidence    ConfidenceBreakdown  `json:"confidence"`
+	TopSignals    []SignalContribution `json:"top_signals"`
+}
+
+// CorroborationCheck records the independent-source check against the zone's policy
+type CorroborationCheck struct {
+	Sources    []string `json:"sources"` // Source types with at least one effective signal
+	Required   int      `json:"required"`
+	Sufficient bool     `json:"sufficient"`
+}
+
+// ThresholdCheck records one comparison of a summary value against an evaluator threshold
+type ThresholdCheck struct {
+	Name      string  `json:"name"`
+	Value     float64 `json:"value"`
+	Threshold float64 `json:"threshold"`
+	Crossed   bool    `json:"crossed"`
+}
+
+// ConfidenceBreakdown shows how the summary's confidence was derived: the weighted mean of each
+// source type's mean quality, boosted by neighbour pressure
+type ConfidenceBreakdown struct {
+	Sources           []SourceContribution `json:"sources"`
+	WeightSum         float64              `json:"weight_sum"`
+	Base              float64              `json:"base"` // Sum of weighted qualities / weight sum
+	NeighbourPressure float64              `json:"neighbour_pressure"`
+	Final             float64              `json:"final"` // Confidence the evaluation used
+}
+
+// SourceContribution is one source type's share of the confidence
+type SourceContribution struct {
+	SourceType  string  `json:"source_type"`
+	Weight      float64 `json:"weight"`
+	Signals     int     `json:"signals"`
+	MeanQuality float64 `json:"mean_quality"`
+	Weighted    float64 `json:"weighted"` // Weight x mean quality
+}
+
+// SignalContribution is one signal's share of the base confidence
+type SignalContribution struct {
+	SignalID     string    `json:"signal_id"`
+	SourceType   string    `json:"source_type"`
+	SignalType   string    `json:"signal_type"`
+	Quality      float64   `json:"quality"`
+	Timestamp    time.Time `json:"timestamp"`
+	Contribution float64   `json:"contribution"`
+}
+
+// EvaluationRecord is a persisted evaluation with its explanation
+type EvaluationRecord struct {
+	ID             string      `gorm:"primaryKey;type:varchar(255)" json:"id"`
+	ZoneID         string      `gorm:"index:idx_evaluation_zone_created,priority:1;type:varchar(10);not null" json:"zone_id"`
+	SummaryID      string      `gorm:"type:varchar(255);not null" json:"summary_id"`
+	CurrentState   string      `gorm:"type:varchar(10);not null" json:"current_state"`
+	TargetState    string      `gorm:"type:varchar(10);not null" json:"target_state"`
+	ShouldEscalate bool        `json:"should_escalate"`
+	Reason         string      `gorm:"type:text" json:"reason"`
+	PolicyVersion  string      `gorm:"type:varchar(100)" json:"policy_version"`
+	Result         model.JSONB `gorm:"type:jsonb" json:"result"` // Full evaluation result including the explanation
+	CreatedAt      time.Time   `gorm:"index:idx_evaluation_zone_created,priority:2;not null" json:"created_at"`
+}
+
+// TableName specifies the table name
+func (EvaluationRecord) TableName() string {
+	return "evaluation_records"
+}
+
+// thresholdChecks compares a summary against every threshold the evaluator may apply
+func thresholdChecks(summary *model.AggregatedSummary, neighbourPressure float64, cfg config.EvaluatorConfig) []ThresholdCheck {
+	preAlert := cfg.PreAlertConfidence
+	if neighbourPressure >= cfg.NeighbourPreAlertPressure {
+		preAlert = cfg.PressuredPreAlertConfidence
+	}
+
+	return []ThresholdCheck{
+		{Name: "escalation_confidence", Value: summary.Confidence, Threshold: cfg.EscalationConfidence, Crossed: summary.Confidence > cfg.EscalationConfidence},
+		{Name: "escalation_weighted_value", Value: summary.WeightedValue, Threshold: cfg.EscalationWeightedValue, Crossed: summary.WeightedValue > cfg.EscalationWeightedValue},
+		{Name: "anomaly_escalation_score", Value: summary.AnomalyScore, Threshold: cfg.AnomalyEscalationScore, Crossed: summary.AnomalyScore >= cfg.AnomalyEscalationScore},
+		{Name: "moderate_confidence", Value: summary.Confidence, Threshold: cfg.ModerateConfidence, Crossed: summary.Confidence > cfg.ModerateConfidence},
+		{Name: "uncorroborated_confidence", Value: summary.Confidence, Threshold: cfg.UncorroboratedConfidence, Crossed: summary.Confidence > cfg.UncorroboratedConfidence},
+		{Name: "neighbour_pre_alert_pressure", Value: neighbourPressure, Threshold: cfg.NeighbourPreAlertPressure, Crossed: neighbourPressure >= cfg.NeighbourPreAlertPressure},
+		{Name: "pre_alert_confidence", Value: summary.Confidence, Threshold: preAlert, Crossed: summary.Confidence > preAlert},
+		{Name: "anomaly_pre_alert_score", Value: summary.AnomalyScore, Threshold: cfg.AnomalyPreAlertScore, Crossed: summary.AnomalyScore >= cfg.AnomalyPreAlertScore},
+	}
+}
+
+// explain builds the explanation for an evaluation of a summary
+func (e *DecisionEvaluator) explain(ctx context.Context, summary *model.AggregatedSummary, currentState DecisionState, policy *Policy, rule string, corroborated bool, neighbourPressure float64, cfg config.EvaluatorConfig) *Explanation {
+	explanation := &Explanation{
+		Rule:         rule,
+		SourceCounts: make(map[string]int),
+		Corroboration: CorroborationCheck{
+			Sources:    make([]string, 0),
+			Required:   policy.MinSources(currentState),
+			Sufficient: corroborated,
+		},
+		Thresholds: thresholdChecks(summary, neighbourPressure, cfg),
+		Confidence: ConfidenceBreakdown{
+			Sources:           make([]SourceContribution, 0),
+			NeighbourPressure: summary.NeighbourPressure,
+			Final:             summary.Confidence,
+		},
+		TopSignals: make([]SignalContribution, 0),
+	}
+
+	for _, sourceType := range sourceTypes {
+		count := int(countValue(summary.SourceCount[sourceType]))
+		if count > 0 {
+			explanation.SourceCounts[sourceType] = count
+			explanation.Corroboration.Sources = append(explanation.Corroboration.Sources, sourceType)
+		}
+	}
+
+	e.explainConfidence(ctx, summary, explanation)
+	return explanation
+}
+
+// explainConfidence breaks the summary's confidence down by source type and signal, mirroring
+// how the aggregation engine computes it. It is left empty if the window's signals are unavailable.
+func (e *DecisionEvaluator) explainConfidence(ctx context.Context, summary *model.AggregatedSummary, explanation *Explanation) {
+	if e.aggEngine == nil {
+		return
+	}
+	weights := e.aggEngine.Weights(summary.ZoneID)
+
+	var signals []*model.Signal
+	if err := e.db.WithContext(ctx).
+		Where("zone_id = ? AND timestamp >= ? AND timestamp < ?", summary.ZoneID, summary.WindowStart, summary.WindowEnd).
+		Find(&signals).Error; err != nil {
+		return
+	}
+
+	grouped := make(map[string][]*model.Signal)
+	for _, signal := range signals {
+		if _, weighted := weights[signal.SourceType]; weighted && signal.QualityScore > 0 {
+			grouped[signal.SourceType] = append(grouped[signal.SourceType], signal)
+		}
+	}
+
+	breakdown := &explanation.Confidence
+	var weightedSum float64
+	for sourceType, group := range grouped {
+		var sum float64
+		for _, signal := range group {
+			sum += signal.QualityScore
+		}
+		contribution := SourceContribution{
+			SourceType:  sourceType,
+			Weight:      weights[sourceType],
+			Signals:     len(group),
+			MeanQuality: sum / float64(len(group)),
+		}
+		contribution.Weighted = contribution.Weight * contribution.MeanQuality
+		breakdown.Sources = append(breakdown.Sources, contribution)
+		breakdown.WeightSum += contribution.Weight
+		weightedSum += contribution.Weighted
+	}
+	sort.Slice(breakdown.Sources, func(i, j int) bool {
+		return breakdown.Sources[i].Weighted > breakdown.Sources[j].Weighted
+	})
+	if breakdown.WeightSum == 0 {
+		return
+	}
+	breakdown.Base = weightedSum / breakdown.WeightSum
+
+	// Each signal contributes its source type's weight times its quality, shared across the type's signals
+	contributions := make([]SignalContribution, 0, len(signals))
+	for sourceType, group := range grouped {
+		for _, signal := range group {
+			contributions = append(contributions, SignalContribution{
+				SignalID:     signal.ID,
+				SourceType:   sourceType,
+				SignalType:   signal.SignalType,
+				Quality:      signal.QualityScore,
+				Timestamp:    signal.Timestamp,
+				Contribution: weights[sourceType] * signal.QualityScore / (float64(len(group)) * breakdown.WeightSum),
+			})
+		}
+	}
+	sort.Slice(contributions, func(i, j int) bool {
+		if contributions[i].Contribution != contributions[j].Contribution {
+			return contributions[i].Contribution > contributions[j].Contribution
+		}
+		return contributions[i].SignalID < contributions[j].SignalID
+	})
+	if len(contributions) > maxTopSignals {
+		contributions = contributions[:maxTopSignals]
+	}
+	explanation.TopSignals = contributions
+}
+
+// describeRule summarizes the deciding rule and the values behind it for the result's reason
+func describeRule(rule string, summary *model.AggregatedSummary, explanation *Explanation, cfg config.EvaluatorConfig) string {
+	switch rule {
+	case RuleUncorroborated:
+		return fmt.Sprintf("%d of %d required independent source types, capped below D3 at confidence %.2f",
+			len(explanation.Corroboration.Sources), explanation.Corroboration.Required, summary.Confidence)
+	case RuleEscalation:
+		return fmt.Sprintf("confidence %.2f > %.2f with weighted value %.2f (threshold %.2f) or anomaly score %.2f (threshold %.2f)",
+			summary.Confidence, cfg.EscalationConfidence, summary.WeightedValue, cfg.EscalationWeightedValue, summary.AnomalyScore, cfg.AnomalyEscalationScore)
+	case RuleModerate:
+		return fmt.Sprintf("confidence %.2f > moderate threshold %.2f", summary.Confidence, cfg.ModerateConfidence)
+	case RulePreAlert:
+		for _, check := range explanation.Thresholds {
+			if check.Name == "pre_alert_confidence" && check.Crossed {
+				return fmt.Sprintf("confidence %.2f > pre-alert threshold %.2f", check.Value, check.Threshold)
+			}
+		}
+		return fmt.Sprintf("anomaly score %.2f >= %.2f", summary.AnomalyScore, cfg.AnomalyPreAlertScore)
+	default:
+		return ""
+	}
+}
+
+// record persists an evaluation and its explanation
+func (e *DecisionEvaluator) record(ctx context.Context, summary *model.AggregatedSummary, currentState DecisionState, result *EvaluationResult) error {
+	record := &EvaluationRecord{
+		ID:             fmt.Sprintf("eval_%s", uuid.New().String()),
+		ZoneID:         summary.ZoneID,
+		SummaryID:      summary.ID,
+		CurrentState:   string(currentState),
+		TargetState:    string(result.TargetState),
+		ShouldEscalate: result.ShouldEscalate,
+		Reason:         result.Reason,
+		PolicyVersion:  result.PolicyVersion,
+		CreatedAt:      time.Now(),
+	}
+	result.EvaluationID = record.ID
+
+	data, err := json.Marshal(result)
+	if err != nil {
+		return fmt.Errorf("failed to encode evaluation: %w", err)
+	}
+	if err := json.Unmarshal(data, &record.Result); err != nil {
+		return fmt.Errorf("failed to encode evaluation: %w", err)
+	}
+
+	if err := e.db.WithContext(ctx).Create(record).Error; err != nil {
+		return fmt.Errorf("failed to record evaluation: %w", err)
+	}
+	return nil
+}
+
+// GetEvaluation returns a recorded evaluation by ID
+func (e *DecisionEvaluator) GetEvaluation(ctx context.Context, evaluationID string) (*EvaluationRecord, error) {
+	var record EvaluationRecord
+	if err := e.db.WithContext(ctx).Where("id = ?", evaluationID).First(&record).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get evaluation: %w", err)
+	}
+	return &record, nil
+}
+
+// ListEvaluations returns a zone's most recent recorded evaluations, newest first
+func (e *DecisionEvaluator) ListEvaluations(ctx context.Context, zoneID string, limit int) ([]*EvaluationRecord, error) {
+	var records []*EvaluationRecord
+	if err := e.db.WithContext(ctx).
+		Where("zone_id = ?", zoneID).
+		Order("created_at DESC").
+		Limit(limit).
+		Find(&records).Error; err != nil {
+		return nil, fmt.Errorf("failed to list evaluations: %w", err)
+	}
+	return records, nil
+}
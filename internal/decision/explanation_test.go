@@ -0,0 +1,94 @@
+package decision
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/erh-safety-system/poc/internal/aggregation"
+	"github.com/erh-safety-system/poc/internal/config"
+	"github.com/erh-safety-system/poc/internal/model"
+	"github.com/erh-safety-system/poc/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecisionEvaluator_RecordsExplanation(t *testing.T) {
+	db := setupDecisionTestDB(t)
+	require.NoError(t, db.AutoMigrate(&model.Signal{}, &model.SignalBaseline{}, &EvaluationRecord{}))
+	cfg := config.Load()
+	engine := aggregation.NewAggregationEngine(&cfg.Aggregation, db, service.NewSignalService(db))
+	evaluator := NewDecisionEvaluator(&cfg.Evaluator, db, engine)
+	ctx := context.Background()
+
+	_, err := evaluator.EvaluateZone(ctx, "Z1", StateInactive)
+	assert.ErrorIs(t, err, ErrNoSummary)
+
+	windowStart := time.Now().Add(-time.Minute)
+	for i, signal := range []struct {
+		sourceType string
+		quality    float64
+	}{{"infrastructure", 0.9}, {"infrastructure", 0.5}, {"staff", 0.4}} {
+		require.NoError(t, db.Create(&model.Signal{
+			SourceType:   signal.sourceType,
+			SourceID:     string(rune('a' + i)),
+			Timestamp:    windowStart.Add(time.Duration(i+1) * time.Second),
+			ZoneID:       "Z1",
+			SignalType:   "occupancy",
+			QualityScore: signal.quality,
+		}).Error)
+	}
+	_, err = engine.Aggregate(ctx, "Z1", windowStart)
+	require.NoError(t, err)
+
+	result, err := evaluator.EvaluateZone(ctx, "Z1", StateInactive)
+	require.NoError(t, err)
+	require.NotEmpty(t, result.EvaluationID)
+
+	// Infrastructure averages 0.7 at weight 0.4, staff 0.4 at weight 0.4: (0.28 + 0.16) / 0.8
+	explanation := result.Explanation
+	require.NotNil(t, explanation)
+	assert.Equal(t, map[string]int{"infrastructure": 2}, explanation.SourceCounts, "the staff signal is below the quality floor")
+	assert.InDelta(t, 0.55, explanation.Confidence.Base, 1e-9)
+	assert.InDelta(t, 0.8, explanation.Confidence.WeightSum, 1e-9)
+	require.Len(t, explanation.Confidence.Sources, 2)
+	assert.Equal(t, "infrastructure", explanation.Confidence.Sources[0].SourceType)
+	require.Len(t, explanation.TopSignals, 3)
+	assert.InDelta(t, 0.4*0.9/(2*0.8), explanation.TopSignals[0].Contribution, 1e-9)
+
+	assert.True(t, explanation.Corroboration.Sufficient)
+	assert.Equal(t, 1, explanation.Corroboration.Required)
+	assert.Equal(t, RulePreAlert, explanation.Rule)
+	assert.Equal(t, StateD0, result.TargetState)
+	assert.Equal(t, "Escalating from inactive to D0: confidence 0.55 > pre-alert threshold 0.40", result.Reason)
+
+	var preAlert *ThresholdCheck
+	for i := range explanation.Thresholds {
+		if explanation.Thresholds[i].Name == "pre_alert_confidence" {
+			preAlert = &explanation.Thresholds[i]
+		}
+	}
+	require.NotNil(t, preAlert)
+	assert.True(t, preAlert.Crossed)
+
+	records, err := evaluator.ListEvaluations(ctx, "Z1", 10)
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	assert.Equal(t, result.EvaluationID, records[0].ID)
+	assert.Equal(t, RulePreAlert, records[0].Result["explanation"].(map[string]interface{})["rule"])
+
+	record, err := evaluator.GetEvaluation(ctx, result.EvaluationID)
+	require.NoError(t, err)
+	assert.Equal(t, result.Reason, record.Reason)
+
+	// From D0 one source type no longer corroborates, and the built-in policy does not allow D0 -> D2
+	result = evaluator.EvaluateSummary(ctx, &model.AggregatedSummary{
+		ZoneID:      "Z1",
+		SourceCount: model.JSONB{"staff": 3},
+		Confidence:  0.75,
+	}, StateD0)
+	assert.Equal(t, StateD0, result.TargetState)
+	assert.Equal(t, RulePolicyDisallows, result.Explanation.Rule)
+	assert.Contains(t, result.Reason, "does not allow D0 -> D2 (1 of 2 required independent source types")
+	assert.Empty(t, result.EvaluationID, "summaries evaluated directly are not recorded")
+}
@@ -0,0 +1,236 @@
+package decision
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/erh-safety-system/poc/internal/model"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// DecisionTransition is an immutable record of one state change of a decision
+type DecisionTransition struct {
+	ID            string      `gorm:"primaryKey;type:varchar(255)" json:"id"`
+	DecisionID    string      `gorm:"uniqueIndex:idx_transition_decision_sequence,priority:1;type:varchar(255);not null" json:"decision_id"`
+	Sequence      int         `gorm:"uniqueIndex:idx_transition_decision_sequence,priority:2;not null" json:"sequence"` // 1-based position in the decision's timeline; concurrent appends conflict
+	ZoneID        string      `gorm:"index;type:varchar(10);not null" json:"zone_id"`
+	FromState     string      `gorm:"type:varchar(10);not null" json:"from_state"`
+	ToState       string      `gorm:"type:varchar(10);not null" json:"to_state"`
+	OperatorID    string      `gorm:"type:varchar(255);not null" json:"operator_id"`
+	Reason        string      `gorm:"type:text" json:"reason,omitempty"`
+	SummaryID     string      `gorm:"type:varchar(255)" json:"summary_id,omitempty"` // Evidence the transition was based on
+	Evaluation    model.JSONB `gorm:"type:jsonb" json:"evaluation,omitempty"`        // Evaluator result at the time of transition
+	EvaluationID  string      `gorm:"type:varchar(255);index" json:"evaluation_id,omitempty"`
+	ApprovalID    string      `gorm:"type:varchar(255);index" json:"approval_id,omitempty"`
+	PolicyVersion string      `gorm:"type:varchar(100)" json:"policy_version"`
+	CreatedAt     time.Time   `gorm:"index;not null" json:"created_at"`
+}
+
+// TableName specifies the table name
+func (DecisionTransition) TableName() string {
+	return "decision_transitions"
+}
+
+// BeforeUpdate prevents modification of recorded transitions
+func (t *DecisionTransition) BeforeUpdate(tx *gorm.DB) error {
+	return ErrImmutableTransition
+}
+
+// BeforeDelete prevents deletion of recorded transitions
+func (t *DecisionTransition) BeforeDelete(tx *gorm.DB) error {
+	return ErrImmutableTransition
+}
+
+// TransitionRequest describes a requested state change and the context it was made in
+type TransitionRequest struct {
+	DecisionID      string
+	TargetState     DecisionState
+	OperatorID      string
+	Reason          string
+	SummaryID       string            // Defaults to the decision's current summary
+	Evaluation      *EvaluationResult // Optional evaluator output backing the transition
+	EvaluationID    string            // Recorded evaluation backing the transition; defaults to the latest for the summary
+	ApprovalID      string            // Approval authorizing a high-impact transition, if any
+	ExpectedVersion int               // Version the caller based the request on; 0 skips the check
+	Automatic       bool              // Set by safety mechanisms (rollback, de-escalation); transition guards do not apply
+}
+
+// recordTransition appends a transition to a decision's timeline within a transaction
+func recordTransition(tx *gorm.DB, state *DecisionStateRecord, from DecisionState, req *TransitionRequest, at time.Time) (*DecisionTransition, error) {
+	var sequence int64
+	if err := tx.Model(&DecisionTransition{}).Where("decision_id = ?", state.ID).Count(&sequence).Error; err != nil {
+		return nil, fmt.Errorf("failed to count transitions: %w", err)
+	}
+
+	evaluation, err := evaluationJSONB(req.Evaluation)
+	if err != nil {
+		return nil, err
+	}
+
+	summaryID := req.SummaryID
+	if summaryID == "" {
+		summaryID = state.AggregatedSummaryID
+	}
+
+	var evaluationID string
+	if req.Evaluation != nil {
+		evaluationID = req.Evaluation.EvaluationID
+	}
+
+	transition := &DecisionTransition{
+		ID:            fmt.Sprintf("trn_%s", uuid.New().String()),
+		DecisionID:    state.ID,
+		Sequence:      int(sequence) + 1,
+		ZoneID:        state.ZoneID,
+		FromState:     string(from),
+		ToState:       string(req.TargetState),
+		OperatorID:    req.OperatorID,
+		Reason:        req.Reason,
+		SummaryID:     summaryID,
+		Evaluation:    evaluation,
+		EvaluationID:  evaluationID,
+		ApprovalID:    req.ApprovalID,
+		PolicyVersion: state.PolicyVersion,
+		CreatedAt:     at,
+	}
+
+	if err := tx.Create(transition).Error; err != nil {
+		return nil, fmt.Errorf("failed to record transition: %w", err)
+	}
+
+	return transition, nil
+}
+
+// attachEvaluation fills in the evaluation backing a transition: the one the caller named, or the
+// latest recorded for the transition's summary when evaluations are being recorded
+func (s *DecisionService) attachEvaluation(tx *gorm.DB, zoneID, summaryID string, req *TransitionRequest) error {
+	if req.Evaluation != nil {
+		return nil
+	}
+
+	query := tx.Where("zone_id = ?", zoneID)
+	switch {
+	case req.EvaluationID != "":
+		query = query.Where("id = ?", req.EvaluationID)
+	case s.evaluator != nil && summaryID != "":
+		query = query.Where("summary_id = ?", summaryID).Order("created_at DESC")
+	default:
+		return nil
+	}
+
+	var record EvaluationRecord
+	err := query.First(&record).Error
+	if err == gorm.ErrRecordNotFound {
+		if req.EvaluationID != "" {
+			return fmt.Errorf("%w: %s", ErrEvaluationNotFound, req.EvaluationID)
+		}
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get evaluation: %w", err)
+	}
+
+	data, err := json.Marshal(record.Result)
+	if err != nil {
+		return fmt.Errorf("failed to decode evaluation: %w", err)
+	}
+	var result EvaluationResult
+	if err := json.Unmarshal(data, &result); err != nil {
+		return fmt.Errorf("failed to decode evaluation: %w", err)
+	}
+	result.EvaluationID = record.ID
+	req.Evaluation = &result
+	return nil
+}
+
+// evaluationJSONB converts an evaluator result for storage
+func evaluationJSONB(result *EvaluationResult) (model.JSONB, error) {
+	if result == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode evaluation: %w", err)
+	}
+	var evaluation model.JSONB
+	if err := json.Unmarshal(data, &evaluation); err != nil {
+		return nil, fmt.Errorf("failed to encode evaluation: %w", err)
+	}
+	return evaluation, nil
+}
+
+// GetTimeline returns every transition of a decision in order
+func (s *DecisionService) GetTimeline(ctx context.Context, decisionID string) ([]*DecisionTransition, error) {
+	var transitions []*DecisionTransition
+	if err := s.db.WithContext(ctx).
+		Where("decision_id = ?", decisionID).
+		Order("sequence ASC").
+		Find(&transitions).Error; err != nil {
+		return nil, fmt.Errorf("failed to get decision timeline: %w", err)
+	}
+	return transitions, nil
+}
+
+// StateAt reconstructs a decision's state at a point in time from its timeline.
+// It returns StateInactive and a nil transition if the decision did not exist yet,
+// and ErrDecisionNotFound if there is no such decision.
+func (s *DecisionService) StateAt(ctx context.Context, decisionID string, at time.Time) (DecisionState, *DecisionTransition, error) {
+	var transition DecisionTransition
+	err := s.db.WithContext(ctx).
+		Where("decision_id = ? AND created_at <= ?", decisionID, at).
+		Order("sequence DESC").
+		First(&transition).Error
+
+	if err == gorm.ErrRecordNotFound {
+		var count int64
+		if err := s.db.WithContext(ctx).Model(&DecisionStateRecord{}).Where("id = ?", decisionID).Count(&count).Error; err != nil {
+			return "", nil, fmt.Errorf("failed to get decision: %w", err)
+		}
+		if count == 0 {
+			return "", nil, ErrDecisionNotFound
+		}
+		return StateInactive, nil, nil
+	}
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to reconstruct decision state: %w", err)
+	}
+
+	return DecisionState(transition.ToState), &transition, nil
+}
+
+// BackfillTransitions seeds the timeline of decisions stored before transitions were recorded with a single
+// transition into their current state at their creation time, so StateAt can reconstruct them.
+// It returns the number of decisions seeded.
+func BackfillTransitions(ctx context.Context, db *gorm.DB) (int, error) {
+	var states []DecisionStateRecord
+	if err := db.WithContext(ctx).
+		Where("NOT EXISTS (SELECT 1 FROM decision_transitions WHERE decision_transitions.decision_id = decision_states.id)").
+		Find(&states).Error; err != nil {
+		return 0, fmt.Errorf("failed to get decisions without transitions: %w", err)
+	}
+	if len(states) == 0 {
+		return 0, nil
+	}
+
+	err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for i := range states {
+			state := &states[i]
+			req := &TransitionRequest{
+				TargetState: DecisionState(state.CurrentState),
+				OperatorID:  "system_backfill",
+				Reason:      "seeded from the decision's state when it had no recorded transitions",
+			}
+			if _, err := recordTransition(tx, state, StateInactive, req, state.CreatedAt); err != nil {
+				return fmt.Errorf("failed to seed transition of %s: %w", state.ID, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return len(states), nil
+}
@@ -0,0 +1,49 @@
+package decision
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+// TransitionGuard can refuse a transition before it is applied, e.g. while required checklist items are open.
+// It runs inside the transition's transaction; returning an error wrapping ErrTransitionBlocked aborts it.
+type TransitionGuard func(ctx context.Context, tx *gorm.DB, state *DecisionStateRecord, req *TransitionRequest) error
+
+// TransitionObserver is called inside the transaction after a decision enters a new state,
+// including the D0 pre-alert. An error rolls the transition back.
+type TransitionObserver func(ctx context.Context, tx *gorm.DB, state *DecisionStateRecord, transition *DecisionTransition) error
+
+// AddTransitionGuard registers a guard consulted before every operator transition.
+// Automatic transitions (rollback, de-escalation) are never guarded.
+func (s *DecisionService) AddTransitionGuard(guard TransitionGuard) {
+	s.guards = append(s.guards, guard)
+}
+
+// OnTransition registers an observer called whenever a decision enters a new state
+func (s *DecisionService) OnTransition(observer TransitionObserver) {
+	s.observers = append(s.observers, observer)
+}
+
+// checkGuards runs the registered guards for a transition
+func (s *DecisionService) checkGuards(ctx context.Context, tx *gorm.DB, state *DecisionStateRecord, req *TransitionRequest) error {
+	if req.Automatic {
+		return nil
+	}
+	for _, guard := range s.guards {
+		if err := guard(ctx, tx, state, req); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// notifyObservers runs the registered observers for a recorded transition
+func (s *DecisionService) notifyObservers(ctx context.Context, tx *gorm.DB, state *DecisionStateRecord, transition *DecisionTransition) error {
+	for _, observer := range s.observers {
+		if err := observer(ctx, tx, state, transition); err != nil {
+			return err
+		}
+	}
+	return nil
+}
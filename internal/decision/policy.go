@@ -0,0 +1,516 @@
+package decision
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/erh-safety-system/poc/internal/aggregation"
+	"github.com/erh-safety-system/poc/internal/config"
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	// allStates lists every decision state a policy may refer to
+	allStates = []DecisionState{StateInactive, StateD0, StateD1, StateD2, StateD3, StateD4, StateD5, StateD6}
+
+	// gateableStates are the states an approval request can be raised for
+	gateableStates = []DecisionState{StateD3, StateD4, StateD5}
+
+	// sourceTypes are the independent source types counted for corroboration
+	sourceTypes = []string{"infrastructure", "staff", "crowd", "emergency"}
+
+	// policyZones are the zones a policy may be assigned to
+	policyZones = []aggregation.ZoneID{aggregation.ZoneZ1, aggregation.ZoneZ2, aggregation.ZoneZ3, aggregation.ZoneZ4}
+)
+
+// PolicyDocument is the schema of a zone type's policy file
+type PolicyDocument struct {
+	ZoneType      string                      `yaml:"zone_type" toml:"zone_type"`
+	Version       int                         `yaml:"version" toml:"version"` // Must be bumped whenever the content changes
+	Description   string                      `yaml:"description" toml:"description"`
+	Zones         []string                    `yaml:"zones" toml:"zones"`             // Zones of this type
+	Transitions   map[string][]string         `yaml:"transitions" toml:"transitions"` // state -> allowed target states
+	Gates         []string                    `yaml:"gates" toml:"gates"`             // States whose escalation requires an approval; defaults to D3, D4, D5
+	Corroboration *CorroborationPolicy        `yaml:"corroboration" toml:"corroboration"`
+	Thresholds    *config.EvaluatorFileConfig `yaml:"thresholds" toml:"thresholds"` // Overrides of the evaluator thresholds
+}
+
+// CorroborationPolicy sets how many independent source types must report before the evaluator escalates
+type CorroborationPolicy struct {
+	MinSources        int            `yaml:"min_sources" toml:"min_sources" json:"min_sources"`
+	MinSourcesByState map[string]int `yaml:"min_sources_by_state" toml:"min_sources_by_state" json:"min_sources_by_state"` // current state -> minimum
+}
+
+// Policy is a validated decision policy for one zone type
+type Policy struct {
+	ZoneType      string                            `json:"zone_type"`
+	Version       int                               `json:"version"`
+	Description   string                            `json:"description,omitempty"`
+	Zones         []string                          `json:"zones"`
+	Transitions   map[DecisionState][]DecisionState `json:"transitions"`
+	Gates         []DecisionState                   `json:"gates"`
+	Corroboration CorroborationPolicy               `json:"corroboration"`
+	Thresholds    *config.EvaluatorFileConfig       `json:"thresholds,omitempty"`
+	Source        string                            `json:"source"` // File the policy was loaded from, or "builtin"
+
+	stateMachine *StateMachine
+	digest       string
+}
+
+// BuiltinPolicy returns the policy applied to zones without a policy document
+func BuiltinPolicy() *Policy {
+	policy := &Policy{
+		ZoneType:    "builtin",
+		Version:     1,
+		Transitions: defaultTransitions(),
+		Gates:       gateableStates,
+		Corroboration: CorroborationPolicy{
+			MinSources:        1,
+			MinSourcesByState: map[string]int{"D0": 2, "D3": 2, "D4": 2, "D5": 2},
+		},
+		Source: "builtin",
+	}
+	policy.stateMachine = &StateMachine{validTransitions: policy.Transitions}
+	return policy
+}
+
+// ID identifies the policy and version, e.g. "station_interior@v3"
+func (p *Policy) ID() string {
+	return fmt.Sprintf("%s@v%d", p.ZoneType, p.Version)
+}
+
+// StateMachine returns the state machine defined by the policy's transition table
+func (p *Policy) StateMachine() *StateMachine {
+	return p.stateMachine
+}
+
+// RequiresApproval reports whether moving from current to target needs an approval.
+// Escalations into gated states do; de-escalations (e.g. rollbacks from D4 to D3) do not.
+func (p *Policy) RequiresApproval(current, target DecisionState) bool {
+	return p.IsGated(target) && target.DecisionDepth() > current.DecisionDepth()
+}
+
+// IsGated reports whether entering a state is subject to approval under the policy
+func (p *Policy) IsGated(state DecisionState) bool {
+	return containsState(p.Gates, state)
+}
+
+// MinSources returns the number of independent source types required to escalate from the current state
+func (p *Policy) MinSources(current DecisionState) int {
+	if minimum, exists := p.Corroboration.MinSourcesByState[string(current)]; exists {
+		return minimum
+	}
+	return p.Corroboration.MinSources
+}
+
+// ApplyThresholds returns the evaluator thresholds with the policy's overrides applied
+func (p *Policy) ApplyThresholds(cfg config.EvaluatorConfig) config.EvaluatorConfig {
+	p.Thresholds.ApplyTo(&cfg)
+	return cfg
+}
+
+// ParsePolicy decodes and validates a policy document. The format is chosen by extension (.yaml, .yml or .toml).
+// Thresholds are validated against base, the evaluator configuration they override.
+func ParsePolicy(name string, data []byte, base config.EvaluatorConfig) (*Policy, error) {
+	doc := &PolicyDocument{}
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".yaml", ".yml":
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		if err := decoder.Decode(doc); err != nil {
+			return nil, fmt.Errorf("failed to parse policy %s: %w", name, err)
+		}
+	case ".toml":
+		decoder := toml.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(doc); err != nil {
+			return nil, fmt.Errorf("failed to parse policy %s: %w", name, err)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported policy file extension: %s", filepath.Ext(name))
+	}
+
+	policy, problems := buildPolicy(doc, base)
+	if len(problems) > 0 {
+		for i, problem := range problems {
+			problems[i] = fmt.Sprintf("%s: %s", filepath.Base(name), problem)
+		}
+		return nil, &config.ValidationError{Problems: problems}
+	}
+	policy.Source = name
+	return policy, nil
+}
+
+// buildPolicy converts a document into a policy and lists every problem with it
+func buildPolicy(doc *PolicyDocument, base config.EvaluatorConfig) (*Policy, []string) {
+	problems := make([]string, 0)
+
+	if doc.ZoneType == "" {
+		problems = append(problems, "zone_type: must not be empty")
+	}
+	if doc.Version < 1 {
+		problems = append(problems, "version: must be at least 1")
+	}
+
+	if len(doc.Zones) == 0 {
+		problems = append(problems, "zones: must not be empty")
+	}
+	for _, zoneID := range doc.Zones {
+		if !containsZone(zoneID) {
+			problems = append(problems, fmt.Sprintf("zones: unknown zone %s", zoneID))
+		}
+	}
+
+	transitions := make(map[DecisionState][]DecisionState, len(doc.Transitions))
+	for from, targets := range doc.Transitions {
+		if !containsState(allStates, DecisionState(from)) {
+			problems = append(problems, fmt.Sprintf("transitions.%s: unknown state", from))
+			continue
+		}
+		for _, to := range targets {
+			switch {
+			case !containsState(allStates, DecisionState(to)):
+				problems = append(problems, fmt.Sprintf("transitions.%s: unknown target state %s", from, to))
+			case to == from:
+				problems = append(problems, fmt.Sprintf("transitions.%s: must not transition to itself", from))
+			default:
+				transitions[DecisionState(from)] = append(transitions[DecisionState(from)], DecisionState(to))
+			}
+		}
+	}
+	if !containsState(transitions[StateInactive], StateD0) {
+		problems = append(problems, "transitions.inactive: must allow D0 (pre-alert)")
+	}
+
+	gates := gateableStates
+	if doc.Gates != nil {
+		gates = make([]DecisionState, 0, len(doc.Gates))
+		for _, gate := range doc.Gates {
+			if !containsState(gateableStates, DecisionState(gate)) {
+				problems = append(problems, fmt.Sprintf("gates: %s cannot be gated; approvals exist only for D3, D4 and D5", gate))
+				continue
+			}
+			gates = append(gates, DecisionState(gate))
+		}
+	}
+	for _, state := range gateableStates {
+		if state.IsHighImpact() && !containsState(gates, state) {
+			problems = append(problems, fmt.Sprintf("gates: must include %s; escalation into high-impact states always requires approval", state))
+		}
+	}
+
+	problems = append(problems, reachabilityProblems(transitions)...)
+
+	corroboration := BuiltinPolicy().Corroboration
+	if doc.Corroboration != nil {
+		corroboration = *doc.Corroboration
+		if corroboration.MinSources < 1 || corroboration.MinSources > len(sourceTypes) {
+			problems = append(problems, fmt.Sprintf("corroboration.min_sources: must be within [1, %d]", len(sourceTypes)))
+		}
+		for state, minimum := range corroboration.MinSourcesByState {
+			if !containsState(allStates, DecisionState(state)) {
+				problems = append(problems, fmt.Sprintf("corroboration.min_sources_by_state.%s: unknown state", state))
+			}
+			if minimum < 1 || minimum > len(sourceTypes) {
+				problems = append(problems, fmt.Sprintf("corroboration.min_sources_by_state.%s: must be within [1, %d]", state, len(sourceTypes)))
+			}
+		}
+	}
+
+	thresholds := base
+	doc.Thresholds.ApplyTo(&thresholds)
+	problems = append(problems, thresholds.Problems("thresholds")...)
+
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return nil, problems
+	}
+
+	policy := &Policy{
+		ZoneType:      doc.ZoneType,
+		Version:       doc.Version,
+		Description:   doc.Description,
+		Zones:         doc.Zones,
+		Transitions:   transitions,
+		Gates:         gates,
+		Corroboration: corroboration,
+		Thresholds:    doc.Thresholds,
+		stateMachine:  &StateMachine{validTransitions: transitions},
+	}
+	policy.digest = documentDigest(doc)
+	return policy, nil
+}
+
+// reachabilityProblems checks that every state in the table can be entered from inactive and can stand down
+// to inactive again
+func reachabilityProblems(transitions map[DecisionState][]DecisionState) []string {
+	problems := make([]string, 0)
+
+	reachable := map[DecisionState]bool{StateInactive: true}
+	queue := []DecisionState{StateInactive}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, next := range transitions[current] {
+			if !reachable[next] {
+				reachable[next] = true
+				queue = append(queue, next)
+			}
+		}
+	}
+
+	for _, state := range allStates {
+		_, listed := transitions[state]
+		if listed && !reachable[state] {
+			problems = append(problems, fmt.Sprintf("transitions.%s: not reachable from inactive", state))
+		}
+		if reachable[state] && state != StateInactive && !canReach(transitions, state, StateInactive) {
+			problems = append(problems, fmt.Sprintf("transitions.%s: cannot return to inactive", state))
+		}
+	}
+
+	return problems
+}
+
+// canReach reports whether target can be reached from start
+func canReach(transitions map[DecisionState][]DecisionState, start, target DecisionState) bool {
+	visited := map[DecisionState]bool{start: true}
+	queue := []DecisionState{start}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		if current == target {
+			return true
+		}
+		for _, next := range transitions[current] {
+			if !visited[next] {
+				visited[next] = true
+				queue = append(queue, next)
+			}
+		}
+	}
+	return false
+}
+
+// documentDigest fingerprints a document's content so that edits without a version bump can be detected
+func documentDigest(doc *PolicyDocument) string {
+	data, _ := json.Marshal(doc)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// PolicyRegistry holds the current policy of each zone type and assigns zones to them.
+// Zones without a policy document use the built-in policy.
+type PolicyRegistry struct {
+	dir  string
+	base config.EvaluatorConfig
+
+	mu       sync.RWMutex
+	builtin  *Policy
+	byType   map[string]*Policy
+	byZone   map[string]*Policy
+	versions map[string]string // policy ID -> content digest of every version seen
+}
+
+// NewPolicyRegistry creates a registry that applies the built-in policy to every zone
+func NewPolicyRegistry() *PolicyRegistry {
+	return &PolicyRegistry{
+		builtin:  BuiltinPolicy(),
+		byType:   make(map[string]*Policy),
+		byZone:   make(map[string]*Policy),
+		versions: make(map[string]string),
+	}
+}
+
+// LoadPolicies creates a registry from the policy documents in dir.
+// Thresholds in the documents are validated against base.
+func LoadPolicies(dir string, base *config.EvaluatorConfig) (*PolicyRegistry, error) {
+	registry := NewPolicyRegistry()
+	registry.dir = dir
+	registry.base = *base
+
+	if _, err := registry.Reload(); err != nil {
+		return nil, err
+	}
+	return registry, nil
+}
+
+// Dir returns the directory policies are loaded from, or "" for built-in only
+func (r *PolicyRegistry) Dir() string {
+	return r.dir
+}
+
+// Reload re-reads the policy directory and returns the zones whose policy changed.
+// If any document is invalid the current policies are kept.
+func (r *PolicyRegistry) Reload() ([]string, error) {
+	if r.dir == "" {
+		return []string{}, nil
+	}
+
+	entries, err := os.ReadDir(r.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read policy directory: %w", err)
+	}
+
+	r.mu.RLock()
+	base := r.base
+	versions := r.versions
+	r.mu.RUnlock()
+
+	problems := make([]string, 0)
+	byType := make(map[string]*Policy)
+	byZone := make(map[string]*Policy)
+	for _, entry := range entries {
+		ext := strings.ToLower(filepath.Ext(entry.Name()))
+		if entry.IsDir() || (ext != ".yaml" && ext != ".yml" && ext != ".toml") {
+			continue
+		}
+
+		path := filepath.Join(r.dir, entry.Name())
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read policy file: %w", err)
+		}
+
+		policy, err := ParsePolicy(path, data, base)
+		if err != nil {
+			if validationErr, ok := err.(*config.ValidationError); ok {
+				problems = append(problems, validationErr.Problems...)
+			} else {
+				problems = append(problems, err.Error())
+			}
+			continue
+		}
+
+		if other, exists := byType[policy.ZoneType]; exists {
+			problems = append(problems, fmt.Sprintf("%s: zone type %s is also defined in %s", entry.Name(), policy.ZoneType, filepath.Base(other.Source)))
+			continue
+		}
+		if digest, seen := versions[policy.ID()]; seen && digest != policy.digest {
+			problems = append(problems, fmt.Sprintf("%s: content changed without bumping version %d", entry.Name(), policy.Version))
+		}
+		for _, zoneID := range policy.Zones {
+			if other, exists := byZone[zoneID]; exists {
+				problems = append(problems, fmt.Sprintf("%s: zone %s is already assigned to %s", entry.Name(), zoneID, other.ZoneType))
+				continue
+			}
+			byZone[zoneID] = policy
+		}
+		byType[policy.ZoneType] = policy
+	}
+
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return nil, &config.ValidationError{Problems: problems}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	changes := make([]string, 0)
+	for _, zoneID := range policyZones {
+		old, updated := r.forZoneLocked(string(zoneID)), r.builtin
+		if policy, exists := byZone[string(zoneID)]; exists {
+			updated = policy
+		}
+		if old.ID() != updated.ID() {
+			changes = append(changes, fmt.Sprintf("policies.%s: %s -> %s", zoneID, old.ID(), updated.ID()))
+		}
+	}
+	for _, policy := range byType {
+		r.versions[policy.ID()] = policy.digest
+	}
+	r.byType = byType
+	r.byZone = byZone
+
+	return changes, nil
+}
+
+// CheckBase validates the threshold overrides of the loaded policies against new evaluator thresholds
+func (r *PolicyRegistry) CheckBase(base *config.EvaluatorConfig) error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.checkBaseLocked(base)
+}
+
+// UpdateBase replaces the evaluator thresholds the policies' overrides apply to and subsequent reloads validate against.
+// If an override no longer fits the new thresholds the current base is kept.
+func (r *PolicyRegistry) UpdateBase(base *config.EvaluatorConfig) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.checkBaseLocked(base); err != nil {
+		return err
+	}
+	r.base = *base
+	return nil
+}
+
+func (r *PolicyRegistry) checkBaseLocked(base *config.EvaluatorConfig) error {
+	problems := make([]string, 0)
+	for _, policy := range r.byType {
+		for _, problem := range policy.ApplyThresholds(*base).Problems("thresholds") {
+			problems = append(problems, fmt.Sprintf("%s: %s", filepath.Base(policy.Source), problem))
+		}
+	}
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return &config.ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+// For returns the policy that applies to a zone
+func (r *PolicyRegistry) For(zoneID string) *Policy {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.forZoneLocked(zoneID)
+}
+
+// Policies returns the loaded policies ordered by zone type, followed by the built-in policy
+func (r *PolicyRegistry) Policies() []*Policy {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	policies := make([]*Policy, 0, len(r.byType)+1)
+	for _, policy := range r.byType {
+		policies = append(policies, policy)
+	}
+	sort.Slice(policies, func(i, j int) bool {
+		return policies[i].ZoneType < policies[j].ZoneType
+	})
+	return append(policies, r.builtin)
+}
+
+func (r *PolicyRegistry) forZoneLocked(zoneID string) *Policy {
+	if policy, exists := r.byZone[zoneID]; exists {
+		return policy
+	}
+	return r.builtin
+}
+
+func containsState(states []DecisionState, state DecisionState) bool {
+	for _, s := range states {
+		if s == state {
+			return true
+		}
+	}
+	return false
+}
+
+func containsZone(zoneID string) bool {
+	for _, z := range policyZones {
+		if string(z) == zoneID {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,177 @@
+package decision
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/erh-safety-system/poc/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testPolicy = `
+zone_type: open_plaza
+version: 1
+zones: [Z4]
+transitions:
+  inactive: [D0]
+  D0: [D1, inactive]
+  D1: [D2, D0, inactive]
+  D2: [D3, D1, inactive]
+  D3: [D2, inactive]
+corroboration:
+  min_sources: 2
+thresholds:
+  pre_alert_confidence: 0.45
+`
+
+func writePolicy(t *testing.T, dir, name, content string) {
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write policy file: %v", err)
+	}
+}
+
+func TestParsePolicy_ExampleDocuments(t *testing.T) {
+	registry, err := LoadPolicies("../../configs/policies", &config.Load().Evaluator)
+	require.NoError(t, err)
+
+	assert.Equal(t, "station_interior@v1", registry.For("Z1").ID())
+	assert.Equal(t, "open_plaza@v1", registry.For("Z4").ID())
+	assert.Equal(t, "builtin@v1", registry.For("Z2").ID())
+	assert.Len(t, registry.Policies(), 3)
+}
+
+func TestParsePolicy_Validation(t *testing.T) {
+	base := config.Load().Evaluator
+
+	policy, err := ParsePolicy("plaza.yaml", []byte(testPolicy), base)
+	require.NoError(t, err)
+	assert.Equal(t, 2, policy.MinSources(StateD1))
+	assert.Equal(t, 0.45, policy.ApplyThresholds(base).PreAlertConfidence)
+	assert.Equal(t, base.EscalationConfidence, policy.ApplyThresholds(base).EscalationConfidence)
+	assert.True(t, policy.RequiresApproval(StateD2, StateD3))
+	assert.False(t, policy.StateMachine().CanTransition(StateD0, StateD3))
+
+	_, err = ParsePolicy("bad.yaml", []byte(`
+zone_type: broken
+version: 0
+zones: [Z9]
+transitions:
+  inactive: [D0]
+  D0: [D5, inactive]
+  D5: [D0]
+  D6: [inactive]
+gates: [D2, D3]
+thresholds:
+  pre_alert_confidence: 0.9
+`), base)
+	var validationErr *config.ValidationError
+	require.True(t, errors.As(err, &validationErr))
+	assert.Contains(t, validationErr.Problems, "bad.yaml: version: must be at least 1")
+	assert.Contains(t, validationErr.Problems, "bad.yaml: zones: unknown zone Z9")
+	assert.Contains(t, validationErr.Problems, "bad.yaml: gates: D2 cannot be gated; approvals exist only for D3, D4 and D5")
+	assert.Contains(t, validationErr.Problems, "bad.yaml: gates: must include D4; escalation into high-impact states always requires approval")
+	assert.Contains(t, validationErr.Problems, "bad.yaml: gates: must include D5; escalation into high-impact states always requires approval")
+	assert.Contains(t, validationErr.Problems, "bad.yaml: transitions.D6: not reachable from inactive")
+	assert.Contains(t, validationErr.Problems, "bad.yaml: thresholds: thresholds must satisfy pre_alert_confidence < moderate_confidence < escalation_confidence")
+
+	// An otherwise valid policy cannot drop the approval for a high-impact state
+	_, err = ParsePolicy("ungated.yaml", []byte(testPolicy+"gates: [D3, D5]\n"), base)
+	require.True(t, errors.As(err, &validationErr))
+	assert.Equal(t, []string{"ungated.yaml: gates: must include D4; escalation into high-impact states always requires approval"}, validationErr.Problems)
+
+	_, err = ParsePolicy("typo.yaml", []byte("zone_type: x\nversoin: 1\n"), base)
+	assert.Error(t, err, "unknown keys are rejected")
+}
+
+func TestPolicyRegistry_ReloadRequiresVersionBump(t *testing.T) {
+	dir := t.TempDir()
+	writePolicy(t, dir, "plaza.yaml", testPolicy)
+
+	registry, err := LoadPolicies(dir, &config.Load().Evaluator)
+	require.NoError(t, err)
+	assert.Equal(t, "open_plaza@v1", registry.For("Z4").ID())
+
+	// Editing a published version is rejected and the current policy kept
+	writePolicy(t, dir, "plaza.yaml", testPolicy+"description: edited\n")
+	_, err = registry.Reload()
+	assert.Error(t, err)
+	assert.Empty(t, registry.For("Z4").Description)
+
+	writePolicy(t, dir, "plaza.yaml", "description: edited\n"+
+		"zone_type: open_plaza\nversion: 2\nzones: [Z3, Z4]\ntransitions:\n  inactive: [D0]\n  D0: [inactive]\n")
+	changes, err := registry.Reload()
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{
+		"policies.Z3: builtin@v1 -> open_plaza@v2",
+		"policies.Z4: open_plaza@v1 -> open_plaza@v2",
+	}, changes)
+
+	// Zones may not be claimed by two zone types
+	writePolicy(t, dir, "interior.yaml", "zone_type: station_interior\nversion: 1\nzones: [Z4]\ntransitions:\n  inactive: [D0]\n  D0: [inactive]\n")
+	_, err = registry.Reload()
+	assert.Error(t, err)
+	assert.Equal(t, "open_plaza@v2", registry.For("Z4").ID())
+}
+
+func TestPolicyRegistry_UpdateBaseRevalidatesOverrides(t *testing.T) {
+	dir := t.TempDir()
+	writePolicy(t, dir, "plaza.yaml", testPolicy)
+	base := config.Load().Evaluator
+	registry, err := LoadPolicies(dir, &base)
+	require.NoError(t, err)
+
+	// The plaza's pre-alert override no longer sits below a lowered moderate threshold
+	lowered := base
+	lowered.ModerateConfidence = 0.42
+	var validationErr *config.ValidationError
+	require.True(t, errors.As(registry.CheckBase(&lowered), &validationErr))
+	assert.Equal(t, []string{"plaza.yaml: thresholds: thresholds must satisfy pre_alert_confidence < moderate_confidence < escalation_confidence"}, validationErr.Problems)
+	assert.Error(t, registry.UpdateBase(&lowered))
+
+	// The rejected base is not used to validate later reloads
+	_, err = registry.Reload()
+	require.NoError(t, err)
+
+	raised := base
+	raised.ModerateConfidence = 0.5
+	require.NoError(t, registry.UpdateBase(&raised))
+}
+
+func TestDecisionService_AppliesZonePolicy(t *testing.T) {
+	dir := t.TempDir()
+	writePolicy(t, dir, "plaza.yaml", testPolicy)
+	registry, err := LoadPolicies(dir, &config.Load().Evaluator)
+	require.NoError(t, err)
+
+	db := setupDecisionTestDB(t)
+	service := NewDecisionService(db, nil)
+	service.SetPolicies(registry)
+	service.SetApprovalGate(&stubApprovalGate{approvals: map[string]string{"Z4": "approval_1"}})
+	ctx := context.Background()
+
+	summary := createTestSummary(t, db, "Z4")
+	state, err := service.CreatePreAlert(ctx, "Z4", "op_1", summary.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "open_plaza@v1", state.PolicyVersion)
+
+	// The built-in table allows D0 -> D3, this zone's policy does not
+	_, err = service.TransitionState(ctx, state.ID, StateD3, "op_1", 0)
+	assert.ErrorIs(t, err, ErrInvalidTransition)
+
+	_, err = service.TransitionState(ctx, state.ID, StateD1, "op_1", 0)
+	require.NoError(t, err)
+
+	timeline, err := service.GetTimeline(ctx, state.ID)
+	require.NoError(t, err)
+	for _, transition := range timeline {
+		assert.Equal(t, "open_plaza@v1", transition.PolicyVersion)
+	}
+
+	other, err := service.CreatePreAlert(ctx, "Z1", "op_1", createTestSummary(t, db, "Z1").ID)
+	require.NoError(t, err)
+	assert.Equal(t, "builtin@v1", other.PolicyVersion)
+}
@@ -3,8 +3,10 @@ package decision
 import (
 	"context"
 	"fmt"
+	"log"
 	"time"
 
+	"github.com/erh-safety-system/poc/internal/cache"
 	"github.com/erh-safety-system/poc/internal/model"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
@@ -14,7 +16,13 @@ import (
 type DecisionService struct {
 	db           *gorm.DB
 	evaluator    *DecisionEvaluator
-	stateMachine *StateMachine
+	policies     *PolicyRegistry
+	cache        *cache.ZoneCache
+	approvalGate ApprovalGate
+	complexity   ComplexityScorer
+	guards       []TransitionGuard
+	observers    []TransitionObserver
+	view         bool // Created by WithDB; the cache is left to the owner of the connection
 }
 
 // NewDecisionService creates a new decision service
@@ -22,10 +30,36 @@ func NewDecisionService(db *gorm.DB, evaluator *DecisionEvaluator) *DecisionServ
 	return &DecisionService{
 		db:           db,
 		evaluator:    evaluator,
-		stateMachine: NewStateMachine(),
+		policies:     NewPolicyRegistry(),
 	}
 }
 
+// SetCache enables write-through caching of each zone's latest state
+func (s *DecisionService) SetCache(zoneCache *cache.ZoneCache) {
+	s.cache = zoneCache
+}
+
+// SetPolicies sets the registry of per-zone-type transition and approval policies
+func (s *DecisionService) SetPolicies(policies *PolicyRegistry) {
+	s.policies = policies
+}
+
+// SetApprovalGate sets the gate that authorizes high-impact transitions.
+// Without a gate, escalations into D3/D4/D5 are refused.
+func (s *DecisionService) SetApprovalGate(gate ApprovalGate) {
+	s.approvalGate = gate
+}
+
+// WithDB returns a decision service sharing this service's collaborators but using the given connection (e.g. a transaction).
+// The view neither reads nor writes the cache, since its changes may still be rolled back; callers cache the
+// resulting state with CacheState once the transaction has committed.
+func (s *DecisionService) WithDB(db *gorm.DB) *DecisionService {
+	clone := *s
+	clone.db = db
+	clone.view = true
+	return &clone
+}
+
 // CreatePreAlert creates a D0 Pre-Alert state
 func (s *DecisionService) CreatePreAlert(ctx context.Context, zoneID string, operatorID string, summaryID string) (*DecisionStateRecord, error) {
 	// Get aggregated summary
@@ -36,6 +70,7 @@ func (s *DecisionService) CreatePreAlert(ctx context.Context, zoneID string, ope
 	
 	// Count effective signals
 	signalCount := s.countEffectiveSignals(&summary)
+	policy := s.policies.For(zoneID)
 	
 	// Create decision state
 	state := &DecisionStateRecord{
@@ -45,53 +80,168 @@ func (s *DecisionService) CreatePreAlert(ctx context.Context, zoneID string, ope
 		AggregatedSummaryID: summaryID,
 		SignalCount:         signalCount,
 		DecisionDepth:       StateD0.DecisionDepth(),
-		ContextStates:       1, // TODO: calculate properly
-		ComplexityTotal:     0.0, // TODO: calculate
+		PolicyVersion:       policy.ID(),
+		Version:             1,
+		FirstSignalAt:       s.firstSignalAt(ctx, &summary),
 	}
 	
-	if err := s.db.WithContext(ctx).Create(state).Error; err != nil {
-		return nil, fmt.Errorf("failed to create decision state: %w", err)
+	// Record the state together with the first entry of its timeline
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := s.updateComplexity(tx, state, &summary); err != nil {
+			return err
+		}
+		if err := tx.Create(state).Error; err != nil {
+			return fmt.Errorf("failed to create decision state: %w", err)
+		}
+		req := &TransitionRequest{
+			DecisionID:  state.ID,
+			TargetState: StateD0,
+			OperatorID:  operatorID,
+			Reason:      "pre-alert",
+			SummaryID:   summaryID,
+		}
+		if err := s.attachEvaluation(tx, zoneID, summaryID, req); err != nil {
+			return err
+		}
+		transition, err := recordTransition(tx, state, StateInactive, req, state.CreatedAt)
+		if err != nil {
+			return err
+		}
+		return s.notifyObservers(ctx, tx, state, transition)
+	})
+	if err != nil {
+		return nil, err
 	}
 	
+	s.CacheState(ctx, state)
+	
 	return state, nil
 }
 
-// TransitionState transitions to a new decision state
-func (s *DecisionService) TransitionState(ctx context.Context, stateID string, targetState DecisionState, operatorID string) (*DecisionStateRecord, error) {
-	// Get current state
+// TransitionState transitions to a new decision state.
+// A non-zero expectedVersion must match the decision's current version.
+func (s *DecisionService) TransitionState(ctx context.Context, stateID string, targetState DecisionState, operatorID string, expectedVersion int) (*DecisionStateRecord, error) {
+	return s.Transition(ctx, &TransitionRequest{
+		DecisionID:      stateID,
+		TargetState:     targetState,
+		OperatorID:      operatorID,
+		ExpectedVersion: expectedVersion,
+	})
+}
+
+// Transition transitions a decision to a new state and appends the change to its timeline
+func (s *DecisionService) Transition(ctx context.Context, req *TransitionRequest) (*DecisionStateRecord, error) {
 	var state DecisionStateRecord
-	if err := s.db.WithContext(ctx).Where("id = ?", stateID).First(&state).Error; err != nil {
-		return nil, fmt.Errorf("failed to get decision state: %w", err)
-	}
-	
-	currentState := DecisionState(state.CurrentState)
-	
-	// Check if transition is valid
-	if !s.stateMachine.CanTransition(currentState, targetState) {
-		return nil, ErrInvalidTransition
-	}
-	
-	// Perform transition
-	newState, err := s.stateMachine.Transition(currentState, targetState)
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Get current state
+		if err := tx.Where("id = ?", req.DecisionID).First(&state).Error; err != nil {
+			return fmt.Errorf("failed to get decision state: %w", err)
+		}
+		
+		version := state.Version
+		if req.ExpectedVersion != 0 && req.ExpectedVersion != version {
+			return ErrVersionConflict
+		}
+		
+		currentState := DecisionState(state.CurrentState)
+		policy := s.policies.For(state.ZoneID)
+		
+		// Check if transition is valid under the zone's policy
+		newState, err := policy.StateMachine().Transition(currentState, req.TargetState)
+		if err != nil {
+			return err
+		}
+		
+		// Guards such as SOP checklists may hold the decision in its current state
+		if err := s.checkGuards(ctx, tx, &state, req); err != nil {
+			return err
+		}
+		
+		// High-impact escalations must consume an approval for this zone and action
+		approvalID := req.ApprovalID
+		if policy.RequiresApproval(currentState, newState) {
+			if s.approvalGate == nil {
+				return ErrMissingApproval
+			}
+			approvalID, err = s.approvalGate.ConsumeApproval(ctx, tx, state.ID, state.ZoneID, newState, req.ApprovalID)
+			if err != nil {
+				return err
+			}
+		}
+		
+		// Update state
+		now := time.Now()
+		state.CurrentState = string(newState)
+		state.DecisionDepth = newState.DecisionDepth()
+		state.PolicyVersion = policy.ID()
+		state.UpdatedAt = now
+		if req.SummaryID != "" {
+			state.AggregatedSummaryID = req.SummaryID
+		}
+		
+		// Recompute x_s from the decision's evidence, then x_c and x_total for the new state
+		var summary *model.AggregatedSummary
+		var evidence model.AggregatedSummary
+		err = tx.Where("id = ?", state.AggregatedSummaryID).First(&evidence).Error
+		if err == nil {
+			summary = &evidence
+			state.SignalCount = s.countEffectiveSignals(summary)
+		} else if err != gorm.ErrRecordNotFound {
+			return fmt.Errorf("failed to get summary: %w", err)
+		}
+		if err := s.updateComplexity(tx, &state, summary); err != nil {
+			return err
+		}
+		
+		state.Version = version + 1
+		
+		// Only apply the update if nobody else transitioned the decision since it was read.
+		// Acknowledgement is recorded separately and must not be overwritten.
+		result := tx.Model(&state).Where("version = ?", version).Select("*").
+			Omit("first_signal_at", "acknowledged_by", "acknowledged_at", "time_to_acknowledge").Updates(&state)
+		if result.Error != nil {
+			return fmt.Errorf("failed to update decision state: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return ErrVersionConflict
+		}
+		
+		recorded := *req
+		recorded.ApprovalID = approvalID
+		if err := s.attachEvaluation(tx, state.ZoneID, state.AggregatedSummaryID, &recorded); err != nil {
+			return err
+		}
+		transition, err := recordTransition(tx, &state, currentState, &recorded, now)
+		if err != nil {
+			return err
+		}
+		return s.notifyObservers(ctx, tx, &state, transition)
+	})
 	if err != nil {
 		return nil, err
 	}
 	
-	// Update state
-	state.CurrentState = string(newState)
-	state.DecisionDepth = newState.DecisionDepth()
-	state.UpdatedAt = time.Now()
+	s.CacheState(ctx, &state)
 	
-	if err := s.db.WithContext(ctx).Save(&state).Error; err != nil {
-		return nil, fmt.Errorf("failed to update decision state: %w", err)
+	return &state, nil
+}
+
+// GetDecision gets a decision state by ID
+func (s *DecisionService) GetDecision(ctx context.Context, decisionID string) (*DecisionStateRecord, error) {
+	var state DecisionStateRecord
+	if err := s.db.WithContext(ctx).Where("id = ?", decisionID).First(&state).Error; err != nil {
+		return nil, fmt.Errorf("failed to get decision state: %w", err)
 	}
-	
 	return &state, nil
 }
 
 // GetLatestState gets the latest decision state for a zone
 func (s *DecisionService) GetLatestState(ctx context.Context, zoneID string) (*DecisionStateRecord, error) {
 	var state DecisionStateRecord
+	if s.cache != nil && !s.view && s.cache.Get(ctx, cache.KindState, zoneID, &state) {
+		return &state, nil
+	}
+	
 	err := s.db.WithContext(ctx).
 		Where("zone_id = ?", zoneID).
 		Order("updated_at DESC").
@@ -105,15 +255,29 @@ func (s *DecisionService) GetLatestState(ctx context.Context, zoneID string) (*D
 		return nil, fmt.Errorf("failed to get decision state: %w", err)
 	}
 	
+	s.CacheState(ctx, &state)
+	
 	return &state, nil
 }
 
+// CacheState writes a zone's latest state through to the cache unless a more recently updated state is
+// already cached, e.g. by a concurrent transition. Cache failures never fail the caller.
+// Views created by WithDB skip the write.
+func (s *DecisionService) CacheState(ctx context.Context, state *DecisionStateRecord) {
+	if s.cache == nil || s.view {
+		return
+	}
+	if _, err := s.cache.SetIfNewer(ctx, cache.KindState, state.ZoneID, state, state.UpdatedAt.UnixNano()); err != nil {
+		log.Printf("Failed to cache decision state for zone %s: %v", state.ZoneID, err)
+	}
+}
+
 // countEffectiveSignals counts effective signal sources from summary
 func (s *DecisionService) countEffectiveSignals(summary *model.AggregatedSummary) int {
 	count := 0
 	if summary.SourceCount != nil {
 		for _, v := range summary.SourceCount {
-			if num, ok := v.(float64); ok && num > 0 {
+			if num := countValue(v); num > 0 {
 				count += int(num)
 			}
 		}
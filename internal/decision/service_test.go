@@ -0,0 +1,408 @@
+package decision
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/erh-safety-system/poc/internal/cache"
+	"github.com/erh-safety-system/poc/internal/config"
+	"github.com/erh-safety-system/poc/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// stubApprovalGate approves transitions for zones listed in approvals
+type stubApprovalGate struct {
+	approvals map[string]string // zone_id -> approval ID
+}
+
+func (g *stubApprovalGate) ConsumeApproval(ctx context.Context, tx *gorm.DB, decisionID, zoneID string, target DecisionState, approvalID string) (string, error) {
+	id, exists := g.approvals[zoneID]
+	if !exists {
+		return "", ErrMissingApproval
+	}
+	delete(g.approvals, zoneID)
+	return id, nil
+}
+
+func setupDecisionTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to open test database: %v", err)
+	}
+
+	if err := db.AutoMigrate(&model.AggregatedSummary{}, &DecisionStateRecord{}, &DecisionTransition{}); err != nil {
+		t.Fatalf("Failed to migrate: %v", err)
+	}
+
+	return db
+}
+
+func createTestSummary(t *testing.T, db *gorm.DB, zoneID string) *model.AggregatedSummary {
+	now := time.Now()
+	summary := &model.AggregatedSummary{
+		ZoneID:      zoneID,
+		WindowStart: now.Add(-time.Minute),
+		WindowEnd:   now,
+		SourceCount: model.JSONB{"infrastructure": 2, "staff": 1},
+		Confidence:  0.7,
+	}
+	require.NoError(t, db.Create(summary).Error)
+	return summary
+}
+
+func TestDecisionService_TransitionHistory(t *testing.T) {
+	db := setupDecisionTestDB(t)
+	service := NewDecisionService(db, nil)
+	service.SetApprovalGate(&stubApprovalGate{approvals: map[string]string{"Z1": "approval_1"}})
+	ctx := context.Background()
+
+	summary := createTestSummary(t, db, "Z1")
+	state, err := service.CreatePreAlert(ctx, "Z1", "op_1", summary.ID)
+	require.NoError(t, err)
+
+	_, err = service.Transition(ctx, &TransitionRequest{
+		DecisionID:  state.ID,
+		TargetState: StateD1,
+		OperatorID:  "op_2",
+		Reason:      "crowd density rising",
+		Evaluation:  &EvaluationResult{TargetState: StateD1, CorroborationSufficient: true},
+	})
+	require.NoError(t, err)
+
+	_, err = service.Transition(ctx, &TransitionRequest{
+		DecisionID:  state.ID,
+		TargetState: StateD3,
+		OperatorID:  "op_3",
+		Reason:      "dual control approved",
+	})
+	require.NoError(t, err)
+
+	// Invalid transitions are rejected and not recorded
+	_, err = service.Transition(ctx, &TransitionRequest{DecisionID: state.ID, TargetState: StateD3, OperatorID: "op_3"})
+	assert.ErrorIs(t, err, ErrInvalidTransition)
+
+	timeline, err := service.GetTimeline(ctx, state.ID)
+	require.NoError(t, err)
+	require.Len(t, timeline, 3)
+
+	assert.Equal(t, string(StateInactive), timeline[0].FromState)
+	assert.Equal(t, string(StateD0), timeline[0].ToState)
+	assert.Equal(t, summary.ID, timeline[0].SummaryID)
+
+	assert.Equal(t, "op_2", timeline[1].OperatorID)
+	assert.Equal(t, "crowd density rising", timeline[1].Reason)
+	assert.Equal(t, summary.ID, timeline[1].SummaryID, "summary defaults to the decision's current evidence")
+	assert.Equal(t, true, timeline[1].Evaluation["corroboration_sufficient"])
+
+	assert.Equal(t, string(StateD1), timeline[2].FromState)
+	assert.Equal(t, string(StateD3), timeline[2].ToState)
+	assert.Equal(t, "approval_1", timeline[2].ApprovalID)
+	assert.Equal(t, 3, timeline[2].Sequence)
+
+	// Recorded transitions cannot be changed
+	timeline[2].Reason = "rewritten"
+	assert.ErrorIs(t, db.Save(timeline[2]).Error, ErrImmutableTransition)
+	assert.ErrorIs(t, db.Delete(timeline[2]).Error, ErrImmutableTransition)
+}
+
+func TestDecisionService_TransitionAttachesEvaluation(t *testing.T) {
+	db := setupDecisionTestDB(t)
+	require.NoError(t, db.AutoMigrate(&EvaluationRecord{}))
+	cfg := config.Load()
+	service := NewDecisionService(db, NewDecisionEvaluator(&cfg.Evaluator, db, nil))
+	ctx := context.Background()
+
+	summary := createTestSummary(t, db, "Z1")
+	for i, id := range []string{"eval_old", "eval_new"} {
+		require.NoError(t, db.Create(&EvaluationRecord{
+			ID:           id,
+			ZoneID:       "Z1",
+			SummaryID:    summary.ID,
+			CurrentState: string(StateInactive),
+			TargetState:  string(StateD0),
+			Result:       model.JSONB{"target_state": "D0", "reason": id},
+			CreatedAt:    time.Now().Add(time.Duration(i) * time.Second),
+		}).Error)
+	}
+
+	state, err := service.CreatePreAlert(ctx, "Z1", "op_1", summary.ID)
+	require.NoError(t, err)
+
+	// An unknown evaluation is rejected
+	_, err = service.Transition(ctx, &TransitionRequest{DecisionID: state.ID, TargetState: StateD1, OperatorID: "op_2", EvaluationID: "eval_missing"})
+	assert.ErrorIs(t, err, ErrEvaluationNotFound)
+
+	_, err = service.Transition(ctx, &TransitionRequest{DecisionID: state.ID, TargetState: StateD1, OperatorID: "op_2", EvaluationID: "eval_old"})
+	require.NoError(t, err)
+
+	timeline, err := service.GetTimeline(ctx, state.ID)
+	require.NoError(t, err)
+	require.Len(t, timeline, 2)
+	assert.Equal(t, "eval_new", timeline[0].EvaluationID, "defaults to the latest evaluation of the summary")
+	assert.Equal(t, "eval_new", timeline[0].Evaluation["reason"])
+	assert.Equal(t, "eval_old", timeline[1].EvaluationID)
+	assert.Equal(t, "eval_old", timeline[1].Evaluation["evaluation_id"])
+}
+
+func TestDecisionService_TransitionSequenceIsUnique(t *testing.T) {
+	db := setupDecisionTestDB(t)
+	service := NewDecisionService(db, nil)
+	ctx := context.Background()
+
+	summary := createTestSummary(t, db, "Z1")
+	state, err := service.CreatePreAlert(ctx, "Z1", "op_1", summary.ID)
+	require.NoError(t, err)
+
+	// A concurrent append that counted the same timeline length must fail
+	err = db.Create(&DecisionTransition{
+		ID:         "trn_duplicate",
+		DecisionID: state.ID,
+		Sequence:   1,
+		ZoneID:     "Z1",
+		FromState:  string(StateInactive),
+		ToState:    string(StateD0),
+		OperatorID: "op_2",
+		CreatedAt:  time.Now(),
+	}).Error
+	assert.Error(t, err)
+}
+
+func TestDecisionService_ViewLeavesCacheToCaller(t *testing.T) {
+	db := setupDecisionTestDB(t)
+	service := NewDecisionService(db, nil)
+	zoneCache := cache.NewZoneCache(nil)
+	service.SetCache(zoneCache)
+	ctx := context.Background()
+
+	state, err := service.CreatePreAlert(ctx, "Z1", "op_1", createTestSummary(t, db, "Z1").ID)
+	require.NoError(t, err)
+
+	// A transition made through a view of a transaction that rolls back never reaches the cache
+	errRolledBack := errors.New("rolled back")
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if _, err := service.WithDB(tx).TransitionState(ctx, state.ID, StateD1, "op_1", 0); err != nil {
+			return err
+		}
+		return errRolledBack
+	})
+	require.ErrorIs(t, err, errRolledBack)
+
+	var cached DecisionStateRecord
+	require.True(t, zoneCache.Peek(ctx, cache.KindState, "Z1", &cached))
+	assert.Equal(t, string(StateD0), cached.CurrentState)
+
+	latest, err := service.GetLatestState(ctx, "Z1")
+	require.NoError(t, err)
+	assert.Equal(t, string(StateD0), latest.CurrentState)
+}
+
+func TestDecisionService_StaleCacheWriteIsDropped(t *testing.T) {
+	db := setupDecisionTestDB(t)
+	service := NewDecisionService(db, nil)
+	zoneCache := cache.NewZoneCache(nil)
+	service.SetCache(zoneCache)
+	ctx := context.Background()
+
+	state, err := service.CreatePreAlert(ctx, "Z1", "op_1", createTestSummary(t, db, "Z1").ID)
+	require.NoError(t, err)
+	stale := *state
+
+	_, err = service.TransitionState(ctx, state.ID, StateD1, "op_1", 0)
+	require.NoError(t, err)
+
+	// A reader that loaded the state before the transition fills the cache after it
+	service.CacheState(ctx, &stale)
+
+	var cached DecisionStateRecord
+	require.True(t, zoneCache.Peek(ctx, cache.KindState, "Z1", &cached))
+	assert.Equal(t, string(StateD1), cached.CurrentState)
+
+	latest, err := service.GetLatestState(ctx, "Z1")
+	require.NoError(t, err)
+	assert.Equal(t, string(StateD1), latest.CurrentState)
+}
+
+func TestDecisionService_StateAt(t *testing.T) {
+	db := setupDecisionTestDB(t)
+	service := NewDecisionService(db, nil)
+	ctx := context.Background()
+
+	summary := createTestSummary(t, db, "Z2")
+	state, err := service.CreatePreAlert(ctx, "Z2", "op_1", summary.ID)
+	require.NoError(t, err)
+	_, err = service.TransitionState(ctx, state.ID, StateD1, "op_1", state.Version)
+	require.NoError(t, err)
+
+	timeline, err := service.GetTimeline(ctx, state.ID)
+	require.NoError(t, err)
+	require.Len(t, timeline, 2)
+
+	current, transition, err := service.StateAt(ctx, state.ID, timeline[0].CreatedAt.Add(-time.Second))
+	require.NoError(t, err)
+	assert.Equal(t, StateInactive, current)
+	assert.Nil(t, transition)
+
+	current, _, err = service.StateAt(ctx, state.ID, timeline[0].CreatedAt)
+	require.NoError(t, err)
+	assert.Equal(t, StateD0, current)
+
+	current, transition, err = service.StateAt(ctx, state.ID, time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, StateD1, current)
+	assert.Equal(t, timeline[1].ID, transition.ID)
+
+	_, _, err = service.StateAt(ctx, "dec_unknown", time.Now())
+	assert.ErrorIs(t, err, ErrDecisionNotFound)
+}
+
+func TestBackfillTransitions(t *testing.T) {
+	db := setupDecisionTestDB(t)
+	service := NewDecisionService(db, nil)
+	ctx := context.Background()
+
+	// A decision stored before transitions were recorded
+	createdAt := time.Now().Add(-time.Hour).Truncate(time.Second)
+	legacy := &DecisionStateRecord{ID: "dec_legacy", ZoneID: "Z1", CurrentState: string(StateD2), Version: 3, CreatedAt: createdAt}
+	require.NoError(t, db.Create(legacy).Error)
+	current, transition, err := service.StateAt(ctx, legacy.ID, time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, StateInactive, current)
+	assert.Nil(t, transition)
+
+	_, err = service.CreatePreAlert(ctx, "Z2", "op_1", createTestSummary(t, db, "Z2").ID)
+	require.NoError(t, err)
+
+	seeded, err := BackfillTransitions(ctx, db)
+	require.NoError(t, err)
+	assert.Equal(t, 1, seeded)
+
+	current, transition, err = service.StateAt(ctx, legacy.ID, time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, StateD2, current)
+	assert.Equal(t, 1, transition.Sequence)
+	assert.Equal(t, string(StateInactive), transition.FromState)
+	assert.True(t, createdAt.Equal(transition.CreatedAt))
+
+	seeded, err = BackfillTransitions(ctx, db)
+	require.NoError(t, err)
+	assert.Zero(t, seeded)
+}
+
+func TestDecisionService_VersionConflict(t *testing.T) {
+	db := setupDecisionTestDB(t)
+	service := NewDecisionService(db, nil)
+	ctx := context.Background()
+
+	summary := createTestSummary(t, db, "Z3")
+	state, err := service.CreatePreAlert(ctx, "Z3", "op_1", summary.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, state.Version)
+
+	// Two operators read version 1; the first transition wins
+	updated, err := service.TransitionState(ctx, state.ID, StateD1, "op_1", 1)
+	require.NoError(t, err)
+	assert.Equal(t, 2, updated.Version)
+
+	_, err = service.TransitionState(ctx, state.ID, StateInactive, "op_2", 1)
+	assert.ErrorIs(t, err, ErrVersionConflict)
+
+	current, err := service.GetDecision(ctx, state.ID)
+	require.NoError(t, err)
+	assert.Equal(t, string(StateD1), current.CurrentState)
+	assert.Equal(t, 2, current.Version)
+
+	// The losing transition is not recorded
+	timeline, err := service.GetTimeline(ctx, state.ID)
+	require.NoError(t, err)
+	assert.Len(t, timeline, 2)
+}
+
+func TestDecisionService_HighImpactRequiresApproval(t *testing.T) {
+	db := setupDecisionTestDB(t)
+	service := NewDecisionService(db, nil)
+	ctx := context.Background()
+
+	summary := createTestSummary(t, db, "Z4")
+	state, err := service.CreatePreAlert(ctx, "Z4", "op_1", summary.ID)
+	require.NoError(t, err)
+
+	// Without a gate high-impact escalations are refused
+	_, err = service.TransitionState(ctx, state.ID, StateD3, "op_1", 0)
+	assert.ErrorIs(t, err, ErrMissingApproval)
+
+	gate := &stubApprovalGate{approvals: map[string]string{}}
+	service.SetApprovalGate(gate)
+	_, err = service.TransitionState(ctx, state.ID, StateD3, "op_1", 0)
+	assert.ErrorIs(t, err, ErrMissingApproval)
+
+	current, err := service.GetDecision(ctx, state.ID)
+	require.NoError(t, err)
+	assert.Equal(t, string(StateD0), current.CurrentState)
+	assert.Equal(t, 1, current.Version, "a refused transition leaves the decision untouched")
+
+	// An approval for D4 is consumed; de-escalating back to D3 needs none
+	gate.approvals["Z4"] = "approval_d4"
+	_, err = service.TransitionState(ctx, state.ID, StateD4, "op_1", 0)
+	require.NoError(t, err)
+	_, err = service.TransitionState(ctx, state.ID, StateD3, "system_rollback", 0)
+	require.NoError(t, err)
+
+	assert.True(t, BuiltinPolicy().RequiresApproval(StateD2, StateD3))
+	assert.False(t, BuiltinPolicy().RequiresApproval(StateD4, StateD3))
+	assert.False(t, BuiltinPolicy().RequiresApproval(StateD3, StateD2))
+}
+
+type stubComplexityScorer struct{}
+
+func (stubComplexityScorer) ComplexityTotal(signalSources, decisionDepth, contextStates int) float64 {
+	return float64(signalSources)/100 + float64(decisionDepth)/10 + float64(contextStates)
+}
+
+func TestDecisionService_ComputesComplexity(t *testing.T) {
+	db := setupDecisionTestDB(t)
+	service := NewDecisionService(db, nil)
+	service.SetComplexityScorer(stubComplexityScorer{})
+	ctx := context.Background()
+
+	z1, err := service.CreatePreAlert(ctx, "Z1", "op_1", createTestSummary(t, db, "Z1").ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, z1.ContextStates)
+	assert.InDelta(t, 3.0/100+1.0/10+1, z1.ComplexityTotal, 1e-9)
+
+	// A second zone with a different mix of sources: 2 zones x 1 decision point x 2 combinations
+	summary := &model.AggregatedSummary{
+		ZoneID:      "Z3",
+		WindowStart: time.Now().Add(-time.Minute),
+		WindowEnd:   time.Now(),
+		SourceCount: model.JSONB{"infrastructure": 1, "crowd": 2, "staff": 0},
+	}
+	require.NoError(t, db.Create(summary).Error)
+	z3, err := service.CreatePreAlert(ctx, "Z3", "op_1", summary.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 4, z3.ContextStates)
+
+	// Every transition recomputes x_c: D0 and D1 are now both decision points
+	z3, err = service.TransitionState(ctx, z3.ID, StateD1, "op_1", 0)
+	require.NoError(t, err)
+	assert.Equal(t, 8, z3.ContextStates)
+	assert.Equal(t, 3, z3.SignalCount)
+	assert.InDelta(t, 3.0/100+2.0/10+8, z3.ComplexityTotal, 1e-9)
+
+	// Standing Z1 down leaves one active zone
+	z1, err = service.TransitionState(ctx, z1.ID, StateInactive, "op_1", 0)
+	require.NoError(t, err)
+	assert.Equal(t, 1, z1.ContextStates, "only Z3 and its source combination remain active")
+
+	// Only a zone's latest decision counts: Z2 escalated once but has since stood down
+	earlier := time.Now().Add(-time.Hour)
+	require.NoError(t, db.Create(&DecisionStateRecord{ID: "dec_z2_old", ZoneID: "Z2", CurrentState: string(StateD2), CreatedAt: earlier, UpdatedAt: earlier}).Error)
+	require.NoError(t, db.Create(&DecisionStateRecord{ID: "dec_z2_new", ZoneID: "Z2", CurrentState: string(StateInactive)}).Error)
+	z1, err = service.TransitionState(ctx, z1.ID, StateD0, "op_1", 0)
+	require.NoError(t, err)
+	assert.Equal(t, 4, z1.ContextStates, "Z1 and Z3 are active with one combination each")
+}
@@ -0,0 +1,279 @@
+package decision
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/erh-safety-system/poc/internal/model"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ShadowEvaluation records what a candidate policy would have proposed for a summary, next to the active policy
+type ShadowEvaluation struct {
+	ID              string    `gorm:"primaryKey;type:varchar(255)" json:"id"`
+	CandidateID     string    `gorm:"index:idx_shadow_candidate_zone,priority:1;type:varchar(100);not null" json:"candidate_id"`
+	ZoneID          string    `gorm:"index:idx_shadow_candidate_zone,priority:2;type:varchar(10);not null" json:"zone_id"`
+	SummaryID       string    `gorm:"type:varchar(255);not null" json:"summary_id"`
+	CurrentState    string    `gorm:"type:varchar(10);not null" json:"current_state"`
+	ActivePolicy    string    `gorm:"type:varchar(100);not null" json:"active_policy"`
+	ActiveTarget    string    `gorm:"type:varchar(10);not null" json:"active_target"`
+	CandidateTarget string    `gorm:"type:varchar(10);not null" json:"candidate_target"`
+	Diverged        bool      `gorm:"index" json:"diverged"`
+	CreatedAt       time.Time `gorm:"index;not null" json:"created_at"`
+}
+
+// TableName specifies the table name
+func (ShadowEvaluation) TableName() string {
+	return "shadow_evaluations"
+}
+
+// ShadowCandidate is a candidate policy document registered for shadow evaluation, kept so it survives restarts
+type ShadowCandidate struct {
+	ID           string     `gorm:"primaryKey;type:varchar(255)" json:"id"`
+	PolicyID     string     `gorm:"type:varchar(100);not null" json:"policy_id"`
+	Name         string     `gorm:"type:varchar(255);not null" json:"name"` // Its extension selects the document format
+	Document     string     `gorm:"type:text;not null" json:"document"`
+	RegisteredAt time.Time  `gorm:"index;not null" json:"registered_at"`
+	ClearedAt    *time.Time `gorm:"index" json:"cleared_at,omitempty"` // Set when replaced or cleared
+}
+
+// TableName specifies the table name
+func (ShadowCandidate) TableName() string {
+	return "shadow_candidates"
+}
+
+// ZoneDivergence summarizes how often a candidate policy disagreed with the active policy in one zone
+type ZoneDivergence struct {
+	ZoneID          string           `json:"zone_id"`
+	Evaluations     int64            `json:"evaluations"`
+	Divergences     int64            `json:"divergences"`
+	DivergenceRate  float64          `json:"divergence_rate"`
+	CandidateDeeper int64            `json:"candidate_deeper"` // Candidate proposed a deeper state than the active policy
+	CandidateLower  int64            `json:"candidate_lower"`  // Candidate proposed a less severe state
+	Pairs           map[string]int64 `json:"pairs"`            // "active->candidate" target states of diverging evaluations
+}
+
+// ShadowEvaluator runs a candidate policy next to the active one without acting on its proposals
+type ShadowEvaluator struct {
+	db        *gorm.DB
+	evaluator *DecisionEvaluator
+	decisions *DecisionService
+
+	mu           sync.RWMutex
+	candidate    *Policy
+	registeredAt time.Time
+}
+
+// NewShadowEvaluator creates a new shadow evaluator
+func NewShadowEvaluator(db *gorm.DB, evaluator *DecisionEvaluator, decisions *DecisionService) *ShadowEvaluator {
+	return &ShadowEvaluator{
+		db:        db,
+		evaluator: evaluator,
+		decisions: decisions,
+	}
+}
+
+// RegisterCandidate validates a policy document and evaluates it in shadow mode for its zones from now on,
+// replacing any previous candidate
+func (s *ShadowEvaluator) RegisterCandidate(ctx context.Context, name string, data []byte) (*Policy, error) {
+	policy, err := ParsePolicy(name, data, s.evaluator.Thresholds())
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	record := &ShadowCandidate{
+		ID:           fmt.Sprintf("shc_%s", uuid.New().String()),
+		PolicyID:     policy.ID(),
+		Name:         name,
+		Document:     string(data),
+		RegisteredAt: now,
+	}
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := clearCandidates(tx, now); err != nil {
+			return err
+		}
+		if err := tx.Create(record).Error; err != nil {
+			return fmt.Errorf("failed to save candidate policy: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.candidate = policy
+	s.registeredAt = now
+	return policy, nil
+}
+
+// ClearCandidate stops shadow evaluation. Recorded evaluations are kept.
+func (s *ShadowEvaluator) ClearCandidate(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := clearCandidates(s.db.WithContext(ctx), time.Now()); err != nil {
+		return err
+	}
+	s.candidate = nil
+	return nil
+}
+
+// LoadCandidate resumes shadow evaluation of the candidate registered before a restart, if any.
+// The candidate is validated again against the current thresholds; one that no longer fits is not resumed.
+func (s *ShadowEvaluator) LoadCandidate(ctx context.Context) (*Policy, error) {
+	var record ShadowCandidate
+	err := s.db.WithContext(ctx).
+		Where("cleared_at IS NULL").
+		Order("registered_at DESC").
+		First(&record).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get candidate policy: %w", err)
+	}
+
+	policy, err := ParsePolicy(record.Name, []byte(record.Document), s.evaluator.Thresholds())
+	if err != nil {
+		return nil, fmt.Errorf("candidate policy %s is no longer valid: %w", record.PolicyID, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.candidate = policy
+	s.registeredAt = record.RegisteredAt
+	return policy, nil
+}
+
+// clearCandidates marks every active candidate document as cleared
+func clearCandidates(tx *gorm.DB, at time.Time) error {
+	if err := tx.Model(&ShadowCandidate{}).
+		Where("cleared_at IS NULL").
+		Update("cleared_at", at).Error; err != nil {
+		return fmt.Errorf("failed to clear candidate policy: %w", err)
+	}
+	return nil
+}
+
+// Candidate returns the current candidate and when it was registered, or nil
+func (s *ShadowEvaluator) Candidate() (*Policy, time.Time) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.candidate, s.registeredAt
+}
+
+// Observe evaluates a new summary in shadow mode. It matches aggregation.SummaryObserver and never fails the caller.
+func (s *ShadowEvaluator) Observe(ctx context.Context, summary *model.AggregatedSummary) {
+	if _, err := s.Evaluate(ctx, summary); err != nil {
+		log.Printf("Shadow evaluation failed for summary %s: %v", summary.ID, err)
+	}
+}
+
+// Evaluate records what the active and candidate policies would propose for a summary.
+// It returns nil if there is no candidate or the candidate does not cover the summary's zone.
+func (s *ShadowEvaluator) Evaluate(ctx context.Context, summary *model.AggregatedSummary) (*ShadowEvaluation, error) {
+	candidate, _ := s.Candidate()
+	if candidate == nil || !containsString(candidate.Zones, summary.ZoneID) {
+		return nil, nil
+	}
+
+	currentState := StateInactive
+	latest, err := s.decisions.GetLatestState(ctx, summary.ZoneID)
+	if err != nil {
+		return nil, err
+	}
+	if latest != nil {
+		currentState = DecisionState(latest.CurrentState)
+	}
+
+	active := s.evaluator.EvaluateSummary(ctx, summary, currentState)
+	proposed := s.evaluator.EvaluateWithPolicy(ctx, summary, currentState, candidate)
+
+	evaluation := &ShadowEvaluation{
+		ID:              fmt.Sprintf("shd_%s", uuid.New().String()),
+		CandidateID:     candidate.ID(),
+		ZoneID:          summary.ZoneID,
+		SummaryID:       summary.ID,
+		CurrentState:    string(currentState),
+		ActivePolicy:    active.PolicyVersion,
+		ActiveTarget:    string(active.TargetState),
+		CandidateTarget: string(proposed.TargetState),
+		Diverged:        active.TargetState != proposed.TargetState,
+		CreatedAt:       time.Now(),
+	}
+
+	if err := s.db.WithContext(ctx).Create(evaluation).Error; err != nil {
+		return nil, fmt.Errorf("failed to record shadow evaluation: %w", err)
+	}
+
+	return evaluation, nil
+}
+
+// Divergence returns per-zone divergence statistics for a candidate since the given time
+func (s *ShadowEvaluator) Divergence(ctx context.Context, candidateID string, since time.Time) ([]*ZoneDivergence, error) {
+	var rows []struct {
+		ZoneID          string
+		ActiveTarget    string
+		CandidateTarget string
+		Count           int64
+	}
+	if err := s.db.WithContext(ctx).
+		Model(&ShadowEvaluation{}).
+		Select("zone_id, active_target, candidate_target, COUNT(*) AS count").
+		Where("candidate_id = ? AND created_at >= ?", candidateID, since).
+		Group("zone_id, active_target, candidate_target").
+		Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to get shadow evaluations: %w", err)
+	}
+
+	zones := make(map[string]*ZoneDivergence)
+	for _, row := range rows {
+		zone, exists := zones[row.ZoneID]
+		if !exists {
+			zone = &ZoneDivergence{ZoneID: row.ZoneID, Pairs: make(map[string]int64)}
+			zones[row.ZoneID] = zone
+		}
+
+		zone.Evaluations += row.Count
+		if row.ActiveTarget == row.CandidateTarget {
+			continue
+		}
+		zone.Divergences += row.Count
+		zone.Pairs[row.ActiveTarget+"->"+row.CandidateTarget] += row.Count
+		candidate, active := DecisionState(row.CandidateTarget).Severity(), DecisionState(row.ActiveTarget).Severity()
+		if candidate > active {
+			zone.CandidateDeeper += row.Count
+		} else if candidate < active {
+			zone.CandidateLower += row.Count
+		}
+	}
+
+	result := make([]*ZoneDivergence, 0, len(zones))
+	for _, zone := range zones {
+		zone.DivergenceRate = float64(zone.Divergences) / float64(zone.Evaluations)
+		result = append(result, zone)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].ZoneID < result[j].ZoneID
+	})
+
+	return result, nil
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,101 @@
+package decision
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/erh-safety-system/poc/internal/config"
+	"github.com/erh-safety-system/poc/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShadowEvaluator_RecordsDivergence(t *testing.T) {
+	db := setupDecisionTestDB(t)
+	require.NoError(t, db.AutoMigrate(&ShadowEvaluation{}, &ShadowCandidate{}))
+	evaluator := NewDecisionEvaluator(&config.Load().Evaluator, db, nil)
+	shadow := NewShadowEvaluator(db, evaluator, NewDecisionService(db, evaluator))
+	ctx := context.Background()
+
+	summary := func(zoneID string, confidence float64) *model.AggregatedSummary {
+		now := time.Now()
+		s := &model.AggregatedSummary{
+			ZoneID:      zoneID,
+			WindowStart: now.Add(-time.Minute),
+			WindowEnd:   now,
+			SourceCount: model.JSONB{"infrastructure": 2.0, "staff": 1.0},
+			Confidence:  confidence,
+		}
+		require.NoError(t, db.Create(s).Error)
+		return s
+	}
+
+	// Nothing is recorded without a candidate
+	evaluation, err := shadow.Evaluate(ctx, summary("Z4", 0.35))
+	require.NoError(t, err)
+	assert.Nil(t, evaluation)
+
+	// The candidate pre-alerts earlier than the active thresholds
+	_, err = shadow.RegisterCandidate(ctx, "candidate.yaml", []byte("zone_type: open_plaza\nversion: 2\nzones: [Z4]\n"))
+	assert.Error(t, err, "the candidate is validated")
+	candidate, err := shadow.RegisterCandidate(ctx, "candidate.yaml", []byte(`
+zone_type: open_plaza
+version: 2
+zones: [Z4]
+transitions:
+  inactive: [D0]
+  D0: [D1, inactive]
+  D1: [D0, inactive]
+thresholds:
+  pre_alert_confidence: 0.3
+  pressured_pre_alert_confidence: 0.2
+`))
+	require.NoError(t, err)
+
+	evaluation, err = shadow.Evaluate(ctx, summary("Z4", 0.35))
+	require.NoError(t, err)
+	assert.Equal(t, "builtin@v1", evaluation.ActivePolicy)
+	assert.Equal(t, string(StateInactive), evaluation.ActiveTarget)
+	assert.Equal(t, string(StateD0), evaluation.CandidateTarget)
+	assert.True(t, evaluation.Diverged)
+
+	evaluation, err = shadow.Evaluate(ctx, summary("Z4", 0.1))
+	require.NoError(t, err)
+	assert.False(t, evaluation.Diverged)
+
+	// Zones outside the candidate are not evaluated
+	evaluation, err = shadow.Evaluate(ctx, summary("Z1", 0.35))
+	require.NoError(t, err)
+	assert.Nil(t, evaluation)
+
+	// Shadow evaluation never acts
+	latest, err := shadow.decisions.GetLatestState(ctx, "Z4")
+	require.NoError(t, err)
+	assert.Nil(t, latest)
+
+	zones, err := shadow.Divergence(ctx, candidate.ID(), time.Time{})
+	require.NoError(t, err)
+	require.Len(t, zones, 1)
+	assert.Equal(t, "Z4", zones[0].ZoneID)
+	assert.Equal(t, int64(2), zones[0].Evaluations)
+	assert.Equal(t, int64(1), zones[0].Divergences)
+	assert.Equal(t, 0.5, zones[0].DivergenceRate)
+	assert.Equal(t, int64(1), zones[0].CandidateDeeper)
+	assert.Equal(t, map[string]int64{"inactive->D0": 1}, zones[0].Pairs)
+
+	// The candidate survives a restart until it is cleared
+	_, registeredAt := shadow.Candidate()
+	restarted := NewShadowEvaluator(db, evaluator, shadow.decisions)
+	resumed, err := restarted.LoadCandidate(ctx)
+	require.NoError(t, err)
+	require.NotNil(t, resumed)
+	assert.Equal(t, candidate.ID(), resumed.ID())
+	_, resumedAt := restarted.Candidate()
+	assert.True(t, registeredAt.Equal(resumedAt))
+
+	require.NoError(t, restarted.ClearCandidate(ctx))
+	resumed, err = NewShadowEvaluator(db, evaluator, shadow.decisions).LoadCandidate(ctx)
+	require.NoError(t, err)
+	assert.Nil(t, resumed)
+}
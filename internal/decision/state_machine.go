@@ -40,6 +40,14 @@ func (s DecisionState) DecisionDepth() int {
 	}
 }
 
+// Severity orders states by how far a zone has escalated; inactive and D6 (stood down) rank below D0
+func (s DecisionState) Severity() int {
+	if s == StateInactive || s == StateD6 {
+		return 0
+	}
+	return s.DecisionDepth()
+}
+
 // IsHighImpact returns whether the state requires high-impact controls
 func (s DecisionState) IsHighImpact() bool {
 	return s == StateD3 || s == StateD4 || s == StateD5
@@ -70,22 +78,32 @@ type StateMachine struct {
 	validTransitions map[DecisionState][]DecisionState
 }
 
-// NewStateMachine creates a new state machine
+// NewStateMachine creates a new state machine with the built-in transition table
 func NewStateMachine() *StateMachine {
 	return &StateMachine{
-		validTransitions: map[DecisionState][]DecisionState{
-			StateInactive: {StateD0},
-			StateD0:       {StateD1, StateD3, StateD4, StateD5, StateInactive}, // Can skip to high-impact with approval
-			StateD1:       {StateD2, StateD3, StateD4, StateD5, StateD0, StateInactive},
-			StateD2:       {StateD3, StateD4, StateD5, StateD1, StateD0, StateInactive},
-			StateD3:       {StateD4, StateD5, StateD2, StateD1, StateD0, StateD6, StateInactive},
-			StateD4:       {StateD5, StateD3, StateD2, StateD1, StateD0, StateD6, StateInactive},
-			StateD5:       {StateD6, StateD4, StateD3, StateD2, StateD1, StateD0, StateInactive},
-			StateD6:       {StateInactive, StateD0}, // After de-escalation, can go back to monitoring
-		},
+		validTransitions: defaultTransitions(),
 	}
 }
 
+// defaultTransitions returns the built-in transition table used when a zone has no policy document
+func defaultTransitions() map[DecisionState][]DecisionState {
+	return map[DecisionState][]DecisionState{
+		StateInactive: {StateD0},
+		StateD0:       {StateD1, StateD3, StateD4, StateD5, StateInactive}, // Can skip to high-impact with approval
+		StateD1:       {StateD2, StateD3, StateD4, StateD5, StateD0, StateInactive},
+		StateD2:       {StateD3, StateD4, StateD5, StateD1, StateD0, StateInactive},
+		StateD3:       {StateD4, StateD5, StateD2, StateD1, StateD0, StateD6, StateInactive},
+		StateD4:       {StateD5, StateD3, StateD2, StateD1, StateD0, StateD6, StateInactive},
+		StateD5:       {StateD6, StateD4, StateD3, StateD2, StateD1, StateD0, StateInactive},
+		StateD6:       {StateInactive, StateD0}, // After de-escalation, can go back to monitoring
+	}
+}
+
+// Targets returns the states reachable from current in one transition
+func (sm *StateMachine) Targets(current DecisionState) []DecisionState {
+	return sm.validTransitions[current]
+}
+
 // CanTransition checks if a transition from current to target state is valid
 func (sm *StateMachine) CanTransition(current, target DecisionState) bool {
 	allowed, exists := sm.validTransitions[current]
@@ -121,6 +139,12 @@ type DecisionStateRecord struct {
 	DecisionDepth       int           `json:"decision_depth"` // x_d
 	ContextStates       int           `json:"context_states"` // x_c
 	ComplexityTotal     float64       `json:"complexity_total"` // x_total
+	PolicyVersion       string        `gorm:"type:varchar(100)" json:"policy_version"` // Policy the latest transition was made under
+	Version             int           `gorm:"not null;default:1" json:"version"` // Incremented on every transition (optimistic locking)
+	FirstSignalAt       *time.Time    `json:"first_signal_at,omitempty"` // Earliest signal behind the pre-alert
+	AcknowledgedBy      string        `gorm:"type:varchar(255)" json:"acknowledged_by,omitempty"`
+	AcknowledgedAt      *time.Time    `gorm:"index" json:"acknowledged_at,omitempty"`
+	TimeToAcknowledge   *float64      `json:"time_to_acknowledge,omitempty"` // TTA in seconds, from the first signal (docs/09)
 	CreatedAt           time.Time     `gorm:"autoCreateTime" json:"created_at"`
 	UpdatedAt           time.Time     `gorm:"autoUpdateTime" json:"updated_at"`
 }
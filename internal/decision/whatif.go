@@ -0,0 +1,152 @@
+package decision
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/erh-safety-system/poc/internal/model"
+)
+
+// WhatIfScenario describes hypothetical changes to a zone's current window
+type WhatIfScenario struct {
+	ZoneID          string
+	CurrentState    DecisionState   // Defaults to the zone's latest decision state
+	Add             []*model.Signal // Hypothetical signals; IDs, zone and timestamps are filled in
+	RemoveSignalIDs []string
+	RemoveSourceIDs []string // e.g. a sensor the operator suspects is faulty
+}
+
+// ApprovalRequirements lists what the proposed transition would need before it takes effect
+type ApprovalRequirements struct {
+	Required            bool            `json:"required"`             // The policy gates the transition behind an approval
+	Quorum              *ApprovalQuorum `json:"quorum,omitempty"`     // Approvers and roles the zone's quorum requires
+	CorroborationNeeded int             `json:"corroboration_needed"` // Independent source types the policy requires
+}
+
+// ComplexitySnapshot holds the ERH complexity inputs and total for one state of a zone
+type ComplexitySnapshot struct {
+	SignalSources   int     `json:"signal_sources"` // x_s
+	DecisionDepth   int     `json:"decision_depth"` // x_d
+	ContextStates   int     `json:"context_states"` // x_c
+	ComplexityTotal float64 `json:"complexity_total"`
+}
+
+// WhatIfResult is the outcome of a dry-run evaluation. Nothing it describes has been stored.
+type WhatIfResult struct {
+	ZoneID          string                   `json:"zone_id"`
+	CurrentState    DecisionState            `json:"current_state"`
+	TargetState     DecisionState            `json:"target_state"`
+	Evaluation      *EvaluationResult        `json:"evaluation"`
+	Summary         *model.AggregatedSummary `json:"summary"`
+	Approval        ApprovalRequirements     `json:"approval"`
+	Complexity      ComplexitySnapshot       `json:"complexity"`
+	ComplexityAfter ComplexitySnapshot       `json:"complexity_after"`
+	ComplexityDelta ComplexitySnapshot       `json:"complexity_delta"`
+}
+
+// WhatIf aggregates the zone's current window with the scenario's changes and evaluates the result
+// in memory. No signals, summaries, evaluations or transitions are persisted.
+func (s *DecisionService) WhatIf(ctx context.Context, scenario *WhatIfScenario) (*WhatIfResult, error) {
+	if s.evaluator == nil || s.evaluator.aggEngine == nil {
+		return nil, fmt.Errorf("no evaluator configured")
+	}
+	engine := s.evaluator.aggEngine
+
+	window := engine.Window(scenario.ZoneID)
+	if window == 0 {
+		return nil, fmt.Errorf("unknown zone: %s", scenario.ZoneID)
+	}
+
+	// Simulate against the window of the latest summary so baselines and neighbours line up
+	actual, err := engine.GetLatestSummary(ctx, scenario.ZoneID)
+	if err != nil {
+		return nil, err
+	}
+	windowStart := time.Now().Add(-window)
+	if actual != nil {
+		windowStart = actual.WindowStart
+	}
+
+	current, err := s.GetLatestState(ctx, scenario.ZoneID)
+	if err != nil {
+		return nil, err
+	}
+	currentState := scenario.CurrentState
+	if currentState == "" {
+		currentState = StateInactive
+		if current != nil {
+			currentState = DecisionState(current.CurrentState)
+		}
+	}
+
+	added := make([]*model.Signal, 0, len(scenario.Add))
+	for i, signal := range scenario.Add {
+		hypothetical := *signal
+		hypothetical.ID = fmt.Sprintf("whatif_%d", i+1)
+		hypothetical.ZoneID = scenario.ZoneID
+		if hypothetical.Timestamp.IsZero() || hypothetical.Timestamp.Before(windowStart) || !hypothetical.Timestamp.Before(windowStart.Add(window)) {
+			hypothetical.Timestamp = windowStart.Add(window / 2)
+		}
+		added = append(added, &hypothetical)
+	}
+
+	summary, err := engine.Simulate(ctx, scenario.ZoneID, windowStart, added, scenario.RemoveSignalIDs, scenario.RemoveSourceIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	evaluation := s.evaluator.EvaluateSummary(ctx, summary, currentState)
+	policy := s.policies.For(scenario.ZoneID)
+
+	result := &WhatIfResult{
+		ZoneID:       scenario.ZoneID,
+		CurrentState: currentState,
+		TargetState:  evaluation.TargetState,
+		Evaluation:   evaluation,
+		Summary:      summary,
+		Approval: ApprovalRequirements{
+			Required:            evaluation.TargetState != currentState && policy.RequiresApproval(currentState, evaluation.TargetState),
+			Quorum:              evaluation.Quorum,
+			CorroborationNeeded: policy.MinSources(currentState),
+		},
+	}
+
+	before := actual
+	if before == nil {
+		before = &model.AggregatedSummary{ZoneID: scenario.ZoneID}
+	}
+	if result.Complexity, err = s.complexitySnapshot(current, scenario.ZoneID, currentState, before); err != nil {
+		return nil, err
+	}
+	if result.ComplexityAfter, err = s.complexitySnapshot(current, scenario.ZoneID, evaluation.TargetState, summary); err != nil {
+		return nil, err
+	}
+	result.ComplexityDelta = ComplexitySnapshot{
+		SignalSources:   result.ComplexityAfter.SignalSources - result.Complexity.SignalSources,
+		DecisionDepth:   result.ComplexityAfter.DecisionDepth - result.Complexity.DecisionDepth,
+		ContextStates:   result.ComplexityAfter.ContextStates - result.Complexity.ContextStates,
+		ComplexityTotal: result.ComplexityAfter.ComplexityTotal - result.Complexity.ComplexityTotal,
+	}
+	return result, nil
+}
+
+// complexitySnapshot computes the complexity a zone's decision would have in a state with a summary as evidence
+func (s *DecisionService) complexitySnapshot(current *DecisionStateRecord, zoneID string, state DecisionState, summary *model.AggregatedSummary) (ComplexitySnapshot, error) {
+	record := &DecisionStateRecord{ZoneID: zoneID}
+	if current != nil {
+		record.ID = current.ID
+	}
+	record.CurrentState = string(state)
+	record.SignalCount = s.countEffectiveSignals(summary)
+	record.DecisionDepth = state.DecisionDepth()
+	if err := s.updateComplexity(s.db, record, summary); err != nil {
+		return ComplexitySnapshot{}, err
+	}
+	return ComplexitySnapshot{
+		SignalSources:   record.SignalCount,
+		DecisionDepth:   record.DecisionDepth,
+		ContextStates:   record.ContextStates,
+		ComplexityTotal: record.ComplexityTotal,
+	}, nil
+}
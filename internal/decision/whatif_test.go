@@ -0,0 +1,92 @@
+package decision
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/erh-safety-system/poc/internal/aggregation"
+	"github.com/erh-safety-system/poc/internal/config"
+	"github.com/erh-safety-system/poc/internal/model"
+	"github.com/erh-safety-system/poc/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecisionService_WhatIf(t *testing.T) {
+	db := setupDecisionTestDB(t)
+	require.NoError(t, db.AutoMigrate(&model.Signal{}, &model.SignalBaseline{}, &EvaluationRecord{}))
+	cfg := config.Load()
+	engine := aggregation.NewAggregationEngine(&cfg.Aggregation, db, service.NewSignalService(db))
+	evaluator := NewDecisionEvaluator(&cfg.Evaluator, db, engine)
+	evaluator.SetQuorumSource(cfg.Gate.Quorum)
+	decisions := NewDecisionService(db, evaluator)
+	decisions.SetComplexityScorer(stubComplexityScorer{})
+	ctx := context.Background()
+
+	windowStart := time.Now().Add(-time.Minute)
+	require.NoError(t, db.Create(&model.Signal{
+		SourceType:   "infrastructure",
+		SourceID:     "sensor_1",
+		Timestamp:    windowStart.Add(time.Second),
+		ZoneID:       "Z1",
+		SignalType:   "occupancy",
+		QualityScore: 0.5,
+	}).Error)
+	summary, err := engine.Aggregate(ctx, "Z1", windowStart)
+	require.NoError(t, err)
+	state, err := decisions.CreatePreAlert(ctx, "Z1", "op_1", summary.ID)
+	require.NoError(t, err)
+
+	countRows := func() (signals, summaries, evaluations int64) {
+		db.Model(&model.Signal{}).Count(&signals)
+		db.Model(&model.AggregatedSummary{}).Count(&summaries)
+		db.Model(&EvaluationRecord{}).Count(&evaluations)
+		return
+	}
+	signalsBefore, summariesBefore, evaluationsBefore := countRows()
+
+	// A confirmed staff report and two more sensors corroborate the pre-alert
+	result, err := decisions.WhatIf(ctx, &WhatIfScenario{
+		ZoneID: "Z1",
+		Add: []*model.Signal{
+			{SourceType: "staff", SourceID: "staff_7", SignalType: "observation", QualityScore: 0.9},
+			{SourceType: "infrastructure", SourceID: "sensor_2", SignalType: "occupancy", QualityScore: 0.9},
+			{SourceType: "infrastructure", SourceID: "sensor_3", SignalType: "occupancy", QualityScore: 0.9},
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, StateD0, result.CurrentState)
+	assert.True(t, result.Evaluation.CorroborationSufficient)
+	assert.Equal(t, 2, result.Approval.CorroborationNeeded)
+	assert.Equal(t, 4, result.ComplexityAfter.SignalSources)
+	assert.Equal(t, 1, result.Complexity.SignalSources)
+	assert.Equal(t, 3, result.ComplexityDelta.SignalSources)
+	assert.Equal(t, StateD3, result.TargetState)
+	assert.True(t, result.Approval.Required)
+	require.NotNil(t, result.Approval.Quorum)
+	assert.Equal(t, cfg.Gate.Quorum("Z1", "D3").Required, result.Approval.Quorum.Required, "the zone's configured quorum is reported")
+	assert.Equal(t, cfg.Gate.Quorum("Z1", "D3").Roles, result.Approval.Quorum.Roles)
+	assert.Equal(t, 3, result.ComplexityDelta.DecisionDepth)
+	assert.Equal(t, 1, result.ComplexityDelta.ContextStates, "D3 becomes a second decision point")
+	assert.InDelta(t, 3.0/100+3.0/10+1, result.ComplexityDelta.ComplexityTotal, 1e-9)
+
+	// Removing the only real sensor leaves nothing to corroborate
+	result, err = decisions.WhatIf(ctx, &WhatIfScenario{ZoneID: "Z1", RemoveSourceIDs: []string{"sensor_1"}})
+	require.NoError(t, err)
+	assert.Equal(t, 0, result.ComplexityAfter.SignalSources)
+	assert.False(t, result.Evaluation.CorroborationSufficient)
+	assert.Equal(t, RuleUncorroborated, result.Evaluation.Explanation.Rule)
+
+	// Nothing was stored and the decision is untouched
+	signalsAfter, summariesAfter, evaluationsAfter := countRows()
+	assert.Equal(t, signalsBefore, signalsAfter)
+	assert.Equal(t, summariesBefore, summariesAfter)
+	assert.Equal(t, evaluationsBefore, evaluationsAfter)
+	current, err := decisions.GetDecision(ctx, state.ID)
+	require.NoError(t, err)
+	assert.Equal(t, state.Version, current.Version)
+
+	_, err = decisions.WhatIf(ctx, &WhatIfScenario{ZoneID: "Z9"})
+	assert.Error(t, err)
+}
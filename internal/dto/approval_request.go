@@ -1,5 +1,7 @@
 package dto
 
+import "time"
+
 // ApprovalRequestCreate represents a request to create an approval request
 type ApprovalRequestCreate struct {
 	ActionType string                 `json:"action_type" binding:"required,oneof=D3 D4 D5"`
@@ -9,12 +11,21 @@ type ApprovalRequestCreate struct {
 
 // ApprovalRequestApprove represents a request to approve
 type ApprovalRequestApprove struct {
-	// No additional fields needed, approver ID comes from auth context
+	// Approver ID comes from auth context
+	Role            string `json:"role"`                                       // Role the approver acts in; optional when approver profiles are enforced
+	ExpectedVersion int    `json:"expected_version" binding:"omitempty,min=1"` // Version the approver reviewed
+}
+
+// ApprovalTTLExtension represents a request to extend the TTL of an action in effect
+type ApprovalTTLExtension struct {
+	TTLSeconds int    `json:"ttl_seconds" binding:"required,min=1"` // New TTL counted from when the action took effect
+	Reason     string `json:"reason" binding:"required"`
 }
 
 // ApprovalRequestReject represents a request to reject
 type ApprovalRequestReject struct {
-	Reason string `json:"reason" binding:"required"`
+	Reason          string `json:"reason" binding:"required"`
+	ExpectedVersion int    `json:"expected_version" binding:"omitempty,min=1"` // Version the rejector reviewed
 }
 
 // KeepaliveRequest represents a keepalive signal
@@ -22,3 +33,15 @@ type KeepaliveRequest struct {
 	ActionID string `json:"action_id" binding:"required"`
 }
 
+// ApproverProfileUpsert represents a request to create or replace an approver profile
+type ApproverProfileUpsert struct {
+	Name   string   `json:"name"`
+	Role   string   `json:"role" binding:"required"`
+	Zones  []string `json:"zones" binding:"omitempty,dive,oneof=Z1 Z2 Z3 Z4"` // Empty means every zone
+	Active *bool    `json:"active"`                                           // Defaults to true
+}
+
+// ShiftStart represents a request to put an approver on shift
+type ShiftStart struct {
+	Until *time.Time `json:"until"` // Open-ended when omitted
+}
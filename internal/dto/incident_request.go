@@ -0,0 +1,25 @@
+package dto
+
+// IncidentCreate represents a request to open an incident
+type IncidentCreate struct {
+	Title       string `json:"title" binding:"required"`
+	Description string `json:"description"`
+	CommanderID string `json:"commander_id"` // Defaults to the operator opening the incident
+}
+
+// IncidentStatusUpdate represents a request to move an incident through its lifecycle
+type IncidentStatusUpdate struct {
+	Status string `json:"status" binding:"required,oneof=open contained closed"`
+}
+
+// IncidentCommanderAssign represents a request to assign the incident commander
+type IncidentCommanderAssign struct {
+	CommanderID string `json:"commander_id" binding:"required"`
+}
+
+// IncidentLinkCreate represents a request to link an item to an incident
+type IncidentLinkCreate struct {
+	ItemType string `json:"item_type" binding:"required,oneof=decision approval cap_message assistance_request signal"`
+	ItemID   string `json:"item_id" binding:"required"`
+	Note     string `json:"note"`
+}
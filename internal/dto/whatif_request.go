@@ -0,0 +1,21 @@
+package dto
+
+import "time"
+
+// WhatIfRequest represents hypothetical changes to a zone's current window for a dry-run evaluation
+type WhatIfRequest struct {
+	CurrentState    string         `json:"current_state" binding:"omitempty,oneof=inactive D0 D1 D2 D3 D4 D5 D6"` // Defaults to the zone's decision state
+	Add             []WhatIfSignal `json:"add" binding:"omitempty,dive"`
+	RemoveSignalIDs []string       `json:"remove_signal_ids"`
+	RemoveSourceIDs []string       `json:"remove_source_ids"`
+}
+
+// WhatIfSignal represents a hypothetical signal
+type WhatIfSignal struct {
+	SourceType   string                 `json:"source_type" binding:"required,oneof=infrastructure staff crowd emergency"`
+	SourceID     string                 `json:"source_id" binding:"required"`
+	SignalType   string                 `json:"signal_type"`
+	Value        map[string]interface{} `json:"value"`
+	QualityScore float64                `json:"quality_score" binding:"required,min=0,max=1"`
+	Timestamp    *time.Time             `json:"timestamp"` // Defaults to the middle of the window
+}
@@ -75,3 +75,8 @@ func (c *ComplexityCalculator) GetComplexityLevel(xTotal float64) string {
 	return "very_high"
 }
 
+// ComplexityTotal returns x_total for the given components (decision.ComplexityScorer)
+func (c *ComplexityCalculator) ComplexityTotal(signalSources, decisionDepth, contextStates int) float64 {
+	return c.CalculateComplexity(signalSources, decisionDepth, contextStates).ComplexityTotal
+}
+
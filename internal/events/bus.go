@@ -0,0 +1,99 @@
+package events
+
+import (
+	"log"
+	"sync"
+	"time"
+)
+
+// Type identifies what happened
+type Type string
+
+const (
+	ApprovalRequested   Type = "approval.requested"   // A high-impact action was proposed and awaits approval
+	ApprovalVoted       Type = "approval.voted"       // An approver approved a request
+	ApprovalApplied     Type = "approval.applied"     // A fully approved action took effect on its decision
+	ApprovalInvalidated Type = "approval.invalidated" // A fully approved action was not applied because the zone changed
+	ApprovalExpired     Type = "approval.expired"     // A pending request passed its expiry without reaching quorum
+	OperatorNotified    Type = "operator.notified"    // A notification was shown on operator consoles
+)
+
+// Event is a notification about something that happened in the system
+type Event struct {
+	Type       Type                   `json:"type"`
+	ZoneID     string                 `json:"zone_id"`
+	SubjectID  string                 `json:"subject_id"` // ID of the record the event is about
+	Payload    map[string]interface{} `json:"payload,omitempty"`
+	OccurredAt time.Time              `json:"occurred_at"`
+}
+
+// Handler receives published events
+type Handler func(Event)
+
+// Bus is an in-process publish/subscribe event bus.
+// Handlers run synchronously on the publishing goroutine and must not block.
+type Bus struct {
+	mu       sync.RWMutex
+	handlers []subscription
+	nextID   int
+}
+
+// subscription is a registered handler
+type subscription struct {
+	id      int
+	handler Handler
+}
+
+// NewBus creates a new event bus
+func NewBus() *Bus {
+	return &Bus{}
+}
+
+// Subscribe registers a handler for all events and returns a function that removes it
+func (b *Bus) Subscribe(handler Handler) func() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.nextID++
+	id := b.nextID
+	b.handlers = append(b.handlers, subscription{id: id, handler: handler})
+
+	return func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		for i, sub := range b.handlers {
+			if sub.id == id {
+				b.handlers = append(b.handlers[:i:i], b.handlers[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// Publish delivers an event to every handler. A nil bus discards events.
+func (b *Bus) Publish(event Event) {
+	if b == nil {
+		return
+	}
+	if event.OccurredAt.IsZero() {
+		event.OccurredAt = time.Now()
+	}
+
+	b.mu.RLock()
+	handlers := make([]subscription, len(b.handlers))
+	copy(handlers, b.handlers)
+	b.mu.RUnlock()
+
+	for _, sub := range handlers {
+		b.deliver(sub.handler, event)
+	}
+}
+
+// deliver calls a handler, isolating the publisher from handler panics
+func (b *Bus) deliver(handler Handler, event Event) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Event handler panicked on %s: %v", event.Type, r)
+		}
+	}()
+	handler(event)
+}
@@ -0,0 +1,63 @@
+package gate
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/erh-safety-system/poc/internal/decision"
+	"github.com/erh-safety-system/poc/internal/model"
+	"gorm.io/gorm"
+)
+
+// ApprovalGate enforces that high-impact transitions consume a valid approval
+type ApprovalGate struct {
+	ttlManager *TTLManager
+}
+
+// NewApprovalGate creates a new approval gate
+func NewApprovalGate(ttlManager *TTLManager) *ApprovalGate {
+	return &ApprovalGate{
+		ttlManager: ttlManager,
+	}
+}
+
+// ConsumeApproval binds an approved, unexpired and unconsumed approval to a decision and starts its TTL
+func (g *ApprovalGate) ConsumeApproval(ctx context.Context, tx *gorm.DB, decisionID, zoneID string, target decision.DecisionState, approvalID string) (string, error) {
+	now := time.Now()
+	actionType := string(target)
+
+	var request model.ApprovalRequest
+	query := tx.WithContext(ctx).
+		Preload("Votes").
+		Where("kind = ? AND zone_id = ? AND action_type = ? AND status = ? AND consumed_at IS NULL", model.ApprovalKindAction, zoneID, actionType, "approved").
+		Where("expires_at IS NULL OR expires_at > ?", now)
+	if approvalID != "" {
+		query = query.Where("id = ?", approvalID)
+	}
+
+	err := query.Order("approved_at DESC").First(&request).Error
+	if err == gorm.ErrRecordNotFound {
+		return "", fmt.Errorf("%w: no valid %s approval for zone %s", decision.ErrMissingApproval, actionType, zoneID)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get approval request: %w", err)
+	}
+
+	if !request.IsFullyApproved() {
+		return "", fmt.Errorf("%w: approval %s is not fully approved", decision.ErrMissingApproval, request.ID)
+	}
+
+	// Bind the approval to the decision; the version check stops it being consumed twice
+	request.DecisionID = &decisionID
+	request.ConsumedAt = &now
+	if err := saveApproval(tx.WithContext(ctx), &request); err != nil {
+		return "", fmt.Errorf("failed to consume approval: %w", err)
+	}
+
+	if err := g.ttlManager.WithDB(tx).SetTTL(ctx, request.ID, actionType, nil); err != nil {
+		return "", fmt.Errorf("failed to start TTL: %w", err)
+	}
+
+	return request.ID, nil
+}
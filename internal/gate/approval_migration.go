@@ -0,0 +1,143 @@
+package gate
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/erh-safety-system/poc/internal/model"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// legacyApproverColumns are the fixed approver slots approval requests had before votes
+var legacyApproverColumns = []string{"approver1_id", "approver2_id", "approver3_id"}
+
+// legacyKeepaliveColumns are the per-slot keepalive timestamps keepalive sessions had before votes
+var legacyKeepaliveColumns = []string{"approver1_last_keepalive", "approver2_last_keepalive", "approver3_last_keepalive"}
+
+// legacyApproval is an approval request as stored with fixed approver slots
+type legacyApproval struct {
+	ID          string
+	ActionType  string
+	Approver1ID *string
+	Approver2ID *string
+	Approver3ID *string
+	ApprovedAt  *time.Time
+	CreatedAt   time.Time
+}
+
+// legacyKeepalive is a keepalive session as stored with per-slot timestamps
+type legacyKeepalive struct {
+	ActionID               string
+	Approver1LastKeepalive *time.Time
+	Approver2LastKeepalive *time.Time
+	Approver3LastKeepalive *time.Time
+}
+
+// legacyRequiredApprovals is the fixed quorum requests were created with before quorum policies: D4 needed three approvers
+func legacyRequiredApprovals(actionType string) int {
+	if actionType == "D4" {
+		return 3
+	}
+	return 2
+}
+
+// BackfillApprovalVotes upgrades approval requests stored with fixed approver slots: each filled slot becomes a vote
+// carrying the slot's last keepalive, and the request's quorum is set to the fixed one it was created under.
+// The slot columns are dropped afterwards, so the backfill only runs once. It returns the number of requests upgraded.
+func BackfillApprovalVotes(ctx context.Context, db *gorm.DB) (int, error) {
+	migrator := db.WithContext(ctx).Migrator()
+	if !migrator.HasColumn(&model.ApprovalRequest{}, legacyApproverColumns[0]) {
+		return 0, nil
+	}
+
+	var requests []legacyApproval
+	if err := db.WithContext(ctx).Table(model.ApprovalRequest{}.TableName()).
+		Select("id", "action_type", "approver1_id", "approver2_id", "approver3_id", "approved_at", "created_at").
+		Find(&requests).Error; err != nil {
+		return 0, fmt.Errorf("failed to get legacy approval requests: %w", err)
+	}
+
+	keepalives := make(map[string]legacyKeepalive)
+	if migrator.HasColumn(&model.KeepaliveSession{}, legacyKeepaliveColumns[0]) {
+		var sessions []legacyKeepalive
+		if err := db.WithContext(ctx).Table(model.KeepaliveSession{}.TableName()).
+			Select("action_id", "approver1_last_keepalive", "approver2_last_keepalive", "approver3_last_keepalive").
+			Find(&sessions).Error; err != nil {
+			return 0, fmt.Errorf("failed to get legacy keepalive sessions: %w", err)
+		}
+		for _, session := range sessions {
+			keepalives[session.ActionID] = session
+		}
+	}
+
+	err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, request := range requests {
+			session := keepalives[request.ID]
+			slots := []struct {
+				approverID    *string
+				lastKeepalive *time.Time
+			}{
+				{request.Approver1ID, session.Approver1LastKeepalive},
+				{request.Approver2ID, session.Approver2LastKeepalive},
+				{request.Approver3ID, session.Approver3LastKeepalive},
+			}
+
+			votedAt := request.CreatedAt
+			if request.ApprovedAt != nil {
+				votedAt = *request.ApprovedAt
+			}
+			for _, slot := range slots {
+				if slot.approverID == nil || *slot.approverID == "" {
+					continue
+				}
+				vote := &model.ApprovalVote{
+					ID:              fmt.Sprintf("vote_%s", uuid.New().String()),
+					ApprovalID:      request.ID,
+					ApproverID:      *slot.approverID,
+					CreatedAt:       votedAt,
+					LastKeepaliveAt: slot.lastKeepalive,
+				}
+				if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(vote).Error; err != nil {
+					return fmt.Errorf("failed to backfill vote of %s: %w", request.ID, err)
+				}
+			}
+
+			if err := tx.Model(&model.ApprovalRequest{}).
+				Where("id = ?", request.ID).
+				UpdateColumn("required_approvals", legacyRequiredApprovals(request.ActionType)).Error; err != nil {
+				return fmt.Errorf("failed to backfill quorum of %s: %w", request.ID, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	// Drop the slots once their votes are committed; a failure here re-runs the idempotent backfill on the next start
+	for _, column := range legacyApproverColumns {
+		if err := dropLegacyColumn(ctx, db, &model.ApprovalRequest{}, model.ApprovalRequest{}.TableName(), column); err != nil {
+			return len(requests), err
+		}
+	}
+	for _, column := range legacyKeepaliveColumns {
+		if err := dropLegacyColumn(ctx, db, &model.KeepaliveSession{}, model.KeepaliveSession{}.TableName(), column); err != nil {
+			return len(requests), err
+		}
+	}
+	return len(requests), nil
+}
+
+// dropLegacyColumn drops a column the model no longer declares, if it still exists
+func dropLegacyColumn(ctx context.Context, db *gorm.DB, value interface{}, table, column string) error {
+	if !db.WithContext(ctx).Migrator().HasColumn(value, column) {
+		return nil
+	}
+	if err := db.WithContext(ctx).Exec("ALTER TABLE ? DROP COLUMN ?", clause.Table{Name: table}, clause.Column{Name: column}).Error; err != nil {
+		return fmt.Errorf("failed to drop %s.%s: %w", table, column, err)
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package gate
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/erh-safety-system/poc/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackfillApprovalVotes(t *testing.T) {
+	db := setupGateTestDB(t)
+	require.NoError(t, db.AutoMigrate(&model.KeepaliveSession{}))
+	ctx := context.Background()
+
+	// Recreate the fixed approver slots of the schema before votes
+	for _, column := range legacyApproverColumns {
+		require.NoError(t, db.Exec("ALTER TABLE approval_requests ADD COLUMN "+column+" varchar(255)").Error)
+	}
+	for _, column := range legacyKeepaliveColumns {
+		require.NoError(t, db.Exec("ALTER TABLE keepalive_sessions ADD COLUMN "+column+" datetime").Error)
+	}
+
+	keepalive := time.Now().Add(-30 * time.Second).Truncate(time.Second)
+	require.NoError(t, db.Exec(`INSERT INTO approval_requests (id, kind, action_type, zone_id, requester_id, status, version, created_at, approver1_id, approver2_id)
+		VALUES ('approval_d4', 'action', 'D4', 'Z1', 'requester', 'pending', 2, ?, 'op_a', 'op_b')`, time.Now()).Error)
+	require.NoError(t, db.Exec(`INSERT INTO approval_requests (id, kind, action_type, zone_id, requester_id, status, version, created_at, approver1_id)
+		VALUES ('approval_d3', 'action', 'D3', 'Z1', 'requester', 'pending', 1, ?, 'op_a')`, time.Now()).Error)
+	require.NoError(t, db.Exec(`INSERT INTO keepalive_sessions (action_id, keepalive_interval, keepalive_timeout, created_at, approver2_last_keepalive)
+		VALUES ('approval_d4', 60, 120, ?, ?)`, time.Now(), keepalive).Error)
+
+	upgraded, err := BackfillApprovalVotes(ctx, db)
+	require.NoError(t, err)
+	assert.Equal(t, 2, upgraded)
+
+	service := NewApprovalService(db)
+	d4, err := service.GetApprovalRequest(ctx, "approval_d4")
+	require.NoError(t, err)
+	assert.Equal(t, 3, d4.RequiredApprovals, "strict approval keeps its third approver")
+	assert.Equal(t, 1, d4.RemainingApprovals())
+	require.Len(t, d4.Votes, 2)
+	for _, vote := range d4.Votes {
+		if vote.ApproverID == "op_b" {
+			require.NotNil(t, vote.LastKeepaliveAt)
+			assert.WithinDuration(t, keepalive, *vote.LastKeepaliveAt, time.Second)
+		} else {
+			assert.Nil(t, vote.LastKeepaliveAt)
+		}
+	}
+	assert.True(t, d4.HasVoted("op_a"))
+
+	d3, err := service.GetApprovalRequest(ctx, "approval_d3")
+	require.NoError(t, err)
+	assert.Equal(t, 2, d3.RequiredApprovals)
+	assert.Len(t, d3.Votes, 1)
+
+	// The slot columns are gone, so the backfill runs only once
+	assert.False(t, db.Migrator().HasColumn(&model.ApprovalRequest{}, "approver1_id"))
+	assert.False(t, db.Migrator().HasColumn(&model.KeepaliveSession{}, "approver2_last_keepalive"))
+	upgraded, err = BackfillApprovalVotes(ctx, db)
+	require.NoError(t, err)
+	assert.Zero(t, upgraded)
+}
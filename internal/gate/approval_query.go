@@ -0,0 +1,326 @@
+package gate
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/erh-safety-system/poc/internal/model"
+	"gorm.io/gorm"
+)
+
+const (
+	// DefaultApprovalPageSize is the page size when none is requested
+	DefaultApprovalPageSize = 50
+
+	// MaxApprovalPageSize caps the page size
+	MaxApprovalPageSize = 200
+)
+
+// ApprovalFilters selects approval requests. Zero values do not filter.
+type ApprovalFilters struct {
+	ZoneID         string
+	Status         string // pending|approved|rejected|expired|invalidated; pending excludes requests past expiry
+	ActionType     string
+	Kind           string        // action|ttl_extension
+	PendingFor     string        // Only pending requests this operator could approve now
+	ExpiringWithin time.Duration // Only pending requests expiring within this duration, soonest first
+	Limit          int
+	Offset         int
+}
+
+// ApprovalSummary is a compact view of where a request stands
+type ApprovalSummary struct {
+	ID                 string     `json:"id"`
+	Kind               string     `json:"kind"`
+	TargetID           *string    `json:"target_id,omitempty"` // Action a TTL extension extends
+	ActionType         string     `json:"action_type"`
+	ZoneID             string     `json:"zone_id"`
+	Status             string     `json:"status"`
+	RequesterID        string     `json:"requester_id"`
+	RequiredApprovals  int        `json:"required_approvals"`
+	Approvals          int        `json:"approvals"`
+	RemainingSlots     int        `json:"remaining_slots"`
+	MissingRoles       []string   `json:"missing_roles"`
+	Approvers          []string   `json:"approvers"`
+	Version            int        `json:"version"`
+	CreatedAt          time.Time  `json:"created_at"`
+	ExpiresAt          *time.Time `json:"expires_at"`
+	SecondsUntilExpiry *float64   `json:"seconds_until_expiry"` // Pending requests only
+}
+
+// ApprovalOverview counts requests by status and lists where the pending ones stand
+type ApprovalOverview struct {
+	ByStatus map[string]int64   `json:"by_status"`
+	Expiring int                `json:"expiring"` // Pending requests expiring within the overview's window
+	Pending  []*ApprovalSummary `json:"pending"`
+}
+
+// ApprovalMetrics describes how the requests for one action type were resolved
+type ApprovalMetrics struct {
+	ActionType           string  `json:"action_type"`
+	Requested            int     `json:"requested"`
+	Approved             int     `json:"approved"`
+	Rejected             int     `json:"rejected"`
+	Expired              int     `json:"expired"`
+	Invalidated          int     `json:"invalidated"`
+	Pending              int     `json:"pending"`
+	ExpiryRate           float64 `json:"expiry_rate"` // Share of resolved requests that expired
+	LatencyMeanSeconds   float64 `json:"latency_mean_seconds"`
+	LatencyMedianSeconds float64 `json:"latency_median_seconds"`
+	LatencyP95Seconds    float64 `json:"latency_p95_seconds"` // Creation to final approval, nearest rank
+}
+
+// ListApprovalRequests returns one page of matching requests, newest first, and the total number of matches
+func (s *ApprovalService) ListApprovalRequests(ctx context.Context, filters ApprovalFilters) ([]*model.ApprovalRequest, int64, error) {
+	limit := filters.Limit
+	if limit <= 0 {
+		limit = DefaultApprovalPageSize
+	}
+	if limit > MaxApprovalPageSize {
+		limit = MaxApprovalPageSize
+	}
+	offset := filters.Offset
+	if offset < 0 {
+		offset = 0
+	}
+
+	now := time.Now()
+	query := s.db.WithContext(ctx).Model(&model.ApprovalRequest{})
+	if filters.ZoneID != "" {
+		query = query.Where("zone_id = ?", filters.ZoneID)
+	}
+	if filters.ActionType != "" {
+		query = query.Where("action_type = ?", filters.ActionType)
+	}
+	if filters.Kind != "" {
+		query = query.Where("kind = ?", filters.Kind)
+	}
+	pendingOnly := filters.Status == "pending" || filters.PendingFor != "" || filters.ExpiringWithin > 0
+	if pendingOnly {
+		query = query.Where("status = ? AND (expires_at IS NULL OR expires_at > ?)", "pending", now)
+	} else if filters.Status != "" {
+		query = query.Where("status = ?", filters.Status)
+	}
+	if filters.ExpiringWithin > 0 {
+		query = query.Where("expires_at <= ?", now.Add(filters.ExpiringWithin)).Order("expires_at ASC")
+	}
+	if filters.PendingFor != "" {
+		query = query.
+			Where("requester_id <> ?", filters.PendingFor).
+			Where("id NOT IN (?)", s.db.Model(&model.ApprovalVote{}).Select("approval_id").Where("approver_id = ?", filters.PendingFor))
+	}
+	query = query.Order("created_at DESC")
+
+	preloadVotes := func(db *gorm.DB) *gorm.DB {
+		return db.Order("created_at ASC")
+	}
+
+	// Eligibility depends on profiles and exclusions, so "pending for me" is filtered and paged here
+	if filters.PendingFor != "" {
+		var candidates []*model.ApprovalRequest
+		if err := query.Preload("Votes", preloadVotes).Find(&candidates).Error; err != nil {
+			return nil, 0, fmt.Errorf("failed to list approval requests: %w", err)
+		}
+		eligible := make([]*model.ApprovalRequest, 0, len(candidates))
+		for _, request := range candidates {
+			if s.canApprove(ctx, request, filters.PendingFor) {
+				eligible = append(eligible, request)
+			}
+		}
+		total := int64(len(eligible))
+		if offset >= len(eligible) {
+			return []*model.ApprovalRequest{}, total, nil
+		}
+		end := offset + limit
+		if end > len(eligible) {
+			end = len(eligible)
+		}
+		return eligible[offset:end], total, nil
+	}
+
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count approval requests: %w", err)
+	}
+	var requests []*model.ApprovalRequest
+	if err := query.Preload("Votes", preloadVotes).Limit(limit).Offset(offset).Find(&requests).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to list approval requests: %w", err)
+	}
+	return requests, total, nil
+}
+
+// Overview counts requests by status and summarises pending requests, soonest to expire first
+func (s *ApprovalService) Overview(ctx context.Context, zoneID string, expiringWithin time.Duration) (*ApprovalOverview, error) {
+	type statusCount struct {
+		Status string
+		Count  int64
+	}
+	var counts []statusCount
+	query := s.db.WithContext(ctx).Model(&model.ApprovalRequest{})
+	if zoneID != "" {
+		query = query.Where("zone_id = ?", zoneID)
+	}
+	if err := query.Select("status, COUNT(*) AS count").Group("status").Scan(&counts).Error; err != nil {
+		return nil, fmt.Errorf("failed to count approval requests: %w", err)
+	}
+
+	overview := &ApprovalOverview{ByStatus: make(map[string]int64), Pending: make([]*ApprovalSummary, 0)}
+	for _, count := range counts {
+		overview.ByStatus[count.Status] = count.Count
+	}
+
+	// Pending requests past expiry are reported as expired even before they are swept
+	now := time.Now()
+	var stale int64
+	staleQuery := s.db.WithContext(ctx).Model(&model.ApprovalRequest{}).Where("status = ? AND expires_at <= ?", "pending", now)
+	if zoneID != "" {
+		staleQuery = staleQuery.Where("zone_id = ?", zoneID)
+	}
+	if err := staleQuery.Count(&stale).Error; err != nil {
+		return nil, fmt.Errorf("failed to count expired approval requests: %w", err)
+	}
+	if stale > 0 {
+		overview.ByStatus["pending"] -= stale
+		overview.ByStatus["expired"] += stale
+	}
+
+	pending, _, err := s.ListApprovalRequests(ctx, ApprovalFilters{ZoneID: zoneID, Status: "pending", Limit: MaxApprovalPageSize})
+	if err != nil {
+		return nil, err
+	}
+	for _, request := range pending {
+		summary := SummarizeApproval(request, now)
+		overview.Pending = append(overview.Pending, summary)
+		if summary.SecondsUntilExpiry != nil && *summary.SecondsUntilExpiry <= expiringWithin.Seconds() {
+			overview.Expiring++
+		}
+	}
+	sort.SliceStable(overview.Pending, func(i, j int) bool {
+		a, b := overview.Pending[i].SecondsUntilExpiry, overview.Pending[j].SecondsUntilExpiry
+		if a == nil || b == nil {
+			return b == nil && a != nil
+		}
+		return *a < *b
+	})
+	return overview, nil
+}
+
+// Metrics computes approval latency and expiry rate per action type for action requests created within
+// [since, until], optionally for one zone. Pending requests past expiry count as expired; TTL extensions are not counted.
+func (s *ApprovalService) Metrics(ctx context.Context, zoneID string, since, until time.Time) ([]*ApprovalMetrics, error) {
+	query := s.db.WithContext(ctx).Where("kind = ? AND created_at >= ? AND created_at <= ?", model.ApprovalKindAction, since, until)
+	if zoneID != "" {
+		query = query.Where("zone_id = ?", zoneID)
+	}
+
+	var requests []*model.ApprovalRequest
+	if err := query.Find(&requests).Error; err != nil {
+		return nil, fmt.Errorf("failed to get approval requests: %w", err)
+	}
+
+	byAction := make(map[string]*ApprovalMetrics)
+	latencies := make(map[string][]float64)
+	for _, request := range requests {
+		metrics, exists := byAction[request.ActionType]
+		if !exists {
+			metrics = &ApprovalMetrics{ActionType: request.ActionType}
+			byAction[request.ActionType] = metrics
+		}
+		metrics.Requested++
+
+		switch {
+		case request.Status == "pending" && request.IsExpired(), request.Status == "expired":
+			metrics.Expired++
+		case request.Status == "pending":
+			metrics.Pending++
+		case request.Status == "approved":
+			metrics.Approved++
+		case request.Status == "rejected":
+			metrics.Rejected++
+		case request.Status == "invalidated":
+			metrics.Invalidated++
+		}
+		if request.ApprovedAt != nil {
+			latencies[request.ActionType] = append(latencies[request.ActionType], request.ApprovedAt.Sub(request.CreatedAt).Seconds())
+		}
+	}
+
+	result := make([]*ApprovalMetrics, 0, len(byAction))
+	for actionType, metrics := range byAction {
+		if resolved := metrics.Requested - metrics.Pending; resolved > 0 {
+			metrics.ExpiryRate = float64(metrics.Expired) / float64(resolved)
+		}
+		if samples := latencies[actionType]; len(samples) > 0 {
+			sort.Float64s(samples)
+			total := 0.0
+			for _, sample := range samples {
+				total += sample
+			}
+			metrics.LatencyMeanSeconds = total / float64(len(samples))
+			metrics.LatencyMedianSeconds = percentile(samples, 50)
+			metrics.LatencyP95Seconds = percentile(samples, 95)
+		}
+		result = append(result, metrics)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].ActionType < result[j].ActionType })
+	return result, nil
+}
+
+// SummarizeApproval describes the remaining slots and time left on a request at the given time
+func SummarizeApproval(request *model.ApprovalRequest, now time.Time) *ApprovalSummary {
+	summary := &ApprovalSummary{
+		ID:                request.ID,
+		Kind:              request.Kind,
+		TargetID:          request.TargetID,
+		ActionType:        request.ActionType,
+		ZoneID:            request.ZoneID,
+		Status:            request.Status,
+		RequesterID:       request.RequesterID,
+		RequiredApprovals: request.RequiredApprovals,
+		Approvals:         len(request.Votes),
+		RemainingSlots:    request.RemainingApprovals(),
+		MissingRoles:      request.MissingRoles(),
+		Approvers:         make([]string, 0, len(request.Votes)),
+		Version:           request.Version,
+		CreatedAt:         request.CreatedAt,
+		ExpiresAt:         request.ExpiresAt,
+	}
+	for _, vote := range request.Votes {
+		summary.Approvers = append(summary.Approvers, vote.ApproverID)
+	}
+	if request.Status == "pending" && request.ExpiresAt != nil {
+		remaining := request.ExpiresAt.Sub(now).Seconds()
+		if remaining < 0 {
+			remaining = 0
+		}
+		summary.SecondsUntilExpiry = &remaining
+	}
+	return summary
+}
+
+// canApprove checks if an operator could add a vote to a pending request right now
+func (s *ApprovalService) canApprove(ctx context.Context, request *model.ApprovalRequest, operatorID string) bool {
+	role := ""
+	if s.approvers != nil {
+		profile, err := s.approvers.CheckEligibility(ctx, request, operatorID)
+		if err != nil {
+			return false
+		}
+		role = profile.Role
+	} else if operatorID == request.RequesterID {
+		return false
+	}
+	return checkVote(request, operatorID, role) == nil
+}
+
+// percentile returns the nearest-rank percentile of sorted samples
+func percentile(sorted []float64, p float64) float64 {
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
@@ -2,12 +2,24 @@ package gate
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"log"
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/erh-safety-system/poc/internal/audit"
+	"github.com/erh-safety-system/poc/internal/config"
+	"github.com/erh-safety-system/poc/internal/decision"
+	"github.com/erh-safety-system/poc/internal/events"
+	"github.com/erh-safety-system/poc/internal/leader"
 	"github.com/erh-safety-system/poc/internal/model"
+	"github.com/erh-safety-system/poc/internal/notify"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 const (
@@ -22,20 +34,99 @@ const (
 	
 	// Approval request expiration (proposals expire after 10 minutes if not approved)
 	ApprovalRequestExpiration = 10 * time.Minute
+	
+	// Default caps on an action's total TTL including approved extensions
+	DefaultMaxTTLD3 = 2 * time.Hour
+	DefaultMaxTTLD4 = 1 * time.Hour
+	DefaultMaxTTLD5 = 3 * time.Hour
 )
 
 // ApprovalService handles approval requests for high-impact actions
 type ApprovalService struct {
-	db *gorm.DB
+	db        *gorm.DB
+	decisions *decision.DecisionService
+	bus       *events.Bus
+	approvers *ApproverDirectory
+	audit     *audit.AuditLogger
+	notifier  *notify.Dispatcher
+	ttls      *TTLManager
+	channels  []string // Channels requesters are told of expiry on
+
+	mu                sync.RWMutex
+	expiration        time.Duration
+	keepaliveInterval time.Duration
+	keepaliveTimeout  time.Duration
+	quorums           config.GateConfig // Only the quorum settings are used
 }
 
 // NewApprovalService creates a new approval service
 func NewApprovalService(db *gorm.DB) *ApprovalService {
 	return &ApprovalService{
-		db: db,
+		db:                db,
+		expiration:        ApprovalRequestExpiration,
+		keepaliveInterval: DefaultKeepaliveInterval,
+		keepaliveTimeout:  DefaultKeepaliveTimeout,
+		quorums: config.GateConfig{
+			Quorums: map[string]config.QuorumPolicy{
+				"D3": {Required: 2},
+				"D4": {Required: 3},
+				"D5": {Required: 2},
+			},
+		},
 	}
 }
 
+// UpdateConfig replaces the request expiration, keepalive and quorum settings used for new requests.
+// Pending requests keep the quorum they were created with.
+func (s *ApprovalService) UpdateConfig(cfg *config.GateConfig) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.expiration = cfg.ApprovalExpiration
+	s.keepaliveInterval = cfg.KeepaliveInterval
+	s.keepaliveTimeout = cfg.KeepaliveTimeout
+	s.quorums = config.GateConfig{Quorums: cfg.Quorums, ZoneQuorums: cfg.ZoneQuorums}
+}
+
+// Quorum returns the quorum new requests for an action type in a zone are created with
+func (s *ApprovalService) Quorum(zoneID, actionType string) config.QuorumPolicy {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.quorums.Quorum(zoneID, actionType)
+}
+
+// SetDecisionService enables applying fully approved actions to the zone's decision.
+// Without it, an operator must perform the approved transition separately.
+func (s *ApprovalService) SetDecisionService(decisions *decision.DecisionService) {
+	s.decisions = decisions
+}
+
+// SetApproverDirectory enforces approver profiles: the approver's role must allow the action type,
+// they must be on shift and have authority over the zone. Votes take the role from the profile.
+func (s *ApprovalService) SetApproverDirectory(approvers *ApproverDirectory) {
+	s.approvers = approvers
+}
+
+// SetAuditLogger records refused approval attempts in the audit log
+func (s *ApprovalService) SetAuditLogger(auditLogger *audit.AuditLogger) {
+	s.audit = auditLogger
+}
+
+// SetNotifier sets the dispatcher and channels used to tell requesters their request expired
+func (s *ApprovalService) SetNotifier(dispatcher *notify.Dispatcher, channels []string) {
+	s.notifier = dispatcher
+	s.channels = channels
+}
+
+// SetTTLManager sets the TTL manager that approved TTL extensions are applied through
+func (s *ApprovalService) SetTTLManager(ttls *TTLManager) {
+	s.ttls = ttls
+}
+
+// SetEventBus sets the bus that approval outcomes are published on
+func (s *ApprovalService) SetEventBus(bus *events.Bus) {
+	s.bus = bus
+}
+
 // CreateApprovalRequest creates a new approval request
 func (s *ApprovalService) CreateApprovalRequest(
 	ctx context.Context,
@@ -50,89 +141,469 @@ func (s *ApprovalService) CreateApprovalRequest(
 	}
 	
 	// Set expiration time
-	expiresAt := time.Now().Add(ApprovalRequestExpiration)
+	s.mu.RLock()
+	expiresAt := time.Now().Add(s.expiration)
+	s.mu.RUnlock()
+	
+	// Snapshot the quorum so a configuration reload does not change what pending approvers signed up for
+	quorum := s.Quorum(zoneID, actionType)
 	
 	request := &model.ApprovalRequest{
 		ID:         fmt.Sprintf("approval_%s", uuid.New().String()),
+		Kind:       model.ApprovalKindAction,
 		ActionType: actionType,
 		ZoneID:     zoneID,
 		Proposal:   model.JSONB(proposal),
 		RequesterID: requesterID,
+		RequiredApprovals: quorum.Required,
+		RequiredRoles:     model.StringArray(quorum.Roles),
+		ExcludedApprovers: model.StringArray(quorum.Excluded),
 		Status:     "pending",
+		Version:    1,
 		ExpiresAt:  &expiresAt,
 	}
 	
+	// Remember the decision the proposal was made against so a stale approval is not applied
+	if s.decisions != nil {
+		base, err := s.decisions.GetLatestState(ctx, zoneID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get zone decision: %w", err)
+		}
+		if base != nil {
+			request.BaseDecisionID = &base.ID
+			request.BaseState = base.CurrentState
+			request.BaseVersion = base.Version
+		}
+	}
+	
 	if err := s.db.WithContext(ctx).Create(request).Error; err != nil {
 		return nil, fmt.Errorf("failed to create approval request: %w", err)
 	}
 	
+	s.bus.Publish(events.Event{
+		Type:      events.ApprovalRequested,
+		ZoneID:    request.ZoneID,
+		SubjectID: request.ID,
+		Payload: map[string]interface{}{
+			"action_type":        request.ActionType,
+			"requester_id":       request.RequesterID,
+			"required_approvals": request.RequiredApprovals,
+			"required_roles":     []string(request.RequiredRoles),
+			"expires_at":         request.ExpiresAt,
+		},
+	})
+	
 	return request, nil
 }
 
-// Approve adds an approval from an operator
-func (s *ApprovalService) Approve(ctx context.Context, requestID string, approverID string) error {
+// Approve adds an approval from an operator acting in the given role.
+// A non-zero expectedVersion must match the request's current version.
+func (s *ApprovalService) Approve(ctx context.Context, requestID string, approverID string, role string, expectedVersion int) error {
 	var request model.ApprovalRequest
-	if err := s.db.WithContext(ctx).Where("id = ?", requestID).First(&request).Error; err != nil {
+	if err := s.db.WithContext(ctx).Preload("Votes").Where("id = ?", requestID).First(&request).Error; err != nil {
 		return fmt.Errorf("approval request not found: %w", err)
 	}
 	
+	if expectedVersion != 0 && expectedVersion != request.Version {
+		return ErrApprovalConflict
+	}
+	
 	// Check if already expired
-	if request.IsExpired() {
-		request.Status = "expired"
-		s.db.WithContext(ctx).Save(&request)
-		return fmt.Errorf("approval request has expired")
+	if request.IsExpired() && request.Status == "pending" {
+		if err := s.expire(ctx, &request); err != nil && !errors.Is(err, ErrApprovalConflict) {
+			log.Printf("Failed to expire approval request %s: %v", request.ID, err)
+		}
+		return s.refuse(ctx, &request, approverID, fmt.Errorf("approval request has expired"))
 	}
 	
 	// Check if already approved or rejected
 	if request.Status != "pending" {
-		return fmt.Errorf("approval request is not pending")
+		return s.refuse(ctx, &request, approverID, fmt.Errorf("approval request is not pending"))
+	}
+	
+	role, err := s.checkApprover(ctx, &request, approverID, role)
+	if err != nil {
+		return s.refuse(ctx, &request, approverID, err)
+	}
+	if err := checkVote(&request, approverID, role); err != nil {
+		return s.refuse(ctx, &request, approverID, err)
 	}
 	
-	// Assign approver to first available slot
 	now := time.Now()
-	if request.Approver1ID == nil {
-		request.Approver1ID = &approverID
-	} else if request.Approver2ID == nil {
-		// Check if same person is trying to approve twice
-		if *request.Approver1ID == approverID {
-			return fmt.Errorf("same operator cannot approve twice")
-		}
-		request.Approver2ID = &approverID
-	} else if request.RequiresStrictApproval() && request.Approver3ID == nil {
-		// Check if same person is trying to approve again
-		if *request.Approver1ID == approverID || *request.Approver2ID == approverID {
-			return fmt.Errorf("same operator cannot approve multiple times")
-		}
-		request.Approver3ID = &approverID
-	} else {
-		return fmt.Errorf("all approval slots are filled")
-	}
-	
-	// Check if fully approved
-	if request.IsFullyApproved() {
-		request.Status = "approved"
-		request.ApprovedAt = &now
+	vote := model.ApprovalVote{
+		ID:              fmt.Sprintf("vote_%s", uuid.New().String()),
+		ApprovalID:      request.ID,
+		ApproverID:      approverID,
+		Role:            role,
+		LastKeepaliveAt: &now, // Approving counts as the approver's first keepalive
+	}
+	request.Votes = append(request.Votes, vote)
+	
+	// Record the vote only if no other approver changed the request first
+	var applied *decision.DecisionStateRecord
+	var extended *model.ApprovalRequest
+	var invalidation string
+	var blocked error
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Check if fully approved
+		fullyApproved := request.IsFullyApproved()
+		if fullyApproved {
+			request.Status = "approved"
+			request.ApprovedAt = &now
+		}
+		
+		if err := saveApproval(tx, &request); err != nil {
+			if err == ErrApprovalConflict {
+				return err
+			}
+			return fmt.Errorf("failed to update approval request: %w", err)
+		}
+		if err := tx.Create(&vote).Error; err != nil {
+			return fmt.Errorf("failed to record approval vote: %w", err)
+		}
+		
+		if fullyApproved && request.IsExtension() {
+			var err error
+			extended, invalidation, err = s.applyExtension(ctx, tx, &request)
+			return err
+		}
+		if !fullyApproved {
+			return nil
+		}
 		
-		// Create keepalive session
-		if err := s.createKeepaliveSession(ctx, &request); err != nil {
+		// Apply the approved state together with the final approval
+		if s.decisions != nil {
+			var err error
+			applied, invalidation, err = s.applyApproval(ctx, tx, &request, approverID)
+			switch {
+			case errors.Is(err, decision.ErrTransitionBlocked):
+				// Keep the vote: the request stays approved for the operator to apply once the guard passes
+				blocked = err
+			case err != nil:
+				return err
+			case invalidation != "":
+				return nil // An invalidated request never takes effect, so its approvers are not kept alive
+			}
+		}
+		
+		// Create keepalive session; an extension runs under the session of the action it extends
+		if err := s.createKeepaliveSession(tx, &request); err != nil {
 			return fmt.Errorf("failed to create keepalive session: %w", err)
 		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if applied != nil {
+		s.decisions.CacheState(ctx, applied)
 	}
 	
-	if err := s.db.WithContext(ctx).Save(&request).Error; err != nil {
-		return fmt.Errorf("failed to update approval request: %w", err)
+	s.bus.Publish(events.Event{
+		Type:      events.ApprovalVoted,
+		ZoneID:    request.ZoneID,
+		SubjectID: request.ID,
+		Payload: map[string]interface{}{
+			"approver_id":     approverID,
+			"role":            role,
+			"status":          request.Status,
+			"remaining_slots": request.RemainingApprovals(),
+			"missing_roles":   request.MissingRoles(),
+		},
+	})
+	if applied != nil {
+		s.bus.Publish(events.Event{
+			Type:      events.ApprovalApplied,
+			ZoneID:    request.ZoneID,
+			SubjectID: request.ID,
+			Payload: map[string]interface{}{
+				"decision_id": applied.ID,
+				"state":       applied.CurrentState,
+				"approver_id": approverID,
+			},
+		})
+	}
+	if extended != nil {
+		s.bus.Publish(events.Event{
+			Type:      events.ApprovalApplied,
+			ZoneID:    request.ZoneID,
+			SubjectID: request.ID,
+			Payload: map[string]interface{}{
+				"target_id":   extended.ID,
+				"ttl_seconds": request.RequestedTTL,
+				"expires_at":  extended.ExpiresAt,
+				"approver_id": approverID,
+			},
+		})
+	}
+	if invalidation != "" {
+		s.bus.Publish(events.Event{
+			Type:      events.ApprovalInvalidated,
+			ZoneID:    request.ZoneID,
+			SubjectID: request.ID,
+			Payload: map[string]interface{}{
+				"reason":      invalidation,
+				"approver_id": approverID,
+			},
+		})
+		return fmt.Errorf("%w: %s", ErrApprovalInvalidated, invalidation)
+	}
+	if blocked != nil {
+		return fmt.Errorf("%w: %w", ErrApprovalBlocked, blocked)
 	}
 	
 	return nil
 }
 
-// Reject rejects an approval request
-func (s *ApprovalService) Reject(ctx context.Context, requestID string, rejectorID string, reason string) error {
+// checkApprover verifies the operator may approve the request and returns the role they approve in
+func (s *ApprovalService) checkApprover(ctx context.Context, request *model.ApprovalRequest, approverID, role string) (string, error) {
+	if s.approvers == nil {
+		if approverID == request.RequesterID {
+			return "", ErrSelfApproval
+		}
+		return role, nil
+	}
+	
+	profile, err := s.approvers.CheckEligibility(ctx, request, approverID)
+	if err != nil {
+		return "", err
+	}
+	if role != "" && role != profile.Role {
+		return "", fmt.Errorf("%w: %s holds role %s, not %s", ErrApproverIneligible, approverID, profile.Role, role)
+	}
+	return profile.Role, nil
+}
+
+// refuse records a refused approval attempt in the audit log and returns err
+func (s *ApprovalService) refuse(ctx context.Context, request *model.ApprovalRequest, approverID string, err error) error {
+	if s.audit == nil {
+		return err
+	}
+	
+	metadata, _ := json.Marshal(map[string]interface{}{
+		"action_type":  request.ActionType,
+		"zone_id":      request.ZoneID,
+		"requester_id": request.RequesterID,
+		"version":      request.Version,
+	})
+	entry := &audit.AuditLogEntry{
+		OperationType: "approval",
+		OperatorID:    approverID,
+		TargetType:    "approval_request",
+		TargetID:      request.ID,
+		Action:        "approve",
+		Result:        "failure",
+		Reason:        err.Error(),
+		Metadata:      string(metadata),
+	}
+	if logErr := s.audit.LogOperation(ctx, entry); logErr != nil {
+		log.Printf("Failed to log refused approval of %s: %v", request.ID, logErr)
+	}
+	return err
+}
+
+// checkVote verifies an operator may add a vote in role without making the quorum unreachable
+func checkVote(request *model.ApprovalRequest, approverID, role string) error {
+	if request.HasVoted(approverID) {
+		return fmt.Errorf("%w: %s", ErrAlreadyApproved, approverID)
+	}
+	if request.IsExcluded(approverID) {
+		return fmt.Errorf("%w: %s", ErrApproverExcluded, approverID)
+	}
+	// Once the remaining votes are all needed for missing roles, only those roles may vote
+	missing := request.MissingRoles()
+	if len(missing) == 0 || request.RemainingApprovals() > len(missing) {
+		return nil
+	}
+	for _, needed := range missing {
+		if role == needed {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: remaining approvals must come from %s", ErrRoleNotNeeded, strings.Join(missing, ", "))
+}
+
+// applyApproval transitions the decision a fully approved request was proposed against to the approved state,
+// consuming the approval and starting its TTL. If the zone's decision changed since the proposal the request is
+// invalidated instead and the reason returned; the decision is left untouched. If a transition guard refuses the
+// transition, its ErrTransitionBlocked error is returned and the request left approved.
+func (s *ApprovalService) applyApproval(ctx context.Context, tx *gorm.DB, request *model.ApprovalRequest, approverID string) (*decision.DecisionStateRecord, string, error) {
+	decisions := s.decisions.WithDB(tx)
+	
+	var reason string
+	latest, err := decisions.GetLatestState(ctx, request.ZoneID)
+	switch {
+	case err != nil:
+		return nil, "", fmt.Errorf("failed to get zone decision: %w", err)
+	case request.BaseDecisionID == nil:
+		reason = "no decision existed for the zone when the action was proposed"
+	case latest == nil || latest.ID != *request.BaseDecisionID:
+		reason = "the zone has a newer decision than the one the action was proposed against"
+	default:
+		// The transition runs in a savepoint, so a refusal leaves the enclosing transaction usable
+		state, err := decisions.Transition(ctx, &decision.TransitionRequest{
+			DecisionID:      *request.BaseDecisionID,
+			TargetState:     decision.DecisionState(request.ActionType),
+			OperatorID:      approverID,
+			Reason:          fmt.Sprintf("approval %s applied", request.ID),
+			ApprovalID:      request.ID,
+			ExpectedVersion: request.BaseVersion,
+		})
+		switch {
+		case err == nil:
+			return state, "", nil
+		case errors.Is(err, decision.ErrVersionConflict):
+			reason = fmt.Sprintf("the decision changed since the action was proposed (was %s, version %d)", request.BaseState, request.BaseVersion)
+		case errors.Is(err, decision.ErrInvalidTransition):
+			reason = fmt.Sprintf("%s cannot be applied from the decision's current state", request.ActionType)
+		case errors.Is(err, decision.ErrTransitionBlocked):
+			return nil, "", err
+		default:
+			return nil, "", fmt.Errorf("failed to apply approval: %w", err)
+		}
+	}
+	
+	if err := invalidate(tx, request, reason); err != nil {
+		return nil, "", err
+	}
+	return nil, reason, nil
+}
+
+// applyExtension raises the TTL of the action a fully approved extension targets and marks the extension consumed.
+// If the action's TTL already ran out or the extension no longer fits the cap, the extension is invalidated
+// instead and the reason returned.
+func (s *ApprovalService) applyExtension(ctx context.Context, tx *gorm.DB, request *model.ApprovalRequest) (*model.ApprovalRequest, string, error) {
+	if s.ttls == nil {
+		return nil, "", fmt.Errorf("TTL extensions are not enabled")
+	}
+	
+	action, err := s.ttls.WithDB(tx).ExtendTTL(ctx, request)
+	switch {
+	case err == nil:
+	case errors.Is(err, ErrActionExpired), errors.Is(err, ErrInvalidExtension), errors.Is(err, ErrExtensionCapExceeded):
+		reason := err.Error()
+		if err := invalidate(tx, request, reason); err != nil {
+			return nil, "", err
+		}
+		return nil, reason, nil
+	default:
+		return nil, "", fmt.Errorf("failed to extend TTL: %w", err)
+	}
+	
+	// The extension is consumed by the action it extended
+	now := time.Now()
+	request.DecisionID = action.DecisionID
+	request.ConsumedAt = &now
+	if err := saveApproval(tx, request); err != nil {
+		return nil, "", fmt.Errorf("failed to consume TTL extension: %w", err)
+	}
+	return action, "", nil
+}
+
+// invalidate marks a fully approved request that could not take effect as invalidated, recording why
+func invalidate(tx *gorm.DB, request *model.ApprovalRequest, reason string) error {
+	request.Status = "invalidated"
+	if request.Proposal == nil {
+		request.Proposal = make(model.JSONB)
+	}
+	request.Proposal["invalidation_reason"] = reason
+	request.Proposal["invalidated_at"] = time.Now().Format(time.RFC3339)
+	if err := saveApproval(tx, request); err != nil {
+		return fmt.Errorf("failed to invalidate approval request: %w", err)
+	}
+	return nil
+}
+
+// RequestTTLExtension proposes raising the TTL of an action in effect to newTTL, counted from when the action took effect.
+// The extension needs the same quorum as the action and lapses when the action's current TTL runs out.
+func (s *ApprovalService) RequestTTLExtension(ctx context.Context, actionID string, newTTL time.Duration, reason, requesterID string) (*model.ApprovalRequest, error) {
+	if s.ttls == nil {
+		return nil, fmt.Errorf("TTL extensions are not enabled")
+	}
+	
+	var action model.ApprovalRequest
+	if err := s.db.WithContext(ctx).Where("id = ?", actionID).First(&action).Error; err != nil {
+		return nil, fmt.Errorf("approval request not found: %w", err)
+	}
+	
+	now := time.Now()
+	if err := s.ttls.CheckExtension(&action, newTTL, now); err != nil {
+		return nil, err
+	}
+	
+	var pending int64
+	if err := s.db.WithContext(ctx).Model(&model.ApprovalRequest{}).
+		Where("kind = ? AND target_id = ? AND status = ? AND expires_at > ?", model.ApprovalKindTTLExtension, action.ID, "pending", now).
+		Count(&pending).Error; err != nil {
+		return nil, fmt.Errorf("failed to check pending extensions: %w", err)
+	}
+	if pending > 0 {
+		return nil, fmt.Errorf("%w: %s", ErrExtensionPending, action.ID)
+	}
+	
+	// The extension must be approved while the action is still in effect
+	s.mu.RLock()
+	expiresAt := now.Add(s.expiration)
+	s.mu.RUnlock()
+	if action.ExpiresAt.Before(expiresAt) {
+		expiresAt = *action.ExpiresAt
+	}
+	
+	request := &model.ApprovalRequest{
+		ID:                fmt.Sprintf("approval_%s", uuid.New().String()),
+		Kind:              model.ApprovalKindTTLExtension,
+		ActionType:        action.ActionType,
+		ZoneID:            action.ZoneID,
+		TargetID:          &action.ID,
+		RequestedTTL:      int(newTTL.Seconds()),
+		Proposal: model.JSONB{
+			"reason":              reason,
+			"current_ttl_seconds": int(action.ExpiresAt.Sub(*action.ConsumedAt).Seconds()),
+			"ttl_seconds":         int(newTTL.Seconds()),
+		},
+		RequesterID:       requesterID,
+		RequiredApprovals: action.RequiredApprovals,
+		RequiredRoles:     action.RequiredRoles,
+		ExcludedApprovers: action.ExcludedApprovers,
+		Status:            "pending",
+		Version:           1,
+		ExpiresAt:         &expiresAt,
+	}
+	if err := s.db.WithContext(ctx).Create(request).Error; err != nil {
+		return nil, fmt.Errorf("failed to create TTL extension request: %w", err)
+	}
+	
+	s.bus.Publish(events.Event{
+		Type:      events.ApprovalRequested,
+		ZoneID:    request.ZoneID,
+		SubjectID: request.ID,
+		Payload: map[string]interface{}{
+			"kind":               request.Kind,
+			"target_id":          action.ID,
+			"action_type":        request.ActionType,
+			"requester_id":       request.RequesterID,
+			"ttl_seconds":        request.RequestedTTL,
+			"required_approvals": request.RequiredApprovals,
+			"required_roles":     []string(request.RequiredRoles),
+			"expires_at":         request.ExpiresAt,
+		},
+	})
+	
+	return request, nil
+}
+
+// Reject rejects an approval request.
+// A non-zero expectedVersion must match the request's current version.
+func (s *ApprovalService) Reject(ctx context.Context, requestID string, rejectorID string, reason string, expectedVersion int) error {
 	var request model.ApprovalRequest
 	if err := s.db.WithContext(ctx).Where("id = ?", requestID).First(&request).Error; err != nil {
 		return fmt.Errorf("approval request not found: %w", err)
 	}
 	
+	if expectedVersion != 0 && expectedVersion != request.Version {
+		return ErrApprovalConflict
+	}
+	
 	if request.Status != "pending" {
 		return fmt.Errorf("approval request is not pending")
 	}
@@ -147,33 +618,155 @@ func (s *ApprovalService) Reject(ctx context.Context, requestID string, rejector
 	request.Proposal["rejected_by"] = rejectorID
 	request.Proposal["rejected_at"] = time.Now().Format(time.RFC3339)
 	
-	return s.db.WithContext(ctx).Save(&request).Error
+	return saveApproval(s.db.WithContext(ctx), &request)
 }
 
 // GetApprovalRequest gets an approval request by ID
 func (s *ApprovalService) GetApprovalRequest(ctx context.Context, requestID string) (*model.ApprovalRequest, error) {
 	var request model.ApprovalRequest
-	if err := s.db.WithContext(ctx).Where("id = ?", requestID).First(&request).Error; err != nil {
+	if err := s.db.WithContext(ctx).Preload("Votes", func(db *gorm.DB) *gorm.DB {
+		return db.Order("created_at ASC")
+	}).Where("id = ?", requestID).First(&request).Error; err != nil {
 		return nil, fmt.Errorf("approval request not found: %w", err)
 	}
 	
 	// Check expiration
 	if request.IsExpired() && request.Status == "pending" {
-		request.Status = "expired"
-		s.db.WithContext(ctx).Save(&request)
+		if err := s.expire(ctx, &request); err != nil && !errors.Is(err, ErrApprovalConflict) {
+			log.Printf("Failed to expire approval request %s: %v", request.ID, err)
+		}
 	}
 	
 	return &request, nil
 }
 
+// ExpireStale expires pending requests past their expiry and returns how many were expired.
+// Requests changed by someone else in the meantime are left for the next sweep.
+func (s *ApprovalService) ExpireStale(ctx context.Context) (int, error) {
+	var stale []model.ApprovalRequest
+	if err := s.db.WithContext(ctx).Preload("Votes").
+		Where("status = ? AND expires_at <= ?", "pending", time.Now()).
+		Order("expires_at ASC").
+		Find(&stale).Error; err != nil {
+		return 0, fmt.Errorf("failed to get stale approval requests: %w", err)
+	}
+	
+	expired := 0
+	for i := range stale {
+		err := s.expire(ctx, &stale[i])
+		switch {
+		case err == nil:
+			expired++
+		case errors.Is(err, ErrApprovalConflict):
+		default:
+			return expired, fmt.Errorf("failed to expire approval request %s: %w", stale[i].ID, err)
+		}
+	}
+	return expired, nil
+}
+
+// expire marks a pending request as expired, records it in the audit log and tells the requester
+func (s *ApprovalService) expire(ctx context.Context, request *model.ApprovalRequest) error {
+	request.Status = "expired"
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return saveApproval(tx, request)
+	})
+	if err != nil {
+		request.Status = "pending"
+		return err
+	}
+	
+	s.bus.Publish(events.Event{
+		Type:      events.ApprovalExpired,
+		ZoneID:    request.ZoneID,
+		SubjectID: request.ID,
+		Payload: map[string]interface{}{
+			"action_type":     request.ActionType,
+			"requester_id":    request.RequesterID,
+			"approvals":       len(request.Votes),
+			"remaining_slots": request.RemainingApprovals(),
+			"expires_at":      request.ExpiresAt,
+		},
+	})
+	
+	if s.audit != nil {
+		metadata, _ := json.Marshal(map[string]interface{}{
+			"action_type":        request.ActionType,
+			"zone_id":            request.ZoneID,
+			"requester_id":       request.RequesterID,
+			"approvals":          len(request.Votes),
+			"required_approvals": request.RequiredApprovals,
+			"expires_at":         request.ExpiresAt,
+		})
+		entry := &audit.AuditLogEntry{
+			OperationType: "approval",
+			OperatorID:    "system_expiry",
+			TargetType:    "approval_request",
+			TargetID:      request.ID,
+			Action:        "expire",
+			Result:        "success",
+			Reason:        "approval request expired before reaching quorum",
+			Metadata:      string(metadata),
+		}
+		if err := s.audit.LogOperation(ctx, entry); err != nil {
+			log.Printf("Failed to log expiry of approval %s: %v", request.ID, err)
+		}
+	}
+	
+	if s.notifier != nil && len(s.channels) > 0 {
+		notification := &notify.Notification{
+			Role:      "requester",
+			Recipient: request.RequesterID,
+			ZoneID:    request.ZoneID,
+			State:     request.ActionType,
+			Subject:   fmt.Sprintf("%s approval for zone %s expired", request.ActionType, request.ZoneID),
+			Message: fmt.Sprintf("Approval request %s expired with %d of %d approvals; propose the action again if it is still needed",
+				request.ID, len(request.Votes), request.RequiredApprovals),
+		}
+		if request.BaseDecisionID != nil {
+			notification.DecisionID = *request.BaseDecisionID
+		}
+		if _, err := s.notifier.Send(ctx, s.channels, notification); err != nil {
+			log.Printf("Failed to notify %s of expired approval %s: %v", request.RequesterID, request.ID, err)
+		}
+	}
+	
+	return nil
+}
+
 // createKeepaliveSession creates a keepalive session for an approved action
-func (s *ApprovalService) createKeepaliveSession(ctx context.Context, request *model.ApprovalRequest) error {
+func (s *ApprovalService) createKeepaliveSession(tx *gorm.DB, request *model.ApprovalRequest) error {
+	s.mu.RLock()
 	session := &model.KeepaliveSession{
 		ActionID:          request.ID,
-		KeepaliveInterval: int(DefaultKeepaliveInterval.Seconds()),
-		KeepaliveTimeout:  int(DefaultKeepaliveTimeout.Seconds()),
+		KeepaliveInterval: int(s.keepaliveInterval.Seconds()),
+		KeepaliveTimeout:  int(s.keepaliveTimeout.Seconds()),
 	}
+	s.mu.RUnlock()
 	
-	return s.db.WithContext(ctx).Create(session).Error
+	return tx.Create(session).Error
+}
+
+// saveApproval persists an approval request only if nobody modified it since it was read and,
+// for leader-only tasks, only while no newer leader has taken over
+func saveApproval(tx *gorm.DB, request *model.ApprovalRequest) error {
+	if err := leader.Guard(tx); err != nil {
+		return err
+	}
+	
+	expected := request.Version
+	request.Version = expected + 1
+	
+	result := tx.Model(request).Where("version = ?", expected).Select("*").Omit(clause.Associations).Updates(request)
+	if result.Error != nil {
+		request.Version = expected
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		request.Version = expected
+		return ErrApprovalConflict
+	}
+	
+	return nil
 }
 
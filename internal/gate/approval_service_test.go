@@ -2,9 +2,18 @@ package gate
 
 import (
 	"context"
+	"encoding/json"
 	"testing"
+	"time"
 
+	"github.com/erh-safety-system/poc/internal/audit"
+	"github.com/erh-safety-system/poc/internal/config"
+	"github.com/erh-safety-system/poc/internal/decision"
+	"github.com/erh-safety-system/poc/internal/events"
+	"github.com/erh-safety-system/poc/internal/leader"
 	"github.com/erh-safety-system/poc/internal/model"
+	"github.com/erh-safety-system/poc/internal/notify"
+	"github.com/erh-safety-system/poc/internal/playbook"
 	"github.com/stretchr/testify/assert"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
@@ -16,8 +25,8 @@ func setupGateTestDB(t *testing.T) *gorm.DB {
 		t.Fatalf("Failed to open test database: %v", err)
 	}
 	
-	// Auto migrate the approval_requests table
-	if err := db.AutoMigrate(&model.ApprovalRequest{}); err != nil {
+	// Auto migrate the approval_requests and approval_votes tables
+	if err := db.AutoMigrate(&model.ApprovalRequest{}, &model.ApprovalVote{}); err != nil {
 		t.Fatalf("Failed to auto migrate: %v", err)
 	}
 	
@@ -48,3 +57,793 @@ func TestApprovalService_CreateApprovalRequest(t *testing.T) {
 	assert.Equal(t, "pending", request.Status)
 }
 
+
+func TestApprovalService_ApproveVersionConflict(t *testing.T) {
+	db := setupGateTestDB(t)
+	assert.NoError(t, db.AutoMigrate(&model.KeepaliveSession{}))
+	service := NewApprovalService(db)
+	ctx := context.Background()
+	
+	request, err := service.CreateApprovalRequest(ctx, "D3", "Z1", map[string]interface{}{"reason": "test"}, "requester")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, request.Version)
+	
+	// Both approvers reviewed version 1; only the first claims the slot
+	assert.NoError(t, service.Approve(ctx, request.ID, "approver_a", "", 1))
+	assert.ErrorIs(t, service.Approve(ctx, request.ID, "approver_b", "", 1), ErrApprovalConflict)
+	
+	current, err := service.GetApprovalRequest(ctx, request.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, current.Version)
+	assert.Len(t, current.Votes, 1)
+	assert.Equal(t, "approver_a", current.Votes[0].ApproverID)
+	
+	// A stale in-memory copy cannot overwrite the newer row
+	stale := *request
+	stale.Status = "rejected"
+	assert.ErrorIs(t, saveApproval(db, &stale), ErrApprovalConflict)
+	
+	// Retrying against the current version succeeds and completes the approval
+	assert.NoError(t, service.Approve(ctx, request.ID, "approver_b", "", current.Version))
+	current, err = service.GetApprovalRequest(ctx, request.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "approved", current.Status)
+	assert.Equal(t, 3, current.Version)
+}
+
+func TestApprovalGate_ConsumeApproval(t *testing.T) {
+	db := setupGateTestDB(t)
+	assert.NoError(t, db.AutoMigrate(&model.KeepaliveSession{}, &model.AggregatedSummary{}, &decision.DecisionStateRecord{}, &decision.DecisionTransition{}))
+	approvals := NewApprovalService(db)
+	ttlManager := NewTTLManager(db)
+	decisionService := decision.NewDecisionService(db, nil)
+	decisionService.SetApprovalGate(NewApprovalGate(ttlManager))
+	ctx := context.Background()
+	
+	summary := &model.AggregatedSummary{ZoneID: "Z2", WindowStart: time.Now().Add(-time.Minute), WindowEnd: time.Now()}
+	assert.NoError(t, db.Create(summary).Error)
+	state, err := decisionService.CreatePreAlert(ctx, "Z2", "op_1", summary.ID)
+	assert.NoError(t, err)
+	
+	// A pending approval does not authorize the transition
+	request, err := approvals.CreateApprovalRequest(ctx, "D3", "Z2", map[string]interface{}{"reason": "test"}, "requester")
+	assert.NoError(t, err)
+	assert.NoError(t, approvals.Approve(ctx, request.ID, "approver_a", "", 0))
+	_, err = decisionService.TransitionState(ctx, state.ID, decision.StateD3, "op_1", 0)
+	assert.ErrorIs(t, err, decision.ErrMissingApproval)
+	
+	// An approval for another zone does not either
+	other, err := approvals.CreateApprovalRequest(ctx, "D3", "Z1", map[string]interface{}{"reason": "test"}, "requester")
+	assert.NoError(t, err)
+	assert.NoError(t, approvals.Approve(ctx, other.ID, "approver_a", "", 0))
+	assert.NoError(t, approvals.Approve(ctx, other.ID, "approver_b", "", 0))
+	_, err = decisionService.TransitionState(ctx, state.ID, decision.StateD3, "op_1", 0)
+	assert.ErrorIs(t, err, decision.ErrMissingApproval)
+	
+	// Once fully approved, the transition consumes it and starts the TTL
+	assert.NoError(t, approvals.Approve(ctx, request.ID, "approver_b", "", 0))
+	_, err = decisionService.TransitionState(ctx, state.ID, decision.StateD3, "op_1", 0)
+	assert.NoError(t, err)
+	
+	consumed, err := approvals.GetApprovalRequest(ctx, request.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, state.ID, *consumed.DecisionID)
+	assert.NotNil(t, consumed.ConsumedAt)
+	assert.WithinDuration(t, consumed.ConsumedAt.Add(DefaultTTLD3), *consumed.ExpiresAt, time.Second)
+	
+	timeline, err := decisionService.GetTimeline(ctx, state.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, request.ID, timeline[len(timeline)-1].ApprovalID)
+	
+	// The same approval cannot be used twice
+	_, err = decisionService.TransitionState(ctx, state.ID, decision.StateD2, "op_1", 0)
+	assert.NoError(t, err)
+	_, err = decisionService.TransitionState(ctx, state.ID, decision.StateD3, "op_1", 0)
+	assert.ErrorIs(t, err, decision.ErrMissingApproval)
+}
+
+func TestApprovalService_ApproveAppliesDecision(t *testing.T) {
+	db := setupGateTestDB(t)
+	assert.NoError(t, db.AutoMigrate(&model.KeepaliveSession{}, &model.AggregatedSummary{}, &decision.DecisionStateRecord{}, &decision.DecisionTransition{}))
+	decisionService := decision.NewDecisionService(db, nil)
+	decisionService.SetApprovalGate(NewApprovalGate(NewTTLManager(db)))
+	approvals := NewApprovalService(db)
+	approvals.SetDecisionService(decisionService)
+	bus := events.NewBus()
+	var published []events.Event
+	bus.Subscribe(func(event events.Event) {
+		if event.Type == events.ApprovalApplied || event.Type == events.ApprovalInvalidated {
+			published = append(published, event)
+		}
+	})
+	approvals.SetEventBus(bus)
+	ctx := context.Background()
+	
+	createDecision := func(zoneID string) *decision.DecisionStateRecord {
+		summary := &model.AggregatedSummary{ZoneID: zoneID, WindowStart: time.Now().Add(-time.Minute), WindowEnd: time.Now()}
+		assert.NoError(t, db.Create(summary).Error)
+		state, err := decisionService.CreatePreAlert(ctx, zoneID, "op_1", summary.ID)
+		assert.NoError(t, err)
+		return state
+	}
+	
+	// The final approval applies the approved state
+	state := createDecision("Z1")
+	request, err := approvals.CreateApprovalRequest(ctx, "D3", "Z1", map[string]interface{}{"reason": "test"}, "requester")
+	assert.NoError(t, err)
+	assert.Equal(t, state.ID, *request.BaseDecisionID)
+	assert.Equal(t, "D0", request.BaseState)
+	assert.Equal(t, 1, request.BaseVersion)
+	
+	assert.NoError(t, approvals.Approve(ctx, request.ID, "approver_a", "", 0))
+	assert.NoError(t, approvals.Approve(ctx, request.ID, "approver_b", "", 0))
+	
+	current, err := decisionService.GetDecision(ctx, state.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "D3", current.CurrentState)
+	
+	applied, err := approvals.GetApprovalRequest(ctx, request.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "approved", applied.Status)
+	assert.True(t, applied.IsConsumed())
+	assert.NotNil(t, applied.ExpiresAt)
+	
+	assert.Len(t, published, 1)
+	assert.Equal(t, events.ApprovalApplied, published[0].Type)
+	assert.Equal(t, state.ID, published[0].Payload["decision_id"])
+	
+	// If the decision moved on since the proposal the approval fails safe
+	state = createDecision("Z2")
+	request, err = approvals.CreateApprovalRequest(ctx, "D3", "Z2", map[string]interface{}{"reason": "test"}, "requester")
+	assert.NoError(t, err)
+	_, err = decisionService.TransitionState(ctx, state.ID, decision.StateD1, "op_1", 0)
+	assert.NoError(t, err)
+	
+	assert.NoError(t, approvals.Approve(ctx, request.ID, "approver_a", "", 0))
+	err = approvals.Approve(ctx, request.ID, "approver_b", "", 0)
+	assert.ErrorIs(t, err, ErrApprovalInvalidated)
+	
+	current, err = decisionService.GetDecision(ctx, state.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "D1", current.CurrentState)
+	
+	invalidated, err := approvals.GetApprovalRequest(ctx, request.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "invalidated", invalidated.Status)
+	assert.False(t, invalidated.IsConsumed())
+	assert.NotEmpty(t, invalidated.Proposal["invalidation_reason"])
+	
+	assert.Len(t, published, 2)
+	assert.Equal(t, events.ApprovalInvalidated, published[1].Type)
+	
+	// Only the applied action is kept alive by its approvers
+	var sessions []model.KeepaliveSession
+	assert.NoError(t, db.Find(&sessions).Error)
+	assert.Len(t, sessions, 1)
+	assert.Equal(t, applied.ID, sessions[0].ActionID)
+}
+
+func TestApprovalService_ApproveBlockedByChecklist(t *testing.T) {
+	db := setupGateTestDB(t)
+	assert.NoError(t, db.AutoMigrate(&model.KeepaliveSession{}, &model.AggregatedSummary{}, &decision.DecisionStateRecord{}, &decision.DecisionTransition{}, &playbook.Checklist{}, &playbook.ChecklistItem{}, &audit.AuditLog{}))
+	decisionService := decision.NewDecisionService(db, nil)
+	decisionService.SetApprovalGate(NewApprovalGate(NewTTLManager(db)))
+	checklists := playbook.NewChecklistService(db, playbook.NewRegistry(), decision.NewPolicyRegistry(), audit.NewAuditLogger(db))
+	decisionService.AddTransitionGuard(checklists.Guard)
+	decisionService.OnTransition(checklists.Observe)
+	approvals := NewApprovalService(db)
+	approvals.SetDecisionService(decisionService)
+	ctx := context.Background()
+	
+	summary := &model.AggregatedSummary{ZoneID: "Z1", WindowStart: time.Now().Add(-time.Minute), WindowEnd: time.Now()}
+	assert.NoError(t, db.Create(summary).Error)
+	state, err := decisionService.CreatePreAlert(ctx, "Z1", "op_1", summary.ID)
+	assert.NoError(t, err)
+	request, err := approvals.CreateApprovalRequest(ctx, "D3", "Z1", map[string]interface{}{"reason": "test"}, "requester")
+	assert.NoError(t, err)
+	
+	// The quorum completes while the D0 checklist still has required items pending
+	assert.NoError(t, approvals.Approve(ctx, request.ID, "approver_a", "", 0))
+	err = approvals.Approve(ctx, request.ID, "approver_b", "", 0)
+	assert.ErrorIs(t, err, ErrApprovalBlocked)
+	assert.ErrorIs(t, err, decision.ErrTransitionBlocked)
+	
+	current, err := decisionService.GetDecision(ctx, state.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "D0", current.CurrentState)
+	
+	// The final vote is kept and the request stays approved
+	blocked, err := approvals.GetApprovalRequest(ctx, request.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "approved", blocked.Status)
+	assert.Len(t, blocked.Votes, 2)
+	assert.False(t, blocked.IsConsumed())
+	
+	// Once the checklist is resolved the operator's transition consumes the approval
+	active, err := checklists.GetActiveChecklist(ctx, state.ID)
+	assert.NoError(t, err)
+	for _, item := range active.Items {
+		_, err := checklists.CompleteItem(ctx, item.ID, "op_1")
+		assert.NoError(t, err)
+	}
+	_, err = decisionService.TransitionState(ctx, state.ID, decision.StateD3, "op_1", 0)
+	assert.NoError(t, err)
+	
+	applied, err := approvals.GetApprovalRequest(ctx, request.ID)
+	assert.NoError(t, err)
+	assert.True(t, applied.IsConsumed())
+	assert.Equal(t, state.ID, *applied.DecisionID)
+}
+
+func TestApprovalService_QuorumRolesAndExclusions(t *testing.T) {
+	db := setupGateTestDB(t)
+	assert.NoError(t, db.AutoMigrate(&model.KeepaliveSession{}))
+	service := NewApprovalService(db)
+	service.UpdateConfig(&config.GateConfig{
+		ApprovalExpiration: 10 * time.Minute,
+		KeepaliveInterval:  time.Second,
+		KeepaliveTimeout:   time.Minute,
+		Quorums: map[string]config.QuorumPolicy{
+			"D4": {Required: 3, Roles: []string{"station_master", "control_supervisor"}, Excluded: []string{"op_conflicted"}},
+		},
+		ZoneQuorums: map[string]map[string]config.QuorumPolicy{
+			"Z2": {"D4": {Required: 2}},
+		},
+	})
+	ctx := context.Background()
+
+	request, err := service.CreateApprovalRequest(ctx, "D4", "Z1", map[string]interface{}{"reason": "test"}, "requester")
+	assert.NoError(t, err)
+	assert.Equal(t, 3, request.RequiredApprovals)
+
+	assert.ErrorIs(t, service.Approve(ctx, request.ID, "op_conflicted", "station_master", 0), ErrApproverExcluded)
+	assert.NoError(t, service.Approve(ctx, request.ID, "op_a", "operator", 0))
+	assert.ErrorIs(t, service.Approve(ctx, request.ID, "op_a", "station_master", 0), ErrAlreadyApproved)
+
+	// Two slots are left and both roles are missing, so another plain operator cannot take one
+	assert.ErrorIs(t, service.Approve(ctx, request.ID, "op_b", "operator", 0), ErrRoleNotNeeded)
+	assert.NoError(t, service.Approve(ctx, request.ID, "op_sm", "station_master", 0))
+
+	current, err := service.GetApprovalRequest(ctx, request.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "pending", current.Status)
+	assert.Equal(t, 1, current.RemainingApprovals())
+	assert.Equal(t, []string{"control_supervisor"}, current.MissingRoles())
+
+	assert.NoError(t, service.Approve(ctx, request.ID, "op_cs", "control_supervisor", 0))
+	current, err = service.GetApprovalRequest(ctx, request.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "approved", current.Status)
+	assert.Len(t, current.Votes, 3)
+
+	// Zone overrides apply to new requests in that zone
+	other, err := service.CreateApprovalRequest(ctx, "D4", "Z2", map[string]interface{}{"reason": "test"}, "requester")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, other.RequiredApprovals)
+	assert.Empty(t, other.RequiredRoles)
+}
+
+func TestKeepaliveService_TracksEveryApprover(t *testing.T) {
+	db := setupGateTestDB(t)
+	assert.NoError(t, db.AutoMigrate(&model.KeepaliveSession{}))
+	approvals := NewApprovalService(db)
+	approvals.UpdateConfig(&config.GateConfig{
+		ApprovalExpiration: 10 * time.Minute,
+		KeepaliveInterval:  time.Second,
+		KeepaliveTimeout:   time.Minute,
+		Quorums:            map[string]config.QuorumPolicy{"D5": {Required: 4}},
+	})
+	keepalives := NewKeepaliveService(db)
+	ctx := context.Background()
+
+	request, err := approvals.CreateApprovalRequest(ctx, "D5", "Z1", map[string]interface{}{"reason": "test"}, "requester")
+	assert.NoError(t, err)
+	for _, approver := range []string{"op_1", "op_2", "op_3", "op_4"} {
+		assert.NoError(t, approvals.Approve(ctx, request.ID, approver, "", 0))
+	}
+
+	alive, err := keepalives.CheckKeepaliveStatus(ctx, request.ID)
+	assert.NoError(t, err)
+	assert.True(t, alive, "approving counts as the first keepalive")
+
+	// One of four approvers going silent breaks the quorum
+	stale := time.Now().Add(-2 * time.Minute)
+	assert.NoError(t, db.Model(&model.ApprovalVote{}).
+		Where("approval_id = ? AND approver_id = ?", request.ID, "op_4").
+		Update("last_keepalive_at", stale).Error)
+	alive, err = keepalives.CheckKeepaliveStatus(ctx, request.ID)
+	assert.NoError(t, err)
+	assert.False(t, alive)
+
+	assert.NoError(t, keepalives.SendKeepalive(ctx, request.ID, "op_4"))
+	alive, err = keepalives.CheckKeepaliveStatus(ctx, request.ID)
+	assert.NoError(t, err)
+	assert.True(t, alive)
+
+	assert.Error(t, keepalives.SendKeepalive(ctx, request.ID, "op_5"))
+}
+
+func TestApprovalService_ApproverEligibility(t *testing.T) {
+	db := setupGateTestDB(t)
+	assert.NoError(t, db.AutoMigrate(&model.KeepaliveSession{}, &model.ApproverProfile{}, &audit.AuditLog{}))
+	gateConfig := &config.GateConfig{
+		ApprovalExpiration: 10 * time.Minute,
+		KeepaliveInterval:  time.Second,
+		KeepaliveTimeout:   time.Minute,
+		Quorums:            map[string]config.QuorumPolicy{"D4": {Required: 2, Roles: []string{"station_master"}}},
+		ApproverRoles: map[string][]string{
+			"station_master":     {"D3", "D4"},
+			"control_supervisor": {"D3", "D4"},
+			"duty_operator":      {"D3"},
+		},
+	}
+	approvers := NewApproverDirectory(db, gateConfig)
+	service := NewApprovalService(db)
+	service.UpdateConfig(gateConfig)
+	service.SetApproverDirectory(approvers)
+	auditLogger := audit.NewAuditLogger(db)
+	service.SetAuditLogger(auditLogger)
+	ctx := context.Background()
+
+	for _, profile := range []*model.ApproverProfile{
+		{OperatorID: "op_sm", Role: "station_master", Active: true},
+		{OperatorID: "op_cs", Role: "control_supervisor", Active: true, Zones: model.StringArray{"Z1"}},
+		{OperatorID: "op_cs_z2", Role: "control_supervisor", Active: true, Zones: model.StringArray{"Z2"}},
+		{OperatorID: "op_duty", Role: "duty_operator", Active: true},
+		{OperatorID: "op_off", Role: "station_master", Active: true},
+	} {
+		assert.NoError(t, approvers.SaveProfile(ctx, profile))
+		if profile.OperatorID != "op_off" {
+			_, err := approvers.StartShift(ctx, profile.OperatorID, nil)
+			assert.NoError(t, err)
+		}
+	}
+	assert.ErrorIs(t, approvers.SaveProfile(ctx, &model.ApproverProfile{OperatorID: "op_x", Role: "janitor"}), ErrInvalidProfile)
+
+	request, err := service.CreateApprovalRequest(ctx, "D4", "Z1", map[string]interface{}{"reason": "test"}, "op_sm")
+	assert.NoError(t, err)
+
+	assert.ErrorIs(t, service.Approve(ctx, request.ID, "op_sm", "", 0), ErrSelfApproval)
+	assert.ErrorIs(t, service.Approve(ctx, request.ID, "op_unknown", "", 0), ErrApproverIneligible)
+	assert.ErrorIs(t, service.Approve(ctx, request.ID, "op_duty", "", 0), ErrApproverIneligible, "duty operators may not approve D4")
+	assert.ErrorIs(t, service.Approve(ctx, request.ID, "op_off", "", 0), ErrApproverIneligible, "off shift")
+	assert.ErrorIs(t, service.Approve(ctx, request.ID, "op_cs_z2", "", 0), ErrApproverIneligible, "no authority over Z1")
+	assert.ErrorIs(t, service.Approve(ctx, request.ID, "op_cs", "station_master", 0), ErrApproverIneligible, "claimed role must match the profile")
+
+	// Votes take the role from the profile
+	assert.NoError(t, service.Approve(ctx, request.ID, "op_cs", "", 0))
+	current, err := service.GetApprovalRequest(ctx, request.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "control_supervisor", current.Votes[0].Role)
+
+	// Ending a shift removes eligibility
+	_, err = approvers.StartShift(ctx, "op_off", nil)
+	assert.NoError(t, err)
+	_, err = approvers.EndShift(ctx, "op_off")
+	assert.NoError(t, err)
+	assert.ErrorIs(t, service.Approve(ctx, request.ID, "op_off", "", 0), ErrApproverIneligible)
+
+	onShift, err := approvers.ListProfiles(ctx, true)
+	assert.NoError(t, err)
+	assert.Len(t, onShift, 4)
+
+	// Every refused attempt is audited with its reason
+	logs, err := auditLogger.GetAuditLogs(ctx, &audit.AuditLogFilters{OperationType: "approval", TargetID: request.ID})
+	assert.NoError(t, err)
+	assert.Len(t, logs, 7)
+	for _, entry := range logs {
+		assert.Equal(t, "failure", entry.Result)
+		assert.NotEmpty(t, entry.Reason)
+	}
+}
+
+func TestApprovalService_ListAndSummarize(t *testing.T) {
+	db := setupGateTestDB(t)
+	assert.NoError(t, db.AutoMigrate(&model.KeepaliveSession{}))
+	service := NewApprovalService(db)
+	bus := events.NewBus()
+	var requested []events.Event
+	unsubscribe := bus.Subscribe(func(event events.Event) {
+		if event.Type == events.ApprovalRequested {
+			requested = append(requested, event)
+		}
+	})
+	service.SetEventBus(bus)
+	ctx := context.Background()
+
+	create := func(actionType, zoneID, requester string) *model.ApprovalRequest {
+		request, err := service.CreateApprovalRequest(ctx, actionType, zoneID, map[string]interface{}{"reason": "test"}, requester)
+		assert.NoError(t, err)
+		return request
+	}
+	d3 := create("D3", "Z1", "op_a")
+	d4 := create("D4", "Z1", "op_b")
+	create("D5", "Z2", "op_b")
+	assert.Len(t, requested, 3)
+	unsubscribe()
+	create("D3", "Z3", "op_c")
+	assert.Len(t, requested, 3, "unsubscribed handlers receive nothing")
+
+	// One request is past its expiry but not yet swept
+	stale := create("D3", "Z4", "op_c")
+	assert.NoError(t, db.Model(&model.ApprovalRequest{}).Where("id = ?", stale.ID).Update("expires_at", time.Now().Add(-time.Minute)).Error)
+	assert.NoError(t, service.Approve(ctx, d4.ID, "op_a", "", 0))
+
+	byZone, total, err := service.ListApprovalRequests(ctx, ApprovalFilters{ZoneID: "Z1"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+	assert.Len(t, byZone, 2)
+
+	page, total, err := service.ListApprovalRequests(ctx, ApprovalFilters{Status: "pending", Limit: 2, Offset: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(4), total, "expired requests are not pending")
+	assert.Len(t, page, 2)
+
+	d3s, _, err := service.ListApprovalRequests(ctx, ApprovalFilters{ActionType: "D3", Status: "pending"})
+	assert.NoError(t, err)
+	assert.Len(t, d3s, 2)
+
+	// op_a requested d3 and already approved d4, so only the other zones' requests are waiting for them
+	inbox, total, err := service.ListApprovalRequests(ctx, ApprovalFilters{PendingFor: "op_a"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+	for _, request := range inbox {
+		assert.NotEqual(t, d3.ID, request.ID)
+		assert.NotEqual(t, d4.ID, request.ID)
+	}
+
+	expiring, _, err := service.ListApprovalRequests(ctx, ApprovalFilters{ExpiringWithin: time.Hour})
+	assert.NoError(t, err)
+	assert.Len(t, expiring, 4)
+	expiring, _, err = service.ListApprovalRequests(ctx, ApprovalFilters{ExpiringWithin: time.Minute})
+	assert.NoError(t, err)
+	assert.Empty(t, expiring)
+
+	summary := SummarizeApproval(d4, time.Now())
+	assert.Equal(t, 3, summary.RequiredApprovals)
+	assert.Equal(t, 0, summary.Approvals, "summaries reflect the record they are given")
+	current, err := service.GetApprovalRequest(ctx, d4.ID)
+	assert.NoError(t, err)
+	summary = SummarizeApproval(current, time.Now())
+	assert.Equal(t, 2, summary.RemainingSlots)
+	assert.Equal(t, []string{"op_a"}, summary.Approvers)
+	assert.InDelta(t, ApprovalRequestExpiration.Seconds(), *summary.SecondsUntilExpiry, 5)
+
+	overview, err := service.Overview(ctx, "", 15*time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(4), overview.ByStatus["pending"])
+	assert.Equal(t, int64(1), overview.ByStatus["expired"])
+	assert.Len(t, overview.Pending, 4)
+	assert.Equal(t, 4, overview.Expiring)
+}
+
+func TestApprovalService_ExpireStaleIsFenced(t *testing.T) {
+	db := setupGateTestDB(t)
+	assert.NoError(t, db.AutoMigrate(&leader.LeaderFence{}))
+	service := NewApprovalService(db)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	stale, err := service.CreateApprovalRequest(ctx, "D3", "Z1", map[string]interface{}{"reason": "stale"}, "requester")
+	assert.NoError(t, err)
+	assert.NoError(t, db.Model(&model.ApprovalRequest{}).Where("id = ?", stale.ID).
+		Update("expires_at", time.Now().Add(-time.Minute)).Error)
+
+	// Elect this instance and capture the context its leader-only tasks run with
+	elector := leader.NewElector(leader.NewMemoryStore(), db, "background", "instance_a", time.Minute)
+	terms := make(chan context.Context, 1)
+	elector.Go(func(termCtx context.Context) {
+		terms <- termCtx
+		<-termCtx.Done()
+	})
+	go elector.Run(ctx)
+	termCtx := <-terms
+
+	// Another instance takes over while this one still runs its tick
+	assert.NoError(t, db.Model(&leader.LeaderFence{}).Where("name = ?", "background").Update("token", 1000).Error)
+
+	expired, err := service.ExpireStale(termCtx)
+	assert.ErrorIs(t, err, leader.ErrFenced)
+	assert.Zero(t, expired)
+	var current model.ApprovalRequest
+	assert.NoError(t, db.Where("id = ?", stale.ID).First(&current).Error)
+	assert.Equal(t, "pending", current.Status)
+
+	// Requests outside a leader term are not fenced
+	expired, err = service.ExpireStale(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, expired)
+}
+
+func TestApprovalService_ExpireStaleAndMetrics(t *testing.T) {
+	db := setupGateTestDB(t)
+	assert.NoError(t, db.AutoMigrate(&model.KeepaliveSession{}, &audit.AuditLog{}))
+	bus := events.NewBus()
+	service := NewApprovalService(db)
+	service.SetEventBus(bus)
+	auditLogger := audit.NewAuditLogger(db)
+	service.SetAuditLogger(auditLogger)
+	service.SetNotifier(notify.NewDispatcher(notify.NewConsoleNotifier(bus)), []string{notify.ChannelConsole})
+	ctx := context.Background()
+
+	var expiredEvents []events.Event
+	var notified []string
+	bus.Subscribe(func(event events.Event) {
+		switch event.Type {
+		case events.ApprovalExpired:
+			expiredEvents = append(expiredEvents, event)
+		case events.OperatorNotified:
+			notified = append(notified, event.Payload["recipient"].(string))
+		}
+	})
+
+	stale, err := service.CreateApprovalRequest(ctx, "D3", "Z1", map[string]interface{}{"reason": "stale"}, "requester_a")
+	assert.NoError(t, err)
+	fresh, err := service.CreateApprovalRequest(ctx, "D3", "Z1", map[string]interface{}{"reason": "fresh"}, "requester_b")
+	assert.NoError(t, err)
+	approved, err := service.CreateApprovalRequest(ctx, "D5", "Z2", map[string]interface{}{"reason": "approved"}, "requester_c")
+	assert.NoError(t, err)
+	assert.NoError(t, service.Approve(ctx, approved.ID, "approver_a", "", 0))
+	assert.NoError(t, service.Approve(ctx, approved.ID, "approver_b", "", 0))
+	assert.NoError(t, db.Model(&model.ApprovalRequest{}).Where("id = ?", stale.ID).
+		Update("expires_at", time.Now().Add(-time.Minute)).Error)
+
+	// The sweep expires only stale pending requests, and only once
+	expired, err := service.ExpireStale(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, expired)
+	expired, err = service.ExpireStale(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, expired)
+
+	current, err := service.GetApprovalRequest(ctx, stale.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "expired", current.Status)
+	current, err = service.GetApprovalRequest(ctx, fresh.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "pending", current.Status)
+
+	assert.Len(t, expiredEvents, 1)
+	assert.Equal(t, stale.ID, expiredEvents[0].SubjectID)
+	assert.Equal(t, []string{"requester_a"}, notified)
+
+	logs, err := auditLogger.GetAuditLogs(ctx, &audit.AuditLogFilters{OperationType: "approval", TargetID: stale.ID})
+	assert.NoError(t, err)
+	if assert.Len(t, logs, 1) {
+		assert.Equal(t, "expire", logs[0].Action)
+		assert.Equal(t, "success", logs[0].Result)
+	}
+
+	metrics, err := service.Metrics(ctx, "", time.Now().Add(-time.Hour), time.Now().Add(time.Minute))
+	assert.NoError(t, err)
+	if assert.Len(t, metrics, 2) {
+		assert.Equal(t, "D3", metrics[0].ActionType)
+		assert.Equal(t, 2, metrics[0].Requested)
+		assert.Equal(t, 1, metrics[0].Expired)
+		assert.Equal(t, 1, metrics[0].Pending)
+		assert.Equal(t, 1.0, metrics[0].ExpiryRate)
+
+		assert.Equal(t, "D5", metrics[1].ActionType)
+		assert.Equal(t, 1, metrics[1].Approved)
+		assert.Equal(t, 0.0, metrics[1].ExpiryRate)
+		assert.GreaterOrEqual(t, metrics[1].LatencyP95Seconds, 0.0)
+	}
+}
+
+func TestApprovalService_TTLExtension(t *testing.T) {
+	db := setupGateTestDB(t)
+	assert.NoError(t, db.AutoMigrate(&model.KeepaliveSession{}))
+	ttls := NewTTLManager(db)
+	ttls.UpdateConfig(&config.GateConfig{
+		TTLs:    map[string]time.Duration{"D3": 30 * time.Minute},
+		MaxTTLs: map[string]time.Duration{"D3": time.Hour},
+	})
+	service := NewApprovalService(db)
+	service.SetTTLManager(ttls)
+	ctx := context.Background()
+
+	// Put an approved D3 action into effect ten minutes ago with its default TTL
+	action, err := service.CreateApprovalRequest(ctx, "D3", "Z1", map[string]interface{}{"reason": "test"}, "requester")
+	assert.NoError(t, err)
+	assert.NoError(t, service.Approve(ctx, action.ID, "approver_a", "", 0))
+	assert.NoError(t, service.Approve(ctx, action.ID, "approver_b", "", 0))
+	consumedAt := time.Now().Add(-10 * time.Minute)
+	assert.NoError(t, db.Model(&model.ApprovalRequest{}).Where("id = ?", action.ID).Updates(map[string]interface{}{
+		"consumed_at": consumedAt,
+		"expires_at":  consumedAt.Add(30 * time.Minute),
+	}).Error)
+
+	_, err = service.RequestTTLExtension(ctx, action.ID, 2*time.Hour, "still clearing", "requester")
+	assert.ErrorIs(t, err, ErrExtensionCapExceeded)
+	_, err = service.RequestTTLExtension(ctx, action.ID, 20*time.Minute, "shorter", "requester")
+	assert.ErrorIs(t, err, ErrInvalidExtension)
+
+	extension, err := service.RequestTTLExtension(ctx, action.ID, 50*time.Minute, "still clearing", "requester")
+	assert.NoError(t, err)
+	assert.Equal(t, model.ApprovalKindTTLExtension, extension.Kind)
+	assert.Equal(t, action.ID, *extension.TargetID)
+	assert.Equal(t, action.RequiredApprovals, extension.RequiredApprovals, "the action's quorum applies")
+	assert.False(t, extension.ExpiresAt.After(consumedAt.Add(30*time.Minute)), "the extension lapses with the action")
+	_, err = service.RequestTTLExtension(ctx, action.ID, 55*time.Minute, "again", "requester")
+	assert.ErrorIs(t, err, ErrExtensionPending)
+
+	// Nothing changes until the quorum approves
+	assert.NoError(t, service.Approve(ctx, extension.ID, "approver_a", "", 0))
+	current, err := service.GetApprovalRequest(ctx, action.ID)
+	assert.NoError(t, err)
+	assert.WithinDuration(t, consumedAt.Add(30*time.Minute), *current.ExpiresAt, time.Second)
+
+	assert.NoError(t, service.Approve(ctx, extension.ID, "approver_b", "", 0))
+	current, err = service.GetApprovalRequest(ctx, action.ID)
+	assert.NoError(t, err)
+	assert.WithinDuration(t, consumedAt.Add(50*time.Minute), *current.ExpiresAt, time.Second)
+	extended, err := service.GetApprovalRequest(ctx, extension.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "approved", extended.Status)
+	assert.True(t, extended.IsConsumed())
+
+	// An extension approved after the action's TTL ran out is invalidated
+	late, err := service.RequestTTLExtension(ctx, action.ID, time.Hour, "late", "requester")
+	assert.NoError(t, err)
+	assert.NoError(t, db.Model(&model.ApprovalRequest{}).Where("id = ?", action.ID).Update("expires_at", time.Now().Add(-time.Second)).Error)
+	assert.NoError(t, service.Approve(ctx, late.ID, "approver_a", "", 0))
+	assert.ErrorIs(t, service.Approve(ctx, late.ID, "approver_b", "", 0), ErrApprovalInvalidated)
+	_, err = service.RequestTTLExtension(ctx, action.ID, time.Hour, "too late", "requester")
+	assert.ErrorIs(t, err, ErrActionExpired)
+
+	// Only the action is due for rollback, never its extensions
+	expired, err := ttls.GetExpiredActions(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{action.ID}, expired)
+}
+
+// fakeRetractor records retractions instead of publishing them
+type fakeRetractor struct {
+	previews  int
+	published []*RetractionRequest
+}
+
+func (f *fakeRetractor) retraction(req *RetractionRequest) *Retraction {
+	return &Retraction{
+		MsgType:     "Cancel",
+		References:  "erh-safety-system,CAP-original,2026-01-01T00:00:00Z",
+		ZoneID:      req.ZoneID,
+		Headline:    map[string]string{"en": "Alert cancelled"},
+		Description: map[string]string{"en": "Measures have been stood down."},
+	}
+}
+
+func (f *fakeRetractor) PreviewRetraction(ctx context.Context, req *RetractionRequest) (*Retraction, error) {
+	f.previews++
+	return f.retraction(req), nil
+}
+
+func (f *fakeRetractor) Retract(ctx context.Context, req *RetractionRequest) (*Retraction, error) {
+	f.published = append(f.published, req)
+	retraction := f.retraction(req)
+	retraction.Identifier = "CAP-retraction"
+	return retraction, nil
+}
+
+// fakePushNotifier counts situation updates
+type fakePushNotifier struct {
+	zones []string
+}
+
+func (f *fakePushNotifier) SendSituationUpdate(ctx context.Context, zoneID string, headline, body map[string]string) (int, error) {
+	f.zones = append(f.zones, zoneID)
+	return 3, nil
+}
+
+func TestRollbackService_RetractsPublicAlert(t *testing.T) {
+	db := setupGateTestDB(t)
+	assert.NoError(t, db.AutoMigrate(&model.KeepaliveSession{}, &model.AggregatedSummary{}, &decision.DecisionStateRecord{}, &decision.DecisionTransition{}, &audit.EvidenceRecord{}))
+	ttls := NewTTLManager(db)
+	decisionService := decision.NewDecisionService(db, nil)
+	decisionService.SetApprovalGate(NewApprovalGate(ttls))
+	approvals := NewApprovalService(db)
+	approvals.SetDecisionService(decisionService)
+	keepalives := NewKeepaliveService(db)
+	archive := audit.NewEvidenceArchive(db)
+	retractor := &fakeRetractor{}
+	pusher := &fakePushNotifier{}
+	rollbacks := NewRollbackService(db, decisionService, keepalives, ttls)
+	rollbacks.SetMessageRetractor(retractor)
+	rollbacks.SetPushNotifier(pusher)
+	rollbacks.SetEvidenceArchive(archive)
+	ctx := context.Background()
+
+	// Put an approved D3 action into effect
+	summary := &model.AggregatedSummary{ZoneID: "Z1", WindowStart: time.Now().Add(-time.Minute), WindowEnd: time.Now()}
+	assert.NoError(t, db.Create(summary).Error)
+	state, err := decisionService.CreatePreAlert(ctx, "Z1", "op_1", summary.ID)
+	assert.NoError(t, err)
+	action, err := approvals.CreateApprovalRequest(ctx, "D3", "Z1", map[string]interface{}{"reason": "test"}, "requester")
+	assert.NoError(t, err)
+	assert.NoError(t, approvals.Approve(ctx, action.ID, "approver_a", "", 0))
+	assert.NoError(t, approvals.Approve(ctx, action.ID, "approver_b", "", 0))
+
+	// Previewing shows the retraction without publishing anything or changing the decision
+	preview, err := rollbacks.PreviewRollback(ctx, action.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "D3", preview.CurrentState)
+	assert.Equal(t, "D2", preview.TargetState)
+	assert.Equal(t, "Cancel", preview.Retraction.MsgType)
+	assert.Empty(t, preview.Retraction.Identifier)
+	assert.Equal(t, 1, retractor.previews)
+	assert.Empty(t, retractor.published)
+	assert.Empty(t, pusher.zones)
+
+	// Rolling back publishes the retraction, pushes the update and archives both
+	assert.NoError(t, rollbacks.RollbackAction(ctx, action.ID, RollbackReasonTTLExpired))
+	current, err := decisionService.GetDecision(ctx, state.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "D2", current.CurrentState)
+	assert.Len(t, retractor.published, 1)
+	assert.Equal(t, "D2", retractor.published[0].TargetState)
+	assert.Equal(t, []string{"Z1"}, pusher.zones)
+
+	record, err := archive.GetEvidenceByRelatedID(ctx, "rollback", action.ID)
+	assert.NoError(t, err)
+	var evidence RollbackEvidence
+	assert.NoError(t, json.Unmarshal([]byte(record.Snapshot), &evidence))
+	assert.Equal(t, "D3", evidence.FromState)
+	assert.Equal(t, "D2", evidence.ToState)
+	assert.Equal(t, string(RollbackReasonTTLExpired), evidence.Reason)
+	assert.Equal(t, "CAP-retraction", evidence.Retraction.Identifier)
+	assert.Equal(t, "erh-safety-system,CAP-original,2026-01-01T00:00:00Z", evidence.Retraction.References)
+	assert.Equal(t, 3, evidence.PushRecipients)
+
+	// A rolled back action cannot be previewed or rolled back again
+	_, err = rollbacks.PreviewRollback(ctx, action.ID)
+	assert.ErrorIs(t, err, ErrNotRollbackable)
+}
+
+func TestRollbackService_EndsSupersededAction(t *testing.T) {
+	db := setupGateTestDB(t)
+	assert.NoError(t, db.AutoMigrate(&model.KeepaliveSession{}, &model.AggregatedSummary{}, &decision.DecisionStateRecord{}, &decision.DecisionTransition{}))
+	ttls := NewTTLManager(db)
+	decisionService := decision.NewDecisionService(db, nil)
+	decisionService.SetApprovalGate(NewApprovalGate(ttls))
+	approvals := NewApprovalService(db)
+	approvals.SetDecisionService(decisionService)
+	retractor := &fakeRetractor{}
+	rollbacks := NewRollbackService(db, decisionService, NewKeepaliveService(db), ttls)
+	rollbacks.SetMessageRetractor(retractor)
+	ctx := context.Background()
+
+	summary := &model.AggregatedSummary{ZoneID: "Z1", WindowStart: time.Now().Add(-time.Minute), WindowEnd: time.Now()}
+	assert.NoError(t, db.Create(summary).Error)
+	state, err := decisionService.CreatePreAlert(ctx, "Z1", "op_1", summary.ID)
+	assert.NoError(t, err)
+	action, err := approvals.CreateApprovalRequest(ctx, "D3", "Z1", map[string]interface{}{"reason": "test"}, "requester")
+	assert.NoError(t, err)
+	assert.NoError(t, approvals.Approve(ctx, action.ID, "approver_a", "", 0))
+	assert.NoError(t, approvals.Approve(ctx, action.ID, "approver_b", "", 0))
+
+	// An operator stands the decision down before the action's TTL runs out
+	_, err = decisionService.TransitionState(ctx, state.ID, decision.StateD2, "op_1", 0)
+	assert.NoError(t, err)
+
+	_, err = rollbacks.PreviewRollback(ctx, action.ID)
+	assert.ErrorIs(t, err, ErrActionSuperseded)
+
+	// The expired action is ended without touching the decision or publishing a retraction
+	err = rollbacks.RollbackAction(ctx, action.ID, RollbackReasonTTLExpired)
+	assert.ErrorIs(t, err, ErrActionSuperseded)
+	assert.ErrorIs(t, err, ErrNotRollbackable)
+
+	current, err := decisionService.GetDecision(ctx, state.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "D2", current.CurrentState)
+	assert.Empty(t, retractor.published)
+
+	ended, err := approvals.GetApprovalRequest(ctx, action.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "ended", ended.Status)
+	assert.Equal(t, "decision "+state.ID+" moved to D2", ended.Proposal["end_reason"])
+
+	expired, err := ttls.GetExpiredActions(ctx)
+	assert.NoError(t, err)
+	assert.NotContains(t, expired, action.ID)
+}
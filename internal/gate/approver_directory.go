@@ -0,0 +1,157 @@
+package gate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/erh-safety-system/poc/internal/config"
+	"github.com/erh-safety-system/poc/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ApproverDirectory holds approver profiles and decides who may approve which requests
+type ApproverDirectory struct {
+	db *gorm.DB
+
+	mu          sync.RWMutex
+	roleActions map[string][]string
+}
+
+// NewApproverDirectory creates a directory using the role permissions in cfg
+func NewApproverDirectory(db *gorm.DB, cfg *config.GateConfig) *ApproverDirectory {
+	d := &ApproverDirectory{db: db}
+	d.UpdateConfig(cfg)
+	return d
+}
+
+// UpdateConfig replaces the action types each role may approve
+func (d *ApproverDirectory) UpdateConfig(cfg *config.GateConfig) {
+	roleActions := make(map[string][]string, len(cfg.ApproverRoles))
+	for role, actionTypes := range cfg.ApproverRoles {
+		roleActions[role] = append([]string(nil), actionTypes...)
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.roleActions = roleActions
+}
+
+// RoleAllows checks if a role may approve an action type
+func (d *ApproverDirectory) RoleAllows(role, actionType string) bool {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	for _, allowed := range d.roleActions[role] {
+		if allowed == actionType {
+			return true
+		}
+	}
+	return false
+}
+
+// SaveProfile creates or replaces an approver profile
+func (d *ApproverDirectory) SaveProfile(ctx context.Context, profile *model.ApproverProfile) error {
+	if profile.OperatorID == "" || profile.Role == "" {
+		return fmt.Errorf("%w: operator ID and role are required", ErrInvalidProfile)
+	}
+	d.mu.RLock()
+	_, known := d.roleActions[profile.Role]
+	d.mu.RUnlock()
+	if !known {
+		return fmt.Errorf("%w: unknown role %s", ErrInvalidProfile, profile.Role)
+	}
+
+	if err := d.db.WithContext(ctx).Clauses(clause.OnConflict{UpdateAll: true}).Create(profile).Error; err != nil {
+		return fmt.Errorf("failed to save approver profile: %w", err)
+	}
+	return nil
+}
+
+// GetProfile gets an approver profile by operator ID
+func (d *ApproverDirectory) GetProfile(ctx context.Context, operatorID string) (*model.ApproverProfile, error) {
+	var profile model.ApproverProfile
+	if err := d.db.WithContext(ctx).Where("operator_id = ?", operatorID).First(&profile).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrProfileNotFound
+		}
+		return nil, fmt.Errorf("failed to get approver profile: %w", err)
+	}
+	return &profile, nil
+}
+
+// ListProfiles lists approver profiles, optionally only those on shift now
+func (d *ApproverDirectory) ListProfiles(ctx context.Context, onShiftOnly bool) ([]*model.ApproverProfile, error) {
+	var profiles []*model.ApproverProfile
+	if err := d.db.WithContext(ctx).Order("operator_id ASC").Find(&profiles).Error; err != nil {
+		return nil, fmt.Errorf("failed to list approver profiles: %w", err)
+	}
+	if !onShiftOnly {
+		return profiles, nil
+	}
+
+	now := time.Now()
+	onShift := make([]*model.ApproverProfile, 0, len(profiles))
+	for _, profile := range profiles {
+		if profile.Active && profile.OnShift(now) {
+			onShift = append(onShift, profile)
+		}
+	}
+	return onShift, nil
+}
+
+// StartShift puts an operator on shift from now until end; a nil end keeps the shift open
+func (d *ApproverDirectory) StartShift(ctx context.Context, operatorID string, end *time.Time) (*model.ApproverProfile, error) {
+	now := time.Now()
+	if end != nil && !end.After(now) {
+		return nil, fmt.Errorf("%w: shift must end in the future", ErrInvalidProfile)
+	}
+	return d.updateShift(ctx, operatorID, map[string]interface{}{"shift_start": now, "shift_end": end})
+}
+
+// EndShift takes an operator off shift now
+func (d *ApproverDirectory) EndShift(ctx context.Context, operatorID string) (*model.ApproverProfile, error) {
+	return d.updateShift(ctx, operatorID, map[string]interface{}{"shift_end": time.Now()})
+}
+
+// CheckEligibility returns the approver's profile if they may approve the request, or why not.
+// The requester can never approve their own request.
+func (d *ApproverDirectory) CheckEligibility(ctx context.Context, request *model.ApprovalRequest, operatorID string) (*model.ApproverProfile, error) {
+	if operatorID == request.RequesterID {
+		return nil, ErrSelfApproval
+	}
+
+	profile, err := d.GetProfile(ctx, operatorID)
+	if errors.Is(err, ErrProfileNotFound) {
+		return nil, fmt.Errorf("%w: %s has no approver profile", ErrApproverIneligible, operatorID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case !profile.Active:
+		return nil, fmt.Errorf("%w: %s is not an active approver", ErrApproverIneligible, operatorID)
+	case !d.RoleAllows(profile.Role, request.ActionType):
+		return nil, fmt.Errorf("%w: role %s may not approve %s", ErrApproverIneligible, profile.Role, request.ActionType)
+	case !profile.OnShift(time.Now()):
+		return nil, fmt.Errorf("%w: %s is not on shift", ErrApproverIneligible, operatorID)
+	case !profile.CoversZone(request.ZoneID):
+		return nil, fmt.Errorf("%w: %s has no authority over zone %s", ErrApproverIneligible, operatorID, request.ZoneID)
+	}
+	return profile, nil
+}
+
+// updateShift applies shift changes to a profile and returns it
+func (d *ApproverDirectory) updateShift(ctx context.Context, operatorID string, updates map[string]interface{}) (*model.ApproverProfile, error) {
+	result := d.db.WithContext(ctx).Model(&model.ApproverProfile{}).Where("operator_id = ?", operatorID).Updates(updates)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to update shift: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrProfileNotFound
+	}
+	return d.GetProfile(ctx, operatorID)
+}
@@ -6,9 +6,11 @@ import (
 	"time"
 )
 
-// BackgroundMonitor runs background tasks for monitoring and rollback
+// BackgroundMonitor runs background tasks for monitoring, rollback and approval expiry
 type BackgroundMonitor struct {
 	rollbackService *RollbackService
+	approvals       *ApprovalService
+	fence           func(ctx context.Context) error // Refuses a tick once a newer leader took over
 	checkInterval   time.Duration
 }
 
@@ -20,6 +22,16 @@ func NewBackgroundMonitor(rollbackService *RollbackService) *BackgroundMonitor {
 	}
 }
 
+// SetApprovalService sets the service whose stale pending requests are expired on each check
+func (m *BackgroundMonitor) SetApprovalService(approvals *ApprovalService) {
+	m.approvals = approvals
+}
+
+// SetFence sets the check run before each tick; a failing check skips the tick's work
+func (m *BackgroundMonitor) SetFence(fence func(ctx context.Context) error) {
+	m.fence = fence
+}
+
 // Start starts the background monitoring loop
 func (m *BackgroundMonitor) Start(ctx context.Context) {
 	ticker := time.NewTicker(m.checkInterval)
@@ -31,10 +43,28 @@ func (m *BackgroundMonitor) Start(ctx context.Context) {
 			log.Println("Background monitor stopped")
 			return
 		case <-ticker.C:
+			if m.fence != nil {
+				if err := m.fence(ctx); err != nil {
+					log.Printf("Skipping background checks: %v", err)
+					continue
+				}
+			}
+
 			// Check and rollback expired actions
 			if err := m.rollbackService.CheckAndRollback(ctx); err != nil {
 				log.Printf("Error in background rollback check: %v", err)
 			}
+
+			// Expire pending approvals nobody has looked at since they went stale
+			if m.approvals != nil {
+				expired, err := m.approvals.ExpireStale(ctx)
+				if err != nil {
+					log.Printf("Error in background approval expiry: %v", err)
+				}
+				if expired > 0 {
+					log.Printf("Expired %d stale approval requests", expired)
+				}
+			}
 		}
 	}
 }
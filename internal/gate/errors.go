@@ -0,0 +1,53 @@
+package gate
+
+import "errors"
+
+var (
+	// ErrApprovalConflict indicates the approval request was modified by someone else since it was read
+	ErrApprovalConflict = errors.New("approval request was modified concurrently")
+
+	// ErrApprovalInvalidated indicates a fully approved request was not applied because the zone's decision changed since the proposal
+	ErrApprovalInvalidated = errors.New("approval request invalidated")
+
+	// ErrApprovalBlocked indicates a fully approved request was kept approved but not applied because a transition guard refused it
+	ErrApprovalBlocked = errors.New("approval recorded but not applied")
+
+	// ErrAlreadyApproved indicates the operator already approved the request
+	ErrAlreadyApproved = errors.New("operator already approved this request")
+
+	// ErrApproverExcluded indicates the operator is barred from approving by conflict of interest
+	ErrApproverExcluded = errors.New("approver excluded by conflict of interest")
+
+	// ErrRoleNotNeeded indicates the vote would not count towards the quorum's remaining roles
+	ErrRoleNotNeeded = errors.New("approval role not needed for the quorum")
+
+	// ErrSelfApproval indicates the requester tried to approve their own request
+	ErrSelfApproval = errors.New("requester cannot approve their own request")
+
+	// ErrApproverIneligible indicates the operator's role, shift or zone authority does not allow the approval
+	ErrApproverIneligible = errors.New("approver not eligible")
+
+	// ErrProfileNotFound indicates an operator has no approver profile
+	ErrProfileNotFound = errors.New("approver profile not found")
+
+	// ErrInvalidExtension indicates a TTL extension does not apply to the action or does not lengthen its TTL
+	ErrInvalidExtension = errors.New("invalid TTL extension")
+
+	// ErrActionExpired indicates the action's TTL ran out before the extension could take effect
+	ErrActionExpired = errors.New("action TTL already expired")
+
+	// ErrExtensionCapExceeded indicates the extended TTL would exceed the action type's cumulative cap
+	ErrExtensionCapExceeded = errors.New("TTL extension exceeds the cumulative cap")
+
+	// ErrExtensionPending indicates the action already has a pending TTL extension
+	ErrExtensionPending = errors.New("TTL extension already pending")
+
+	// ErrNotRollbackable indicates the action is not in effect, so there is nothing to roll back
+	ErrNotRollbackable = errors.New("action cannot be rolled back")
+
+	// ErrActionSuperseded indicates the action's decision was escalated further or stood down since the action took effect
+	ErrActionSuperseded = errors.New("the decision moved on since the action took effect")
+
+	// ErrInvalidProfile indicates an approver profile or shift change is malformed
+	ErrInvalidProfile = errors.New("invalid approver profile")
+)
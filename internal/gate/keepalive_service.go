@@ -28,30 +28,21 @@ func (s *KeepaliveService) SendKeepalive(ctx context.Context, actionID string, a
 		return fmt.Errorf("keepalive session not found: %w", err)
 	}
 	
-	// Update the appropriate approver's last keepalive time
-	now := time.Now()
-	
-	// Find which approver slot this ID matches
-	var request model.ApprovalRequest
-	if err := s.db.WithContext(ctx).Where("id = ?", actionID).First(&request).Error; err != nil {
-		return fmt.Errorf("approval request not found: %w", err)
+	// Update the approver's vote
+	result := s.db.WithContext(ctx).Model(&model.ApprovalVote{}).
+		Where("approval_id = ? AND approver_id = ?", actionID, approverID).
+		Update("last_keepalive_at", time.Now())
+	if result.Error != nil {
+		return fmt.Errorf("failed to record keepalive: %w", result.Error)
 	}
-	
-	// Update keepalive for the matching approver
-	if request.Approver1ID != nil && *request.Approver1ID == approverID {
-		session.Approver1LastKeepalive = &now
-	} else if request.Approver2ID != nil && *request.Approver2ID == approverID {
-		session.Approver2LastKeepalive = &now
-	} else if request.Approver3ID != nil && *request.Approver3ID == approverID {
-		session.Approver3LastKeepalive = &now
-	} else {
+	if result.RowsAffected == 0 {
 		return fmt.Errorf("approver ID does not match any approver for this action")
 	}
 	
-	return s.db.WithContext(ctx).Save(&session).Error
+	return nil
 }
 
-// CheckKeepaliveStatus checks if all required keepalives are within timeout
+// CheckKeepaliveStatus checks if the approvers with a keepalive within timeout still meet the request's quorum
 func (s *KeepaliveService) CheckKeepaliveStatus(ctx context.Context, actionID string) (bool, error) {
 	var session model.KeepaliveSession
 	if err := s.db.WithContext(ctx).Where("action_id = ?", actionID).First(&session).Error; err != nil {
@@ -63,50 +54,22 @@ func (s *KeepaliveService) CheckKeepaliveStatus(ctx context.Context, actionID st
 	
 	// Get approval request to determine required approvers
 	var request model.ApprovalRequest
-	if err := s.db.WithContext(ctx).Where("id = ?", actionID).First(&request).Error; err != nil {
+	if err := s.db.WithContext(ctx).Preload("Votes").Where("id = ?", actionID).First(&request).Error; err != nil {
 		return false, fmt.Errorf("approval request not found: %w", err)
 	}
 	
 	timeout := time.Duration(session.KeepaliveTimeout) * time.Second
-	
-	// Check each required approver's keepalive
-	required := 2
-	if request.RequiresStrictApproval() {
-		required = 3
-	}
-	
 	now := time.Now()
-	validCount := 0
-	
-	// Check approver 1
-	if request.Approver1ID != nil {
-		if session.Approver1LastKeepalive != nil {
-			if now.Sub(*session.Approver1LastKeepalive) <= timeout {
-				validCount++
-			}
-		}
-	}
-	
-	// Check approver 2
-	if request.Approver2ID != nil {
-		if session.Approver2LastKeepalive != nil {
-			if now.Sub(*session.Approver2LastKeepalive) <= timeout {
-				validCount++
-			}
-		}
-	}
 	
-	// Check approver 3 (for D4)
-	if request.Approver3ID != nil {
-		if session.Approver3LastKeepalive != nil {
-			if now.Sub(*session.Approver3LastKeepalive) <= timeout {
-				validCount++
-			}
+	alive := make([]model.ApprovalVote, 0, len(request.Votes))
+	for _, vote := range request.Votes {
+		if vote.LastKeepaliveAt != nil && now.Sub(*vote.LastKeepaliveAt) <= timeout {
+			alive = append(alive, vote)
 		}
 	}
 	
-	// All required keepalives must be valid
-	return validCount >= required, nil
+	// The live approvers must still make up the quorum, including its roles
+	return model.QuorumMet(request.RequiredApprovals, request.RequiredRoles, alive), nil
 }
 
 // GetExpiredKeepalives finds all actions with expired keepalives
@@ -124,8 +87,8 @@ func (s *KeepaliveService) GetExpiredKeepalives(ctx context.Context) ([]string,
 			continue
 		}
 		
-		// Skip if request is not approved
-		if request.Status != "approved" {
+		// Skip if request is not approved or not yet in effect
+		if request.Status != "approved" || !request.IsConsumed() {
 			continue
 		}
 		
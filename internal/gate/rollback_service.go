@@ -2,10 +2,15 @@ package gate
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"log"
 	"time"
 
+	"github.com/erh-safety-system/poc/internal/audit"
 	"github.com/erh-safety-system/poc/internal/decision"
+	"github.com/erh-safety-system/poc/internal/leader"
 	"github.com/erh-safety-system/poc/internal/model"
 	"gorm.io/gorm"
 )
@@ -16,6 +21,9 @@ type RollbackService struct {
 	decisionService *decision.DecisionService
 	keepaliveService *KeepaliveService
 	ttlManager      *TTLManager
+	retractor       MessageRetractor
+	pushNotifier    PushNotifier
+	evidenceArchive *audit.EvidenceArchive
 }
 
 // NewRollbackService creates a new rollback service
@@ -33,6 +41,21 @@ func NewRollbackService(
 	}
 }
 
+// SetMessageRetractor sets the service that updates or cancels the public alert on rollback
+func (s *RollbackService) SetMessageRetractor(retractor MessageRetractor) {
+	s.retractor = retractor
+}
+
+// SetPushNotifier sets the service that tells app users the situation was updated
+func (s *RollbackService) SetPushNotifier(notifier PushNotifier) {
+	s.pushNotifier = notifier
+}
+
+// SetEvidenceArchive sets the archive that keeps the record of each rollback
+func (s *RollbackService) SetEvidenceArchive(archive *audit.EvidenceArchive) {
+	s.evidenceArchive = archive
+}
+
 // RollbackReason represents the reason for rollback
 type RollbackReason string
 
@@ -42,16 +65,81 @@ const (
 	RollbackReasonManual          RollbackReason = "manual"
 )
 
-// RollbackAction rolls back a high-impact action
-func (s *RollbackService) RollbackAction(ctx context.Context, actionID string, reason RollbackReason) error {
+// RetractionRequest describes the rollback a public retraction is issued for
+type RetractionRequest struct {
+	ZoneID      string
+	DecisionID  string
+	ActionType  string
+	TargetState string
+	Reason      RollbackReason
+}
+
+// Retraction is the CAP Update or Cancel that supersedes the alert published for an action
+type Retraction struct {
+	MsgType     string            `json:"msg_type"`             // Update|Cancel
+	Identifier  string            `json:"identifier,omitempty"` // Empty in previews
+	References  string            `json:"references"`           // sender,identifier,sent of the original alert
+	ZoneID      string            `json:"zone_id"`
+	Headline    map[string]string `json:"headline"`
+	Description map[string]string `json:"description"`
+	Instruction map[string]string `json:"instruction"`
+	Channels    []string          `json:"channels,omitempty"`
+}
+
+// MessageRetractor updates or cancels the public alert for a zone. Both methods return nil when no alert is live.
+type MessageRetractor interface {
+	// PreviewRetraction builds the retraction without publishing it
+	PreviewRetraction(ctx context.Context, req *RetractionRequest) (*Retraction, error)
+	// Retract publishes the retraction on every Route 1 channel
+	Retract(ctx context.Context, req *RetractionRequest) (*Retraction, error)
+}
+
+// PushNotifier tells app users in a zone that the situation was updated, returning how many devices were notified
+type PushNotifier interface {
+	SendSituationUpdate(ctx context.Context, zoneID string, headline, body map[string]string) (int, error)
+}
+
+// RollbackPreview shows what a rollback would change and the retraction it would publish
+type RollbackPreview struct {
+	ApprovalID   string      `json:"approval_id"`
+	ActionType   string      `json:"action_type"`
+	ZoneID       string      `json:"zone_id"`
+	DecisionID   string      `json:"decision_id"`
+	CurrentState string      `json:"current_state"`
+	TargetState  string      `json:"target_state"`
+	Retraction   *Retraction `json:"retraction,omitempty"` // Nil when no alert is live
+}
+
+// RollbackEvidence is the archived record of a rollback and the public messaging it triggered
+type RollbackEvidence struct {
+	ApprovalID      string      `json:"approval_id"`
+	ActionType      string      `json:"action_type"`
+	ZoneID          string      `json:"zone_id"`
+	DecisionID      string      `json:"decision_id"`
+	FromState       string      `json:"from_state"`
+	ToState         string      `json:"to_state"`
+	Reason          string      `json:"reason"`
+	RolledBackAt    time.Time   `json:"rolled_back_at"`
+	Retraction      *Retraction `json:"retraction,omitempty"`
+	RetractionError string      `json:"retraction_error,omitempty"`
+	PushRecipients  int         `json:"push_recipients"`
+	PushError       string      `json:"push_error,omitempty"`
+}
+
+// planRollback loads an action that can be rolled back with its decision and the state to return to
+func (s *RollbackService) planRollback(ctx context.Context, actionID string) (*model.ApprovalRequest, *decision.DecisionStateRecord, decision.DecisionState, error) {
 	// Get approval request
 	var request model.ApprovalRequest
 	if err := s.db.WithContext(ctx).Where("id = ?", actionID).First(&request).Error; err != nil {
-		return fmt.Errorf("approval request not found: %w", err)
+		return nil, nil, "", fmt.Errorf("approval request not found: %w", err)
 	}
 	
 	if request.Status != "approved" {
-		return fmt.Errorf("action is not approved, cannot rollback")
+		return nil, nil, "", fmt.Errorf("%w: action is not approved", ErrNotRollbackable)
+	}
+	
+	if !request.IsConsumed() {
+		return nil, nil, "", fmt.Errorf("%w: action has not taken effect", ErrNotRollbackable)
 	}
 	
 	// Determine target rollback state based on action type
@@ -64,40 +152,202 @@ func (s *RollbackService) RollbackAction(ctx context.Context, actionID string, r
 	case "D5":
 		targetState = decision.StateD4 // Rollback to D4, or D3 if D4 doesn't exist
 	default:
-		return fmt.Errorf("unknown action type: %s", request.ActionType)
+		return nil, nil, "", fmt.Errorf("%w: unknown action type %s", ErrNotRollbackable, request.ActionType)
 	}
 	
-	// Get decision state for this zone
-	decisionState, err := s.decisionService.GetLatestState(ctx, request.ZoneID)
+	// Get the decision the approval was consumed by
+	if request.DecisionID == nil {
+		return nil, nil, "", fmt.Errorf("%w: approval is not bound to a decision", ErrNotRollbackable)
+	}
+	decisionState, err := s.decisionService.GetDecision(ctx, *request.DecisionID)
 	if err != nil {
-		return fmt.Errorf("failed to get decision state: %w", err)
+		return nil, nil, "", fmt.Errorf("failed to get decision state: %w", err)
 	}
 	
-	if decisionState == nil {
-		return fmt.Errorf("no decision state found for zone")
+	// Rolling back a decision that was escalated further or stood down would undo a change this action did not make
+	if decisionState.CurrentState != request.ActionType {
+		return &request, decisionState, "", fmt.Errorf("%w: %w: decision %s is in %s, not %s",
+			ErrNotRollbackable, ErrActionSuperseded, decisionState.ID, decisionState.CurrentState, request.ActionType)
+	}
+	
+	return &request, decisionState, targetState, nil
+}
+
+// retractionRequest describes the retraction for rolling an action back to targetState
+func retractionRequest(request *model.ApprovalRequest, decisionState *decision.DecisionStateRecord, targetState decision.DecisionState, reason RollbackReason) *RetractionRequest {
+	return &RetractionRequest{
+		ZoneID:      request.ZoneID,
+		DecisionID:  decisionState.ID,
+		ActionType:  request.ActionType,
+		TargetState: string(targetState),
+		Reason:      reason,
+	}
+}
+
+// PreviewRollback shows the transition and retraction text rolling the action back now would issue, without changing anything
+func (s *RollbackService) PreviewRollback(ctx context.Context, actionID string) (*RollbackPreview, error) {
+	request, decisionState, targetState, err := s.planRollback(ctx, actionID)
+	if err != nil {
+		return nil, err
 	}
 	
-	// Transition to rollback state
-	_, err = s.decisionService.TransitionState(ctx, decisionState.ID, targetState, "system_rollback")
+	preview := &RollbackPreview{
+		ApprovalID:   request.ID,
+		ActionType:   request.ActionType,
+		ZoneID:       request.ZoneID,
+		DecisionID:   decisionState.ID,
+		CurrentState: decisionState.CurrentState,
+		TargetState:  string(targetState),
+	}
+	if s.retractor != nil {
+		preview.Retraction, err = s.retractor.PreviewRetraction(ctx, retractionRequest(request, decisionState, targetState, RollbackReasonManual))
+		if err != nil {
+			return nil, fmt.Errorf("failed to preview retraction: %w", err)
+		}
+	}
+	
+	return preview, nil
+}
+
+// RollbackAction rolls back a high-impact action. An action whose decision moved on since it took effect
+// is ended instead and ErrActionSuperseded returned.
+func (s *RollbackService) RollbackAction(ctx context.Context, actionID string, reason RollbackReason) error {
+	request, decisionState, targetState, err := s.planRollback(ctx, actionID)
+	if errors.Is(err, ErrActionSuperseded) {
+		if endErr := s.endAction(ctx, request, decisionState); endErr != nil {
+			return endErr
+		}
+		return err
+	}
 	if err != nil {
-		return fmt.Errorf("failed to transition state: %w", err)
+		return err
 	}
 	
-	// Mark approval request as rolled back
+	rolledBackAt := time.Now()
 	request.Status = "rolled_back"
 	if request.Proposal == nil {
 		request.Proposal = make(model.JSONB)
 	}
 	request.Proposal["rollback_reason"] = string(reason)
-	request.Proposal["rolled_back_at"] = time.Now().Format(time.RFC3339)
+	request.Proposal["rolled_back_at"] = rolledBackAt.Format(time.RFC3339)
 	
-	if err := s.db.WithContext(ctx).Save(&request).Error; err != nil {
-		return fmt.Errorf("failed to update approval request: %w", err)
+	// Transition to the rollback state and mark the approval rolled back together
+	var rolledBack *decision.DecisionStateRecord
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// A background rollback from a leader that has since been replaced must not touch the decision
+		if err := leader.Guard(tx); err != nil {
+			return err
+		}
+		var err error
+		rolledBack, err = s.decisionService.WithDB(tx).Transition(ctx, &decision.TransitionRequest{
+			DecisionID:      decisionState.ID,
+			TargetState:     targetState,
+			OperatorID:      "system_rollback",
+			Reason:          fmt.Sprintf("rollback: %s", reason),
+			ApprovalID:      request.ID,
+			ExpectedVersion: decisionState.Version,
+			Automatic:       true,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to transition state: %w", err)
+		}
+		if err := saveApproval(tx, request); err != nil {
+			return fmt.Errorf("failed to update approval request: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	s.decisionService.CacheState(ctx, rolledBack)
+	
+	// The public retraction goes out only once the rollback is committed, so messaging failures are recorded rather than returned
+	evidence := &RollbackEvidence{
+		ApprovalID:   request.ID,
+		ActionType:   request.ActionType,
+		ZoneID:       request.ZoneID,
+		DecisionID:   decisionState.ID,
+		FromState:    decisionState.CurrentState,
+		ToState:      string(targetState),
+		Reason:       string(reason),
+		RolledBackAt: rolledBackAt,
+	}
+	s.retract(ctx, retractionRequest(request, decisionState, targetState, reason), evidence)
+	if err := s.archiveEvidence(ctx, evidence); err != nil {
+		log.Printf("Failed to archive rollback evidence for %s: %v", request.ID, err)
+	}
+	
+	return nil
+}
+
+// endAction closes an action whose decision was escalated further or stood down, so its TTL and keepalive no longer apply
+func (s *RollbackService) endAction(ctx context.Context, request *model.ApprovalRequest, decisionState *decision.DecisionStateRecord) error {
+	request.Status = "ended"
+	if request.Proposal == nil {
+		request.Proposal = make(model.JSONB)
 	}
+	request.Proposal["end_reason"] = fmt.Sprintf("decision %s moved to %s", decisionState.ID, decisionState.CurrentState)
+	request.Proposal["ended_at"] = time.Now().Format(time.RFC3339)
 	
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return saveApproval(tx, request)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to end approval request: %w", err)
+	}
 	return nil
 }
 
+// retract publishes the retraction and pushes the situation update, recording the outcome in evidence
+func (s *RollbackService) retract(ctx context.Context, req *RetractionRequest, evidence *RollbackEvidence) {
+	if s.retractor == nil {
+		return
+	}
+	
+	retraction, err := s.retractor.Retract(ctx, req)
+	if err != nil {
+		log.Printf("Failed to retract public alert for zone %s: %v", req.ZoneID, err)
+		evidence.RetractionError = err.Error()
+		return
+	}
+	if retraction == nil {
+		return
+	}
+	evidence.Retraction = retraction
+	
+	if s.pushNotifier == nil {
+		return
+	}
+	recipients, err := s.pushNotifier.SendSituationUpdate(ctx, req.ZoneID, retraction.Headline, retraction.Description)
+	evidence.PushRecipients = recipients
+	if err != nil {
+		log.Printf("Failed to push situation update for zone %s: %v", req.ZoneID, err)
+		evidence.PushError = err.Error()
+	}
+}
+
+// archiveEvidence stores the rollback record for the action
+func (s *RollbackService) archiveEvidence(ctx context.Context, evidence *RollbackEvidence) error {
+	if s.evidenceArchive == nil {
+		return nil
+	}
+	
+	snapshot, err := json.Marshal(evidence)
+	if err != nil {
+		return fmt.Errorf("failed to marshal rollback evidence: %w", err)
+	}
+	
+	_, err = s.evidenceArchive.ArchiveEvidence(ctx, &audit.EvidenceArchiveRequest{
+		EvidenceType:    "rollback",
+		RelatedID:       evidence.ApprovalID,
+		ZoneID:          evidence.ZoneID,
+		Snapshot:        string(snapshot),
+		ArchivedBy:      "system_rollback",
+		RetentionPeriod: 7 * 365 * 24 * time.Hour, // 7 years
+	})
+	return err
+}
+
 // CheckAndRollback checks for conditions requiring rollback and executes them
 func (s *RollbackService) CheckAndRollback(ctx context.Context) error {
 	// Check for expired keepalives
@@ -107,10 +357,7 @@ func (s *RollbackService) CheckAndRollback(ctx context.Context) error {
 	}
 	
 	for _, actionID := range expiredKeepalives {
-		if err := s.RollbackAction(ctx, actionID, RollbackReasonKeepaliveTimeout); err != nil {
-			// Log error but continue with other rollbacks
-			_ = err
-		}
+		s.rollbackExpired(ctx, actionID, RollbackReasonKeepaliveTimeout)
 	}
 	
 	// Check for expired TTLs
@@ -120,12 +367,21 @@ func (s *RollbackService) CheckAndRollback(ctx context.Context) error {
 	}
 	
 	for _, actionID := range expiredTTLs {
-		if err := s.RollbackAction(ctx, actionID, RollbackReasonTTLExpired); err != nil {
-			// Log error but continue with other rollbacks
-			_ = err
-		}
+		s.rollbackExpired(ctx, actionID, RollbackReasonTTLExpired)
 	}
 	
 	return nil
 }
 
+// rollbackExpired rolls back one expired action, logging failures so the remaining rollbacks still run
+func (s *RollbackService) rollbackExpired(ctx context.Context, actionID string, reason RollbackReason) {
+	err := s.RollbackAction(ctx, actionID, reason)
+	switch {
+	case err == nil:
+		log.Printf("Rolled back action %s: %s", actionID, reason)
+	case errors.Is(err, ErrActionSuperseded):
+		log.Printf("Ended action %s instead of rolling back: %v", actionID, err)
+	default:
+		log.Printf("Failed to roll back action %s (%s): %v", actionID, reason, err)
+	}
+}
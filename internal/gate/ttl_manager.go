@@ -3,8 +3,10 @@ package gate
 import (
 	"context"
 	"fmt"
+	"sync"
 	"time"
 
+	"github.com/erh-safety-system/poc/internal/config"
 	"github.com/erh-safety-system/poc/internal/model"
 	"gorm.io/gorm"
 )
@@ -12,15 +14,73 @@ import (
 // TTLManager manages TTL for high-impact actions
 type TTLManager struct {
 	db *gorm.DB
+
+	mu      sync.RWMutex
+	ttls    map[string]time.Duration // action_type -> default TTL
+	maxTTLs map[string]time.Duration // action_type -> cap on the TTL including extensions
 }
 
 // NewTTLManager creates a new TTL manager
 func NewTTLManager(db *gorm.DB) *TTLManager {
 	return &TTLManager{
-		db: db,
+		db:   db,
+		ttls: map[string]time.Duration{
+			"D3": DefaultTTLD3,
+			"D4": DefaultTTLD4,
+			"D5": DefaultTTLD5,
+		},
+		maxTTLs: map[string]time.Duration{
+			"D3": DefaultMaxTTLD3,
+			"D4": DefaultMaxTTLD4,
+			"D5": DefaultMaxTTLD5,
+		},
+	}
+}
+
+// UpdateConfig replaces the default TTLs applied to subsequently approved actions and the caps on extensions
+func (s *TTLManager) UpdateConfig(cfg *config.GateConfig) {
+	ttls := make(map[string]time.Duration, len(cfg.TTLs))
+	for actionType, ttl := range cfg.TTLs {
+		ttls[actionType] = ttl
+	}
+	maxTTLs := make(map[string]time.Duration, len(cfg.MaxTTLs))
+	for actionType, ttl := range cfg.MaxTTLs {
+		maxTTLs[actionType] = ttl
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ttls = ttls
+	s.maxTTLs = maxTTLs
+}
+
+// WithDB returns a TTL manager sharing this manager's settings but using the given connection (e.g. a transaction)
+func (s *TTLManager) WithDB(db *gorm.DB) *TTLManager {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return &TTLManager{
+		db:      db,
+		ttls:    s.ttls,
+		maxTTLs: s.maxTTLs,
 	}
 }
 
+// DefaultTTL returns the configured TTL for an action type
+func (s *TTLManager) DefaultTTL(actionType string) (time.Duration, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	ttl, exists := s.ttls[actionType]
+	return ttl, exists
+}
+
+// MaxTTL returns the cap on an action type's total TTL including extensions
+func (s *TTLManager) MaxTTL(actionType string) (time.Duration, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	ttl, exists := s.maxTTLs[actionType]
+	return ttl, exists
+}
+
 // SetTTL sets TTL for an approved action
 func (s *TTLManager) SetTTL(ctx context.Context, actionID string, actionType string, customTTL *time.Duration) error {
 	var request model.ApprovalRequest
@@ -37,23 +97,22 @@ func (s *TTLManager) SetTTL(ctx context.Context, actionID string, actionType str
 	if customTTL != nil {
 		ttl = *customTTL
 	} else {
-		switch actionType {
-		case "D3":
-			ttl = DefaultTTLD3
-		case "D4":
-			ttl = DefaultTTLD4
-		case "D5":
-			ttl = DefaultTTLD5
-		default:
+		defaultTTL, exists := s.DefaultTTL(actionType)
+		if !exists {
 			return fmt.Errorf("unknown action type: %s", actionType)
 		}
+		ttl = defaultTTL
 	}
 	
-	// Set expiration time
-	if request.ApprovedAt == nil {
+	// Set expiration time; the TTL runs from when the approved action took effect
+	start := request.ConsumedAt
+	if start == nil {
+		start = request.ApprovedAt
+	}
+	if start == nil {
 		return fmt.Errorf("approved_at is not set")
 	}
-	expiresAt := request.ApprovedAt.Add(ttl)
+	expiresAt := start.Add(ttl)
 	
 	// Store TTL in proposal
 	if request.Proposal == nil {
@@ -63,7 +122,7 @@ func (s *TTLManager) SetTTL(ctx context.Context, actionID string, actionType str
 	request.Proposal["expires_at"] = expiresAt.Format(time.RFC3339)
 	
 	request.ExpiresAt = &expiresAt
-	return s.db.WithContext(ctx).Save(&request).Error
+	return saveApproval(s.db.WithContext(ctx), &request)
 }
 
 // CheckTTL checks if an action's TTL has expired
@@ -88,7 +147,7 @@ func (s *TTLManager) CheckTTL(ctx context.Context, actionID string) (bool, error
 func (s *TTLManager) GetExpiredActions(ctx context.Context) ([]string, error) {
 	var requests []model.ApprovalRequest
 	if err := s.db.WithContext(ctx).
-		Where("status = ? AND expires_at IS NOT NULL AND expires_at < ?", "approved", time.Now()).
+		Where("kind = ? AND status = ? AND consumed_at IS NOT NULL AND expires_at IS NOT NULL AND expires_at < ?", model.ApprovalKindAction, "approved", time.Now()).
 		Find(&requests).Error; err != nil {
 		return nil, err
 	}
@@ -101,30 +160,62 @@ func (s *TTLManager) GetExpiredActions(ctx context.Context) ([]string, error) {
 	return expiredActionIDs, nil
 }
 
-// ExtendTTL extends TTL for an action (requires re-approval)
-func (s *TTLManager) ExtendTTL(ctx context.Context, actionID string, newTTL time.Duration) error {
-	var request model.ApprovalRequest
-	if err := s.db.WithContext(ctx).Where("id = ?", actionID).First(&request).Error; err != nil {
-		return fmt.Errorf("approval request not found: %w", err)
+// CheckExtension verifies an action in effect may have its TTL, counted from when it took effect, raised to newTTL
+func (s *TTLManager) CheckExtension(action *model.ApprovalRequest, newTTL time.Duration, now time.Time) error {
+	if action.IsExtension() || action.Status != "approved" || action.ConsumedAt == nil {
+		return fmt.Errorf("%w: %s is not an action in effect", ErrInvalidExtension, action.ID)
+	}
+	if action.ExpiresAt == nil || !now.Before(*action.ExpiresAt) {
+		return fmt.Errorf("%w: %s", ErrActionExpired, action.ID)
 	}
 	
-	if request.Status != "approved" {
-		return fmt.Errorf("action is not approved")
+	current := action.ExpiresAt.Sub(*action.ConsumedAt)
+	if newTTL <= current {
+		return fmt.Errorf("%w: new TTL %s does not exceed the current TTL %s", ErrInvalidExtension, newTTL, current)
+	}
+	maxTTL, exists := s.MaxTTL(action.ActionType)
+	if !exists {
+		return fmt.Errorf("unknown action type: %s", action.ActionType)
+	}
+	if newTTL > maxTTL {
+		return fmt.Errorf("%w: %s exceeds the %s cap of %s", ErrExtensionCapExceeded, newTTL, action.ActionType, maxTTL)
+	}
+	return nil
+}
+
+// ExtendTTL applies an approved TTL extension to the action it targets and returns the extended action.
+// The extension must still fit the cap and arrive before the action's current TTL runs out.
+func (s *TTLManager) ExtendTTL(ctx context.Context, extension *model.ApprovalRequest) (*model.ApprovalRequest, error) {
+	if !extension.IsExtension() || extension.TargetID == nil {
+		return nil, fmt.Errorf("%w: %s is not a TTL extension", ErrInvalidExtension, extension.ID)
+	}
+	if extension.Status != "approved" {
+		return nil, fmt.Errorf("TTL extension is not approved")
 	}
 	
-	// Extend TTL
-	now := time.Now()
-	expiresAt := now.Add(newTTL)
+	var action model.ApprovalRequest
+	if err := s.db.WithContext(ctx).Where("id = ?", *extension.TargetID).First(&action).Error; err != nil {
+		return nil, fmt.Errorf("approval request not found: %w", err)
+	}
 	
-	if request.Proposal == nil {
-		request.Proposal = make(model.JSONB)
+	now := time.Now()
+	newTTL := time.Duration(extension.RequestedTTL) * time.Second
+	if err := s.CheckExtension(&action, newTTL, now); err != nil {
+		return nil, err
 	}
-	request.Proposal["ttl_seconds"] = int(newTTL.Seconds())
-	request.Proposal["expires_at"] = expiresAt.Format(time.RFC3339)
-	request.Proposal["extended_at"] = now.Format(time.RFC3339)
 	
-	request.ExpiresAt = &expiresAt
+	expiresAt := action.ConsumedAt.Add(newTTL)
+	if action.Proposal == nil {
+		action.Proposal = make(model.JSONB)
+	}
+	action.Proposal["ttl_seconds"] = int(newTTL.Seconds())
+	action.Proposal["expires_at"] = expiresAt.Format(time.RFC3339)
+	action.Proposal["extended_at"] = now.Format(time.RFC3339)
+	action.Proposal["extension_id"] = extension.ID
 	
-	return s.db.WithContext(ctx).Save(&request).Error
+	action.ExpiresAt = &expiresAt
+	if err := saveApproval(s.db.WithContext(ctx), &action); err != nil {
+		return nil, err
+	}
+	return &action, nil
 }
-
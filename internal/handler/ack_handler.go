@@ -0,0 +1,153 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/erh-safety-system/poc/internal/decision"
+	"github.com/erh-safety-system/poc/internal/notify"
+	"github.com/erh-safety-system/poc/internal/vo"
+	"github.com/gin-gonic/gin"
+)
+
+// AckHandler handles decision acknowledgement and time-to-acknowledge KPIs
+type AckHandler struct {
+	decisionService *decision.DecisionService
+	ladder          *notify.AckLadder
+}
+
+// NewAckHandler creates a new acknowledgement handler
+func NewAckHandler(decisionService *decision.DecisionService, ladder *notify.AckLadder) *AckHandler {
+	return &AckHandler{
+		decisionService: decisionService,
+		ladder:          ladder,
+	}
+}
+
+// Acknowledge handles POST /api/v1/operator/decisions/:decision_id/acknowledge
+func (h *AckHandler) Acknowledge(c *gin.Context) {
+	operatorID := h.getOperatorID(c)
+	if operatorID == "" {
+		c.JSON(http.StatusUnauthorized, vo.ErrorResponse{
+			Message: "Operator ID not found",
+			Code:    "UNAUTHORIZED",
+		})
+		return
+	}
+
+	state, err := h.decisionService.Acknowledge(c.Request.Context(), c.Param("decision_id"), operatorID)
+	if err != nil {
+		switch {
+		case errors.Is(err, decision.ErrDecisionNotFound):
+			c.JSON(http.StatusNotFound, vo.ErrorResponse{
+				Message: "Decision not found",
+				Code:    "NOT_FOUND",
+			})
+		case errors.Is(err, decision.ErrAlreadyAcknowledged):
+			c.JSON(http.StatusConflict, vo.ErrorResponse{
+				Message: err.Error(),
+				Code:    "ALREADY_ACKNOWLEDGED",
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, vo.ErrorResponse{
+				Message: "Failed to acknowledge decision",
+				Code:    "INTERNAL_ERROR",
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":   "success",
+		"decision": state,
+	})
+}
+
+// ListPending handles GET /api/v1/operator/acknowledgements/pending
+func (h *AckHandler) ListPending(c *gin.Context) {
+	states, err := h.decisionService.ListUnacknowledged(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, vo.ErrorResponse{
+			Message: "Failed to list unacknowledged decisions",
+			Code:    "INTERNAL_ERROR",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":    "success",
+		"decisions": states,
+		"count":     len(states),
+	})
+}
+
+// GetEscalations handles GET /api/v1/operator/decisions/:decision_id/escalations
+func (h *AckHandler) GetEscalations(c *gin.Context) {
+	escalations, err := h.ladder.ListEscalations(c.Request.Context(), c.Param("decision_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, vo.ErrorResponse{
+			Message: "Failed to get escalations",
+			Code:    "INTERNAL_ERROR",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":      "success",
+		"escalations": escalations,
+	})
+}
+
+// GetTTAStats handles GET /api/v1/operator/kpi/tta?zone_id=<zone>&start_time=<RFC3339>&end_time=<RFC3339>.
+// The window defaults to the last 24 hours.
+func (h *AckHandler) GetTTAStats(c *gin.Context) {
+	endTime := time.Now()
+	if endTimeStr := c.Query("end_time"); endTimeStr != "" {
+		t, err := time.Parse(time.RFC3339, endTimeStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, vo.ErrorResponse{
+				Message: "Invalid end_time format",
+				Code:    "INVALID_REQUEST",
+			})
+			return
+		}
+		endTime = t
+	}
+
+	startTime := endTime.Add(-24 * time.Hour)
+	if startTimeStr := c.Query("start_time"); startTimeStr != "" {
+		t, err := time.Parse(time.RFC3339, startTimeStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, vo.ErrorResponse{
+				Message: "Invalid start_time format",
+				Code:    "INVALID_REQUEST",
+			})
+			return
+		}
+		startTime = t
+	}
+
+	stats, err := h.decisionService.AcknowledgementStats(c.Request.Context(), c.Query("zone_id"), startTime, endTime)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, vo.ErrorResponse{
+			Message: "Failed to compute time to acknowledge",
+			Code:    "INTERNAL_ERROR",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"tta":    stats,
+	})
+}
+
+// getOperatorID extracts operator ID from context
+func (h *AckHandler) getOperatorID(c *gin.Context) string {
+	operatorID, exists := c.Get("operator_id")
+	if !exists {
+		return ""
+	}
+	return operatorID.(string)
+}
@@ -1,23 +1,34 @@
 package handler
 
 import (
+	"errors"
+	"io"
 	"net/http"
-
+	"strings"
+	"time"
+	
 	"github.com/erh-safety-system/poc/internal/dto"
+	"github.com/erh-safety-system/poc/internal/events"
 	"github.com/erh-safety-system/poc/internal/gate"
+	"github.com/erh-safety-system/poc/internal/model"
 	"github.com/erh-safety-system/poc/internal/vo"
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 // ApprovalHandler handles approval-related requests
 type ApprovalHandler struct {
 	approvalService *gate.ApprovalService
+	rollbackService *gate.RollbackService
+	bus             *events.Bus
 }
 
-// NewApprovalHandler creates a new approval handler
-func NewApprovalHandler(approvalService *gate.ApprovalService) *ApprovalHandler {
+// NewApprovalHandler creates a new approval handler; the bus feeds the live approval stream
+func NewApprovalHandler(approvalService *gate.ApprovalService, rollbackService *gate.RollbackService, bus *events.Bus) *ApprovalHandler {
 	return &ApprovalHandler{
 		approvalService: approvalService,
+		rollbackService: rollbackService,
+		bus:             bus,
 	}
 }
 
@@ -56,20 +67,7 @@ func (h *ApprovalHandler) CreateApprovalRequest(c *gin.Context) {
 		return
 	}
 	
-	response := vo.ApprovalRequestResponse{
-		ID:          approvalRequest.ID,
-		ActionType:  approvalRequest.ActionType,
-		ZoneID:      approvalRequest.ZoneID,
-		Proposal:    map[string]interface{}(approvalRequest.Proposal),
-		RequesterID: approvalRequest.RequesterID,
-		Approver1ID: approvalRequest.Approver1ID,
-		Approver2ID: approvalRequest.Approver2ID,
-		Approver3ID: approvalRequest.Approver3ID,
-		Status:      approvalRequest.Status,
-		CreatedAt:   approvalRequest.CreatedAt,
-		ExpiresAt:   approvalRequest.ExpiresAt,
-		ApprovedAt:  approvalRequest.ApprovedAt,
-	}
+	response := newApprovalResponse(approvalRequest)
 	
 	c.JSON(http.StatusOK, gin.H{
 		"status":   "success",
@@ -77,6 +75,107 @@ func (h *ApprovalHandler) CreateApprovalRequest(c *gin.Context) {
 	})
 }
 
+// RequestTTLExtension handles POST /api/v1/approvals/:id/extend.
+// The extension is a new approval request needing the same quorum as the action.
+func (h *ApprovalHandler) RequestTTLExtension(c *gin.Context) {
+	var req dto.ApprovalTTLExtension
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, vo.ErrorResponse{
+			Message: err.Error(),
+			Code:    "INVALID_REQUEST",
+		})
+		return
+	}
+	
+	operatorID := h.getOperatorID(c)
+	if operatorID == "" {
+		c.JSON(http.StatusUnauthorized, vo.ErrorResponse{
+			Message: "Operator ID not found",
+			Code:    "UNAUTHORIZED",
+		})
+		return
+	}
+	
+	extension, err := h.approvalService.RequestTTLExtension(
+		c.Request.Context(),
+		c.Param("id"),
+		time.Duration(req.TTLSeconds)*time.Second,
+		req.Reason,
+		operatorID,
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, gate.ErrActionExpired):
+			c.JSON(http.StatusConflict, vo.ErrorResponse{
+				Message: err.Error(),
+				Code:    "ACTION_EXPIRED",
+			})
+		case errors.Is(err, gate.ErrExtensionPending):
+			c.JSON(http.StatusConflict, vo.ErrorResponse{
+				Message: err.Error(),
+				Code:    "EXTENSION_PENDING",
+			})
+		case errors.Is(err, gate.ErrExtensionCapExceeded):
+			c.JSON(http.StatusBadRequest, vo.ErrorResponse{
+				Message: err.Error(),
+				Code:    "TTL_CAP_EXCEEDED",
+			})
+		case errors.Is(err, gate.ErrInvalidExtension):
+			c.JSON(http.StatusBadRequest, vo.ErrorResponse{
+				Message: err.Error(),
+				Code:    "INVALID_EXTENSION",
+			})
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, vo.ErrorResponse{
+				Message: "Approval request not found",
+				Code:    "NOT_FOUND",
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, vo.ErrorResponse{
+				Message: "Failed to request TTL extension",
+				Code:    "INTERNAL_ERROR",
+			})
+		}
+		return
+	}
+	
+	c.JSON(http.StatusOK, gin.H{
+		"status":   "success",
+		"approval": newApprovalResponse(extension),
+	})
+}
+
+// PreviewRollback handles GET /api/v1/approvals/:id/rollback/preview.
+// It shows the state the action would roll back to and the public retraction that would go out.
+func (h *ApprovalHandler) PreviewRollback(c *gin.Context) {
+	preview, err := h.rollbackService.PreviewRollback(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		switch {
+		case errors.Is(err, gate.ErrNotRollbackable):
+			c.JSON(http.StatusConflict, vo.ErrorResponse{
+				Message: err.Error(),
+				Code:    "NOT_ROLLBACKABLE",
+			})
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, vo.ErrorResponse{
+				Message: "Approval request not found",
+				Code:    "NOT_FOUND",
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, vo.ErrorResponse{
+				Message: "Failed to preview rollback",
+				Code:    "INTERNAL_ERROR",
+			})
+		}
+		return
+	}
+	
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"preview": preview,
+	})
+}
+
 // Approve handles POST /api/v1/approvals/:id/approve
 func (h *ApprovalHandler) Approve(c *gin.Context) {
 	requestID := c.Param("id")
@@ -99,7 +198,26 @@ func (h *ApprovalHandler) Approve(c *gin.Context) {
 		return
 	}
 	
-	if err := h.approvalService.Approve(c.Request.Context(), requestID, operatorID); err != nil {
+	if err := h.approvalService.Approve(c.Request.Context(), requestID, operatorID, req.Role, req.ExpectedVersion); err != nil {
+		if errors.Is(err, gate.ErrApprovalConflict) {
+			h.respondConflict(c, requestID)
+			return
+		}
+		if errors.Is(err, gate.ErrApprovalInvalidated) {
+			h.respondNotApplied(c, requestID, "APPROVAL_INVALIDATED", err)
+			return
+		}
+		if errors.Is(err, gate.ErrApprovalBlocked) {
+			h.respondNotApplied(c, requestID, "TRANSITION_BLOCKED", err)
+			return
+		}
+		if errors.Is(err, gate.ErrSelfApproval) || errors.Is(err, gate.ErrApproverIneligible) || errors.Is(err, gate.ErrApproverExcluded) {
+			c.JSON(http.StatusForbidden, vo.ErrorResponse{
+				Message: err.Error(),
+				Code:    "APPROVER_INELIGIBLE",
+			})
+			return
+		}
 		c.JSON(http.StatusBadRequest, vo.ErrorResponse{
 			Message: err.Error(),
 			Code:    "APPROVAL_FAILED",
@@ -117,20 +235,7 @@ func (h *ApprovalHandler) Approve(c *gin.Context) {
 		return
 	}
 	
-	response := vo.ApprovalRequestResponse{
-		ID:          approvalRequest.ID,
-		ActionType:  approvalRequest.ActionType,
-		ZoneID:      approvalRequest.ZoneID,
-		Proposal:    map[string]interface{}(approvalRequest.Proposal),
-		RequesterID: approvalRequest.RequesterID,
-		Approver1ID: approvalRequest.Approver1ID,
-		Approver2ID: approvalRequest.Approver2ID,
-		Approver3ID: approvalRequest.Approver3ID,
-		Status:      approvalRequest.Status,
-		CreatedAt:   approvalRequest.CreatedAt,
-		ExpiresAt:   approvalRequest.ExpiresAt,
-		ApprovedAt:  approvalRequest.ApprovedAt,
-	}
+	response := newApprovalResponse(approvalRequest)
 	
 	c.JSON(http.StatusOK, gin.H{
 		"status":   "success",
@@ -160,7 +265,11 @@ func (h *ApprovalHandler) Reject(c *gin.Context) {
 		return
 	}
 	
-	if err := h.approvalService.Reject(c.Request.Context(), requestID, operatorID, req.Reason); err != nil {
+	if err := h.approvalService.Reject(c.Request.Context(), requestID, operatorID, req.Reason, req.ExpectedVersion); err != nil {
+		if errors.Is(err, gate.ErrApprovalConflict) {
+			h.respondConflict(c, requestID)
+			return
+		}
 		c.JSON(http.StatusBadRequest, vo.ErrorResponse{
 			Message: err.Error(),
 			Code:    "REJECTION_FAILED",
@@ -187,20 +296,7 @@ func (h *ApprovalHandler) GetApprovalRequest(c *gin.Context) {
 		return
 	}
 	
-	response := vo.ApprovalRequestResponse{
-		ID:          approvalRequest.ID,
-		ActionType:  approvalRequest.ActionType,
-		ZoneID:      approvalRequest.ZoneID,
-		Proposal:    map[string]interface{}(approvalRequest.Proposal),
-		RequesterID: approvalRequest.RequesterID,
-		Approver1ID: approvalRequest.Approver1ID,
-		Approver2ID: approvalRequest.Approver2ID,
-		Approver3ID: approvalRequest.Approver3ID,
-		Status:      approvalRequest.Status,
-		CreatedAt:   approvalRequest.CreatedAt,
-		ExpiresAt:   approvalRequest.ExpiresAt,
-		ApprovedAt:  approvalRequest.ApprovedAt,
-	}
+	response := newApprovalResponse(approvalRequest)
 	
 	c.JSON(http.StatusOK, gin.H{
 		"status":   "success",
@@ -208,6 +304,306 @@ func (h *ApprovalHandler) GetApprovalRequest(c *gin.Context) {
 	})
 }
 
+// newApprovalResponse converts an approval request and its votes into a response
+func newApprovalResponse(approvalRequest *model.ApprovalRequest) vo.ApprovalRequestResponse {
+	votes := make([]vo.ApprovalVoteResponse, 0, len(approvalRequest.Votes))
+	for _, vote := range approvalRequest.Votes {
+		votes = append(votes, vo.ApprovalVoteResponse{
+			ApproverID:      vote.ApproverID,
+			Role:            vote.Role,
+			CreatedAt:       vote.CreatedAt,
+			LastKeepaliveAt: vote.LastKeepaliveAt,
+		})
+	}
+	
+	return vo.ApprovalRequestResponse{
+		ID:                 approvalRequest.ID,
+		Kind:               approvalRequest.Kind,
+		ActionType:         approvalRequest.ActionType,
+		TargetID:           approvalRequest.TargetID,
+		RequestedTTL:       approvalRequest.RequestedTTL,
+		ZoneID:             approvalRequest.ZoneID,
+		Proposal:           map[string]interface{}(approvalRequest.Proposal),
+		RequesterID:        approvalRequest.RequesterID,
+		RequiredApprovals:  approvalRequest.RequiredApprovals,
+		RequiredRoles:      []string(approvalRequest.RequiredRoles),
+		ExcludedApprovers:  []string(approvalRequest.ExcludedApprovers),
+		Votes:              votes,
+		RemainingApprovals: approvalRequest.RemainingApprovals(),
+		MissingRoles:       approvalRequest.MissingRoles(),
+		Status:             approvalRequest.Status,
+		Version:            approvalRequest.Version,
+		CreatedAt:          approvalRequest.CreatedAt,
+		ExpiresAt:          approvalRequest.ExpiresAt,
+		ApprovedAt:         approvalRequest.ApprovedAt,
+		DecisionID:         approvalRequest.DecisionID,
+		ConsumedAt:         approvalRequest.ConsumedAt,
+		BaseDecisionID:     approvalRequest.BaseDecisionID,
+		BaseState:          approvalRequest.BaseState,
+		BaseVersion:        approvalRequest.BaseVersion,
+	}
+}
+
+// ListApprovalRequests handles
+// GET /api/v1/approvals?zone_id=&status=&action_type=&kind=&mine=true&expiring_within=5m&limit=&offset=.
+// mine=true lists pending requests the caller could approve now.
+func (h *ApprovalHandler) ListApprovalRequests(c *gin.Context) {
+	filters := gate.ApprovalFilters{
+		ZoneID:     c.Query("zone_id"),
+		Status:     c.Query("status"),
+		ActionType: c.Query("action_type"),
+		Kind:       c.Query("kind"),
+	}
+	if c.Query("mine") == "true" {
+		filters.PendingFor = h.getOperatorID(c)
+		if filters.PendingFor == "" {
+			c.JSON(http.StatusUnauthorized, vo.ErrorResponse{
+				Message: "Operator ID not found",
+				Code:    "UNAUTHORIZED",
+			})
+			return
+		}
+	}
+	h.listApprovals(c, filters)
+}
+
+// Inbox handles GET /api/v1/approvals/inbox: pending requests the caller could approve now
+func (h *ApprovalHandler) Inbox(c *gin.Context) {
+	operatorID := h.getOperatorID(c)
+	if operatorID == "" {
+		c.JSON(http.StatusUnauthorized, vo.ErrorResponse{
+			Message: "Operator ID not found",
+			Code:    "UNAUTHORIZED",
+		})
+		return
+	}
+	h.listApprovals(c, gate.ApprovalFilters{
+		ZoneID:     c.Query("zone_id"),
+		ActionType: c.Query("action_type"),
+		PendingFor: operatorID,
+	})
+}
+
+// GetSummary handles GET /api/v1/approvals/summary?zone_id=<zone>&expiring_within=2m
+func (h *ApprovalHandler) GetSummary(c *gin.Context) {
+	expiringWithin := 2 * time.Minute
+	if value := c.Query("expiring_within"); value != "" {
+		duration, err := time.ParseDuration(value)
+		if err != nil || duration <= 0 {
+			c.JSON(http.StatusBadRequest, vo.ErrorResponse{
+				Message: "Invalid expiring_within duration",
+				Code:    "INVALID_REQUEST",
+			})
+			return
+		}
+		expiringWithin = duration
+	}
+	
+	overview, err := h.approvalService.Overview(c.Request.Context(), c.Query("zone_id"), expiringWithin)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, vo.ErrorResponse{
+			Message: "Failed to summarise approval requests",
+			Code:    "INTERNAL_ERROR",
+		})
+		return
+	}
+	
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"summary": overview,
+	})
+}
+
+// GetMetrics handles GET /api/v1/approvals/metrics?zone_id=<zone>&start_time=<RFC3339>&end_time=<RFC3339>:
+// approval latency and expiry rate per action type. The window defaults to the last 24 hours.
+func (h *ApprovalHandler) GetMetrics(c *gin.Context) {
+	endTime := time.Now()
+	if endTimeStr := c.Query("end_time"); endTimeStr != "" {
+		t, err := time.Parse(time.RFC3339, endTimeStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, vo.ErrorResponse{
+				Message: "Invalid end_time format",
+				Code:    "INVALID_REQUEST",
+			})
+			return
+		}
+		endTime = t
+	}
+	
+	startTime := endTime.Add(-24 * time.Hour)
+	if startTimeStr := c.Query("start_time"); startTimeStr != "" {
+		t, err := time.Parse(time.RFC3339, startTimeStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, vo.ErrorResponse{
+				Message: "Invalid start_time format",
+				Code:    "INVALID_REQUEST",
+			})
+			return
+		}
+		startTime = t
+	}
+	
+	metrics, err := h.approvalService.Metrics(c.Request.Context(), c.Query("zone_id"), startTime, endTime)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, vo.ErrorResponse{
+			Message: "Failed to compute approval metrics",
+			Code:    "INTERNAL_ERROR",
+		})
+		return
+	}
+	
+	c.JSON(http.StatusOK, gin.H{
+		"status":     "success",
+		"metrics":    metrics,
+		"start_time": startTime,
+		"end_time":   endTime,
+	})
+}
+
+// GetApprovalSummary handles GET /api/v1/approvals/:id/summary
+func (h *ApprovalHandler) GetApprovalSummary(c *gin.Context) {
+	approvalRequest, err := h.approvalService.GetApprovalRequest(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, vo.ErrorResponse{
+			Message: "Approval request not found",
+			Code:    "NOT_FOUND",
+		})
+		return
+	}
+	
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"summary": gate.SummarizeApproval(approvalRequest, time.Now()),
+	})
+}
+
+// Stream handles GET /api/v1/approvals/stream?zone_id=<zone> as server-sent events.
+// Each approval event is sent with its type as the event name; a ping keeps idle connections open.
+func (h *ApprovalHandler) Stream(c *gin.Context) {
+	zoneID := c.Query("zone_id")
+	feed := make(chan events.Event, 16)
+	unsubscribe := h.bus.Subscribe(func(event events.Event) {
+		if !strings.HasPrefix(string(event.Type), "approval.") || (zoneID != "" && event.ZoneID != zoneID) {
+			return
+		}
+		select {
+		case feed <- event:
+		default:
+			// A slow client misses events rather than blocking the publisher
+		}
+	})
+	defer unsubscribe()
+	
+	ping := time.NewTicker(30 * time.Second)
+	defer ping.Stop()
+	
+	// The stream outlives the server's write timeout
+	_ = http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{})
+	
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no")
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case event := <-feed:
+			c.SSEvent(string(event.Type), event)
+			return true
+		case now := <-ping.C:
+			c.SSEvent("ping", now.Format(time.RFC3339))
+			return true
+		}
+	})
+}
+
+// listApprovals responds with one page of approval requests
+func (h *ApprovalHandler) listApprovals(c *gin.Context, filters gate.ApprovalFilters) {
+	if value := c.Query("expiring_within"); value != "" {
+		duration, err := time.ParseDuration(value)
+		if err != nil || duration <= 0 {
+			c.JSON(http.StatusBadRequest, vo.ErrorResponse{
+				Message: "Invalid expiring_within duration",
+				Code:    "INVALID_REQUEST",
+			})
+			return
+		}
+		filters.ExpiringWithin = duration
+	}
+	if limitStr := c.Query("limit"); limitStr != "" {
+		if limit, err := parseInt(limitStr); err == nil && limit > 0 {
+			filters.Limit = limit
+		}
+	}
+	if offsetStr := c.Query("offset"); offsetStr != "" {
+		if offset, err := parseInt(offsetStr); err == nil && offset >= 0 {
+			filters.Offset = offset
+		}
+	}
+	
+	requests, total, err := h.approvalService.ListApprovalRequests(c.Request.Context(), filters)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, vo.ErrorResponse{
+			Message: "Failed to list approval requests",
+			Code:    "INTERNAL_ERROR",
+		})
+		return
+	}
+	
+	now := time.Now()
+	approvals := make([]vo.ApprovalRequestResponse, 0, len(requests))
+	summaries := make([]*gate.ApprovalSummary, 0, len(requests))
+	for _, request := range requests {
+		approvals = append(approvals, newApprovalResponse(request))
+		summaries = append(summaries, gate.SummarizeApproval(request, now))
+	}
+	
+	c.JSON(http.StatusOK, gin.H{
+		"status":    "success",
+		"approvals": approvals,
+		"summaries": summaries,
+		"count":     len(approvals),
+		"total":     total,
+		"offset":    filters.Offset,
+	})
+}
+
+// respondConflict returns 409 with the approval request's current state
+func (h *ApprovalHandler) respondConflict(c *gin.Context, requestID string) {
+	current, err := h.approvalService.GetApprovalRequest(c.Request.Context(), requestID)
+	if err != nil {
+		c.JSON(http.StatusConflict, vo.ErrorResponse{
+			Message: gate.ErrApprovalConflict.Error(),
+			Code:    "VERSION_CONFLICT",
+		})
+		return
+	}
+	
+	c.JSON(http.StatusConflict, gin.H{
+		"message": gate.ErrApprovalConflict.Error(),
+		"code":    "VERSION_CONFLICT",
+		"current": current,
+	})
+}
+
+// respondNotApplied returns 409 when the final approval could not be applied, because the zone changed
+// (the request is invalidated) or a transition guard refused it (the request stays approved)
+func (h *ApprovalHandler) respondNotApplied(c *gin.Context, requestID, code string, cause error) {
+	current, err := h.approvalService.GetApprovalRequest(c.Request.Context(), requestID)
+	if err != nil {
+		c.JSON(http.StatusConflict, vo.ErrorResponse{
+			Message: cause.Error(),
+			Code:    code,
+		})
+		return
+	}
+	
+	c.JSON(http.StatusConflict, gin.H{
+		"message": cause.Error(),
+		"code":    code,
+		"current": current,
+	})
+}
+
 // getOperatorID extracts operator ID from context
 func (h *ApprovalHandler) getOperatorID(c *gin.Context) string {
 	// TODO: Implement proper operator ID extraction from auth token
@@ -0,0 +1,151 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/erh-safety-system/poc/internal/dto"
+	"github.com/erh-safety-system/poc/internal/gate"
+	"github.com/erh-safety-system/poc/internal/model"
+	"github.com/erh-safety-system/poc/internal/vo"
+	"github.com/gin-gonic/gin"
+)
+
+// ApproverHandler handles approver profiles and shifts
+type ApproverHandler struct {
+	approvers *gate.ApproverDirectory
+}
+
+// NewApproverHandler creates a new approver handler
+func NewApproverHandler(approvers *gate.ApproverDirectory) *ApproverHandler {
+	return &ApproverHandler{
+		approvers: approvers,
+	}
+}
+
+// ListApprovers handles GET /api/v1/approvers?on_shift=true
+func (h *ApproverHandler) ListApprovers(c *gin.Context) {
+	profiles, err := h.approvers.ListProfiles(c.Request.Context(), c.Query("on_shift") == "true")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, vo.ErrorResponse{
+			Message: "Failed to list approvers",
+			Code:    "INTERNAL_ERROR",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":    "success",
+		"approvers": profiles,
+		"count":     len(profiles),
+	})
+}
+
+// GetApprover handles GET /api/v1/approvers/:operator_id
+func (h *ApproverHandler) GetApprover(c *gin.Context) {
+	profile, err := h.approvers.GetProfile(c.Request.Context(), c.Param("operator_id"))
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":   "success",
+		"approver": profile,
+	})
+}
+
+// SaveApprover handles PUT /api/v1/approvers/:operator_id
+func (h *ApproverHandler) SaveApprover(c *gin.Context) {
+	var req dto.ApproverProfileUpsert
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, vo.ErrorResponse{
+			Message: err.Error(),
+			Code:    "INVALID_REQUEST",
+		})
+		return
+	}
+
+	profile := &model.ApproverProfile{
+		OperatorID: c.Param("operator_id"),
+		Name:       req.Name,
+		Role:       req.Role,
+		Zones:      model.StringArray(req.Zones),
+		Active:     req.Active == nil || *req.Active,
+	}
+
+	// Keep the current shift when a profile is replaced
+	if existing, err := h.approvers.GetProfile(c.Request.Context(), profile.OperatorID); err == nil {
+		profile.ShiftStart = existing.ShiftStart
+		profile.ShiftEnd = existing.ShiftEnd
+		profile.CreatedAt = existing.CreatedAt
+	}
+
+	if err := h.approvers.SaveProfile(c.Request.Context(), profile); err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":   "success",
+		"approver": profile,
+	})
+}
+
+// StartShift handles POST /api/v1/approvers/:operator_id/shift
+func (h *ApproverHandler) StartShift(c *gin.Context) {
+	var req dto.ShiftStart
+	if err := c.ShouldBindJSON(&req); err != nil && c.Request.ContentLength > 0 {
+		c.JSON(http.StatusBadRequest, vo.ErrorResponse{
+			Message: err.Error(),
+			Code:    "INVALID_REQUEST",
+		})
+		return
+	}
+
+	profile, err := h.approvers.StartShift(c.Request.Context(), c.Param("operator_id"), req.Until)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":   "success",
+		"approver": profile,
+	})
+}
+
+// EndShift handles DELETE /api/v1/approvers/:operator_id/shift
+func (h *ApproverHandler) EndShift(c *gin.Context) {
+	profile, err := h.approvers.EndShift(c.Request.Context(), c.Param("operator_id"))
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":   "success",
+		"approver": profile,
+	})
+}
+
+// respondError maps approver directory errors to responses
+func (h *ApproverHandler) respondError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, gate.ErrProfileNotFound):
+		c.JSON(http.StatusNotFound, vo.ErrorResponse{
+			Message: err.Error(),
+			Code:    "NOT_FOUND",
+		})
+	case errors.Is(err, gate.ErrInvalidProfile):
+		c.JSON(http.StatusBadRequest, vo.ErrorResponse{
+			Message: err.Error(),
+			Code:    "INVALID_REQUEST",
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, vo.ErrorResponse{
+			Message: "Failed to update approver",
+			Code:    "INTERNAL_ERROR",
+		})
+	}
+}
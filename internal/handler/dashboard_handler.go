@@ -13,6 +13,7 @@ import (
 // DashboardHandler handles dashboard/operator interface requests
 type DashboardHandler struct {
 	decisionService        *decision.DecisionService
+	evaluator              *decision.DecisionEvaluator
 	complexityCalculator   *erh.ComplexityCalculator
 	ethicalPrimeCalculator *erh.EthicalPrimeCalculator
 }
@@ -20,11 +21,13 @@ type DashboardHandler struct {
 // NewDashboardHandler creates a new dashboard handler
 func NewDashboardHandler(
 	decisionService *decision.DecisionService,
+	evaluator *decision.DecisionEvaluator,
 	complexityCalculator *erh.ComplexityCalculator,
 	ethicalPrimeCalculator *erh.EthicalPrimeCalculator,
 ) *DashboardHandler {
 	return &DashboardHandler{
 		decisionService:        decisionService,
+		evaluator:              evaluator,
 		complexityCalculator:   complexityCalculator,
 		ethicalPrimeCalculator: ethicalPrimeCalculator,
 	}
@@ -79,12 +82,19 @@ func (h *DashboardHandler) GetDashboardData(c *gin.Context) {
 		}
 	}
 	
+	// Latest recorded evaluation and why it proposed what it did
+	var latestEvaluation *decision.EvaluationRecord
+	if evaluations, err := h.evaluator.ListEvaluations(c.Request.Context(), zoneID, 1); err == nil && len(evaluations) > 0 {
+		latestEvaluation = evaluations[0]
+	}
+	
 	c.JSON(http.StatusOK, gin.H{
 		"status":            "success",
 		"zone_id":           zoneID,
 		"decision_state":    state,
 		"complexity_metrics": complexityMetrics,
 		"ethical_primes":    ethicalPrimes,
+		"latest_evaluation": latestEvaluation,
 	})
 }
 
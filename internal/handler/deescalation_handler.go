@@ -0,0 +1,126 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/erh-safety-system/poc/internal/decision"
+	"github.com/erh-safety-system/poc/internal/vo"
+	"github.com/gin-gonic/gin"
+)
+
+// DeEscalationHandler handles operator confirmation of proposed stand-downs
+type DeEscalationHandler struct {
+	deEscalator *decision.DeEscalator
+}
+
+// NewDeEscalationHandler creates a new de-escalation handler
+func NewDeEscalationHandler(deEscalator *decision.DeEscalator) *DeEscalationHandler {
+	return &DeEscalationHandler{
+		deEscalator: deEscalator,
+	}
+}
+
+// ListProposals handles GET /api/v1/operator/deescalations?zone_id=<zone>&status=<status>.
+// Pending proposals are listed unless another status (or "all") is requested.
+func (h *DeEscalationHandler) ListProposals(c *gin.Context) {
+	status := c.DefaultQuery("status", decision.ProposalPending)
+	if status == "all" {
+		status = ""
+	}
+
+	proposals, err := h.deEscalator.ListProposals(c.Request.Context(), c.Query("zone_id"), status)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, vo.ErrorResponse{
+			Message: "Failed to list de-escalation proposals",
+			Code:    "INTERNAL_ERROR",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":    "success",
+		"proposals": proposals,
+		"count":     len(proposals),
+	})
+}
+
+// ConfirmProposal handles POST /api/v1/operator/deescalations/:proposal_id/confirm
+func (h *DeEscalationHandler) ConfirmProposal(c *gin.Context) {
+	operatorID := h.getOperatorID(c)
+	if operatorID == "" {
+		c.JSON(http.StatusUnauthorized, vo.ErrorResponse{
+			Message: "Operator ID not found",
+			Code:    "UNAUTHORIZED",
+		})
+		return
+	}
+
+	state, err := h.deEscalator.ConfirmProposal(c.Request.Context(), c.Param("proposal_id"), operatorID)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":   "success",
+		"decision": state,
+	})
+}
+
+// RejectProposal handles POST /api/v1/operator/deescalations/:proposal_id/reject
+func (h *DeEscalationHandler) RejectProposal(c *gin.Context) {
+	operatorID := h.getOperatorID(c)
+	if operatorID == "" {
+		c.JSON(http.StatusUnauthorized, vo.ErrorResponse{
+			Message: "Operator ID not found",
+			Code:    "UNAUTHORIZED",
+		})
+		return
+	}
+
+	if err := h.deEscalator.RejectProposal(c.Request.Context(), c.Param("proposal_id"), operatorID); err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "De-escalation proposal rejected",
+	})
+}
+
+// respondError maps de-escalation errors to responses
+func (h *DeEscalationHandler) respondError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, decision.ErrProposalNotFound):
+		c.JSON(http.StatusNotFound, vo.ErrorResponse{
+			Message: err.Error(),
+			Code:    "NOT_FOUND",
+		})
+	case errors.Is(err, decision.ErrProposalResolved):
+		c.JSON(http.StatusConflict, vo.ErrorResponse{
+			Message: err.Error(),
+			Code:    "PROPOSAL_RESOLVED",
+		})
+	case errors.Is(err, decision.ErrVersionConflict), errors.Is(err, decision.ErrInvalidTransition):
+		c.JSON(http.StatusConflict, vo.ErrorResponse{
+			Message: "Decision changed since the proposal was made; the proposal was withdrawn",
+			Code:    "PROPOSAL_WITHDRAWN",
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, vo.ErrorResponse{
+			Message: "Failed to resolve de-escalation proposal",
+			Code:    "INTERNAL_ERROR",
+		})
+	}
+}
+
+// getOperatorID extracts operator ID from context
+func (h *DeEscalationHandler) getOperatorID(c *gin.Context) string {
+	operatorID, exists := c.Get("operator_id")
+	if !exists {
+		return ""
+	}
+	return operatorID.(string)
+}
@@ -0,0 +1,238 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/erh-safety-system/poc/internal/dto"
+	"github.com/erh-safety-system/poc/internal/incident"
+	"github.com/erh-safety-system/poc/internal/vo"
+	"github.com/gin-gonic/gin"
+)
+
+// IncidentHandler handles incidents spanning multiple zones and decisions
+type IncidentHandler struct {
+	incidentService *incident.IncidentService
+}
+
+// NewIncidentHandler creates a new incident handler
+func NewIncidentHandler(incidentService *incident.IncidentService) *IncidentHandler {
+	return &IncidentHandler{
+		incidentService: incidentService,
+	}
+}
+
+// CreateIncident handles POST /api/v1/operator/incidents
+func (h *IncidentHandler) CreateIncident(c *gin.Context) {
+	operatorID := h.getOperatorID(c)
+	if operatorID == "" {
+		c.JSON(http.StatusUnauthorized, vo.ErrorResponse{
+			Message: "Operator ID not found",
+			Code:    "UNAUTHORIZED",
+		})
+		return
+	}
+
+	var req dto.IncidentCreate
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, vo.ErrorResponse{
+			Message: "Invalid request: " + err.Error(),
+			Code:    "INVALID_REQUEST",
+		})
+		return
+	}
+	if req.CommanderID == "" {
+		req.CommanderID = operatorID
+	}
+
+	created, err := h.incidentService.CreateIncident(c.Request.Context(), &incident.CreateInput{
+		Title:       req.Title,
+		Description: req.Description,
+		CommanderID: req.CommanderID,
+		OpenedBy:    operatorID,
+	})
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"status":   "success",
+		"incident": created,
+	})
+}
+
+// ListIncidents handles GET /api/v1/operator/incidents?status=<status>&zone_id=<zone>
+func (h *IncidentHandler) ListIncidents(c *gin.Context) {
+	incidents, err := h.incidentService.ListIncidents(c.Request.Context(), c.Query("status"), c.Query("zone_id"))
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":    "success",
+		"incidents": incidents,
+		"count":     len(incidents),
+	})
+}
+
+// GetIncident handles GET /api/v1/operator/incidents/:incident_id.
+// The response groups linked items by type for per-incident review.
+func (h *IncidentHandler) GetIncident(c *gin.Context) {
+	report, err := h.incidentService.GetReport(c.Request.Context(), c.Param("incident_id"))
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   report,
+	})
+}
+
+// UpdateStatus handles POST /api/v1/operator/incidents/:incident_id/status
+func (h *IncidentHandler) UpdateStatus(c *gin.Context) {
+	operatorID := h.getOperatorID(c)
+	if operatorID == "" {
+		c.JSON(http.StatusUnauthorized, vo.ErrorResponse{
+			Message: "Operator ID not found",
+			Code:    "UNAUTHORIZED",
+		})
+		return
+	}
+
+	var req dto.IncidentStatusUpdate
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, vo.ErrorResponse{
+			Message: "Invalid request: " + err.Error(),
+			Code:    "INVALID_REQUEST",
+		})
+		return
+	}
+
+	updated, err := h.incidentService.UpdateStatus(c.Request.Context(), c.Param("incident_id"), req.Status, operatorID)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":   "success",
+		"incident": updated,
+	})
+}
+
+// AssignCommander handles PUT /api/v1/operator/incidents/:incident_id/commander
+func (h *IncidentHandler) AssignCommander(c *gin.Context) {
+	var req dto.IncidentCommanderAssign
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, vo.ErrorResponse{
+			Message: "Invalid request: " + err.Error(),
+			Code:    "INVALID_REQUEST",
+		})
+		return
+	}
+
+	updated, err := h.incidentService.AssignCommander(c.Request.Context(), c.Param("incident_id"), req.CommanderID)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":   "success",
+		"incident": updated,
+	})
+}
+
+// LinkItem handles POST /api/v1/operator/incidents/:incident_id/links
+func (h *IncidentHandler) LinkItem(c *gin.Context) {
+	operatorID := h.getOperatorID(c)
+	if operatorID == "" {
+		c.JSON(http.StatusUnauthorized, vo.ErrorResponse{
+			Message: "Operator ID not found",
+			Code:    "UNAUTHORIZED",
+		})
+		return
+	}
+
+	var req dto.IncidentLinkCreate
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, vo.ErrorResponse{
+			Message: "Invalid request: " + err.Error(),
+			Code:    "INVALID_REQUEST",
+		})
+		return
+	}
+
+	link, err := h.incidentService.LinkItem(c.Request.Context(), c.Param("incident_id"), req.ItemType, req.ItemID, req.Note, operatorID)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"link":   link,
+	})
+}
+
+// UnlinkItem handles DELETE /api/v1/operator/incidents/:incident_id/links/:item_type/:item_id
+func (h *IncidentHandler) UnlinkItem(c *gin.Context) {
+	operatorID := h.getOperatorID(c)
+	if operatorID == "" {
+		c.JSON(http.StatusUnauthorized, vo.ErrorResponse{
+			Message: "Operator ID not found",
+			Code:    "UNAUTHORIZED",
+		})
+		return
+	}
+
+	err := h.incidentService.UnlinkItem(c.Request.Context(), c.Param("incident_id"), c.Param("item_type"), c.Param("item_id"), operatorID)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Item unlinked from incident",
+	})
+}
+
+// respondError maps incident errors to responses
+func (h *IncidentHandler) respondError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, incident.ErrIncidentNotFound), errors.Is(err, incident.ErrItemNotFound), errors.Is(err, incident.ErrNotLinked):
+		c.JSON(http.StatusNotFound, vo.ErrorResponse{
+			Message: err.Error(),
+			Code:    "NOT_FOUND",
+		})
+	case errors.Is(err, incident.ErrInvalidItemType):
+		c.JSON(http.StatusBadRequest, vo.ErrorResponse{
+			Message: err.Error(),
+			Code:    "INVALID_REQUEST",
+		})
+	case errors.Is(err, incident.ErrIncidentClosed), errors.Is(err, incident.ErrInvalidStatus):
+		c.JSON(http.StatusConflict, vo.ErrorResponse{
+			Message: err.Error(),
+			Code:    "INVALID_INCIDENT_STATE",
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, vo.ErrorResponse{
+			Message: "Failed to process incident request",
+			Code:    "INTERNAL_ERROR",
+		})
+	}
+}
+
+// getOperatorID extracts operator ID from context
+func (h *IncidentHandler) getOperatorID(c *gin.Context) string {
+	operatorID, exists := c.Get("operator_id")
+	if !exists {
+		return ""
+	}
+	return operatorID.(string)
+}
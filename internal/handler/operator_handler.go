@@ -1,9 +1,14 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/erh-safety-system/poc/internal/decision"
+	"github.com/erh-safety-system/poc/internal/dto"
+	"github.com/erh-safety-system/poc/internal/model"
 	"github.com/erh-safety-system/poc/internal/service"
 	"github.com/erh-safety-system/poc/internal/vo"
 	"github.com/gin-gonic/gin"
@@ -12,13 +17,15 @@ import (
 // OperatorHandler handles operator-related requests
 type OperatorHandler struct {
 	decisionService *decision.DecisionService
+	evaluator       *decision.DecisionEvaluator
 	signalService   *service.SignalService
 }
 
 // NewOperatorHandler creates a new operator handler
-func NewOperatorHandler(decisionService *decision.DecisionService, signalService *service.SignalService) *OperatorHandler {
+func NewOperatorHandler(decisionService *decision.DecisionService, evaluator *decision.DecisionEvaluator, signalService *service.SignalService) *OperatorHandler {
 	return &OperatorHandler{
 		decisionService: decisionService,
+		evaluator:       evaluator,
 		signalService:   signalService,
 	}
 }
@@ -70,7 +77,12 @@ func (h *OperatorHandler) TransitionState(c *gin.Context) {
 	operatorID := h.getOperatorID(c)
 	
 	var req struct {
-		TargetState string `json:"target_state" binding:"required,oneof=D0 D1 D2 D3 D4 D5 D6"`
+		TargetState     string `json:"target_state" binding:"required,oneof=D0 D1 D2 D3 D4 D5 D6"`
+		Reason          string `json:"reason"`
+		SummaryID       string `json:"summary_id"`
+		ApprovalID      string `json:"approval_id"`
+		EvaluationID    string `json:"evaluation_id"` // Evaluation the operator acted on; defaults to the latest for the summary
+		ExpectedVersion int    `json:"expected_version" binding:"omitempty,min=1"` // Version the operator based the transition on
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, vo.ErrorResponse{
@@ -80,12 +92,52 @@ func (h *OperatorHandler) TransitionState(c *gin.Context) {
 		return
 	}
 	
-	targetState := decision.DecisionState(req.TargetState)
-	
 	// Transition state
-	decisionState, err := h.decisionService.TransitionState(c.Request.Context(), decisionID, targetState, operatorID)
+	decisionState, err := h.decisionService.Transition(c.Request.Context(), &decision.TransitionRequest{
+		DecisionID:      decisionID,
+		TargetState:     decision.DecisionState(req.TargetState),
+		OperatorID:      operatorID,
+		Reason:          req.Reason,
+		SummaryID:       req.SummaryID,
+		ApprovalID:      req.ApprovalID,
+		EvaluationID:    req.EvaluationID,
+		ExpectedVersion: req.ExpectedVersion,
+	})
 	if err != nil {
-		if err == decision.ErrInvalidTransition {
+		if errors.Is(err, decision.ErrVersionConflict) {
+			current, getErr := h.decisionService.GetDecision(c.Request.Context(), decisionID)
+			if getErr != nil {
+				c.JSON(http.StatusConflict, vo.ErrorResponse{
+					Message: decision.ErrVersionConflict.Error(),
+					Code:    "VERSION_CONFLICT",
+				})
+				return
+			}
+			c.JSON(http.StatusConflict, gin.H{
+				"message": decision.ErrVersionConflict.Error(),
+				"code":    "VERSION_CONFLICT",
+				"current": current,
+			})
+			return
+		}
+		
+		if errors.Is(err, decision.ErrMissingApproval) {
+			c.JSON(http.StatusForbidden, vo.ErrorResponse{
+				Message: err.Error(),
+				Code:    "APPROVAL_REQUIRED",
+			})
+			return
+		}
+		
+		if errors.Is(err, decision.ErrTransitionBlocked) {
+			c.JSON(http.StatusConflict, vo.ErrorResponse{
+				Message: err.Error(),
+				Code:    "TRANSITION_BLOCKED",
+			})
+			return
+		}
+		
+		if errors.Is(err, decision.ErrInvalidTransition) {
 			c.JSON(http.StatusBadRequest, vo.ErrorResponse{
 				Message: "Invalid state transition",
 				Code:    "INVALID_TRANSITION",
@@ -93,6 +145,14 @@ func (h *OperatorHandler) TransitionState(c *gin.Context) {
 			return
 		}
 		
+		if errors.Is(err, decision.ErrEvaluationNotFound) {
+			c.JSON(http.StatusBadRequest, vo.ErrorResponse{
+				Message: err.Error(),
+				Code:    "EVALUATION_NOT_FOUND",
+			})
+			return
+		}
+		
 		c.JSON(http.StatusInternalServerError, vo.ErrorResponse{
 			Message: "Failed to transition state",
 			Code:    "INTERNAL_ERROR",
@@ -106,6 +166,77 @@ func (h *OperatorHandler) TransitionState(c *gin.Context) {
 	})
 }
 
+// GetTimeline handles GET /api/v1/operator/decisions/:decision_id/timeline
+func (h *OperatorHandler) GetTimeline(c *gin.Context) {
+	decisionID := c.Param("decision_id")
+	
+	transitions, err := h.decisionService.GetTimeline(c.Request.Context(), decisionID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, vo.ErrorResponse{
+			Message: "Failed to get decision timeline",
+			Code:    "INTERNAL_ERROR",
+		})
+		return
+	}
+	
+	if len(transitions) == 0 {
+		c.JSON(http.StatusNotFound, vo.ErrorResponse{
+			Message: "Decision not found",
+			Code:    "NOT_FOUND",
+		})
+		return
+	}
+	
+	c.JSON(http.StatusOK, gin.H{
+		"status":      "success",
+		"decision_id": decisionID,
+		"transitions": transitions,
+		"count":       len(transitions),
+	})
+}
+
+// GetStateAt handles GET /api/v1/operator/decisions/:decision_id/state?at=<RFC3339>
+func (h *OperatorHandler) GetStateAt(c *gin.Context) {
+	decisionID := c.Param("decision_id")
+	
+	at := time.Now()
+	if atStr := c.Query("at"); atStr != "" {
+		parsed, err := time.Parse(time.RFC3339, atStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, vo.ErrorResponse{
+				Message: "Invalid at parameter, expected RFC3339 timestamp",
+				Code:    "INVALID_REQUEST",
+			})
+			return
+		}
+		at = parsed
+	}
+	
+	state, transition, err := h.decisionService.StateAt(c.Request.Context(), decisionID, at)
+	if errors.Is(err, decision.ErrDecisionNotFound) {
+		c.JSON(http.StatusNotFound, vo.ErrorResponse{
+			Message: "Decision not found",
+			Code:    "NOT_FOUND",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, vo.ErrorResponse{
+			Message: "Failed to reconstruct decision state",
+			Code:    "INTERNAL_ERROR",
+		})
+		return
+	}
+	
+	c.JSON(http.StatusOK, gin.H{
+		"status":      "success",
+		"decision_id": decisionID,
+		"at":          at,
+		"state":       state,
+		"transition":  transition,
+	})
+}
+
 // GetLatestState handles GET /api/v1/operator/zones/:zone_id/state
 func (h *OperatorHandler) GetLatestState(c *gin.Context) {
 	zoneID := c.Param("zone_id")
@@ -134,6 +265,153 @@ func (h *OperatorHandler) GetLatestState(c *gin.Context) {
 	})
 }
 
+// EvaluateZone handles POST /api/v1/operator/zones/:zone_id/evaluate
+func (h *OperatorHandler) EvaluateZone(c *gin.Context) {
+	zoneID := c.Param("zone_id")
+	
+	currentState := decision.StateInactive
+	state, err := h.decisionService.GetLatestState(c.Request.Context(), zoneID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, vo.ErrorResponse{
+			Message: "Failed to get decision state",
+			Code:    "INTERNAL_ERROR",
+		})
+		return
+	}
+	if state != nil {
+		currentState = decision.DecisionState(state.CurrentState)
+	}
+	
+	result, err := h.evaluator.EvaluateZone(c.Request.Context(), zoneID, currentState)
+	if err != nil {
+		if errors.Is(err, decision.ErrNoSummary) {
+			c.JSON(http.StatusNotFound, vo.ErrorResponse{
+				Message: err.Error(),
+				Code:    "NOT_FOUND",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, vo.ErrorResponse{
+			Message: "Failed to evaluate zone",
+			Code:    "INTERNAL_ERROR",
+		})
+		return
+	}
+	
+	c.JSON(http.StatusOK, gin.H{
+		"status":        "success",
+		"zone_id":       zoneID,
+		"current_state": currentState,
+		"evaluation":    result,
+	})
+}
+
+// ListEvaluations handles GET /api/v1/operator/zones/:zone_id/evaluations?limit=<n>
+func (h *OperatorHandler) ListEvaluations(c *gin.Context) {
+	zoneID := c.Param("zone_id")
+	
+	limit := 20
+	if limitStr := c.Query("limit"); limitStr != "" {
+		parsed, err := strconv.Atoi(limitStr)
+		if err != nil || parsed < 1 || parsed > 200 {
+			c.JSON(http.StatusBadRequest, vo.ErrorResponse{
+				Message: "Invalid limit parameter, expected 1-200",
+				Code:    "INVALID_REQUEST",
+			})
+			return
+		}
+		limit = parsed
+	}
+	
+	evaluations, err := h.evaluator.ListEvaluations(c.Request.Context(), zoneID, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, vo.ErrorResponse{
+			Message: "Failed to list evaluations",
+			Code:    "INTERNAL_ERROR",
+		})
+		return
+	}
+	
+	c.JSON(http.StatusOK, gin.H{
+		"status":      "success",
+		"zone_id":     zoneID,
+		"evaluations": evaluations,
+		"count":       len(evaluations),
+	})
+}
+
+// GetEvaluation handles GET /api/v1/operator/evaluations/:evaluation_id
+func (h *OperatorHandler) GetEvaluation(c *gin.Context) {
+	evaluation, err := h.evaluator.GetEvaluation(c.Request.Context(), c.Param("evaluation_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, vo.ErrorResponse{
+			Message: "Failed to get evaluation",
+			Code:    "INTERNAL_ERROR",
+		})
+		return
+	}
+	
+	if evaluation == nil {
+		c.JSON(http.StatusNotFound, vo.ErrorResponse{
+			Message: "Evaluation not found",
+			Code:    "NOT_FOUND",
+		})
+		return
+	}
+	
+	c.JSON(http.StatusOK, gin.H{
+		"status":     "success",
+		"evaluation": evaluation,
+	})
+}
+
+// WhatIf handles POST /api/v1/operator/zones/:zone_id/whatif.
+// Hypothetical signals are aggregated and evaluated in memory; nothing is stored.
+func (h *OperatorHandler) WhatIf(c *gin.Context) {
+	var req dto.WhatIfRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, vo.ErrorResponse{
+			Message: "Invalid request: " + err.Error(),
+			Code:    "INVALID_REQUEST",
+		})
+		return
+	}
+	
+	scenario := &decision.WhatIfScenario{
+		ZoneID:          c.Param("zone_id"),
+		CurrentState:    decision.DecisionState(req.CurrentState),
+		RemoveSignalIDs: req.RemoveSignalIDs,
+		RemoveSourceIDs: req.RemoveSourceIDs,
+	}
+	for _, signal := range req.Add {
+		hypothetical := &model.Signal{
+			SourceType:   signal.SourceType,
+			SourceID:     signal.SourceID,
+			SignalType:   signal.SignalType,
+			Value:        model.JSONB(signal.Value),
+			QualityScore: signal.QualityScore,
+		}
+		if signal.Timestamp != nil {
+			hypothetical.Timestamp = *signal.Timestamp
+		}
+		scenario.Add = append(scenario.Add, hypothetical)
+	}
+	
+	result, err := h.decisionService.WhatIf(c.Request.Context(), scenario)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, vo.ErrorResponse{
+			Message: err.Error(),
+			Code:    "WHATIF_FAILED",
+		})
+		return
+	}
+	
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   result,
+	})
+}
+
 // getOperatorID extracts operator ID from context
 func (h *OperatorHandler) getOperatorID(c *gin.Context) string {
 	// TODO: Implement proper operator ID extraction from auth token
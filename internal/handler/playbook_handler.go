@@ -0,0 +1,153 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/erh-safety-system/poc/internal/playbook"
+	"github.com/erh-safety-system/poc/internal/vo"
+	"github.com/gin-gonic/gin"
+)
+
+// PlaybookHandler handles SOP checklists bound to decision states
+type PlaybookHandler struct {
+	playbooks  *playbook.Registry
+	checklists *playbook.ChecklistService
+}
+
+// NewPlaybookHandler creates a new playbook handler
+func NewPlaybookHandler(playbooks *playbook.Registry, checklists *playbook.ChecklistService) *PlaybookHandler {
+	return &PlaybookHandler{
+		playbooks:  playbooks,
+		checklists: checklists,
+	}
+}
+
+// GetPlaybooks handles GET /api/v1/system/playbooks
+func (h *PlaybookHandler) GetPlaybooks(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"status":    "success",
+		"playbooks": h.playbooks.Playbooks(),
+	})
+}
+
+// GetChecklists handles GET /api/v1/operator/decisions/:decision_id/checklists.
+// The checklist of the decision's current state is returned as "active".
+func (h *PlaybookHandler) GetChecklists(c *gin.Context) {
+	decisionID := c.Param("decision_id")
+
+	checklists, err := h.checklists.ListChecklists(c.Request.Context(), decisionID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, vo.ErrorResponse{
+			Message: "Failed to get checklists",
+			Code:    "INTERNAL_ERROR",
+		})
+		return
+	}
+
+	var active *playbook.Checklist
+	for _, checklist := range checklists {
+		if checklist.Status == playbook.ChecklistOpen {
+			active = checklist
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":      "success",
+		"decision_id": decisionID,
+		"active":      active,
+		"checklists":  checklists,
+	})
+}
+
+// CompleteItem handles POST /api/v1/operator/checklist-items/:item_id/complete
+func (h *PlaybookHandler) CompleteItem(c *gin.Context) {
+	operatorID := h.getOperatorID(c)
+	if operatorID == "" {
+		c.JSON(http.StatusUnauthorized, vo.ErrorResponse{
+			Message: "Operator ID not found",
+			Code:    "UNAUTHORIZED",
+		})
+		return
+	}
+
+	item, err := h.checklists.CompleteItem(c.Request.Context(), c.Param("item_id"), operatorID)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"item":   item,
+	})
+}
+
+// WaiveItem handles POST /api/v1/operator/checklist-items/:item_id/waive
+func (h *PlaybookHandler) WaiveItem(c *gin.Context) {
+	operatorID := h.getOperatorID(c)
+	if operatorID == "" {
+		c.JSON(http.StatusUnauthorized, vo.ErrorResponse{
+			Message: "Operator ID not found",
+			Code:    "UNAUTHORIZED",
+		})
+		return
+	}
+
+	var req struct {
+		Reason string `json:"reason" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, vo.ErrorResponse{
+			Message: "A waiver reason is required",
+			Code:    "INVALID_REQUEST",
+		})
+		return
+	}
+
+	item, err := h.checklists.WaiveItem(c.Request.Context(), c.Param("item_id"), operatorID, req.Reason)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"item":   item,
+	})
+}
+
+// respondError maps checklist errors to responses
+func (h *PlaybookHandler) respondError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, playbook.ErrChecklistNotFound):
+		c.JSON(http.StatusNotFound, vo.ErrorResponse{
+			Message: err.Error(),
+			Code:    "NOT_FOUND",
+		})
+	case errors.Is(err, playbook.ErrWaiverReason):
+		c.JSON(http.StatusBadRequest, vo.ErrorResponse{
+			Message: err.Error(),
+			Code:    "INVALID_REQUEST",
+		})
+	case errors.Is(err, playbook.ErrChecklistClosed), errors.Is(err, playbook.ErrItemResolved):
+		c.JSON(http.StatusConflict, vo.ErrorResponse{
+			Message: err.Error(),
+			Code:    "CHECKLIST_CONFLICT",
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, vo.ErrorResponse{
+			Message: "Failed to update checklist item",
+			Code:    "INTERNAL_ERROR",
+		})
+	}
+}
+
+// getOperatorID extracts operator ID from context
+func (h *PlaybookHandler) getOperatorID(c *gin.Context) string {
+	operatorID, exists := c.Get("operator_id")
+	if !exists {
+		return ""
+	}
+	return operatorID.(string)
+}
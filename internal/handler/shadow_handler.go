@@ -0,0 +1,146 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/erh-safety-system/poc/internal/config"
+	"github.com/erh-safety-system/poc/internal/decision"
+	"github.com/erh-safety-system/poc/internal/vo"
+	"github.com/gin-gonic/gin"
+)
+
+// ShadowHandler handles shadow evaluation of candidate decision policies
+type ShadowHandler struct {
+	shadow *decision.ShadowEvaluator
+}
+
+// NewShadowHandler creates a new shadow handler
+func NewShadowHandler(shadow *decision.ShadowEvaluator) *ShadowHandler {
+	return &ShadowHandler{
+		shadow: shadow,
+	}
+}
+
+// RegisterCandidate handles POST /api/v1/system/shadow/candidate.
+// The body is a policy document; TOML is read when the content type mentions toml, YAML otherwise.
+func (h *ShadowHandler) RegisterCandidate(c *gin.Context) {
+	data, err := c.GetRawData()
+	if err != nil || len(data) == 0 {
+		c.JSON(http.StatusBadRequest, vo.ErrorResponse{
+			Message: "Policy document is required",
+			Code:    "INVALID_REQUEST",
+		})
+		return
+	}
+
+	name := "candidate.yaml"
+	if strings.Contains(c.ContentType(), "toml") {
+		name = "candidate.toml"
+	}
+
+	policy, err := h.shadow.RegisterCandidate(c.Request.Context(), name, data)
+	if err != nil {
+		var validationErr *config.ValidationError
+		if errors.As(err, &validationErr) {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{
+				"message":  validationErr.Error(),
+				"code":     "INVALID_POLICY",
+				"problems": validationErr.Problems,
+			})
+			return
+		}
+		c.JSON(http.StatusBadRequest, vo.ErrorResponse{
+			Message: err.Error(),
+			Code:    "INVALID_POLICY",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":    "success",
+		"candidate": policy,
+	})
+}
+
+// GetCandidate handles GET /api/v1/system/shadow/candidate
+func (h *ShadowHandler) GetCandidate(c *gin.Context) {
+	policy, registeredAt := h.shadow.Candidate()
+	if policy == nil {
+		c.JSON(http.StatusNotFound, vo.ErrorResponse{
+			Message: "No candidate policy is registered",
+			Code:    "NOT_FOUND",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":        "success",
+		"candidate":     policy,
+		"registered_at": registeredAt,
+	})
+}
+
+// ClearCandidate handles DELETE /api/v1/system/shadow/candidate
+func (h *ShadowHandler) ClearCandidate(c *gin.Context) {
+	if err := h.shadow.ClearCandidate(c.Request.Context()); err != nil {
+		c.JSON(http.StatusInternalServerError, vo.ErrorResponse{
+			Message: "Failed to stop shadow evaluation",
+			Code:    "INTERNAL_ERROR",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Shadow evaluation stopped",
+	})
+}
+
+// GetDivergence handles GET /api/v1/system/shadow/divergence?candidate=<policy id>&since=<RFC3339>.
+// Without parameters it reports on the current candidate since it was registered.
+func (h *ShadowHandler) GetDivergence(c *gin.Context) {
+	candidateID := c.Query("candidate")
+	var since time.Time
+	if policy, registeredAt := h.shadow.Candidate(); policy != nil && (candidateID == "" || candidateID == policy.ID()) {
+		candidateID = policy.ID()
+		since = registeredAt
+	}
+	if candidateID == "" {
+		c.JSON(http.StatusBadRequest, vo.ErrorResponse{
+			Message: "candidate is required when no candidate policy is registered",
+			Code:    "INVALID_REQUEST",
+		})
+		return
+	}
+
+	if sinceParam := c.Query("since"); sinceParam != "" {
+		parsed, err := time.Parse(time.RFC3339, sinceParam)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, vo.ErrorResponse{
+				Message: "since must be an RFC3339 timestamp",
+				Code:    "INVALID_REQUEST",
+			})
+			return
+		}
+		since = parsed
+	}
+
+	zones, err := h.shadow.Divergence(c.Request.Context(), candidateID, since)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, vo.ErrorResponse{
+			Message: "Failed to get divergence statistics",
+			Code:    "INTERNAL_ERROR",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":    "success",
+		"candidate": candidateID,
+		"since":     since,
+		"zones":     zones,
+	})
+}
@@ -0,0 +1,131 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/erh-safety-system/poc/internal/cache"
+	"github.com/erh-safety-system/poc/internal/config"
+	"github.com/erh-safety-system/poc/internal/decision"
+	"github.com/erh-safety-system/poc/internal/vo"
+	"github.com/gin-gonic/gin"
+)
+
+// SystemHandler handles system configuration requests
+type SystemHandler struct {
+	reloader  *config.Reloader
+	zoneCache *cache.ZoneCache
+	policies  *decision.PolicyRegistry
+}
+
+// NewSystemHandler creates a new system handler
+func NewSystemHandler(reloader *config.Reloader, zoneCache *cache.ZoneCache, policies *decision.PolicyRegistry) *SystemHandler {
+	return &SystemHandler{
+		reloader:  reloader,
+		zoneCache: zoneCache,
+		policies:  policies,
+	}
+}
+
+// GetConfig handles GET /api/v1/system/config
+func (h *SystemHandler) GetConfig(c *gin.Context) {
+	cfg := h.reloader.Current()
+
+	zones := make(map[string]gin.H)
+	for zoneID, window := range cfg.Aggregation.TimeWindows {
+		zones[zoneID] = gin.H{
+			"window":  window.String(),
+			"weights": cfg.Aggregation.Weights[zoneID],
+		}
+	}
+
+	ttls := make(map[string]string)
+	for actionType, ttl := range cfg.Gate.TTLs {
+		ttls[actionType] = ttl.String()
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":      "success",
+		"config_file": h.reloader.Path(),
+		"zones":       zones,
+		"evaluator":   cfg.Evaluator,
+		"ttls":        ttls,
+		"keepalive": gin.H{
+			"interval": cfg.Gate.KeepaliveInterval.String(),
+			"timeout":  cfg.Gate.KeepaliveTimeout.String(),
+		},
+		"approval_expiration": cfg.Gate.ApprovalExpiration.String(),
+		"timestamp":           time.Now(),
+	})
+}
+
+// ReloadConfig handles POST /api/v1/system/config/reload
+func (h *SystemHandler) ReloadConfig(c *gin.Context) {
+	changes, err := h.reloader.Reload()
+	if err != nil {
+		h.respondReloadError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"changes": changes,
+	})
+}
+
+// GetPolicies handles GET /api/v1/system/policies
+func (h *SystemHandler) GetPolicies(c *gin.Context) {
+	zones := make(map[string]string)
+	for _, zoneID := range []string{"Z1", "Z2", "Z3", "Z4"} {
+		zones[zoneID] = h.policies.For(zoneID).ID()
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":     "success",
+		"policy_dir": h.policies.Dir(),
+		"zones":      zones,
+		"policies":   h.policies.Policies(),
+		"timestamp":  time.Now(),
+	})
+}
+
+// ReloadPolicies handles POST /api/v1/system/policies/reload
+func (h *SystemHandler) ReloadPolicies(c *gin.Context) {
+	changes, err := h.policies.Reload()
+	if err != nil {
+		h.respondReloadError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"changes": changes,
+	})
+}
+
+// respondReloadError returns 422 with every problem for invalid files, 400 otherwise
+func (h *SystemHandler) respondReloadError(c *gin.Context, err error) {
+	var validationErr *config.ValidationError
+	if errors.As(err, &validationErr) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"message":  validationErr.Error(),
+			"code":     "INVALID_CONFIG",
+			"problems": validationErr.Problems,
+		})
+		return
+	}
+	c.JSON(http.StatusBadRequest, vo.ErrorResponse{
+		Message: err.Error(),
+		Code:    "RELOAD_FAILED",
+	})
+}
+
+// GetCacheStats handles GET /api/v1/system/cache/stats
+func (h *SystemHandler) GetCacheStats(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"status":    "success",
+		"cache":     h.zoneCache.Stats(),
+		"timestamp": time.Now(),
+	})
+}
@@ -0,0 +1,23 @@
+package incident
+
+import "errors"
+
+var (
+	// ErrIncidentNotFound indicates an unknown incident
+	ErrIncidentNotFound = errors.New("incident not found")
+
+	// ErrInvalidStatus indicates an unknown incident status or a disallowed lifecycle change
+	ErrInvalidStatus = errors.New("invalid incident status transition")
+
+	// ErrIncidentClosed indicates a change to an incident that has been closed
+	ErrIncidentClosed = errors.New("incident is closed")
+
+	// ErrInvalidItemType indicates an item type that cannot be linked to an incident
+	ErrInvalidItemType = errors.New("invalid incident item type")
+
+	// ErrItemNotFound indicates the item to link does not exist
+	ErrItemNotFound = errors.New("incident item not found")
+
+	// ErrNotLinked indicates the item is not linked to the incident
+	ErrNotLinked = errors.New("item is not linked to incident")
+)
@@ -0,0 +1,382 @@
+package incident
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/erh-safety-system/poc/internal/cap"
+	"github.com/erh-safety-system/poc/internal/decision"
+	"github.com/erh-safety-system/poc/internal/model"
+	"github.com/erh-safety-system/poc/internal/route2"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Incident lifecycle statuses
+const (
+	StatusOpen      = "open"
+	StatusContained = "contained"
+	StatusClosed    = "closed"
+)
+
+// Item types that can be linked to an incident
+const (
+	ItemDecision          = "decision"
+	ItemApproval          = "approval"
+	ItemCAPMessage        = "cap_message"
+	ItemAssistanceRequest = "assistance_request"
+	ItemSignal            = "signal"
+)
+
+// statusTransitions lists the allowed lifecycle changes. A contained incident can flare up again;
+// a closed incident is final.
+var statusTransitions = map[string][]string{
+	StatusOpen:      {StatusContained, StatusClosed},
+	StatusContained: {StatusOpen, StatusClosed},
+}
+
+// Incident groups the decisions, approvals, CAP messages, assistance requests and signals
+// of one real-world event across zones
+type Incident struct {
+	ID          string     `gorm:"primaryKey;type:varchar(255)" json:"id"`
+	Title       string     `gorm:"type:varchar(255);not null" json:"title"`
+	Description string     `gorm:"type:text" json:"description"`
+	Status      string     `gorm:"index;type:varchar(20);not null" json:"status"` // open|contained|closed
+	CommanderID string     `gorm:"index;type:varchar(255)" json:"commander_id"`
+	OpenedBy    string     `gorm:"type:varchar(255);not null" json:"opened_by"`
+	ContainedAt *time.Time `json:"contained_at,omitempty"`
+	ClosedAt    *time.Time `json:"closed_at,omitempty"`
+	ClosedBy    string     `gorm:"type:varchar(255)" json:"closed_by,omitempty"`
+	CreatedAt   time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt   time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// TableName specifies the table name
+func (Incident) TableName() string {
+	return "incidents"
+}
+
+// IncidentLink links an item to an incident. Unlinked items keep their link row (with UnlinkedAt set)
+// so post-incident reviews can see what was considered part of the incident and when.
+type IncidentLink struct {
+	ID         string     `gorm:"primaryKey;type:varchar(255)" json:"id"`
+	IncidentID string     `gorm:"index;type:varchar(255);not null" json:"incident_id"`
+	ItemType   string     `gorm:"index;type:varchar(30);not null" json:"item_type"`
+	ItemID     string     `gorm:"index;type:varchar(255);not null" json:"item_id"`
+	ZoneID     string     `gorm:"type:varchar(10)" json:"zone_id"` // Zone of the item, when it has one
+	Note       string     `gorm:"type:text" json:"note,omitempty"`
+	LinkedBy   string     `gorm:"type:varchar(255);not null" json:"linked_by"`
+	LinkedAt   time.Time  `gorm:"autoCreateTime" json:"linked_at"`
+	UnlinkedBy string     `gorm:"type:varchar(255)" json:"unlinked_by,omitempty"`
+	UnlinkedAt *time.Time `gorm:"index" json:"unlinked_at,omitempty"`
+}
+
+// TableName specifies the table name
+func (IncidentLink) TableName() string {
+	return "incident_links"
+}
+
+// Report is an incident with its currently linked items grouped by type
+type Report struct {
+	Incident *Incident                  `json:"incident"`
+	Zones    []string                   `json:"zones"`
+	Items    map[string][]*IncidentLink `json:"items"`
+}
+
+// CreateInput represents the input for opening an incident
+type CreateInput struct {
+	Title       string
+	Description string
+	CommanderID string
+	OpenedBy    string
+}
+
+// IncidentService manages incidents and their linked items
+type IncidentService struct {
+	db *gorm.DB
+}
+
+// NewIncidentService creates a new incident service
+func NewIncidentService(db *gorm.DB) *IncidentService {
+	return &IncidentService{
+		db: db,
+	}
+}
+
+// CreateIncident opens a new incident
+func (s *IncidentService) CreateIncident(ctx context.Context, input *CreateInput) (*Incident, error) {
+	incident := &Incident{
+		ID:          fmt.Sprintf("inc_%s", uuid.New().String()),
+		Title:       input.Title,
+		Description: input.Description,
+		Status:      StatusOpen,
+		CommanderID: input.CommanderID,
+		OpenedBy:    input.OpenedBy,
+	}
+	if err := s.db.WithContext(ctx).Create(incident).Error; err != nil {
+		return nil, fmt.Errorf("failed to create incident: %w", err)
+	}
+	return incident, nil
+}
+
+// GetIncident retrieves an incident by ID
+func (s *IncidentService) GetIncident(ctx context.Context, incidentID string) (*Incident, error) {
+	return getIncident(s.db.WithContext(ctx), incidentID)
+}
+
+// ListIncidents returns incidents, newest first, optionally filtered by status and by a zone they have items in
+func (s *IncidentService) ListIncidents(ctx context.Context, status, zoneID string) ([]*Incident, error) {
+	query := s.db.WithContext(ctx).Order("created_at DESC")
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if zoneID != "" {
+		query = query.Where("id IN (?)", s.db.Model(&IncidentLink{}).
+			Select("incident_id").
+			Where("zone_id = ? AND unlinked_at IS NULL", zoneID))
+	}
+
+	var incidents []*Incident
+	if err := query.Find(&incidents).Error; err != nil {
+		return nil, fmt.Errorf("failed to list incidents: %w", err)
+	}
+	return incidents, nil
+}
+
+// UpdateStatus moves an incident through its lifecycle
+func (s *IncidentService) UpdateStatus(ctx context.Context, incidentID, status, operatorID string) (*Incident, error) {
+	var incident *Incident
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		incident, err = getIncident(tx, incidentID)
+		if err != nil {
+			return err
+		}
+		if !canTransition(incident.Status, status) {
+			if incident.Status == StatusClosed {
+				return ErrIncidentClosed
+			}
+			return fmt.Errorf("%w: %s -> %s", ErrInvalidStatus, incident.Status, status)
+		}
+
+		now := time.Now()
+		updates := map[string]interface{}{"status": status}
+		switch status {
+		case StatusContained:
+			updates["contained_at"] = now
+		case StatusClosed:
+			updates["closed_at"] = now
+			updates["closed_by"] = operatorID
+		}
+		if err := tx.Model(incident).Updates(updates).Error; err != nil {
+			return fmt.Errorf("failed to update incident status: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetIncident(ctx, incidentID)
+}
+
+// AssignCommander sets the incident commander
+func (s *IncidentService) AssignCommander(ctx context.Context, incidentID, commanderID string) (*Incident, error) {
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		incident, err := getIncident(tx, incidentID)
+		if err != nil {
+			return err
+		}
+		if incident.Status == StatusClosed {
+			return ErrIncidentClosed
+		}
+		if err := tx.Model(incident).Update("commander_id", commanderID).Error; err != nil {
+			return fmt.Errorf("failed to assign incident commander: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetIncident(ctx, incidentID)
+}
+
+// LinkItem links an existing item to an incident. Linking an item that is already linked returns the existing link.
+func (s *IncidentService) LinkItem(ctx context.Context, incidentID, itemType, itemID, note, operatorID string) (*IncidentLink, error) {
+	var link *IncidentLink
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		incident, err := getIncident(tx, incidentID)
+		if err != nil {
+			return err
+		}
+		if incident.Status == StatusClosed {
+			return ErrIncidentClosed
+		}
+
+		var existing IncidentLink
+		err = tx.Where("incident_id = ? AND item_type = ? AND item_id = ? AND unlinked_at IS NULL", incidentID, itemType, itemID).
+			First(&existing).Error
+		if err == nil {
+			link = &existing
+			return nil
+		}
+		if err != gorm.ErrRecordNotFound {
+			return fmt.Errorf("failed to get incident link: %w", err)
+		}
+
+		zoneID, err := itemZone(tx, itemType, itemID)
+		if err != nil {
+			return err
+		}
+		link = &IncidentLink{
+			ID:         fmt.Sprintf("inl_%s", uuid.New().String()),
+			IncidentID: incidentID,
+			ItemType:   itemType,
+			ItemID:     itemID,
+			ZoneID:     zoneID,
+			Note:       note,
+			LinkedBy:   operatorID,
+		}
+		if err := tx.Create(link).Error; err != nil {
+			return fmt.Errorf("failed to link incident item: %w", err)
+		}
+		return tx.Model(incident).Update("updated_at", time.Now()).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return link, nil
+}
+
+// UnlinkItem removes an item from an incident, keeping the link row for review
+func (s *IncidentService) UnlinkItem(ctx context.Context, incidentID, itemType, itemID, operatorID string) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		incident, err := getIncident(tx, incidentID)
+		if err != nil {
+			return err
+		}
+		if incident.Status == StatusClosed {
+			return ErrIncidentClosed
+		}
+
+		result := tx.Model(&IncidentLink{}).
+			Where("incident_id = ? AND item_type = ? AND item_id = ? AND unlinked_at IS NULL", incidentID, itemType, itemID).
+			Updates(map[string]interface{}{
+				"unlinked_by": operatorID,
+				"unlinked_at": time.Now(),
+			})
+		if result.Error != nil {
+			return fmt.Errorf("failed to unlink incident item: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return ErrNotLinked
+		}
+		return tx.Model(incident).Update("updated_at", time.Now()).Error
+	})
+}
+
+// GetLinks returns an incident's links in the order they were made. Unlinked items are included when requested.
+func (s *IncidentService) GetLinks(ctx context.Context, incidentID string, includeUnlinked bool) ([]*IncidentLink, error) {
+	query := s.db.WithContext(ctx).Where("incident_id = ?", incidentID).Order("linked_at ASC")
+	if !includeUnlinked {
+		query = query.Where("unlinked_at IS NULL")
+	}
+
+	var links []*IncidentLink
+	if err := query.Find(&links).Error; err != nil {
+		return nil, fmt.Errorf("failed to get incident links: %w", err)
+	}
+	return links, nil
+}
+
+// GetReport returns an incident with its linked items grouped by type and the zones they cover
+func (s *IncidentService) GetReport(ctx context.Context, incidentID string) (*Report, error) {
+	incident, err := s.GetIncident(ctx, incidentID)
+	if err != nil {
+		return nil, err
+	}
+	links, err := s.GetLinks(ctx, incidentID, false)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{
+		Incident: incident,
+		Zones:    make([]string, 0),
+		Items:    make(map[string][]*IncidentLink),
+	}
+	zones := map[string]bool{}
+	for _, link := range links {
+		report.Items[link.ItemType] = append(report.Items[link.ItemType], link)
+		if link.ZoneID != "" && !zones[link.ZoneID] {
+			zones[link.ZoneID] = true
+			report.Zones = append(report.Zones, link.ZoneID)
+		}
+	}
+	sort.Strings(report.Zones)
+	return report, nil
+}
+
+// getIncident loads an incident within a transaction or session
+func getIncident(tx *gorm.DB, incidentID string) (*Incident, error) {
+	var incident Incident
+	if err := tx.Where("id = ?", incidentID).First(&incident).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, ErrIncidentNotFound
+		}
+		return nil, fmt.Errorf("failed to get incident: %w", err)
+	}
+	return &incident, nil
+}
+
+// itemZone checks that an item exists and returns its zone
+func itemZone(tx *gorm.DB, itemType, itemID string) (string, error) {
+	var zoneID string
+	var err error
+	switch itemType {
+	case ItemDecision:
+		var record decision.DecisionStateRecord
+		err = tx.Select("id, zone_id").Where("id = ?", itemID).First(&record).Error
+		zoneID = record.ZoneID
+	case ItemApproval:
+		var approval model.ApprovalRequest
+		err = tx.Select("id, zone_id").Where("id = ?", itemID).First(&approval).Error
+		zoneID = approval.ZoneID
+	case ItemAssistanceRequest:
+		var request route2.AssistanceRequest
+		err = tx.Select("id, zone_id").Where("id = ?", itemID).First(&request).Error
+		zoneID = request.ZoneID
+	case ItemSignal:
+		var signal model.Signal
+		err = tx.Select("id, zone_id").Where("id = ?", itemID).First(&signal).Error
+		zoneID = signal.ZoneID
+	case ItemCAPMessage:
+		// CAP messages are addressed to an area; the first zone of the area is recorded
+		var message cap.CAPMessageRecord
+		err = tx.Select("id, area").Where("id = ?", itemID).First(&message).Error
+		if zones, ok := message.Area["zone_id"].([]interface{}); ok && len(zones) > 0 {
+			zoneID, _ = zones[0].(string)
+		}
+	default:
+		return "", fmt.Errorf("%w: %s", ErrInvalidItemType, itemType)
+	}
+
+	if err == gorm.ErrRecordNotFound {
+		return "", fmt.Errorf("%w: %s %s", ErrItemNotFound, itemType, itemID)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get incident item: %w", err)
+	}
+	return zoneID, nil
+}
+
+// canTransition reports whether an incident may move from one status to another
+func canTransition(from, to string) bool {
+	for _, allowed := range statusTransitions[from] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
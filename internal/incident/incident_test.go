@@ -0,0 +1,126 @@
+package incident
+
+import (
+	"context"
+	"testing"
+
+	"github.com/erh-safety-system/poc/internal/decision"
+	"github.com/erh-safety-system/poc/internal/model"
+	"github.com/erh-safety-system/poc/internal/route2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupIncidentTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to open test database: %v", err)
+	}
+
+	if err := db.AutoMigrate(
+		&Incident{},
+		&IncidentLink{},
+		&decision.DecisionStateRecord{},
+		&model.ApprovalRequest{},
+		&model.Signal{},
+		&route2.AssistanceRequest{},
+	); err != nil {
+		t.Fatalf("Failed to migrate: %v", err)
+	}
+
+	return db
+}
+
+func TestIncidentService_LinksItemsAcrossZones(t *testing.T) {
+	db := setupIncidentTestDB(t)
+	service := NewIncidentService(db)
+	ctx := context.Background()
+
+	require.NoError(t, db.Create(&decision.DecisionStateRecord{ID: "decision_z1", ZoneID: "Z1", CurrentState: "D1", Version: 1}).Error)
+	require.NoError(t, db.Create(&decision.DecisionStateRecord{ID: "decision_z3", ZoneID: "Z3", CurrentState: "D0", Version: 1}).Error)
+	require.NoError(t, db.Create(&route2.AssistanceRequest{ID: "assist_1", DeviceID: "dev_1", ZoneID: "Z3", RequestType: "medical", Urgency: "high"}).Error)
+
+	created, err := service.CreateIncident(ctx, &CreateInput{Title: "Fire near platform 2", CommanderID: "op_1", OpenedBy: "op_1"})
+	require.NoError(t, err)
+	assert.Equal(t, StatusOpen, created.Status)
+
+	for _, item := range []struct{ itemType, itemID string }{
+		{ItemDecision, "decision_z1"},
+		{ItemDecision, "decision_z3"},
+		{ItemAssistanceRequest, "assist_1"},
+	} {
+		_, err := service.LinkItem(ctx, created.ID, item.itemType, item.itemID, "", "op_1")
+		require.NoError(t, err)
+	}
+
+	// Linking twice keeps a single link; unknown items and types are refused
+	again, err := service.LinkItem(ctx, created.ID, ItemDecision, "decision_z1", "", "op_2")
+	require.NoError(t, err)
+	assert.Equal(t, "op_1", again.LinkedBy)
+	_, err = service.LinkItem(ctx, created.ID, ItemSignal, "sig_missing", "", "op_1")
+	assert.ErrorIs(t, err, ErrItemNotFound)
+	_, err = service.LinkItem(ctx, created.ID, "weather", "w_1", "", "op_1")
+	assert.ErrorIs(t, err, ErrInvalidItemType)
+
+	report, err := service.GetReport(ctx, created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Z1", "Z3"}, report.Zones)
+	assert.Len(t, report.Items[ItemDecision], 2)
+	assert.Len(t, report.Items[ItemAssistanceRequest], 1)
+
+	byZone, err := service.ListIncidents(ctx, "", "Z3")
+	require.NoError(t, err)
+	require.Len(t, byZone, 1)
+
+	// Unlinked items leave the report but stay in the link history
+	require.NoError(t, service.UnlinkItem(ctx, created.ID, ItemAssistanceRequest, "assist_1", "op_1"))
+	assert.ErrorIs(t, service.UnlinkItem(ctx, created.ID, ItemAssistanceRequest, "assist_1", "op_1"), ErrNotLinked)
+	report, err = service.GetReport(ctx, created.ID)
+	require.NoError(t, err)
+	assert.Empty(t, report.Items[ItemAssistanceRequest])
+	history, err := service.GetLinks(ctx, created.ID, true)
+	require.NoError(t, err)
+	assert.Len(t, history, 3)
+}
+
+func TestIncidentService_Lifecycle(t *testing.T) {
+	db := setupIncidentTestDB(t)
+	service := NewIncidentService(db)
+	ctx := context.Background()
+
+	created, err := service.CreateIncident(ctx, &CreateInput{Title: "Crowd surge", OpenedBy: "op_1"})
+	require.NoError(t, err)
+
+	updated, err := service.AssignCommander(ctx, created.ID, "op_2")
+	require.NoError(t, err)
+	assert.Equal(t, "op_2", updated.CommanderID)
+
+	updated, err = service.UpdateStatus(ctx, created.ID, StatusContained, "op_2")
+	require.NoError(t, err)
+	assert.Equal(t, StatusContained, updated.Status)
+	assert.NotNil(t, updated.ContainedAt)
+
+	// A contained incident can flare up again
+	updated, err = service.UpdateStatus(ctx, created.ID, StatusOpen, "op_2")
+	require.NoError(t, err)
+	assert.Equal(t, StatusOpen, updated.Status)
+	_, err = service.UpdateStatus(ctx, created.ID, StatusOpen, "op_2")
+	assert.ErrorIs(t, err, ErrInvalidStatus)
+
+	updated, err = service.UpdateStatus(ctx, created.ID, StatusClosed, "op_2")
+	require.NoError(t, err)
+	assert.Equal(t, "op_2", updated.ClosedBy)
+
+	// Closed incidents are final
+	_, err = service.UpdateStatus(ctx, created.ID, StatusOpen, "op_2")
+	assert.ErrorIs(t, err, ErrIncidentClosed)
+	_, err = service.AssignCommander(ctx, created.ID, "op_3")
+	assert.ErrorIs(t, err, ErrIncidentClosed)
+	_, err = service.LinkItem(ctx, created.ID, ItemDecision, "decision_z1", "", "op_2")
+	assert.ErrorIs(t, err, ErrIncidentClosed)
+
+	_, err = service.GetIncident(ctx, "inc_missing")
+	assert.ErrorIs(t, err, ErrIncidentNotFound)
+}
@@ -0,0 +1,293 @@
+package leader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrFenced indicates a newer leader has taken over since this instance's term began
+var ErrFenced = errors.New("fenced off by a newer leader")
+
+// LeaderFence records the newest fencing token per lease so writes from a stale leader can be refused
+type LeaderFence struct {
+	Name      string    `gorm:"primaryKey;type:varchar(100)" json:"name"`
+	Token     int64     `gorm:"not null" json:"token"`
+	Holder    string    `gorm:"type:varchar(255)" json:"holder"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName specifies the table name
+func (LeaderFence) TableName() string {
+	return "leader_fences"
+}
+
+// Status describes who leads and whether it is this instance
+type Status struct {
+	Name       string     `json:"name"`
+	InstanceID string     `json:"instance_id"`
+	IsLeader   bool       `json:"is_leader"`
+	Leader     string     `json:"leader,omitempty"` // Empty when nobody holds the lease
+	Token      int64      `json:"token,omitempty"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	Since      *time.Time `json:"since,omitempty"` // When this instance's current term began
+	Tasks      int        `json:"tasks"`
+}
+
+// term identifies the lease and fencing token a leader-only task runs under
+type term struct {
+	name  string
+	token int64
+}
+
+// termKey carries the term a leader-only task runs in
+type termKey struct{}
+
+// withTerm returns a context for tasks of the given lease's term
+func withTerm(ctx context.Context, name string, token int64) context.Context {
+	return context.WithValue(ctx, termKey{}, term{name: name, token: token})
+}
+
+// TokenFromContext returns the fencing token a leader-only task was started with
+func TokenFromContext(ctx context.Context) (int64, bool) {
+	t, ok := ctx.Value(termKey{}).(term)
+	return t.token, ok
+}
+
+// Guard verifies, as part of tx, that the leader-only task whose context tx carries still runs in the newest
+// term. The fence row stays share-locked until tx ends, so a newer leader cannot take over between the check
+// and the guarded writes. Connections whose context carries no term are not checked.
+func Guard(tx *gorm.DB) error {
+	t, ok := tx.Statement.Context.Value(termKey{}).(term)
+	if !ok {
+		return nil
+	}
+	return checkFence(tx.Clauses(clause.Locking{Strength: "SHARE"}), t.name, t.token)
+}
+
+// Elector campaigns for a lease and runs leader-only tasks while this instance holds it
+type Elector struct {
+	store      Store
+	db         *gorm.DB
+	name       string
+	instanceID string
+	ttl        time.Duration
+
+	mu     sync.RWMutex
+	tasks  []func(ctx context.Context)
+	lease  *Lease    // Held lease; nil while following
+	since  time.Time // Start of the current term
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewElector creates an elector for the named lease. An empty instanceID defaults to host name and process ID.
+func NewElector(store Store, db *gorm.DB, name, instanceID string, ttl time.Duration) *Elector {
+	if instanceID == "" {
+		host, _ := os.Hostname()
+		instanceID = fmt.Sprintf("%s-%d", host, os.Getpid())
+	}
+	return &Elector{
+		store:      store,
+		db:         db,
+		name:       name,
+		instanceID: instanceID,
+		ttl:        ttl,
+	}
+}
+
+// Go registers a task to run only while this instance leads. It is started with a context
+// carrying the term's fencing token and cancelled when leadership is lost.
+func (e *Elector) Go(task func(ctx context.Context)) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.tasks = append(e.tasks, task)
+}
+
+// InstanceID returns the identity this instance campaigns under
+func (e *Elector) InstanceID() string {
+	return e.instanceID
+}
+
+// IsLeader checks if this instance currently holds the lease
+func (e *Elector) IsLeader() bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.lease != nil && time.Now().Before(e.lease.ExpiresAt)
+}
+
+// Run campaigns until ctx is done, renewing the lease every third of its TTL, then releases it
+func (e *Elector) Run(ctx context.Context) {
+	ticker := time.NewTicker(e.ttl / 3)
+	defer ticker.Stop()
+
+	e.campaign(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			held := e.IsLeader()
+			e.stepDown()
+			if held {
+				releaseCtx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+				if err := e.store.Release(releaseCtx, e.name, e.instanceID); err != nil {
+					log.Printf("Failed to release %s leadership: %v", e.name, err)
+				}
+				cancel()
+			}
+			log.Printf("Leader election for %s stopped", e.name)
+			return
+		case <-ticker.C:
+			e.campaign(ctx)
+		}
+	}
+}
+
+// campaign acquires or renews the lease once, starting or stopping leader-only tasks as leadership changes
+func (e *Elector) campaign(ctx context.Context) {
+	lease, acquired, err := e.store.Acquire(ctx, e.name, e.instanceID, e.ttl)
+	if err != nil {
+		// Without a confirmed lease another instance may take over, so stop acting as leader
+		log.Printf("Leader election for %s failed: %v", e.name, err)
+		e.stepDown()
+		return
+	}
+	if !acquired {
+		if e.IsLeader() {
+			log.Printf("Lost %s leadership to %s", e.name, lease.Holder)
+		}
+		e.stepDown()
+		return
+	}
+
+	e.mu.Lock()
+	renewed := e.lease != nil && e.lease.Token == lease.Token
+	if renewed {
+		e.lease = lease
+	}
+	e.mu.Unlock()
+	if renewed {
+		return
+	}
+
+	// A new term: end any previous one, then claim the fence so earlier leaders' writes are refused
+	e.stepDown()
+	if err := e.advanceFence(ctx, lease); err != nil {
+		log.Printf("Refusing %s leadership with token %d: %v", e.name, lease.Token, err)
+		if err := e.store.Release(ctx, e.name, e.instanceID); err != nil {
+			log.Printf("Failed to release %s leadership: %v", e.name, err)
+		}
+		return
+	}
+	e.stepUp(ctx, lease)
+	log.Printf("Elected %s leader as %s with token %d", e.name, e.instanceID, lease.Token)
+}
+
+// stepUp starts the leader-only tasks for a new term
+func (e *Elector) stepUp(ctx context.Context, lease *Lease) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	termCtx, cancel := context.WithCancel(withTerm(ctx, e.name, lease.Token))
+	e.lease = lease
+	e.since = time.Now()
+	e.cancel = cancel
+	for _, task := range e.tasks {
+		e.wg.Add(1)
+		go func(task func(ctx context.Context)) {
+			defer e.wg.Done()
+			task(termCtx)
+		}(task)
+	}
+}
+
+// stepDown stops the leader-only tasks and waits for them to return
+func (e *Elector) stepDown() {
+	e.mu.Lock()
+	cancel := e.cancel
+	e.lease = nil
+	e.cancel = nil
+	e.mu.Unlock()
+
+	if cancel != nil {
+		cancel()
+		e.wg.Wait()
+	}
+}
+
+// advanceFence records the lease's token unless a newer one is already recorded
+func (e *Elector) advanceFence(ctx context.Context, lease *Lease) error {
+	db := e.db.WithContext(ctx)
+	if err := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&LeaderFence{Name: e.name}).Error; err != nil {
+		return fmt.Errorf("failed to create fence: %w", err)
+	}
+
+	result := db.Model(&LeaderFence{}).
+		Where("name = ? AND token <= ?", e.name, lease.Token).
+		Updates(map[string]interface{}{"token": lease.Token, "holder": lease.Holder, "updated_at": time.Now()})
+	if result.Error != nil {
+		return fmt.Errorf("failed to advance fence: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrFenced
+	}
+	return nil
+}
+
+// CheckFence verifies no newer leader has taken over since the term a leader-only task runs in began.
+// Contexts without a fencing token are not checked.
+func (e *Elector) CheckFence(ctx context.Context) error {
+	token, ok := TokenFromContext(ctx)
+	if !ok {
+		return nil
+	}
+	return checkFence(e.db.WithContext(ctx), e.name, token)
+}
+
+// checkFence refuses a token older than the one recorded for the named lease
+func checkFence(db *gorm.DB, name string, token int64) error {
+	var fence LeaderFence
+	if err := db.Where("name = ?", name).First(&fence).Error; err != nil {
+		return fmt.Errorf("failed to get fence: %w", err)
+	}
+	if fence.Token > token {
+		return fmt.Errorf("%w: token %d superseded by %d held by %s", ErrFenced, token, fence.Token, fence.Holder)
+	}
+	return nil
+}
+
+// Status describes the current leader as seen by this instance
+func (e *Elector) Status(ctx context.Context) (*Status, error) {
+	e.mu.RLock()
+	status := &Status{
+		Name:       e.name,
+		InstanceID: e.instanceID,
+		Tasks:      len(e.tasks),
+	}
+	if e.lease != nil {
+		since := e.since
+		status.Since = &since
+	}
+	e.mu.RUnlock()
+
+	lease, err := e.store.Current(ctx, e.name)
+	if err != nil {
+		return status, err
+	}
+	if lease != nil {
+		status.Leader = lease.Holder
+		status.Token = lease.Token
+		status.ExpiresAt = &lease.ExpiresAt
+		status.IsLeader = lease.Holder == e.instanceID && e.IsLeader()
+	}
+	if !status.IsLeader {
+		status.Since = nil
+	}
+	return status, nil
+}
@@ -0,0 +1,110 @@
+package leader
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupLeaderTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to open test database: %v", err)
+	}
+	if err := db.AutoMigrate(&LeaderFence{}); err != nil {
+		t.Fatalf("Failed to auto migrate: %v", err)
+	}
+	return db
+}
+
+// countingTask counts how many instances of a leader-only task are running
+func countingTask(running *int32, tokens chan<- int64) func(ctx context.Context) {
+	return func(ctx context.Context) {
+		atomic.AddInt32(running, 1)
+		defer atomic.AddInt32(running, -1)
+		token, _ := TokenFromContext(ctx)
+		tokens <- token
+		<-ctx.Done()
+	}
+}
+
+func TestElector_OnlyLeaderRunsTasks(t *testing.T) {
+	db := setupLeaderTestDB(t)
+	store := NewMemoryStore()
+	ctx := context.Background()
+
+	var running int32
+	tokens := make(chan int64, 4)
+	first := NewElector(store, db, "background", "instance_a", time.Minute)
+	first.Go(countingTask(&running, tokens))
+	second := NewElector(store, db, "background", "instance_b", time.Minute)
+	second.Go(countingTask(&running, tokens))
+
+	first.campaign(ctx)
+	second.campaign(ctx)
+	assert.True(t, first.IsLeader())
+	assert.False(t, second.IsLeader())
+	firstToken := <-tokens
+	assert.Equal(t, int32(1), atomic.LoadInt32(&running))
+
+	// Renewing keeps the same term and does not restart tasks
+	first.campaign(ctx)
+	assert.Len(t, tokens, 0)
+
+	status, err := second.Status(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "instance_a", status.Leader)
+	assert.Equal(t, firstToken, status.Token)
+	assert.False(t, status.IsLeader)
+
+	// When the leader steps down the other instance takes over with a higher token
+	first.stepDown()
+	require.NoError(t, store.Release(ctx, "background", "instance_a"))
+	assert.Equal(t, int32(0), atomic.LoadInt32(&running))
+	second.campaign(ctx)
+	assert.True(t, second.IsLeader())
+	secondToken := <-tokens
+	assert.Greater(t, secondToken, firstToken)
+
+	status, err = second.Status(ctx)
+	require.NoError(t, err)
+	assert.True(t, status.IsLeader)
+	assert.NotNil(t, status.Since)
+	second.stepDown()
+}
+
+func TestElector_FencesStaleLeader(t *testing.T) {
+	db := setupLeaderTestDB(t)
+	store := NewMemoryStore()
+	ctx := context.Background()
+
+	stale := NewElector(store, db, "background", "instance_a", 20*time.Millisecond)
+	stale.campaign(ctx)
+	require.True(t, stale.IsLeader())
+	staleCtx := withTerm(ctx, "background", stale.lease.Token)
+	assert.NoError(t, stale.CheckFence(staleCtx))
+
+	// The stale leader stalls past its lease and another instance is elected
+	time.Sleep(30 * time.Millisecond)
+	current := NewElector(store, db, "background", "instance_b", time.Minute)
+	current.campaign(ctx)
+	require.True(t, current.IsLeader())
+
+	assert.ErrorIs(t, stale.CheckFence(staleCtx), ErrFenced)
+	assert.NoError(t, current.CheckFence(withTerm(ctx, "background", current.lease.Token)))
+	assert.NoError(t, stale.CheckFence(ctx), "contexts outside a term are not fenced")
+
+	// Writes guarded inside a transaction are refused the same way
+	assert.ErrorIs(t, db.WithContext(staleCtx).Transaction(func(tx *gorm.DB) error { return Guard(tx) }), ErrFenced)
+	assert.NoError(t, Guard(db.WithContext(withTerm(ctx, "background", current.lease.Token))))
+	assert.NoError(t, Guard(db.WithContext(ctx)))
+
+	// An old token can never reclaim the fence
+	assert.ErrorIs(t, stale.advanceFence(ctx, &Lease{Name: "background", Holder: "instance_a", Token: 1}), ErrFenced)
+}
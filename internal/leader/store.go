@@ -0,0 +1,196 @@
+package leader
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// Lease is a time-limited claim on leadership. Token increases with every new term and fences off earlier leaders.
+type Lease struct {
+	Name      string    `json:"name"`
+	Holder    string    `json:"holder"`
+	Token     int64     `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Store grants leases shared by every instance
+type Store interface {
+	// Acquire takes the lease if it is free or renews it if holder already has it.
+	// When another instance holds it, the current lease is returned with acquired false.
+	Acquire(ctx context.Context, name, holder string, ttl time.Duration) (lease *Lease, acquired bool, err error)
+	// Release gives the lease up if holder has it
+	Release(ctx context.Context, name, holder string) error
+	// Current returns the lease, or nil if nobody holds it
+	Current(ctx context.Context, name string) (*Lease, error)
+}
+
+// acquireScript takes or renews the lease atomically. A new term increments the fencing token.
+// KEYS: lease key, token key. ARGV: holder, ttl in milliseconds.
+var acquireScript = redis.NewScript(`
+local holder = redis.call('GET', KEYS[1])
+if holder and holder ~= ARGV[1] then
+	return {0, holder, tonumber(redis.call('GET', KEYS[2]) or '0'), redis.call('PTTL', KEYS[1])}
+end
+local token
+if holder then
+	token = tonumber(redis.call('GET', KEYS[2]) or '0')
+else
+	token = redis.call('INCR', KEYS[2])
+end
+redis.call('SET', KEYS[1], ARGV[1], 'PX', ARGV[2])
+return {1, ARGV[1], token, tonumber(ARGV[2])}
+`)
+
+// releaseScript deletes the lease only if ARGV[1] holds it
+var releaseScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
+// RedisStore keeps leases in Redis so every replica sees the same leader
+type RedisStore struct {
+	client *redis.Client
+}
+
+// NewRedisStore creates a lease store on client
+func NewRedisStore(client *redis.Client) *RedisStore {
+	return &RedisStore{client: client}
+}
+
+// leaseKey returns the key holding the lease holder
+func leaseKey(name string) string {
+	return fmt.Sprintf("erh:leader:%s", name)
+}
+
+// tokenKey returns the key holding the latest fencing token
+func tokenKey(name string) string {
+	return fmt.Sprintf("erh:leader:%s:token", name)
+}
+
+// Acquire takes or renews the lease
+func (s *RedisStore) Acquire(ctx context.Context, name, holder string, ttl time.Duration) (*Lease, bool, error) {
+	now := time.Now()
+	result, err := acquireScript.Run(ctx, s.client, []string{leaseKey(name), tokenKey(name)}, holder, ttl.Milliseconds()).Slice()
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to acquire lease %s: %w", name, err)
+	}
+	if len(result) != 4 {
+		return nil, false, fmt.Errorf("failed to acquire lease %s: unexpected reply %v", name, result)
+	}
+
+	lease := &Lease{
+		Name:      name,
+		Holder:    fmt.Sprint(result[1]),
+		Token:     toInt64(result[2]),
+		ExpiresAt: now.Add(time.Duration(toInt64(result[3])) * time.Millisecond),
+	}
+	return lease, toInt64(result[0]) == 1, nil
+}
+
+// Release gives the lease up if holder has it
+func (s *RedisStore) Release(ctx context.Context, name, holder string) error {
+	if err := releaseScript.Run(ctx, s.client, []string{leaseKey(name)}, holder).Err(); err != nil && err != redis.Nil {
+		return fmt.Errorf("failed to release lease %s: %w", name, err)
+	}
+	return nil
+}
+
+// Current returns the lease, or nil if nobody holds it
+func (s *RedisStore) Current(ctx context.Context, name string) (*Lease, error) {
+	pipe := s.client.Pipeline()
+	holder := pipe.Get(ctx, leaseKey(name))
+	token := pipe.Get(ctx, tokenKey(name))
+	ttl := pipe.PTTL(ctx, leaseKey(name))
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, fmt.Errorf("failed to get lease %s: %w", name, err)
+	}
+	if holder.Err() == redis.Nil {
+		return nil, nil
+	}
+
+	tokenValue, _ := strconv.ParseInt(token.Val(), 10, 64)
+	return &Lease{
+		Name:      name,
+		Holder:    holder.Val(),
+		Token:     tokenValue,
+		ExpiresAt: time.Now().Add(ttl.Val()),
+	}, nil
+}
+
+// toInt64 converts an integer script reply
+func toInt64(value interface{}) int64 {
+	switch v := value.(type) {
+	case int64:
+		return v
+	case string:
+		n, _ := strconv.ParseInt(v, 10, 64)
+		return n
+	}
+	return 0
+}
+
+// MemoryStore keeps leases in process, for single-instance deployments and tests
+type MemoryStore struct {
+	mu     sync.Mutex
+	leases map[string]*Lease
+	tokens map[string]int64
+}
+
+// NewMemoryStore creates an in-process lease store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		leases: make(map[string]*Lease),
+		tokens: make(map[string]int64),
+	}
+}
+
+// Acquire takes or renews the lease
+func (s *MemoryStore) Acquire(ctx context.Context, name, holder string, ttl time.Duration) (*Lease, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	lease, exists := s.leases[name]
+	if exists && now.Before(lease.ExpiresAt) && lease.Holder != holder {
+		current := *lease
+		return &current, false, nil
+	}
+	if !exists || !now.Before(lease.ExpiresAt) {
+		s.tokens[name]++
+		lease = &Lease{Name: name, Holder: holder, Token: s.tokens[name]}
+		s.leases[name] = lease
+	}
+	lease.ExpiresAt = now.Add(ttl)
+
+	current := *lease
+	return &current, true, nil
+}
+
+// Release gives the lease up if holder has it
+func (s *MemoryStore) Release(ctx context.Context, name, holder string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if lease, exists := s.leases[name]; exists && lease.Holder == holder {
+		delete(s.leases, name)
+	}
+	return nil
+}
+
+// Current returns the lease, or nil if nobody holds it
+func (s *MemoryStore) Current(ctx context.Context, name string) (*Lease, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	lease, exists := s.leases[name]
+	if !exists || !time.Now().Before(lease.ExpiresAt) {
+		return nil, nil
+	}
+	current := *lease
+	return &current, nil
+}
@@ -20,6 +20,10 @@ type AggregatedSummary struct {
 	SourceCount  JSONB           `gorm:"type:jsonb" json:"source_count"` // {source_type: count}
 	WeightedValue float64        `gorm:"type:decimal(10,4)" json:"weighted_value"`
 	Confidence   float64         `gorm:"type:decimal(3,2)" json:"confidence"`
+	AnomalyScore float64         `gorm:"type:decimal(10,4)" json:"anomaly_score"` // Max deviation from seasonal baseline (z-score)
+	AnomalyDetail JSONB          `gorm:"type:jsonb" json:"anomaly_detail"` // {signal_type: {rate_z, value_z}}
+	NeighbourPressure float64    `gorm:"type:decimal(3,2)" json:"neighbour_pressure"` // 0-1, rising activity in adjacent zones
+	NeighbourDetail JSONB        `gorm:"type:jsonb" json:"neighbour_detail"` // {zone_id: {rising, pressure, ...}}
 	SignalIDs    StringArray     `gorm:"type:text[]" json:"signal_ids"` // Array of signal IDs for tracking
 	CreatedAt    time.Time       `gorm:"autoCreateTime" json:"created_at"`
 }
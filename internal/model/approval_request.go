@@ -4,20 +4,36 @@ import (
 	"time"
 )
 
+// Approval request kinds
+const (
+	ApprovalKindAction       = "action"        // Approves a high-impact action
+	ApprovalKindTTLExtension = "ttl_extension" // Approves a longer TTL for an action already in effect
+)
+
 // ApprovalRequest represents an approval request for high-impact actions
 type ApprovalRequest struct {
 	ID           string          `gorm:"primaryKey;type:varchar(255)" json:"id"`
+	Kind         string          `gorm:"index;type:varchar(20);not null;default:action" json:"kind"` // action|ttl_extension
 	ActionType   string          `gorm:"type:varchar(10);not null" json:"action_type"` // D3|D4|D5
+	TargetID     *string         `gorm:"index;type:varchar(255)" json:"target_id"`     // Action a ttl_extension extends
+	RequestedTTL int             `json:"requested_ttl_seconds,omitempty"`                // TTL a ttl_extension asks for, counted from when the action took effect
 	ZoneID       string          `gorm:"index;type:varchar(10);not null" json:"zone_id"`
 	Proposal     JSONB           `gorm:"type:jsonb" json:"proposal"` // Contains reason, measures, etc.
 	RequesterID  string          `gorm:"type:varchar(255);not null" json:"requester_id"`
-	Approver1ID  *string         `gorm:"type:varchar(255)" json:"approver1_id"`
-	Approver2ID  *string         `gorm:"type:varchar(255)" json:"approver2_id"`
-	Approver3ID  *string         `gorm:"type:varchar(255)" json:"approver3_id"` // For D4 (strict approval)
-	Status       string          `gorm:"index;type:varchar(20);default:pending" json:"status"` // pending|approved|rejected|expired
+	RequiredApprovals int        `gorm:"not null;default:2" json:"required_approvals"` // Quorum snapshot taken when the request was created
+	RequiredRoles StringArray    `gorm:"type:text" json:"required_roles"`             // Roles that must each be held by an approver
+	ExcludedApprovers StringArray `gorm:"type:text" json:"excluded_approvers"`        // Operators barred by conflict of interest
+	Votes        []ApprovalVote  `gorm:"foreignKey:ApprovalID" json:"votes"`
+	Status       string          `gorm:"index;type:varchar(20);default:pending" json:"status"` // pending|approved|rejected|expired|invalidated|rolled_back|ended
+	Version      int             `gorm:"not null;default:1" json:"version"` // Incremented on every update (optimistic locking)
 	CreatedAt    time.Time       `gorm:"autoCreateTime" json:"created_at"`
 	ExpiresAt    *time.Time      `gorm:"index" json:"expires_at"`
 	ApprovedAt   *time.Time      `json:"approved_at"`
+	DecisionID   *string         `gorm:"index;type:varchar(255)" json:"decision_id"` // Decision the approval was consumed by
+	ConsumedAt   *time.Time      `json:"consumed_at"`                               // When the approved action took effect; TTL runs from here
+	BaseDecisionID *string       `gorm:"type:varchar(255)" json:"base_decision_id"` // Zone decision the proposal was made against
+	BaseState    string          `gorm:"type:varchar(10)" json:"base_state"`
+	BaseVersion  int             `json:"base_version"`
 }
 
 // TableName specifies the table name
@@ -25,12 +41,24 @@ func (ApprovalRequest) TableName() string {
 	return "approval_requests"
 }
 
-// KeepaliveSession represents a keepalive session for an approved action
+// ApprovalVote is one approver's approval of a request
+type ApprovalVote struct {
+	ID              string     `gorm:"primaryKey;type:varchar(255)" json:"id"`
+	ApprovalID      string     `gorm:"not null;type:varchar(255);uniqueIndex:idx_approval_vote_approver" json:"approval_id"`
+	ApproverID      string     `gorm:"not null;type:varchar(255);uniqueIndex:idx_approval_vote_approver" json:"approver_id"`
+	Role            string     `gorm:"type:varchar(50)" json:"role"`
+	CreatedAt       time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	LastKeepaliveAt *time.Time `gorm:"index" json:"last_keepalive_at"` // Keepalive from this approver while the action is in effect
+}
+
+// TableName specifies the table name
+func (ApprovalVote) TableName() string {
+	return "approval_votes"
+}
+
+// KeepaliveSession represents a keepalive session for an approved action; keepalives are tracked per vote
 type KeepaliveSession struct {
 	ActionID             string     `gorm:"primaryKey;type:varchar(255)" json:"action_id"` // Links to approval_request or decision_state
-	Approver1LastKeepalive *time.Time `gorm:"index" json:"approver1_last_keepalive"`
-	Approver2LastKeepalive *time.Time `gorm:"index" json:"approver2_last_keepalive"`
-	Approver3LastKeepalive *time.Time `gorm:"index" json:"approver3_last_keepalive"`
 	KeepaliveInterval     int        `gorm:"default:60" json:"keepalive_interval"` // seconds
 	KeepaliveTimeout      int        `gorm:"default:120" json:"keepalive_timeout"` // seconds
 	CreatedAt             time.Time  `gorm:"autoCreateTime" json:"created_at"`
@@ -49,18 +77,74 @@ func (a *ApprovalRequest) IsExpired() bool {
 	return time.Now().After(*a.ExpiresAt)
 }
 
-// RequiresStrictApproval checks if this action type requires strict approval (3 persons)
-func (a *ApprovalRequest) RequiresStrictApproval() bool {
-	return a.ActionType == "D4"
+// IsExtension checks if the request asks for a longer TTL rather than a new action
+func (a *ApprovalRequest) IsExtension() bool {
+	return a.Kind == ApprovalKindTTLExtension
+}
+
+// IsConsumed checks if the approval has been used by a decision transition
+func (a *ApprovalRequest) IsConsumed() bool {
+	return a.ConsumedAt != nil
+}
+
+// HasVoted checks if an operator has already approved the request
+func (a *ApprovalRequest) HasVoted(approverID string) bool {
+	for _, vote := range a.Votes {
+		if vote.ApproverID == approverID {
+			return true
+		}
+	}
+	return false
+}
+
+// IsExcluded checks if an operator is barred from approving the request
+func (a *ApprovalRequest) IsExcluded(approverID string) bool {
+	for _, excluded := range a.ExcludedApprovers {
+		if excluded == approverID {
+			return true
+		}
+	}
+	return false
+}
+
+// RemainingApprovals returns how many more approvals the request needs
+func (a *ApprovalRequest) RemainingApprovals() int {
+	if remaining := a.RequiredApprovals - len(a.Votes); remaining > 0 {
+		return remaining
+	}
+	return 0
+}
+
+// MissingRoles returns the required roles no approver holds yet
+func (a *ApprovalRequest) MissingRoles() []string {
+	return MissingRoles(a.RequiredRoles, a.Votes)
 }
 
-// IsFullyApproved checks if all required approvals are present
+// IsFullyApproved checks if the votes meet the quorum: enough distinct approvers covering every required role
 func (a *ApprovalRequest) IsFullyApproved() bool {
-	if a.RequiresStrictApproval() {
-		// D4 requires 3 approvers
-		return a.Approver1ID != nil && a.Approver2ID != nil && a.Approver3ID != nil
+	return QuorumMet(a.RequiredApprovals, a.RequiredRoles, a.Votes)
+}
+
+// QuorumMet checks if votes satisfy a quorum of required approvers and roles
+func QuorumMet(required int, roles []string, votes []ApprovalVote) bool {
+	return len(votes) >= required && len(MissingRoles(roles, votes)) == 0
+}
+
+// MissingRoles returns the roles not held by any of the votes
+func MissingRoles(roles []string, votes []ApprovalVote) []string {
+	missing := make([]string, 0)
+	for _, role := range roles {
+		held := false
+		for _, vote := range votes {
+			if vote.Role == role {
+				held = true
+				break
+			}
+		}
+		if !held {
+			missing = append(missing, role)
+		}
 	}
-	// D3/D5 require 2 approvers
-	return a.Approver1ID != nil && a.Approver2ID != nil
+	return missing
 }
 
@@ -0,0 +1,44 @@
+package model
+
+import (
+	"time"
+)
+
+// ApproverProfile describes an operator's authority to approve high-impact actions
+type ApproverProfile struct {
+	OperatorID string      `gorm:"primaryKey;type:varchar(255)" json:"operator_id"`
+	Name       string      `gorm:"type:varchar(255)" json:"name"`
+	Role       string      `gorm:"type:varchar(50);not null" json:"role"` // station_master|control_supervisor|incident_commander|duty_operator
+	Zones      StringArray `gorm:"type:text" json:"zones"`                 // Zones the operator has authority over; empty means every zone
+	Active     bool        `gorm:"not null;default:true" json:"active"`
+	ShiftStart *time.Time  `json:"shift_start"`
+	ShiftEnd   *time.Time  `json:"shift_end"`
+	CreatedAt  time.Time   `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt  time.Time   `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// TableName specifies the table name
+func (ApproverProfile) TableName() string {
+	return "approver_profiles"
+}
+
+// OnShift checks if the operator's current shift covers the given time
+func (p *ApproverProfile) OnShift(at time.Time) bool {
+	if p.ShiftStart == nil || at.Before(*p.ShiftStart) {
+		return false
+	}
+	return p.ShiftEnd == nil || at.Before(*p.ShiftEnd)
+}
+
+// CoversZone checks if the operator has authority over a zone
+func (p *ApproverProfile) CoversZone(zoneID string) bool {
+	if len(p.Zones) == 0 {
+		return true
+	}
+	for _, zone := range p.Zones {
+		if zone == zoneID {
+			return true
+		}
+	}
+	return false
+}
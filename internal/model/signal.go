@@ -43,12 +43,14 @@ func (j *JSONB) Scan(value interface{}) error {
 		return nil
 	}
 
-	bytes, ok := value.([]byte)
-	if !ok {
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, j)
+	case string:
+		return json.Unmarshal([]byte(v), j)
+	default:
 		return nil
 	}
-
-	return json.Unmarshal(bytes, j)
 }
 
 // TableName specifies the table name for Signal
@@ -0,0 +1,27 @@
+package model
+
+import (
+	"time"
+)
+
+// SignalBaseline represents a learned seasonal profile for one zone, signal type and hour of week
+type SignalBaseline struct {
+	ID          string    `gorm:"primaryKey;type:varchar(255)" json:"id"`
+	ZoneID      string    `gorm:"index:idx_baseline_lookup;type:varchar(10);not null" json:"zone_id"`
+	SignalType  string    `gorm:"index:idx_baseline_lookup;type:varchar(50);not null" json:"signal_type"`
+	HourOfWeek  int       `gorm:"index:idx_baseline_lookup;not null" json:"hour_of_week"` // 0 = Sunday 00:00, 167 = Saturday 23:00
+	SampleCount int       `json:"sample_count"`                                            // Number of hour slots observed
+	RateMean    float64   `json:"rate_mean"`                                               // Signals per minute
+	RateStdDev  float64   `json:"rate_std_dev"`
+	ValueCount  int       `json:"value_count"` // Number of signals carrying a numeric value
+	ValueMean   float64   `json:"value_mean"`
+	ValueStdDev float64   `json:"value_std_dev"`
+	LearnedFrom time.Time `json:"learned_from"`
+	LearnedTo   time.Time `json:"learned_to"`
+	UpdatedAt   time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// TableName specifies the table name
+func (SignalBaseline) TableName() string {
+	return "signal_baselines"
+}
@@ -0,0 +1,174 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/erh-safety-system/poc/internal/config"
+	"github.com/erh-safety-system/poc/internal/decision"
+	"github.com/erh-safety-system/poc/internal/leader"
+	"github.com/erh-safety-system/poc/internal/model"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// AckEscalation records that a ladder step was notified for an unacknowledged decision
+type AckEscalation struct {
+	ID         string      `gorm:"primaryKey;type:varchar(64)" json:"id"`
+	DecisionID string      `gorm:"type:varchar(64);not null;uniqueIndex:idx_ack_escalation_step" json:"decision_id"`
+	ZoneID     string      `gorm:"type:varchar(10);not null;index" json:"zone_id"`
+	Step       int         `gorm:"not null;uniqueIndex:idx_ack_escalation_step" json:"step"`
+	Role       string      `gorm:"type:varchar(50);not null" json:"role"`
+	Recipient  string      `gorm:"type:varchar(100)" json:"recipient"`
+	State      string      `gorm:"type:varchar(20)" json:"state"`
+	Channels   model.JSONB `gorm:"type:jsonb" json:"channels"` // channel -> "delivered" or error
+	CreatedAt  time.Time   `json:"created_at"`
+}
+
+// TableName specifies the table name
+func (AckEscalation) TableName() string {
+	return "ack_escalations"
+}
+
+// AckLadder escalates unacknowledged decisions up the configured ladder of roles
+type AckLadder struct {
+	db         *gorm.DB
+	decisions  *decision.DecisionService
+	steps      []config.LadderStep
+	dispatcher *Dispatcher
+}
+
+// NewAckLadder creates an escalation ladder
+func NewAckLadder(db *gorm.DB, decisions *decision.DecisionService, cfg *config.NotifyConfig, dispatcher *Dispatcher) *AckLadder {
+	return &AckLadder{
+		db:         db,
+		decisions:  decisions,
+		steps:      cfg.Ladder,
+		dispatcher: dispatcher,
+	}
+}
+
+// Check notifies every ladder step that has come due for unacknowledged decisions.
+// Each step is notified once per decision; a step that could not be delivered on any channel is retried.
+func (l *AckLadder) Check(ctx context.Context) error {
+	// A leader that has since been replaced must not page anyone the new leader will page
+	if err := leader.Guard(l.db.WithContext(ctx)); err != nil {
+		return err
+	}
+
+	states, err := l.decisions.ListUnacknowledged(ctx)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for _, state := range states {
+		var sent []*AckEscalation
+		if err := l.db.WithContext(ctx).Where("decision_id = ?", state.ID).Find(&sent).Error; err != nil {
+			return fmt.Errorf("failed to get escalations: %w", err)
+		}
+		notified := make(map[int]bool, len(sent))
+		for _, escalation := range sent {
+			notified[escalation.Step] = true
+		}
+
+		for i, step := range l.steps {
+			if notified[i] || now.Sub(state.CreatedAt) < step.Delay {
+				continue
+			}
+			if err := l.escalate(ctx, state, i, step); err != nil {
+				log.Printf("Failed to notify %s about decision %s: %v", step.Role, state.ID, err)
+			}
+		}
+	}
+	return nil
+}
+
+// Start runs Check at the given interval until ctx is cancelled
+func (l *AckLadder) Start(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("Acknowledgement ladder stopped")
+			return
+		case <-ticker.C:
+			if err := l.Check(ctx); err != nil {
+				log.Printf("Error in acknowledgement ladder check: %v", err)
+			}
+		}
+	}
+}
+
+// ListEscalations returns the notifications sent for a decision in ladder order
+func (l *AckLadder) ListEscalations(ctx context.Context, decisionID string) ([]*AckEscalation, error) {
+	var escalations []*AckEscalation
+	if err := l.db.WithContext(ctx).
+		Where("decision_id = ?", decisionID).
+		Order("step ASC").
+		Find(&escalations).Error; err != nil {
+		return nil, fmt.Errorf("failed to get escalations: %w", err)
+	}
+	return escalations, nil
+}
+
+// escalate sends one ladder step and records it
+func (l *AckLadder) escalate(ctx context.Context, state *decision.DecisionStateRecord, index int, step config.LadderStep) error {
+	waited := time.Since(state.CreatedAt).Round(time.Second)
+	n := &Notification{
+		Role:       step.Role,
+		Recipient:  step.Recipient,
+		ZoneID:     state.ZoneID,
+		DecisionID: state.ID,
+		State:      state.CurrentState,
+		Subject:    fmt.Sprintf("Unacknowledged %s in zone %s", state.CurrentState, state.ZoneID),
+		Message: fmt.Sprintf("Decision %s in zone %s (%s) has not been acknowledged for %s",
+			state.ID, state.ZoneID, state.CurrentState, waited),
+	}
+
+	delivered, sendErr := l.dispatcher.Send(ctx, step.Channels, n)
+	if len(delivered) == 0 && len(step.Channels) > 0 {
+		return sendErr
+	}
+
+	channels := model.JSONB{}
+	for _, channel := range delivered {
+		channels[channel] = "delivered"
+	}
+	if sendErr != nil {
+		for _, channel := range step.Channels {
+			if _, ok := channels[channel]; !ok {
+				channels[channel] = "failed"
+			}
+		}
+	}
+
+	escalation := &AckEscalation{
+		ID:         fmt.Sprintf("ackesc_%s", uuid.New().String()),
+		DecisionID: state.ID,
+		ZoneID:     state.ZoneID,
+		Step:       index,
+		Role:       step.Role,
+		Recipient:  step.Recipient,
+		State:      state.CurrentState,
+		Channels:   channels,
+	}
+	err := l.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := leader.Guard(tx); err != nil {
+			return err
+		}
+		return tx.Create(escalation).Error
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record escalation: %w", err)
+	}
+	if sendErr != nil {
+		return fmt.Errorf("partially delivered on %s: %w", strings.Join(delivered, ","), sendErr)
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/erh-safety-system/poc/internal/config"
+	"github.com/erh-safety-system/poc/internal/decision"
+	"github.com/erh-safety-system/poc/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// recordingNotifier keeps notifications instead of delivering them
+type recordingNotifier struct {
+	channel string
+	err     error
+	sent    []*Notification
+}
+
+func (r *recordingNotifier) Channel() string {
+	return r.channel
+}
+
+func (r *recordingNotifier) Notify(ctx context.Context, n *Notification) error {
+	if r.err != nil {
+		return r.err
+	}
+	r.sent = append(r.sent, n)
+	return nil
+}
+
+func setupLadder(t *testing.T, notifiers ...Notifier) (*gorm.DB, *decision.DecisionService, *AckLadder) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(
+		&model.AggregatedSummary{},
+		&decision.DecisionStateRecord{},
+		&decision.DecisionTransition{},
+		&AckEscalation{},
+	))
+
+	decisions := decision.NewDecisionService(db, nil)
+	cfg := &config.NotifyConfig{Ladder: []config.LadderStep{
+		{Role: "duty_operator", Recipient: "op_duty", Delay: time.Minute, Channels: []string{ChannelConsole}},
+		{Role: "supervisor", Recipient: "op_super", Delay: 3 * time.Minute, Channels: []string{ChannelConsole, ChannelSMS}},
+		{Role: "incident_commander", Recipient: "op_ic", Delay: 5 * time.Minute, Channels: []string{ChannelSMS}},
+	}}
+	return db, decisions, NewAckLadder(db, decisions, cfg, NewDispatcher(notifiers...))
+}
+
+func raiseDecision(t *testing.T, db *gorm.DB, decisions *decision.DecisionService, zoneID string, age time.Duration) *decision.DecisionStateRecord {
+	now := time.Now()
+	summary := &model.AggregatedSummary{
+		ZoneID:      zoneID,
+		WindowStart: now.Add(-time.Minute),
+		WindowEnd:   now,
+		SourceCount: model.JSONB{"infrastructure": 2},
+	}
+	require.NoError(t, db.Create(summary).Error)
+	state, err := decisions.CreatePreAlert(context.Background(), zoneID, "op_1", summary.ID)
+	require.NoError(t, err)
+	require.NoError(t, db.Model(&decision.DecisionStateRecord{}).
+		Where("id = ?", state.ID).
+		Update("created_at", now.Add(-age)).Error)
+	return state
+}
+
+func TestAckLadder_EscalatesDueSteps(t *testing.T) {
+	console := &recordingNotifier{channel: ChannelConsole}
+	sms := &recordingNotifier{channel: ChannelSMS}
+	db, decisions, ladder := setupLadder(t, console, sms)
+	ctx := context.Background()
+
+	state := raiseDecision(t, db, decisions, "Z1", 4*time.Minute)
+
+	// Duty operator and supervisor are due; the incident commander is not
+	require.NoError(t, ladder.Check(ctx))
+	require.Len(t, console.sent, 2)
+	assert.Equal(t, "duty_operator", console.sent[0].Role)
+	assert.Equal(t, "supervisor", console.sent[1].Role)
+	require.Len(t, sms.sent, 1)
+	assert.Equal(t, "op_super", sms.sent[0].Recipient)
+	assert.Equal(t, state.ID, sms.sent[0].DecisionID)
+
+	// Steps are notified once
+	require.NoError(t, ladder.Check(ctx))
+	assert.Len(t, console.sent, 2)
+
+	escalations, err := ladder.ListEscalations(ctx, state.ID)
+	require.NoError(t, err)
+	require.Len(t, escalations, 2)
+	assert.Equal(t, "delivered", escalations[1].Channels[ChannelSMS])
+
+	// Acknowledging stops the ladder
+	require.NoError(t, db.Model(&decision.DecisionStateRecord{}).
+		Where("id = ?", state.ID).
+		Update("created_at", time.Now().Add(-10*time.Minute)).Error)
+	_, err = decisions.Acknowledge(ctx, state.ID, "op_duty")
+	require.NoError(t, err)
+	require.NoError(t, ladder.Check(ctx))
+	assert.Len(t, sms.sent, 1)
+}
+
+func TestAckLadder_RetriesUndeliveredSteps(t *testing.T) {
+	console := &recordingNotifier{channel: ChannelConsole, err: errors.New("console offline")}
+	db, decisions, ladder := setupLadder(t, console)
+	ctx := context.Background()
+
+	state := raiseDecision(t, db, decisions, "Z2", 2*time.Minute)
+
+	require.NoError(t, ladder.Check(ctx))
+	escalations, err := ladder.ListEscalations(ctx, state.ID)
+	require.NoError(t, err)
+	assert.Empty(t, escalations, "a step delivered nowhere is not recorded")
+
+	console.err = nil
+	require.NoError(t, ladder.Check(ctx))
+	require.Len(t, console.sent, 1)
+	escalations, err = ladder.ListEscalations(ctx, state.ID)
+	require.NoError(t, err)
+	require.Len(t, escalations, 1)
+	assert.Equal(t, "duty_operator", escalations[0].Role)
+}
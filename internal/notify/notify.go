@@ -0,0 +1,186 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/erh-safety-system/poc/internal/events"
+)
+
+// Notification channels
+const (
+	ChannelConsole = "console"
+	ChannelSMS     = "sms"
+	ChannelWebhook = "webhook"
+)
+
+// Notification is a message to a person in a role about a decision
+type Notification struct {
+	Role       string    `json:"role"`
+	Recipient  string    `json:"recipient"`
+	ZoneID     string    `json:"zone_id"`
+	DecisionID string    `json:"decision_id"`
+	State      string    `json:"state"`
+	Subject    string    `json:"subject"`
+	Message    string    `json:"message"`
+	SentAt     time.Time `json:"sent_at"`
+}
+
+// Notifier delivers notifications over one channel
+type Notifier interface {
+	Channel() string
+	Notify(ctx context.Context, n *Notification) error
+}
+
+// Dispatcher sends notifications over the channels that are configured
+type Dispatcher struct {
+	notifiers map[string]Notifier
+}
+
+// NewDispatcher creates a dispatcher for the given notifiers
+func NewDispatcher(notifiers ...Notifier) *Dispatcher {
+	d := &Dispatcher{notifiers: make(map[string]Notifier)}
+	for _, notifier := range notifiers {
+		d.notifiers[notifier.Channel()] = notifier
+	}
+	return d
+}
+
+// Channels returns the configured channels in name order
+func (d *Dispatcher) Channels() []string {
+	channels := make([]string, 0, len(d.notifiers))
+	for channel := range d.notifiers {
+		channels = append(channels, channel)
+	}
+	sort.Strings(channels)
+	return channels
+}
+
+// Send delivers a notification on each channel and returns the channels it reached.
+// Unconfigured channels and delivery failures are reported in the error; other channels are still tried.
+func (d *Dispatcher) Send(ctx context.Context, channels []string, n *Notification) ([]string, error) {
+	if n.SentAt.IsZero() {
+		n.SentAt = time.Now()
+	}
+
+	delivered := make([]string, 0, len(channels))
+	failures := make([]string, 0)
+	for _, channel := range channels {
+		notifier, exists := d.notifiers[channel]
+		if !exists {
+			failures = append(failures, fmt.Sprintf("%s: channel not configured", channel))
+			continue
+		}
+		if err := notifier.Notify(ctx, n); err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", channel, err))
+			continue
+		}
+		delivered = append(delivered, channel)
+	}
+
+	if len(failures) > 0 {
+		return delivered, fmt.Errorf("notification not delivered on %s", strings.Join(failures, "; "))
+	}
+	return delivered, nil
+}
+
+// ConsoleNotifier publishes notifications as events for operator consoles
+type ConsoleNotifier struct {
+	bus *events.Bus
+}
+
+// NewConsoleNotifier creates a console notifier publishing on bus
+func NewConsoleNotifier(bus *events.Bus) *ConsoleNotifier {
+	return &ConsoleNotifier{bus: bus}
+}
+
+// Channel returns the channel name
+func (c *ConsoleNotifier) Channel() string {
+	return ChannelConsole
+}
+
+// Notify publishes an operator.notified event
+func (c *ConsoleNotifier) Notify(ctx context.Context, n *Notification) error {
+	c.bus.Publish(events.Event{
+		Type:      events.OperatorNotified,
+		ZoneID:    n.ZoneID,
+		SubjectID: n.DecisionID,
+		Payload: map[string]interface{}{
+			"role":      n.Role,
+			"recipient": n.Recipient,
+			"state":     n.State,
+			"subject":   n.Subject,
+			"message":   n.Message,
+		},
+		OccurredAt: n.SentAt,
+	})
+	return nil
+}
+
+// SMSNotifier is a stand-in for an SMS gateway; messages are logged
+type SMSNotifier struct{}
+
+// NewSMSNotifier creates an SMS notifier stub
+func NewSMSNotifier() *SMSNotifier {
+	return &SMSNotifier{}
+}
+
+// Channel returns the channel name
+func (s *SMSNotifier) Channel() string {
+	return ChannelSMS
+}
+
+// Notify logs the SMS that would be sent
+func (s *SMSNotifier) Notify(ctx context.Context, n *Notification) error {
+	log.Printf("SMS to %s (%s): %s", n.Recipient, n.Role, n.Message)
+	return nil
+}
+
+// WebhookNotifier posts notifications as JSON to an HTTP endpoint
+type WebhookNotifier struct {
+	url    string
+	client *http.Client
+}
+
+// NewWebhookNotifier creates a webhook notifier for url
+func NewWebhookNotifier(url string) *WebhookNotifier {
+	return &WebhookNotifier{
+		url:    url,
+		client: &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+// Channel returns the channel name
+func (w *WebhookNotifier) Channel() string {
+	return ChannelWebhook
+}
+
+// Notify posts the notification; any non-2xx response is an error
+func (w *WebhookNotifier) Notify(ctx context.Context, n *Notification) error {
+	body, err := json.Marshal(n)
+	if err != nil {
+		return fmt.Errorf("failed to encode notification: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call webhook: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
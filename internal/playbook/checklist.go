@@ -0,0 +1,307 @@
+package playbook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/erh-safety-system/poc/internal/audit"
+	"github.com/erh-safety-system/poc/internal/decision"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Checklist statuses
+const (
+	ChecklistOpen      = "open"
+	ChecklistCompleted = "completed" // Closed with every required item done or waived
+	ChecklistAbandoned = "abandoned" // Closed by an automatic transition with required items outstanding
+)
+
+// Checklist item statuses
+const (
+	ItemPending = "pending"
+	ItemDone    = "done"
+	ItemWaived  = "waived"
+)
+
+var (
+	// ErrChecklistNotFound indicates an unknown checklist or checklist item
+	ErrChecklistNotFound = errors.New("checklist not found")
+
+	// ErrChecklistClosed indicates a change to a checklist whose decision has left the state
+	ErrChecklistClosed = errors.New("checklist is closed")
+
+	// ErrItemResolved indicates an item that was already done or waived
+	ErrItemResolved = errors.New("checklist item already resolved")
+
+	// ErrWaiverReason indicates a waiver without a reason
+	ErrWaiverReason = errors.New("a reason is required to waive a checklist item")
+)
+
+// Checklist is a playbook's checklist instantiated for a decision entering a state
+type Checklist struct {
+	ID           string           `gorm:"primaryKey;type:varchar(255)" json:"id"`
+	DecisionID   string           `gorm:"index;type:varchar(255);not null" json:"decision_id"`
+	ZoneID       string           `gorm:"index;type:varchar(10);not null" json:"zone_id"`
+	State        string           `gorm:"type:varchar(10);not null" json:"state"`
+	PlaybookID   string           `gorm:"type:varchar(100);not null" json:"playbook_id"` // Playbook version the items were copied from
+	TransitionID string           `gorm:"type:varchar(255)" json:"transition_id"`        // Transition that entered the state
+	Status       string           `gorm:"index;type:varchar(20);not null" json:"status"`
+	ClosedAt     *time.Time       `json:"closed_at,omitempty"`
+	CreatedAt    time.Time        `gorm:"autoCreateTime" json:"created_at"`
+	Items        []*ChecklistItem `gorm:"foreignKey:ChecklistID" json:"items"`
+}
+
+// TableName specifies the table name
+func (Checklist) TableName() string {
+	return "checklists"
+}
+
+// ChecklistItem is one step of a checklist and who resolved it
+type ChecklistItem struct {
+	ID           string     `gorm:"primaryKey;type:varchar(255)" json:"id"`
+	ChecklistID  string     `gorm:"index;type:varchar(255);not null" json:"checklist_id"`
+	Position     int        `json:"position"`
+	Key          string     `gorm:"type:varchar(100);not null" json:"key"`
+	Text         string     `gorm:"type:text;not null" json:"text"`
+	Required     bool       `json:"required"`
+	Status       string     `gorm:"type:varchar(20);not null" json:"status"`
+	ResolvedBy   string     `gorm:"type:varchar(255)" json:"resolved_by,omitempty"`
+	ResolvedAt   *time.Time `json:"resolved_at,omitempty"`
+	WaiverReason string     `gorm:"type:text" json:"waiver_reason,omitempty"`
+}
+
+// TableName specifies the table name
+func (ChecklistItem) TableName() string {
+	return "checklist_items"
+}
+
+// ChecklistService instantiates playbook checklists as decisions change state and holds
+// operator transitions until the required items of the current state are resolved
+type ChecklistService struct {
+	db          *gorm.DB
+	playbooks   *Registry
+	policies    *decision.PolicyRegistry
+	auditLogger *audit.AuditLogger
+}
+
+// NewChecklistService creates a new checklist service. Zone types come from the decision policies.
+func NewChecklistService(db *gorm.DB, playbooks *Registry, policies *decision.PolicyRegistry, auditLogger *audit.AuditLogger) *ChecklistService {
+	return &ChecklistService{
+		db:          db,
+		playbooks:   playbooks,
+		policies:    policies,
+		auditLogger: auditLogger,
+	}
+}
+
+// Guard refuses operator transitions while the current state's checklist has pending required items.
+// It matches decision.TransitionGuard.
+func (s *ChecklistService) Guard(ctx context.Context, tx *gorm.DB, state *decision.DecisionStateRecord, req *decision.TransitionRequest) error {
+	checklist, err := openChecklist(tx, state.ID)
+	if err != nil || checklist == nil {
+		return err
+	}
+
+	pending := make([]string, 0)
+	for _, item := range checklist.Items {
+		if item.Required && item.Status == ItemPending {
+			pending = append(pending, item.Key)
+		}
+	}
+	if len(pending) > 0 {
+		return fmt.Errorf("%w: %s checklist has required items pending: %s",
+			decision.ErrTransitionBlocked, checklist.State, strings.Join(pending, ", "))
+	}
+	return nil
+}
+
+// Observe closes the checklist of the state a decision left and instantiates the checklist of the state it entered.
+// It matches decision.TransitionObserver.
+func (s *ChecklistService) Observe(ctx context.Context, tx *gorm.DB, state *decision.DecisionStateRecord, transition *decision.DecisionTransition) error {
+	previous, err := openChecklist(tx, state.ID)
+	if err != nil {
+		return err
+	}
+	if previous != nil {
+		status := ChecklistCompleted
+		for _, item := range previous.Items {
+			if item.Required && item.Status == ItemPending {
+				status = ChecklistAbandoned
+			}
+		}
+		if err := tx.Model(previous).Updates(map[string]interface{}{
+			"status":    status,
+			"closed_at": transition.CreatedAt,
+		}).Error; err != nil {
+			return fmt.Errorf("failed to close checklist: %w", err)
+		}
+	}
+
+	zoneType := s.policies.For(state.ZoneID).ZoneType
+	playbook := s.playbooks.For(zoneType)
+	items := playbook.Checklist(decision.DecisionState(state.CurrentState))
+	if len(items) == 0 {
+		return nil
+	}
+
+	checklist := &Checklist{
+		ID:           fmt.Sprintf("chk_%s", uuid.New().String()),
+		DecisionID:   state.ID,
+		ZoneID:       state.ZoneID,
+		State:        state.CurrentState,
+		PlaybookID:   playbook.ID(),
+		TransitionID: transition.ID,
+		Status:       ChecklistOpen,
+	}
+	for i, item := range items {
+		checklist.Items = append(checklist.Items, &ChecklistItem{
+			ID:       fmt.Sprintf("chki_%s", uuid.New().String()),
+			Position: i + 1,
+			Key:      item.Key,
+			Text:     item.Text,
+			Required: item.Required,
+			Status:   ItemPending,
+		})
+	}
+	if err := tx.Create(checklist).Error; err != nil {
+		return fmt.Errorf("failed to create checklist: %w", err)
+	}
+	return nil
+}
+
+// GetActiveChecklist returns the open checklist of a decision's current state, or nil if it has none
+func (s *ChecklistService) GetActiveChecklist(ctx context.Context, decisionID string) (*Checklist, error) {
+	return openChecklist(s.db.WithContext(ctx), decisionID)
+}
+
+// ListChecklists returns every checklist of a decision in the order the states were entered
+func (s *ChecklistService) ListChecklists(ctx context.Context, decisionID string) ([]*Checklist, error) {
+	var checklists []*Checklist
+	if err := s.db.WithContext(ctx).
+		Preload("Items", func(db *gorm.DB) *gorm.DB { return db.Order("position ASC") }).
+		Where("decision_id = ?", decisionID).
+		Order("created_at ASC").
+		Find(&checklists).Error; err != nil {
+		return nil, fmt.Errorf("failed to list checklists: %w", err)
+	}
+	return checklists, nil
+}
+
+// CompleteItem ticks off a checklist item
+func (s *ChecklistService) CompleteItem(ctx context.Context, itemID, operatorID string) (*ChecklistItem, error) {
+	return s.resolveItem(ctx, itemID, operatorID, ItemDone, "")
+}
+
+// WaiveItem explicitly skips a checklist item; the reason is recorded
+func (s *ChecklistService) WaiveItem(ctx context.Context, itemID, operatorID, reason string) (*ChecklistItem, error) {
+	if strings.TrimSpace(reason) == "" {
+		return nil, ErrWaiverReason
+	}
+	return s.resolveItem(ctx, itemID, operatorID, ItemWaived, reason)
+}
+
+// resolveItem marks an item of an open checklist done or waived and records it in the audit trail
+func (s *ChecklistService) resolveItem(ctx context.Context, itemID, operatorID, status, reason string) (*ChecklistItem, error) {
+	var item ChecklistItem
+	var checklist Checklist
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("id = ?", itemID).First(&item).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return ErrChecklistNotFound
+			}
+			return fmt.Errorf("failed to get checklist item: %w", err)
+		}
+		if err := tx.Where("id = ?", item.ChecklistID).First(&checklist).Error; err != nil {
+			return fmt.Errorf("failed to get checklist: %w", err)
+		}
+		if checklist.Status != ChecklistOpen {
+			return ErrChecklistClosed
+		}
+		if item.Status != ItemPending {
+			return ErrItemResolved
+		}
+
+		now := time.Now()
+		item.Status = status
+		item.ResolvedBy = operatorID
+		item.ResolvedAt = &now
+		item.WaiverReason = reason
+		result := tx.Model(&ChecklistItem{}).
+			Where("id = ? AND status = ?", item.ID, ItemPending).
+			Updates(map[string]interface{}{
+				"status":        item.Status,
+				"resolved_by":   item.ResolvedBy,
+				"resolved_at":   now,
+				"waiver_reason": reason,
+			})
+		if result.Error != nil {
+			return fmt.Errorf("failed to update checklist item: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return ErrItemResolved
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.audit(ctx, &checklist, &item)
+	return &item, nil
+}
+
+// audit records a resolved item in the audit trail
+func (s *ChecklistService) audit(ctx context.Context, checklist *Checklist, item *ChecklistItem) {
+	if s.auditLogger == nil {
+		return
+	}
+	action := "complete"
+	if item.Status == ItemWaived {
+		action = "waive"
+	}
+	metadata, _ := json.Marshal(map[string]interface{}{
+		"decision_id":  checklist.DecisionID,
+		"zone_id":      checklist.ZoneID,
+		"state":        checklist.State,
+		"playbook_id":  checklist.PlaybookID,
+		"checklist_id": checklist.ID,
+		"item_key":     item.Key,
+		"required":     item.Required,
+		"resolved_at":  item.ResolvedAt,
+	})
+	entry := &audit.AuditLogEntry{
+		OperationType: "playbook",
+		OperatorID:    item.ResolvedBy,
+		TargetType:    "checklist_item",
+		TargetID:      item.ID,
+		Action:        action,
+		Result:        "success",
+		Reason:        item.WaiverReason,
+		Metadata:      string(metadata),
+	}
+	if err := s.auditLogger.LogOperation(ctx, entry); err != nil {
+		log.Printf("Failed to audit checklist item %s: %v", item.ID, err)
+	}
+}
+
+// openChecklist loads a decision's open checklist with its items, or nil if it has none
+func openChecklist(tx *gorm.DB, decisionID string) (*Checklist, error) {
+	var checklist Checklist
+	err := tx.Preload("Items", func(db *gorm.DB) *gorm.DB { return db.Order("position ASC") }).
+		Where("decision_id = ? AND status = ?", decisionID, ChecklistOpen).
+		Order("created_at DESC").
+		First(&checklist).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get checklist: %w", err)
+	}
+	return &checklist, nil
+}
@@ -0,0 +1,251 @@
+package playbook
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/erh-safety-system/poc/internal/config"
+	"github.com/erh-safety-system/poc/internal/decision"
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// BuiltinZoneType is the zone type of the playbook used when a zone type has no playbook document
+const BuiltinZoneType = "builtin"
+
+// PlaybookDocument is the schema of a zone type's playbook file
+type PlaybookDocument struct {
+	ZoneType    string                    `yaml:"zone_type" toml:"zone_type"`
+	Version     int                       `yaml:"version" toml:"version"` // Must be bumped whenever the content changes
+	Description string                    `yaml:"description" toml:"description"`
+	States      map[string][]ItemDocument `yaml:"states" toml:"states"` // decision state -> checklist
+}
+
+// ItemDocument is one step of a state's checklist
+type ItemDocument struct {
+	Key      string `yaml:"key" toml:"key"` // Stable identifier, unique within the state
+	Text     string `yaml:"text" toml:"text"`
+	Required bool   `yaml:"required" toml:"required"` // Blocks the next transition until done or waived
+}
+
+// Playbook is a validated set of standard operating procedure checklists for one zone type
+type Playbook struct {
+	ZoneType    string                                    `json:"zone_type"`
+	Version     int                                       `json:"version"`
+	Description string                                    `json:"description,omitempty"`
+	States      map[decision.DecisionState][]ItemDocument `json:"states"`
+	Source      string                                    `json:"source"` // File the playbook was loaded from, or "builtin"
+}
+
+// ID identifies the playbook and version, e.g. "station_interior@v2"
+func (p *Playbook) ID() string {
+	return fmt.Sprintf("%s@v%d", p.ZoneType, p.Version)
+}
+
+// Checklist returns the items to complete while a decision is in a state
+func (p *Playbook) Checklist(state decision.DecisionState) []ItemDocument {
+	return p.States[state]
+}
+
+// BuiltinPlaybook returns the SOP checklists of docs/03, used for zone types without a playbook document
+func BuiltinPlaybook() *Playbook {
+	return &Playbook{
+		ZoneType: BuiltinZoneType,
+		Version:  1,
+		States: map[decision.DecisionState][]ItemDocument{
+			decision.StateD0: {
+				{Key: "review_summary", Text: "Review the aggregated signal summary on the dashboard", Required: true},
+				{Key: "confirm_event", Text: "Confirm the candidate event and start monitoring", Required: true},
+			},
+			decision.StateD1: {
+				{Key: "review_corroboration", Text: "Review the corroborating sources behind the recommendation", Required: true},
+				{Key: "agree_resources", Text: "Agree the recommended resource level with the shift lead", Required: true},
+				{Key: "brief_staff", Text: "Brief dispatched staff on the situation"},
+			},
+			decision.StateD2: {
+				{Key: "activate_guidance", Text: "Activate local guidance for the affected sub-zones", Required: true},
+				{Key: "verify_guidance", Text: "Confirm guidance is visible on site"},
+			},
+			decision.StateD3: {
+				{Key: "select_level", Text: "Select the escalation level (1-3) and the measures to enable", Required: true},
+				{Key: "confirm_measures", Text: "Confirm the zone control measures are in force", Required: true},
+				{Key: "coordinate_control_centre", Text: "Coordinate with the train control centre where the zone requires it"},
+			},
+			decision.StateD4: {
+				{Key: "open_coordination", Text: "Open coordination with the affected zones and network operations", Required: true},
+				{Key: "confirm_commander", Text: "Confirm a single incident commander across zones", Required: true},
+			},
+			decision.StateD5: {
+				{Key: "verify_wording", Text: "Verify the warning wording, languages and channels", Required: true},
+				{Key: "confirm_delivery", Text: "Confirm the warning was delivered on every channel", Required: true},
+			},
+			decision.StateD6: {
+				{Key: "record_reason", Text: "Record the de-escalation reason", Required: true},
+				{Key: "lift_measures", Text: "Confirm escalation measures, guidance and warnings are lifted", Required: true},
+				{Key: "seal_evidence", Text: "Confirm evidence sealing completed", Required: true},
+			},
+		},
+		Source: "builtin",
+	}
+}
+
+// ParsePlaybook decodes and validates a playbook document. The format is chosen by extension (.yaml, .yml or .toml).
+func ParsePlaybook(name string, data []byte) (*Playbook, error) {
+	doc := &PlaybookDocument{}
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".yaml", ".yml":
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		if err := decoder.Decode(doc); err != nil {
+			return nil, fmt.Errorf("failed to parse playbook %s: %w", name, err)
+		}
+	case ".toml":
+		decoder := toml.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(doc); err != nil {
+			return nil, fmt.Errorf("failed to parse playbook %s: %w", name, err)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported playbook file extension: %s", filepath.Ext(name))
+	}
+
+	problems := make([]string, 0)
+	if doc.ZoneType == "" {
+		problems = append(problems, "zone_type: must not be empty")
+	}
+	if doc.Version < 1 {
+		problems = append(problems, "version: must be at least 1")
+	}
+
+	playbook := &Playbook{
+		ZoneType:    doc.ZoneType,
+		Version:     doc.Version,
+		Description: doc.Description,
+		States:      make(map[decision.DecisionState][]ItemDocument),
+		Source:      name,
+	}
+	for state, items := range doc.States {
+		if !knownState(decision.DecisionState(state)) {
+			problems = append(problems, fmt.Sprintf("states.%s: unknown decision state", state))
+			continue
+		}
+		keys := make(map[string]bool, len(items))
+		for i, item := range items {
+			if item.Key == "" {
+				problems = append(problems, fmt.Sprintf("states.%s[%d].key: must not be empty", state, i))
+			} else if keys[item.Key] {
+				problems = append(problems, fmt.Sprintf("states.%s[%d].key: duplicate key %s", state, i, item.Key))
+			}
+			keys[item.Key] = true
+			if item.Text == "" {
+				problems = append(problems, fmt.Sprintf("states.%s[%d].text: must not be empty", state, i))
+			}
+		}
+		playbook.States[decision.DecisionState(state)] = items
+	}
+
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		for i, problem := range problems {
+			problems[i] = fmt.Sprintf("%s: %s", filepath.Base(name), problem)
+		}
+		return nil, &config.ValidationError{Problems: problems}
+	}
+	return playbook, nil
+}
+
+// Registry holds the playbook of every zone type
+type Registry struct {
+	builtin *Playbook
+	byType  map[string]*Playbook
+}
+
+// NewRegistry creates a registry that applies the built-in playbook to every zone type
+func NewRegistry() *Registry {
+	return &Registry{
+		builtin: BuiltinPlaybook(),
+		byType:  make(map[string]*Playbook),
+	}
+}
+
+// LoadPlaybooks creates a registry from the playbook documents in dir; an empty dir uses the built-in playbook only
+func LoadPlaybooks(dir string) (*Registry, error) {
+	registry := NewRegistry()
+	if dir == "" {
+		return registry, nil
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read playbook directory: %w", err)
+	}
+
+	problems := make([]string, 0)
+	for _, entry := range entries {
+		ext := strings.ToLower(filepath.Ext(entry.Name()))
+		if entry.IsDir() || (ext != ".yaml" && ext != ".yml" && ext != ".toml") {
+			continue
+		}
+
+		path := filepath.Join(dir, entry.Name())
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read playbook file: %w", err)
+		}
+
+		playbook, err := ParsePlaybook(path, data)
+		if err != nil {
+			if validationErr, ok := err.(*config.ValidationError); ok {
+				problems = append(problems, validationErr.Problems...)
+			} else {
+				problems = append(problems, err.Error())
+			}
+			continue
+		}
+		if other, exists := registry.byType[playbook.ZoneType]; exists {
+			problems = append(problems, fmt.Sprintf("%s: zone type %s is also defined in %s", entry.Name(), playbook.ZoneType, filepath.Base(other.Source)))
+			continue
+		}
+		registry.byType[playbook.ZoneType] = playbook
+	}
+
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return nil, &config.ValidationError{Problems: problems}
+	}
+	return registry, nil
+}
+
+// For returns the playbook for a zone type
+func (r *Registry) For(zoneType string) *Playbook {
+	if playbook, exists := r.byType[zoneType]; exists {
+		return playbook
+	}
+	return r.builtin
+}
+
+// Playbooks returns the loaded playbooks ordered by zone type, followed by the built-in playbook
+func (r *Registry) Playbooks() []*Playbook {
+	playbooks := make([]*Playbook, 0, len(r.byType)+1)
+	for _, playbook := range r.byType {
+		playbooks = append(playbooks, playbook)
+	}
+	sort.Slice(playbooks, func(i, j int) bool {
+		return playbooks[i].ZoneType < playbooks[j].ZoneType
+	})
+	return append(playbooks, r.builtin)
+}
+
+func knownState(state decision.DecisionState) bool {
+	switch state {
+	case decision.StateD0, decision.StateD1, decision.StateD2, decision.StateD3,
+		decision.StateD4, decision.StateD5, decision.StateD6:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,135 @@
+package playbook
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/erh-safety-system/poc/internal/audit"
+	"github.com/erh-safety-system/poc/internal/config"
+	"github.com/erh-safety-system/poc/internal/decision"
+	"github.com/erh-safety-system/poc/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupPlaybookTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to open test database: %v", err)
+	}
+
+	if err := db.AutoMigrate(
+		&model.AggregatedSummary{},
+		&decision.DecisionStateRecord{},
+		&decision.DecisionTransition{},
+		&Checklist{},
+		&ChecklistItem{},
+		&audit.AuditLog{},
+	); err != nil {
+		t.Fatalf("Failed to migrate: %v", err)
+	}
+
+	return db
+}
+
+func TestLoadPlaybooks(t *testing.T) {
+	registry, err := LoadPlaybooks(filepath.Join("..", "..", "configs", "playbooks"))
+	require.NoError(t, err)
+	station := registry.For("station_interior")
+	assert.Equal(t, "station_interior@v1", station.ID())
+	assert.NotEmpty(t, station.Checklist(decision.StateD3))
+	assert.Equal(t, "builtin@v1", registry.For("open_plaza").ID())
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "bad.yaml"), []byte(`
+zone_type: open_plaza
+version: 1
+states:
+  D9:
+    - key: x
+      text: unknown state
+  D1:
+    - key: brief
+      text: Brief staff
+    - key: brief
+`), 0o644))
+	_, err = LoadPlaybooks(dir)
+	var validationErr *config.ValidationError
+	require.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, []string{
+		"bad.yaml: states.D1[1].key: duplicate key brief",
+		"bad.yaml: states.D1[1].text: must not be empty",
+		"bad.yaml: states.D9: unknown decision state",
+	}, validationErr.Problems)
+}
+
+func TestChecklistService_BlocksTransitionsUntilResolved(t *testing.T) {
+	db := setupPlaybookTestDB(t)
+	decisions := decision.NewDecisionService(db, nil)
+	auditLogger := audit.NewAuditLogger(db)
+	checklists := NewChecklistService(db, NewRegistry(), decision.NewPolicyRegistry(), auditLogger)
+	decisions.AddTransitionGuard(checklists.Guard)
+	decisions.OnTransition(checklists.Observe)
+	ctx := context.Background()
+
+	summary := &model.AggregatedSummary{ZoneID: "Z1", SourceCount: model.JSONB{"infrastructure": 1, "staff": 1}}
+	require.NoError(t, db.Create(summary).Error)
+	state, err := decisions.CreatePreAlert(ctx, "Z1", "op_1", summary.ID)
+	require.NoError(t, err)
+
+	// Entering D0 instantiates its checklist from the built-in playbook
+	active, err := checklists.GetActiveChecklist(ctx, state.ID)
+	require.NoError(t, err)
+	require.NotNil(t, active)
+	assert.Equal(t, "D0", active.State)
+	assert.Equal(t, "builtin@v1", active.PlaybookID)
+	require.Len(t, active.Items, 2)
+
+	_, err = decisions.TransitionState(ctx, state.ID, decision.StateD1, "op_1", 0)
+	assert.ErrorIs(t, err, decision.ErrTransitionBlocked)
+	assert.Contains(t, err.Error(), "review_summary, confirm_event")
+
+	_, err = checklists.CompleteItem(ctx, active.Items[0].ID, "op_1")
+	require.NoError(t, err)
+	_, err = checklists.CompleteItem(ctx, active.Items[0].ID, "op_2")
+	assert.ErrorIs(t, err, ErrItemResolved)
+	_, err = checklists.WaiveItem(ctx, active.Items[1].ID, "op_1", " ")
+	assert.ErrorIs(t, err, ErrWaiverReason)
+	item, err := checklists.WaiveItem(ctx, active.Items[1].ID, "op_1", "confirmed by radio")
+	require.NoError(t, err)
+	assert.Equal(t, ItemWaived, item.Status)
+
+	state, err = decisions.TransitionState(ctx, state.ID, decision.StateD1, "op_1", 0)
+	require.NoError(t, err)
+
+	// Automatic transitions are never held, and leave the abandoned checklist behind for review
+	_, err = decisions.Transition(ctx, &decision.TransitionRequest{
+		DecisionID:  state.ID,
+		TargetState: decision.StateD0,
+		OperatorID:  "system_rollback",
+		Automatic:   true,
+	})
+	require.NoError(t, err)
+
+	history, err := checklists.ListChecklists(ctx, state.ID)
+	require.NoError(t, err)
+	require.Len(t, history, 3)
+	assert.Equal(t, ChecklistCompleted, history[0].Status)
+	assert.Equal(t, ChecklistAbandoned, history[1].Status)
+	assert.Equal(t, ChecklistOpen, history[2].Status)
+	assert.Equal(t, "D0", history[2].State)
+
+	_, err = checklists.CompleteItem(ctx, history[1].Items[0].ID, "op_1")
+	assert.ErrorIs(t, err, ErrChecklistClosed)
+
+	// Completions and waivers are in the audit trail
+	logs, err := auditLogger.GetAuditLogs(ctx, &audit.AuditLogFilters{OperationType: "playbook"})
+	require.NoError(t, err)
+	require.Len(t, logs, 2)
+	actions := []string{logs[0].Action, logs[1].Action}
+	assert.ElementsMatch(t, []string{"complete", "waive"}, actions)
+}
@@ -13,6 +13,7 @@ type CAPMessageInterface interface {
 	GetStatus() string
 	GetMsgType() string
 	GetScope() string
+	GetReferences() string
 	GetInfoBlocks() []InfoBlock
 	GetArea() AreaInfo
 	GetSignature() *SignatureInfo
@@ -6,6 +6,7 @@ import (
 	"math"
 	"time"
 
+	"github.com/erh-safety-system/poc/internal/aggregation"
 	"github.com/erh-safety-system/poc/internal/cap"
 	"github.com/erh-safety-system/poc/internal/decision"
 	"gorm.io/gorm"
@@ -108,13 +109,8 @@ func (g *GuidanceEngine) getAvoidZones(ctx context.Context, currentZone string)
 // calculatePath calculates the recommended path from current to target zone
 func (g *GuidanceEngine) calculatePath(currentZone, targetZone string, avoidZones []string) []string {
 	// Simple path calculation (can be enhanced with graph algorithms)
-	// Zone connectivity map (simplified)
-	zoneMap := map[string][]string{
-		"Z1": {"Z2", "Z3"},
-		"Z2": {"Z1", "Z4"},
-		"Z3": {"Z1", "Z4"},
-		"Z4": {"Z2", "Z3"},
-	}
+	// Zone connectivity map (shared with spatial correlation in aggregation)
+	zoneMap := aggregation.Adjacency()
 	
 	// If target is same as current, return empty path
 	if currentZone == targetZone {
@@ -215,12 +211,7 @@ type RouteCalculator struct {
 // NewRouteCalculator creates a new route calculator
 func NewRouteCalculator() *RouteCalculator {
 	return &RouteCalculator{
-		zoneGraph: map[string][]string{
-			"Z1": {"Z2", "Z3"},
-			"Z2": {"Z1", "Z4"},
-			"Z3": {"Z1", "Z4"},
-			"Z4": {"Z2", "Z3"},
-		},
+		zoneGraph: aggregation.Adjacency(),
 	}
 }
 
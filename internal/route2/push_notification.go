@@ -3,6 +3,7 @@ package route2
 import (
 	"context"
 	"fmt"
+	"sort"
 	"time"
 
 	"github.com/erh-safety-system/poc/internal/cap"
@@ -97,6 +98,45 @@ func (p *PushNotificationService) SendPersonalizedNotification(ctx context.Conte
 	return nil
 }
 
+// SendSituationUpdate tells devices in a zone that the situation was updated, in each device's language.
+// It returns how many devices were notified.
+func (p *PushNotificationService) SendSituationUpdate(ctx context.Context, zoneID string, headline, body map[string]string) (int, error) {
+	sent := 0
+	for _, device := range p.deviceRegistry {
+		if device.ZoneID != zoneID {
+			continue
+		}
+		
+		lang := device.Language
+		if _, ok := headline[lang]; !ok {
+			lang = fallbackLanguage(headline)
+		}
+		
+		// In production, would send actual push notification via FCM/APNs
+		fmt.Printf("Sending situation update to device %s (platform: %s): %s - %s\n",
+			device.DeviceID, device.Platform, headline[lang], body[lang])
+		sent++
+	}
+	
+	return sent, nil
+}
+
+// fallbackLanguage picks English if available, otherwise the first language in order
+func fallbackLanguage(texts map[string]string) string {
+	if _, ok := texts["en"]; ok {
+		return "en"
+	}
+	languages := make([]string, 0, len(texts))
+	for lang := range texts {
+		languages = append(languages, lang)
+	}
+	sort.Strings(languages)
+	if len(languages) == 0 {
+		return ""
+	}
+	return languages[0]
+}
+
 // getCurrentTime returns current time in ISO8601 format
 func (p *PushNotificationService) getCurrentTime() string {
 	// In production, use proper time formatting
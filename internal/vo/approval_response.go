@@ -4,22 +4,41 @@ import "time"
 
 // ApprovalRequestResponse represents an approval request response
 type ApprovalRequestResponse struct {
-	ID          string                 `json:"id"`
-	ActionType  string                 `json:"action_type"`
-	ZoneID      string                 `json:"zone_id"`
-	Proposal    map[string]interface{} `json:"proposal"`
-	RequesterID string                 `json:"requester_id"`
-	Approver1ID *string                `json:"approver1_id"`
-	Approver2ID *string                `json:"approver2_id"`
-	Approver3ID *string                `json:"approver3_id"`
-	Status      string                 `json:"status"`
-	CreatedAt   time.Time              `json:"created_at"`
-	ExpiresAt   *time.Time             `json:"expires_at"`
-	ApprovedAt  *time.Time             `json:"approved_at"`
+	ID                 string                 `json:"id"`
+	Kind               string                 `json:"kind"` // action|ttl_extension
+	ActionType         string                 `json:"action_type"`
+	TargetID           *string                `json:"target_id,omitempty"`             // Action a TTL extension extends
+	RequestedTTL       int                    `json:"requested_ttl_seconds,omitempty"` // TTL a TTL extension asks for
+	ZoneID             string                 `json:"zone_id"`
+	Proposal           map[string]interface{} `json:"proposal"`
+	RequesterID        string                 `json:"requester_id"`
+	RequiredApprovals  int                    `json:"required_approvals"`
+	RequiredRoles      []string               `json:"required_roles"`
+	ExcludedApprovers  []string               `json:"excluded_approvers"`
+	Votes              []ApprovalVoteResponse `json:"votes"`
+	RemainingApprovals int                    `json:"remaining_approvals"`
+	MissingRoles       []string               `json:"missing_roles"`
+	Status             string                 `json:"status"`
+	Version            int                    `json:"version"`
+	CreatedAt          time.Time              `json:"created_at"`
+	ExpiresAt          *time.Time             `json:"expires_at"`
+	ApprovedAt         *time.Time             `json:"approved_at"`
+	DecisionID         *string                `json:"decision_id"`
+	ConsumedAt         *time.Time             `json:"consumed_at"`
+	BaseDecisionID     *string                `json:"base_decision_id"`
+	BaseState          string                 `json:"base_state"`
+	BaseVersion        int                    `json:"base_version"`
+}
+
+// ApprovalVoteResponse represents one approver's vote
+type ApprovalVoteResponse struct {
+	ApproverID      string     `json:"approver_id"`
+	Role            string     `json:"role,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+	LastKeepaliveAt *time.Time `json:"last_keepalive_at"`
 }
 
 // KeepaliveResponse represents a keepalive response
 type KeepaliveResponse struct {
 	Status string `json:"status"`
 }
-
@@ -12,6 +12,8 @@ type DecisionResponse struct {
 	DecisionDepth       int       `json:"decision_depth"`
 	ContextStates       int       `json:"context_states"`
 	ComplexityTotal     float64   `json:"complexity_total"`
+	PolicyVersion       string    `json:"policy_version"`
+	Version             int       `json:"version"`
 	CreatedAt           time.Time `json:"created_at"`
 	UpdatedAt           time.Time `json:"updated_at"`
 }